@@ -3,11 +3,16 @@
 package gonuts
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -26,30 +31,89 @@ import (
 //		errPlus.Log()
 //	}
 type ErrorPlus struct {
-	err        error                  // Original wrapped error
-	msg        string                 // Error message with context
-	code       int                    // Error code, can be HTTP code or custom code
-	context    map[string]interface{} // Additional contextual information
-	stackTrace []string               // Stack trace at the point of error creation
-	timestamp  time.Time              // Time when the error was created
+	err         error                  // Original wrapped error
+	msg         string                 // Error message with context
+	code        int                    // Error code, can be HTTP code or custom code
+	kind        string                 // Constructor kind (ErrorKindCustom, ErrorKindNotFound, ...), empty for decoded errors
+	context     map[string]interface{} // Additional contextual information
+	contextKeys []string               // Context keys in insertion order, used for serialization
+	publicKeys  map[string]bool        // Context keys exposed in HTTP responses (see WithPublicContext)
+	stackTrace  []uintptr              // Program counters at the point of error creation, resolved lazily
+	timestamp   time.Time              // Time when the error was created
+	callerSkip  int                    // Number of stack frames skipped when reporting the caller
+
+	remoteStack  []string         // Rendered stack trace of an error reconstructed by UnmarshalJSON
+	remoteCaller *errorCallerJSON // Caller of an error reconstructed by UnmarshalJSON
 }
 
 // NewErrorPlus creates a new ErrorPlus instance by wrapping an error with a custom message and code.
 // It captures the stack trace at the point of creation.
 func NewErrorPlus(err error, msg string, code int) *ErrorPlus {
+	return newErrorPlus(err, msg, code, ErrorKindCustom)
+}
+
+// newErrorPlus is called directly by all exported constructors, so the captured stack
+// starts at the constructor's caller. kind names the constructor for the metrics hook.
+func newErrorPlus(err error, msg string, code int, kind string) *ErrorPlus {
+	if hook := errorMetricsHook.Load(); hook != nil {
+		callErrorMetricsHook(*hook, code, kind)
+	}
 	return &ErrorPlus{
 		err:        err,
 		msg:        msg,
 		code:       code,
+		kind:       kind,
 		context:    make(map[string]interface{}),
 		stackTrace: captureStackTrace(),
 		timestamp:  time.Now(),
 	}
 }
 
+// clone returns a copy of the ErrorPlus with its own context map and key order.
+func (e *ErrorPlus) clone() *ErrorPlus {
+	c := *e
+	c.context = copyContext(e.context)
+	c.contextKeys = append([]string(nil), e.contextKeys...)
+	if e.publicKeys != nil {
+		c.publicKeys = make(map[string]bool, len(e.publicKeys))
+		for key := range e.publicKeys {
+			c.publicKeys[key] = true
+		}
+	}
+	return &c
+}
+
+// setContext sets a context value, keeping the position of an existing key. Only call it on a clone.
+func (e *ErrorPlus) setContext(key string, value interface{}) {
+	if e.context == nil {
+		e.context = make(map[string]interface{})
+	}
+	if _, exists := e.context[key]; !exists {
+		e.contextKeys = append(e.contextKeys, key)
+	}
+	e.context[key] = value
+}
+
+var errorCallerInMessage atomic.Bool
+
+// SetErrorCallerInMessage enables or disables appending the compact caller location
+// ("(at pkg.Func file.go:42)") to the output of Error().
+func SetErrorCallerInMessage(enabled bool) {
+	errorCallerInMessage.Store(enabled)
+}
+
 // Error implements the error interface, returning the error message including context and original error.
 func (e *ErrorPlus) Error() string {
-	return fmt.Sprintf("%s: %s", e.msg, e.err.Error())
+	msg := e.msg
+	if e.err != nil {
+		msg = fmt.Sprintf("%s: %s", e.msg, e.err.Error())
+	}
+	if errorCallerInMessage.Load() {
+		if function, file, line := e.Caller(); function != "" {
+			msg = fmt.Sprintf("%s (at %s %s:%d)", msg, shortFunctionName(function), filepath.Base(file), line)
+		}
+	}
+	return msg
 }
 
 // ErrorMsg returns the error message without the original error.
@@ -57,19 +121,36 @@ func (e *ErrorPlus) Msg() string {
 	return e.msg
 }
 
-// ErrorStr returns the error message as a string.
+// ContextStr returns the context as indented JSON, with keys in insertion order and redacted values masked.
 func (e *ErrorPlus) ContextStr() string {
-	return GetPrettyJson(e.context)
+	return GetPrettyJson(e.orderedContext())
 }
 
 // Context returns the additional context associated with the ErrorPlus.
+// Values added with Redacted are returned as RedactedValue; use RevealContext to read them.
 func (e *ErrorPlus) Context() map[string]interface{} {
 	return e.context
 }
 
-// CodeStr returns the error code as a string.
+// ContextKeys returns the context keys in the order they were first added.
+func (e *ErrorPlus) ContextKeys() []string {
+	return append([]string(nil), e.contextKeys...)
+}
+
+// RevealContext returns the context value for key, unwrapping values added with Redacted.
+// It is meant for in-process inspection only; never log or serialize its result.
+func (e *ErrorPlus) RevealContext(key string) (interface{}, bool) {
+	value, ok := e.context[key]
+	if redacted, isRedacted := value.(RedactedValue); isRedacted {
+		return redacted.value, ok
+	}
+	return value, ok
+}
+
+// CodeStr returns the name registered for the error code (see RegisterErrorCode),
+// or the code as a string if it is not registered.
 func (e *ErrorPlus) CodeStr() string {
-	return fmt.Sprintf("%d", e.code)
+	return codeString(e.code)
 }
 
 // Code returns the error code associated with the ErrorPlus.
@@ -93,8 +174,12 @@ func (e *ErrorPlus) As(target interface{}) bool {
 }
 
 // StackTrace returns the stack trace associated with the error.
+// Frames are resolved and filtered with the current stack trace filter (see SetStackTraceFilter).
 func (e *ErrorPlus) StackTrace() []string {
-	return e.stackTrace
+	if len(e.stackTrace) == 0 && e.remoteStack != nil {
+		return append([]string(nil), e.remoteStack...)
+	}
+	return renderStackTrace(e.stackTrace)
 }
 
 // Timestamp returns the time when the error was created.
@@ -104,74 +189,351 @@ func (e *ErrorPlus) Timestamp() time.Time {
 
 // WithMsg returns a new ErrorPlus with the provided message, preserving immutability.
 func (e *ErrorPlus) WithMsg(msg string) *ErrorPlus {
-	return &ErrorPlus{
-		err:        e.err,
-		msg:        msg,
-		code:       e.code,
-		context:    copyContext(e.context),
-		stackTrace: e.stackTrace, // Stack trace remains the same
-		timestamp:  e.timestamp,
-	}
+	c := e.clone() // Stack trace remains the same
+	c.msg = msg
+	return c
 }
 
 // WithCode returns a new ErrorPlus with the provided code, preserving immutability.
 func (e *ErrorPlus) WithCode(code int) *ErrorPlus {
-	return &ErrorPlus{
-		err:        e.err,
-		msg:        e.msg,
-		code:       code,
-		context:    copyContext(e.context),
-		stackTrace: e.stackTrace,
-		timestamp:  e.timestamp,
-	}
+	c := e.clone()
+	c.code = code
+	return c
 }
 
 // WithContext returns a new ErrorPlus with the additional context, preserving immutability.
+// Keys keep the position of their first insertion when serialized. The value is internal:
+// it is not part of HTTP responses, even if the key was added with WithPublicContext before.
 func (e *ErrorPlus) WithContext(key string, value interface{}) *ErrorPlus {
-	newContext := copyContext(e.context)
-	newContext[key] = value
-	return &ErrorPlus{
-		err:        e.err,
-		msg:        e.msg,
-		code:       e.code,
-		context:    newContext,
-		stackTrace: e.stackTrace,
-		timestamp:  e.timestamp,
-	}
+	c := e.clone()
+	c.setContext(key, value)
+	delete(c.publicKeys, key)
+	return c
+}
+
+// WithField is an alias of WithContext for chaining ordered context fields.
+//
+// Example usage:
+//
+//	err := NewBadRequestError("invalid order", nil).
+//		WithField("orderID", orderID).
+//		WithField("step", "validation")
+func (e *ErrorPlus) WithField(key string, value interface{}) *ErrorPlus {
+	return e.WithContext(key, value)
+}
+
+// Redacted returns a new ErrorPlus with a context value that is masked as "[REDACTED]" in
+// ContextStr, MarshalJSON, %v formatting and logs. The value stays available via RevealContext.
+//
+// Example usage:
+//
+//	err := NewUnauthorizedError("token rejected", cause).
+//		WithField("userID", userID).
+//		Redacted("token", token)
+func (e *ErrorPlus) Redacted(key string, value interface{}) *ErrorPlus {
+	c := e.clone()
+	c.setContext(key, RedactedValue{value: value})
+	delete(c.publicKeys, key)
+	return c
 }
 
 // WithValues returns a new ErrorPlus with the provided message and code, preserving immutability.
 func (e *ErrorPlus) WithValues(msg string, code int) *ErrorPlus {
-	return &ErrorPlus{
-		err:        e.err,
-		msg:        msg,
-		code:       code,
-		context:    copyContext(e.context),
-		stackTrace: e.stackTrace,
-		timestamp:  e.timestamp,
+	c := e.clone()
+	c.msg = msg
+	c.code = code
+	return c
+}
+
+// WithCallerSkip returns a new ErrorPlus whose reported caller skips n additional stack frames,
+// preserving immutability. Use it in helpers that create errors on behalf of their caller,
+// so Caller reports the real origin instead of the helper.
+//
+// Example usage:
+//
+//	func notFound(what string) *ErrorPlus {
+//		return NewNotFoundError(what+" not found", nil).WithCallerSkip(1)
+//	}
+func (e *ErrorPlus) WithCallerSkip(n int) *ErrorPlus {
+	c := e.clone()
+	c.callerSkip += n
+	if c.callerSkip < 0 {
+		c.callerSkip = 0
 	}
+	return c
+}
+
+// Caller returns the function, file and line where the error was created,
+// i.e. the first frame after the ErrorPlus constructors (adjusted by WithCallerSkip).
+// The file path is trimmed by the TrimSourceRoot of the stack trace filter.
+// All results are empty if the caller is unknown.
+func (e *ErrorPlus) Caller() (function, file string, line int) {
+	if len(e.stackTrace) == 0 {
+		if e.remoteCaller != nil {
+			return e.remoteCaller.Function, e.remoteCaller.File, e.remoteCaller.Line
+		}
+		return "", "", 0
+	}
+	frames := runtime.CallersFrames(e.stackTrace)
+	for skip := e.callerSkip; ; skip-- {
+		frame, more := frames.Next()
+		if skip == 0 {
+			function, file, line = frame.Function, frame.File, frame.Line
+			break
+		}
+		if !more {
+			return "", "", 0
+		}
+	}
+	if filter := stackTraceFilter.Load(); filter != nil && filter.TrimSourceRoot != "" {
+		file = strings.TrimPrefix(file, filter.TrimSourceRoot)
+	}
+	return function, file, line
+}
+
+// errorCallerJSON is the JSON representation of the caller of an ErrorPlus.
+type errorCallerJSON struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// errorJSON is the JSON representation of an ErrorPlus.
+type errorJSON struct {
+	Msg        string           `json:"msg"`
+	Code       int              `json:"code"`
+	Error      string           `json:"error,omitempty"`
+	Context    json.RawMessage  `json:"context,omitempty"`
+	StackTrace []string         `json:"stackTrace,omitempty"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Caller     *errorCallerJSON `json:"caller,omitempty"`
+}
+
+// ErrorJSONOptions configures MarshalJSONWith.
+type ErrorJSONOptions struct {
+	OmitStackTrace bool // leave out the stack trace and the caller, e.g. for external-facing payloads
+}
+
+var errorJSONOmitStackTrace atomic.Bool
+
+// SetErrorJSONStackTrace enables (the default) or disables the stack trace and caller in the
+// output of MarshalJSON, e.g. for services whose serialized errors leave the trust boundary.
+func SetErrorJSONStackTrace(enabled bool) {
+	errorJSONOmitStackTrace.Store(!enabled)
 }
 
 // MarshalJSON implements the json.Marshaler interface, allowing custom JSON serialization.
+// It writes msg, code, the wrapped error text as error, the context with keys in insertion
+// order and redacted values masked, the stack trace rendered with the stack trace filter
+// (see SetStackTraceFilter), the timestamp and the caller. Use SetErrorJSONStackTrace or
+// MarshalJSONWith to leave out the stack trace.
 func (e *ErrorPlus) MarshalJSON() ([]byte, error) {
-	type Alias ErrorPlus
-	return json.Marshal(&struct {
-		*Alias
-		Error     string    `json:"error"`
-		Timestamp time.Time `json:"timestamp"`
-	}{
-		Alias:     (*Alias)(e),
-		Error:     e.err.Error(),
-		Timestamp: e.timestamp,
-	})
+	return e.MarshalJSONWith(ErrorJSONOptions{OmitStackTrace: errorJSONOmitStackTrace.Load()})
+}
+
+// MarshalJSONWith serializes the error like MarshalJSON with the given options, regardless of
+// SetErrorJSONStackTrace.
+//
+// Example usage:
+//
+//	payload, err := errPlus.MarshalJSONWith(gonuts.ErrorJSONOptions{OmitStackTrace: true})
+func (e *ErrorPlus) MarshalJSONWith(opts ErrorJSONOptions) ([]byte, error) {
+	out := errorJSON{Msg: e.msg, Code: e.code, Timestamp: e.timestamp}
+	if e.err != nil {
+		out.Error = e.err.Error()
+	}
+	if len(e.context) > 0 {
+		context, err := e.orderedContext().MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		out.Context = context
+	}
+	if !opts.OmitStackTrace {
+		out.StackTrace = e.StackTrace()
+		if function, file, line := e.Caller(); function != "" {
+			out.Caller = &errorCallerJSON{Function: function, File: file, Line: line}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, reconstructing an ErrorPlus
+// serialized by MarshalJSON, e.g. by another service. The wrapped error becomes
+// errors.New of its serialized text, context values are generic JSON values in their original
+// order, and StackTrace and Caller return the serialized stack trace and caller.
+//
+// Example usage:
+//
+//	var remote gonuts.ErrorPlus
+//	if err := json.Unmarshal(body, &remote); err == nil && remote.IsNotFound() {
+//		...
+//	}
+func (e *ErrorPlus) UnmarshalJSON(data []byte) error {
+	var in errorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	keys, values, err := decodeOrderedObject(in.Context)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal context: %w", err)
+	}
+	*e = ErrorPlus{
+		msg:          in.Msg,
+		code:         in.Code,
+		context:      values,
+		contextKeys:  keys,
+		timestamp:    in.Timestamp,
+		remoteStack:  in.StackTrace,
+		remoteCaller: in.Caller,
+	}
+	if in.Error != "" {
+		e.err = errors.New(in.Error)
+	}
+	return nil
+}
+
+// decodeOrderedObject decodes a JSON object, returning its keys in document order.
+// An empty or null input results in an empty map.
+func decodeOrderedObject(data json.RawMessage) ([]string, map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if len(data) == 0 || string(data) == "null" {
+		return nil, values, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	} else if token != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected an object, got %v", token)
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := token.(string)
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values, nil
+}
+
+// RedactedValue wraps a context value added with Redacted. It renders as "[REDACTED]" in
+// JSON and fmt output; the wrapped value is only available via ErrorPlus.RevealContext.
+type RedactedValue struct {
+	value interface{}
+}
+
+const redactedPlaceholder = "[REDACTED]"
+
+// String implements fmt.Stringer.
+func (RedactedValue) String() string { return redactedPlaceholder }
+
+// GoString implements fmt.GoStringer, so %#v does not reveal the value either.
+func (RedactedValue) GoString() string { return redactedPlaceholder }
+
+// MarshalJSON implements json.Marshaler.
+func (RedactedValue) MarshalJSON() ([]byte, error) { return json.Marshal(redactedPlaceholder) }
+
+// MarshalText implements encoding.TextMarshaler, used e.g. by loggers for map values.
+func (RedactedValue) MarshalText() ([]byte, error) { return []byte(redactedPlaceholder), nil }
+
+// errorContext serializes the context of an ErrorPlus with its keys in insertion order.
+type errorContext struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// orderedContext returns the context in insertion order. Keys that were added to the map
+// returned by Context directly are appended in sorted order.
+func (e *ErrorPlus) orderedContext() *errorContext {
+	keys := e.contextKeys
+	if len(keys) != len(e.context) {
+		known := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			known[key] = true
+		}
+		var extra []string
+		for key := range e.context {
+			if !known[key] {
+				extra = append(extra, key)
+			}
+		}
+		sort.Strings(extra)
+		keys = append(append([]string(nil), keys...), extra...)
+	}
+	return &errorContext{keys: keys, values: e.context}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *errorContext) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	written := 0
+	for _, key := range c.keys {
+		value, ok := c.values[key]
+		if !ok {
+			continue
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal context %q: %w", key, err)
+		}
+		if written > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(valueJSON)
+		written++
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// String renders the context like a map, but in insertion order.
+func (c *errorContext) String() string {
+	var sb strings.Builder
+	sb.WriteString("map[")
+	written := 0
+	for _, key := range c.keys {
+		value, ok := c.values[key]
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%s:%v", key, value)
+		written++
+	}
+	sb.WriteByte(']')
+	return sb.String()
 }
 
 // Format implements the fmt.Formatter interface for custom formatting.
+// For chains created with Wrap, %+v prints every layer (see Chain).
 func (e *ErrorPlus) Format(f fmt.State, c rune) {
 	switch c {
 	case 'v':
 		if f.Flag('+') {
-			fmt.Fprintf(f, "ErrorPlus:\n  Msg: %s\n  Code: %d\n  Error: %+v\n  Context: %v\n  StackTrace:\n%s", e.msg, e.code, e.err, e.context, strings.Join(e.stackTrace, "\n"))
+			if chain := e.Chain(); len(chain) > 1 {
+				formatChain(f, chain)
+				return
+			}
+			function, file, line := e.Caller()
+			fmt.Fprintf(f, "ErrorPlus:\n  Msg: %s\n  Code: %d\n  Error: %+v\n  Context: %v\n  Caller: %s (%s:%d)\n  StackTrace:\n%s", e.msg, e.code, e.err, e.orderedContext(), function, file, line, strings.Join(e.StackTrace(), "\n"))
 		} else {
 			fmt.Fprintf(f, "%s: %v", e.msg, e.err)
 		}
@@ -180,16 +542,75 @@ func (e *ErrorPlus) Format(f fmt.State, c rune) {
 	}
 }
 
-// captureStackTrace captures the current stack trace.
-func captureStackTrace() []string {
+// StackFilterOptions configures how stack traces are rendered.
+type StackFilterOptions struct {
+	SkipPackagePrefixes []string // Frames whose fully qualified function name starts with one of these prefixes are dropped (e.g. "runtime.", "testing.")
+	TrimSourceRoot      string   // Prefix removed from file paths (e.g. the CI checkout directory)
+	MaxFrames           int      // Maximum number of rendered frames (0 means unlimited)
+}
+
+// StackFilterGonutsPackage is the function name prefix of frames inside the gonuts package itself.
+const StackFilterGonutsPackage = "github.com/vaudience/go-nuts."
+
+var stackTraceFilter atomic.Pointer[StackFilterOptions]
+
+// SetStackTraceFilter sets the filter applied when stack traces are rendered.
+// The filter is applied lazily at render time (StackTrace, %+v), so error creation stays cheap
+// and changing the filter also affects errors created before the change.
+//
+// Example usage:
+//
+//	gonuts.SetStackTraceFilter(gonuts.StackFilterOptions{
+//		SkipPackagePrefixes: []string{"runtime.", "testing.", gonuts.StackFilterGonutsPackage},
+//		TrimSourceRoot:      "/home/runner/work/myservice/",
+//		MaxFrames:           10,
+//	})
+func SetStackTraceFilter(opts StackFilterOptions) {
+	opts.SkipPackagePrefixes = append([]string(nil), opts.SkipPackagePrefixes...)
+	stackTraceFilter.Store(&opts)
+}
+
+// captureStackTrace captures the program counters of the current stack,
+// starting at the caller of the exported constructor that called newErrorPlus.
+func captureStackTrace() []uintptr {
 	const maxFrames = 32
 	pcs := make([]uintptr, maxFrames)
-	n := runtime.Callers(3, pcs)
-	frames := runtime.CallersFrames(pcs[:n])
+	n := runtime.Callers(4, pcs)
+	return pcs[:n]
+}
+
+// shortFunctionName strips the import path from a fully qualified function name.
+func shortFunctionName(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
+
+// renderStackTrace resolves program counters into frames, applying the stack trace filter.
+func renderStackTrace(pcs []uintptr) []string {
+	if len(pcs) == 0 {
+		return nil
+	}
+	filter := stackTraceFilter.Load()
+	if filter == nil {
+		filter = &StackFilterOptions{}
+	}
+
+	frames := runtime.CallersFrames(pcs)
 	var stackTrace []string
 	for {
 		frame, more := frames.Next()
-		stackTrace = append(stackTrace, fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line))
+		if !skipStackFrame(frame.Function, filter.SkipPackagePrefixes) {
+			file := frame.File
+			if filter.TrimSourceRoot != "" {
+				file = strings.TrimPrefix(file, filter.TrimSourceRoot)
+			}
+			stackTrace = append(stackTrace, fmt.Sprintf("%s\n\t%s:%d", frame.Function, file, frame.Line))
+			if filter.MaxFrames > 0 && len(stackTrace) >= filter.MaxFrames {
+				break
+			}
+		}
 		if !more {
 			break
 		}
@@ -197,6 +618,16 @@ func captureStackTrace() []string {
 	return stackTrace
 }
 
+// skipStackFrame reports whether a frame's function matches one of the skipped prefixes.
+func skipStackFrame(function string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // copyContext makes a deep copy of the context map.
 func copyContext(original map[string]interface{}) map[string]interface{} {
 	if original == nil {
@@ -213,22 +644,22 @@ func copyContext(original map[string]interface{}) map[string]interface{} {
 
 // NewNotFoundError creates a new ErrorPlus representing a 404 Not Found error.
 func NewNotFoundError(msg string, err error) *ErrorPlus {
-	return NewErrorPlus(err, msg, 404)
+	return newErrorPlus(err, msg, 404, ErrorKindNotFound)
 }
 
 // NewInternalError creates a new ErrorPlus representing a 500 Internal Server Error.
 func NewInternalError(msg string, err error) *ErrorPlus {
-	return NewErrorPlus(err, msg, 500)
+	return newErrorPlus(err, msg, 500, ErrorKindInternal)
 }
 
 // NewUnauthorizedError creates a new ErrorPlus representing a 401 Unauthorized error.
 func NewUnauthorizedError(msg string, err error) *ErrorPlus {
-	return NewErrorPlus(err, msg, 401)
+	return newErrorPlus(err, msg, 401, ErrorKindUnauthorized)
 }
 
 // NewBadRequestError creates a new ErrorPlus representing a 400 Bad Request error.
 func NewBadRequestError(msg string, err error) *ErrorPlus {
-	return NewErrorPlus(err, msg, 400)
+	return newErrorPlus(err, msg, 400, ErrorKindBadRequest)
 }
 
 // WithLogger allows setting a custom logger. By default, uses gonuts.L (the package's default logger).
@@ -240,8 +671,97 @@ func SetErrorLogger(logger *zap.SugaredLogger) {
 }
 
 // Log logs the error using the configured logger.
+// The caller is attached as the structured fields caller_function, caller_file and caller_line.
 func (e *ErrorPlus) Log() {
-	errorLogger.Errorf("%+v", e)
+	function, file, line := e.Caller()
+	errorLogger.With("caller_function", function, "caller_file", file, "caller_line", line).Errorf("%+v", e)
+}
+
+// LogStructured logs the error message as the log line and attaches the code, timestamp,
+// stack trace, wrapped error text, caller and every context value (of all layers, see
+// AllContext) as separate fields, so they stay searchable in JSON log pipelines.
+// Context keys that clash with these fields are prefixed with "context.", and values that
+// cannot be serialized are logged as their fmt %v representation.
+//
+// Parameters:
+//   - logger: the logger to use; the ErrorPlus logger (see SetErrorLogger) if nil
+//
+// Example usage:
+//
+//	err := NewInternalError("payment failed", cause).WithField("orderID", orderID)
+//	err.LogStructured(nil)
+//	// {"level":"error","msg":"payment failed","code":500,"error":"...","stack":[...],"orderID":"o-1",...}
+func (e *ErrorPlus) LogStructured(logger *zap.SugaredLogger) {
+	e.logStructured(logger, nil)
+}
+
+// LogWithContext logs the error like LogStructured with the ErrorPlus logger, adding the request
+// fields of ctx (see LoggerFieldsFromContext) so the entry correlates with the request logs.
+//
+// Example usage:
+//
+//	if err != nil {
+//		err.LogWithContext(r.Context())
+//	}
+func (e *ErrorPlus) LogWithContext(ctx context.Context) {
+	e.logStructured(nil, LoggerFieldsFromContext(ctx))
+}
+
+// errorLogFields are the field names set by LogStructured itself.
+var errorLogFields = map[string]bool{
+	"code": true, "timestamp": true, "stack": true, "error": true,
+	"caller_function": true, "caller_file": true, "caller_line": true,
+}
+
+func (e *ErrorPlus) logStructured(logger *zap.SugaredLogger, extra []zap.Field) {
+	if logger == nil {
+		logger = errorLogger
+	}
+	function, file, line := e.Caller()
+	fields := append(append(make([]zap.Field, 0, len(extra)+7), extra...),
+		zap.Int("code", e.code),
+		zap.Time("timestamp", e.timestamp),
+		zap.Strings("stack", e.StackTrace()),
+		zap.String("caller_function", function),
+		zap.String("caller_file", file),
+		zap.Int("caller_line", line),
+	)
+	if e.err != nil {
+		fields = append(fields, zap.String("error", e.err.Error()))
+	}
+	taken := make(map[string]bool, len(extra))
+	for _, field := range extra {
+		taken[field.Key] = true
+	}
+	keys, values := e.allContext()
+	for _, key := range keys {
+		name := key
+		if errorLogFields[name] || taken[name] {
+			name = "context." + name
+		}
+		fields = append(fields, errorLogField(name, values[key]))
+	}
+	logger.Desugar().WithOptions(zap.AddCallerSkip(2)).Error(e.msg, fields...)
+}
+
+// errorLogField converts a context value into a zap field, stringifying values that cannot be
+// serialized instead of dropping them.
+func errorLogField(key string, value interface{}) zap.Field {
+	switch v := value.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Time, time.Duration, []string:
+		return zap.Any(key, v)
+	case RedactedValue:
+		return zap.String(key, redactedPlaceholder)
+	case error:
+		return zap.String(key, v.Error())
+	case fmt.Stringer:
+		return zap.String(key, v.String())
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return zap.String(key, fmt.Sprintf("%v", value))
+	}
+	return zap.Any(key, value)
 }
 
 // Ensure ErrorPlus satisfies the standard library interfaces.
@@ -249,5 +769,6 @@ var _ interface {
 	error
 	fmt.Formatter
 	json.Marshaler
+	json.Unmarshaler
 	// errors.Wrapper
 } = (*ErrorPlus)(nil)
@@ -0,0 +1,189 @@
+package gonuts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// callerLine returns the line of its call
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// notFoundHelper creates errors on behalf of its caller
+func notFoundHelper(what string) *ErrorPlus {
+	return NewNotFoundError(what+" not found", nil).WithCallerSkip(1)
+}
+
+func TestErrorPlusCallerIsCallSite(t *testing.T) {
+	cause := errors.New("cause")
+	tests := []struct {
+		name string
+		make func() (*ErrorPlus, int)
+	}{
+		{"NewErrorPlus", func() (*ErrorPlus, int) { return NewErrorPlus(cause, "custom", 418), callerLine() }},
+		{"NewNotFoundError", func() (*ErrorPlus, int) { return NewNotFoundError("missing", cause), callerLine() }},
+		{"NewInternalError", func() (*ErrorPlus, int) { return NewInternalError("broken", cause), callerLine() }},
+		{"NewUnauthorizedError", func() (*ErrorPlus, int) { return NewUnauthorizedError("denied", cause), callerLine() }},
+		{"NewBadRequestError", func() (*ErrorPlus, int) { return NewBadRequestError("invalid", cause), callerLine() }},
+		{"Wrap", func() (*ErrorPlus, int) { return Wrap(cause, "wrapped", 500), callerLine() }},
+		{"ValidationErrors.Err", func() (*ErrorPlus, int) {
+			v := NewValidationErrors().AddField("email", "required", "is required", "")
+			return v.Err(), callerLine()
+		}},
+		{"derived", func() (*ErrorPlus, int) {
+			return NewInternalError("broken", cause).WithMsg("derived").WithCode(503), callerLine()
+		}},
+		{"helper with WithCallerSkip", func() (*ErrorPlus, int) { return notFoundHelper("user"), callerLine() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, wantLine := tt.make()
+			function, file, line := err.Caller()
+			if filepath.Base(file) != "nuts.ErrorPlus_test.go" || line != wantLine {
+				t.Errorf("Caller() = %s %s:%d, want nuts.ErrorPlus_test.go:%d", function, file, line, wantLine)
+			}
+			if !strings.HasPrefix(function, "github.com/vaudience/go-nuts.TestErrorPlusCallerIsCallSite.func") {
+				t.Errorf("Caller() function = %s, want a closure of the test", function)
+			}
+		})
+	}
+}
+
+func TestErrorPlusCallerInMessage(t *testing.T) {
+	SetErrorCallerInMessage(true)
+	t.Cleanup(func() { SetErrorCallerInMessage(false) })
+
+	err, line := NewNotFoundError("missing", nil), callerLine()
+	want := "missing (at go-nuts.TestErrorPlusCallerInMessage nuts.ErrorPlus_test.go:" + strconv.Itoa(line) + ")"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+// setStackTraceFilter sets the stack trace filter for the rest of the test
+func setStackTraceFilter(t *testing.T, opts StackFilterOptions) {
+	SetStackTraceFilter(opts)
+	t.Cleanup(func() { SetStackTraceFilter(StackFilterOptions{}) })
+}
+
+func TestStackTraceFilterSkipsPackagesAndLimitsFrames(t *testing.T) {
+	err := NewInternalError("broken", nil) // created before the filter is set
+
+	unfiltered := err.StackTrace()
+	if !strings.HasPrefix(unfiltered[0], "github.com/vaudience/go-nuts.TestStackTraceFilterSkipsPackagesAndLimitsFrames\n") {
+		t.Fatalf("first frame = %q, want the test function", unfiltered[0])
+	}
+	if !slices.ContainsFunc(unfiltered, func(frame string) bool { return strings.HasPrefix(frame, "testing.") }) {
+		t.Fatalf("no testing frame in the unfiltered stack trace:\n%s", strings.Join(unfiltered, "\n"))
+	}
+
+	setStackTraceFilter(t, StackFilterOptions{SkipPackagePrefixes: []string{"runtime.", "testing."}})
+	filtered := err.StackTrace()
+	if len(filtered) != 1 || filtered[0] != unfiltered[0] {
+		t.Errorf("filtered stack trace = %q, want only the test function %q", filtered, unfiltered[0])
+	}
+
+	setStackTraceFilter(t, StackFilterOptions{MaxFrames: 2})
+	if limited := err.StackTrace(); !slices.Equal(limited, unfiltered[:2]) {
+		t.Errorf("stack trace with MaxFrames 2 = %q, want %q", limited, unfiltered[:2])
+	}
+}
+
+func TestStackTraceFilterTrimsSourceRoot(t *testing.T) {
+	_, thisFile, _, _ := runtime.Caller(0)
+	root := filepath.Dir(thisFile) + "/"
+	err, line := NewInternalError("broken", nil), callerLine()
+
+	setStackTraceFilter(t, StackFilterOptions{TrimSourceRoot: root, MaxFrames: 1})
+	want := "github.com/vaudience/go-nuts.TestStackTraceFilterTrimsSourceRoot\n\tnuts.ErrorPlus_test.go:" + strconv.Itoa(line)
+	if stack := err.StackTrace(); len(stack) != 1 || stack[0] != want {
+		t.Errorf("StackTrace() = %q, want [%q]", stack, want)
+	}
+	if _, file, _ := err.Caller(); file != "nuts.ErrorPlus_test.go" {
+		t.Errorf("Caller() file = %q, want it relative to the source root", file)
+	}
+
+	// Frames outside the source root keep their absolute path
+	setStackTraceFilter(t, StackFilterOptions{TrimSourceRoot: root, SkipPackagePrefixes: []string{StackFilterGonutsPackage}})
+	for _, frame := range err.StackTrace() {
+		if file := frame[strings.Index(frame, "\t")+1:]; !filepath.IsAbs(file) {
+			t.Errorf("frame outside the source root has the relative path %q", file)
+		}
+	}
+}
+
+func TestRedactedContextDoesNotLeak(t *testing.T) {
+	const secret = "tok-s3cr3t"
+	inner := NewUnauthorizedError("token rejected", errors.New("signature mismatch")).
+		WithField("userID", "u-1").
+		Redacted("token", secret).
+		WithField("attempt", 3)
+	outer := Wrap(inner, "login failed", 401).Redacted("password", secret).WithField("ip", "10.0.0.1")
+
+	var logs bytes.Buffer
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logs), zapcore.DebugLevel)).Sugar()
+	previous := errorLogger
+	SetErrorLogger(logger)
+	t.Cleanup(func() { SetErrorLogger(previous) })
+
+	for _, err := range []*ErrorPlus{inner, outer} {
+		payload, marshalErr := json.Marshal(err)
+		if marshalErr != nil {
+			t.Fatal(marshalErr)
+		}
+		err.Log()
+		err.LogStructured(nil)
+		outputs := map[string]string{
+			"Error()":         err.Error(),
+			"ContextStr()":    err.ContextStr(),
+			"%v":              fmt.Sprintf("%v", err),
+			"%+v":             fmt.Sprintf("%+v", err),
+			"%#v":             fmt.Sprintf("%#v", err.Context()),
+			"MarshalJSON":     string(payload),
+			"Log":             logs.String(),
+			"%v of Context()": fmt.Sprint(err.Context()),
+		}
+		for name, output := range outputs {
+			if strings.Contains(output, secret) {
+				t.Errorf("%s of %q leaks the redacted value: %s", name, err.Msg(), output)
+			}
+		}
+		if !strings.Contains(outputs["ContextStr()"], redactedPlaceholder) || !strings.Contains(outputs["MarshalJSON"], redactedPlaceholder) {
+			t.Errorf("%q: redacted value not masked as %s:\n%s\n%s", err.Msg(), redactedPlaceholder, outputs["ContextStr()"], outputs["MarshalJSON"])
+		}
+	}
+
+	// Every layer's redacted value is masked in the structured log fields
+	logs.Reset()
+	outer.LogStructured(nil)
+	var entry map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"token", "password"} {
+		if entry[key] != redactedPlaceholder {
+			t.Errorf("log field %s = %v, want %s", key, entry[key], redactedPlaceholder)
+		}
+	}
+	if entry["userID"] != "u-1" || entry["attempt"] != float64(3) || entry["ip"] != "10.0.0.1" {
+		t.Errorf("fields added with WithField missing from the log entry: %v", entry)
+	}
+
+	// The values stay available in-process
+	if value, _ := inner.RevealContext("token"); value != secret {
+		t.Errorf("RevealContext(token) = %v, want the secret", value)
+	}
+}
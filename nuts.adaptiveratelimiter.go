@@ -0,0 +1,252 @@
+package gonuts
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveRateLimiterOptions configures an AdaptiveRateLimiter
+type AdaptiveRateLimiterOptions struct {
+	InitialRate float64 // Starting rate in tokens per second (default MaxRate)
+	MinRate     float64 // Lower bound of the rate
+	MaxRate     float64 // Upper bound of the rate
+	BucketSize  float64 // Maximum number of tokens in the bucket (default 1)
+
+	IncreaseStep   float64 // Rate added per ReportSuccess (default 1)
+	DecreaseFactor float64 // Rate multiplier per ReportThrottled, between 0 and 1 (default 0.5)
+	// DecreaseCooldown is the minimum time between two decreases, so that a burst of throttle
+	// signals from requests that were already in flight only counts once (default 1 second).
+	DecreaseCooldown time.Duration
+
+	// OnRateChange is called after the rate changed. It must not call back into the limiter.
+	OnRateChange func(oldRate, newRate float64)
+}
+
+// AdaptiveRateLimiter is a RateLimiter whose rate follows downstream feedback using
+// additive-increase/multiplicative-decrease (AIMD): every ReportSuccess raises the rate by
+// IncreaseStep, every ReportThrottled multiplies it by DecreaseFactor, always within
+// [MinRate, MaxRate]. A Retry-After reported with ReportThrottled pauses the limiter.
+type AdaptiveRateLimiter struct {
+	limiter *RateLimiter
+	opts    AdaptiveRateLimiterOptions
+
+	mu           sync.Mutex
+	rate         float64
+	lastDecrease time.Time
+	pausedUntil  time.Time
+}
+
+// NewAdaptiveRateLimiter creates a new AdaptiveRateLimiter
+//
+// Example usage:
+//
+//	limiter := gonuts.NewAdaptiveRateLimiter(gonuts.AdaptiveRateLimiterOptions{
+//	    MinRate: 1,
+//	    MaxRate: 100,
+//	    OnRateChange: func(oldRate, newRate float64) {
+//	        L.Infof("upstream rate %.1f -> %.1f/s", oldRate, newRate)
+//	    },
+//	})
+//
+//	if err := limiter.Wait(ctx); err != nil {
+//	    return err
+//	}
+//	resp, err := client.Do(req)
+//	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+//	    limiter.ReportThrottledResponse(resp)
+//	} else if err == nil {
+//	    limiter.ReportSuccess()
+//	}
+func NewAdaptiveRateLimiter(opts AdaptiveRateLimiterOptions) *AdaptiveRateLimiter {
+	if opts.MinRate < 0 {
+		opts.MinRate = 0
+	}
+	if opts.MaxRate < opts.MinRate {
+		opts.MaxRate = opts.MinRate
+	}
+	if opts.InitialRate <= 0 {
+		opts.InitialRate = opts.MaxRate
+	}
+	opts.InitialRate = clampRate(opts.InitialRate, opts.MinRate, opts.MaxRate)
+	if opts.BucketSize <= 0 {
+		opts.BucketSize = 1
+	}
+	if opts.IncreaseStep <= 0 {
+		opts.IncreaseStep = 1
+	}
+	if opts.DecreaseFactor <= 0 || opts.DecreaseFactor >= 1 {
+		opts.DecreaseFactor = 0.5
+	}
+	if opts.DecreaseCooldown <= 0 {
+		opts.DecreaseCooldown = time.Second
+	}
+	return &AdaptiveRateLimiter{
+		limiter: NewRateLimiter(opts.InitialRate, opts.BucketSize),
+		opts:    opts,
+		rate:    opts.InitialRate,
+	}
+}
+
+// Allow checks if a request is allowed under the current rate; it is never allowed while paused
+func (al *AdaptiveRateLimiter) Allow() bool {
+	return al.AllowN(1)
+}
+
+// AllowN checks if n requests are allowed under the current rate; they are never allowed while paused
+func (al *AdaptiveRateLimiter) AllowN(n float64) bool {
+	if al.PausedFor() > 0 {
+		return false
+	}
+	return al.limiter.AllowN(n)
+}
+
+// Wait blocks until a request is allowed or the context is cancelled
+func (al *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	return al.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n requests are allowed or the context is cancelled, sleeping through pauses
+func (al *AdaptiveRateLimiter) WaitN(ctx context.Context, n float64) error {
+	for {
+		if pause := al.PausedFor(); pause > 0 {
+			timer := time.NewTimer(pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		delay, err := al.limiter.acquireOrDelay(n)
+		if err != nil || delay == 0 {
+			return err
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// ReportSuccess signals a request that was not throttled and increases the rate additively
+func (al *AdaptiveRateLimiter) ReportSuccess() {
+	al.mu.Lock()
+	oldRate := al.rate
+	newRate := al.setRateLocked(al.rate + al.opts.IncreaseStep)
+	al.mu.Unlock()
+
+	al.notifyRateChange(oldRate, newRate)
+}
+
+// ReportThrottled signals a throttled request (e.g. HTTP 429) and decreases the rate
+// multiplicatively, at most once per DecreaseCooldown.
+//
+// Parameters:
+//   - retryAfter: pauses the limiter for this duration if positive
+func (al *AdaptiveRateLimiter) ReportThrottled(retryAfter time.Duration) {
+	now := time.Now()
+	al.mu.Lock()
+	if retryAfter > 0 && now.Add(retryAfter).After(al.pausedUntil) {
+		al.pausedUntil = now.Add(retryAfter)
+	}
+	oldRate := al.rate
+	newRate := oldRate
+	if al.lastDecrease.IsZero() || now.Sub(al.lastDecrease) >= al.opts.DecreaseCooldown {
+		newRate = al.setRateLocked(al.rate * al.opts.DecreaseFactor)
+		al.lastDecrease = now
+	}
+	al.mu.Unlock()
+
+	al.notifyRateChange(oldRate, newRate)
+}
+
+// ReportThrottledResponse calls ReportThrottled with the Retry-After header of the response
+func (al *AdaptiveRateLimiter) ReportThrottledResponse(resp *http.Response) {
+	var retryAfter time.Duration
+	if resp != nil {
+		retryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	al.ReportThrottled(retryAfter)
+}
+
+// CurrentRate returns the current rate in tokens per second
+func (al *AdaptiveRateLimiter) CurrentRate() float64 {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.rate
+}
+
+// PausedFor returns how long the limiter remains paused by a Retry-After, 0 if it is not paused
+func (al *AdaptiveRateLimiter) PausedFor() time.Duration {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if d := time.Until(al.pausedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// setRateLocked clamps and applies a new rate to the underlying limiter. The caller must hold al.mu.
+func (al *AdaptiveRateLimiter) setRateLocked(rate float64) float64 {
+	rate = clampRate(rate, al.opts.MinRate, al.opts.MaxRate)
+	if rate != al.rate {
+		al.rate = rate
+		al.limiter.SetRate(rate)
+	}
+	return rate
+}
+
+// notifyRateChange calls OnRateChange if the rate changed
+func (al *AdaptiveRateLimiter) notifyRateChange(oldRate, newRate float64) {
+	if oldRate != newRate && al.opts.OnRateChange != nil {
+		al.opts.OnRateChange(oldRate, newRate)
+	}
+}
+
+func clampRate(rate, lo, hi float64) float64 {
+	if rate < lo {
+		return lo
+	}
+	if rate > hi {
+		return hi
+	}
+	return rate
+}
+
+// ParseRetryAfter parses a Retry-After header value given in seconds or as an HTTP date
+//
+// Parameters:
+//   - value: the header value
+//   - now: the reference time for HTTP dates
+//
+// Returns:
+//   - time.Duration: the time to wait, 0 if the value is empty, invalid or in the past
+//
+// Example usage:
+//
+//	wait := gonuts.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
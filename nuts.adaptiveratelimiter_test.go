@@ -0,0 +1,145 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveRateLimiterAIMD(t *testing.T) {
+	var changes [][2]float64
+	al := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{
+		InitialRate:      10,
+		MinRate:          2,
+		MaxRate:          12,
+		IncreaseStep:     1.5,
+		DecreaseFactor:   0.25,
+		DecreaseCooldown: 20 * time.Millisecond,
+		OnRateChange: func(oldRate, newRate float64) {
+			changes = append(changes, [2]float64{oldRate, newRate})
+		},
+	})
+
+	al.ReportSuccess() // 11.5
+	al.ReportSuccess() // 13 clamped to MaxRate 12
+	al.ReportSuccess() // unchanged, not reported
+	if rate := al.CurrentRate(); rate != 12 {
+		t.Fatalf("rate = %v after increases, want MaxRate 12", rate)
+	}
+
+	al.ReportThrottled(0) // 3
+	al.ReportThrottled(0) // within the cooldown, ignored
+	if rate := al.CurrentRate(); rate != 3 {
+		t.Fatalf("rate = %v after two throttles within the cooldown, want one decrease to 3", rate)
+	}
+	time.Sleep(25 * time.Millisecond)
+	al.ReportThrottled(0) // 0.75 clamped to MinRate 2
+	if rate := al.CurrentRate(); rate != 2 {
+		t.Fatalf("rate = %v after the cooldown, want MinRate 2", rate)
+	}
+	if rate := al.limiter.Rate(); rate != 2 {
+		t.Errorf("underlying limiter rate = %v, want it to follow the adaptive rate", rate)
+	}
+
+	want := [][2]float64{{10, 11.5}, {11.5, 12}, {12, 3}, {3, 2}}
+	if len(changes) != len(want) {
+		t.Fatalf("OnRateChange calls = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("OnRateChange call %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestAdaptiveRateLimiterDefaults(t *testing.T) {
+	al := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MinRate: 1, MaxRate: 100})
+	if rate := al.CurrentRate(); rate != 100 {
+		t.Errorf("initial rate = %v, want MaxRate", rate)
+	}
+	al.ReportThrottled(0)
+	if rate := al.CurrentRate(); rate != 50 {
+		t.Errorf("rate = %v after a throttle, want the default factor 0.5", rate)
+	}
+	al.ReportSuccess()
+	if rate := al.CurrentRate(); rate != 51 {
+		t.Errorf("rate = %v after a success, want the default step 1", rate)
+	}
+}
+
+func TestAdaptiveRateLimiterRetryAfterPauses(t *testing.T) {
+	const pause = 50 * time.Millisecond
+	al := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MaxRate: 1000, BucketSize: 10})
+	if !al.Allow() {
+		t.Fatal("Allow failed before the pause")
+	}
+
+	al.ReportThrottled(pause)
+	al.ReportThrottled(time.Millisecond) // a shorter Retry-After does not shorten the pause
+	if d := al.PausedFor(); d <= pause-10*time.Millisecond || d > pause {
+		t.Errorf("PausedFor() = %s, want about %s", d, pause)
+	}
+	if al.Allow() {
+		t.Error("Allow succeeded while paused, although the bucket holds tokens")
+	}
+
+	// Wait sleeps through the pause, a shorter context gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := al.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait with a context shorter than the pause: error = %v, want DeadlineExceeded", err)
+	}
+	start := time.Now()
+	if err := al.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < pause-20*time.Millisecond {
+		t.Errorf("Wait returned after %s, want it to sleep through the rest of the pause", elapsed)
+	}
+	if al.PausedFor() != 0 || !al.Allow() {
+		t.Error("the limiter is still paused after the Retry-After")
+	}
+}
+
+func TestAdaptiveRateLimiterThrottledResponse(t *testing.T) {
+	al := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MaxRate: 10})
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"2"}}}
+	al.ReportThrottledResponse(resp)
+	if d := al.PausedFor(); d <= time.Second || d > 2*time.Second {
+		t.Errorf("PausedFor() = %s after Retry-After: 2, want about 2s", d)
+	}
+	if rate := al.CurrentRate(); rate != 5 {
+		t.Errorf("rate = %v, want it decreased to 5", rate)
+	}
+
+	// Without a response or header, only the rate decreases
+	other := NewAdaptiveRateLimiter(AdaptiveRateLimiterOptions{MaxRate: 10})
+	other.ReportThrottledResponse(nil)
+	if other.PausedFor() != 0 || other.CurrentRate() != 5 {
+		t.Errorf("PausedFor() = %s, rate = %v without a response, want no pause and rate 5", other.PausedFor(), other.CurrentRate())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"Wed, 01 May 2024 10:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 09:59:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
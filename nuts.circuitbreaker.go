@@ -1,7 +1,9 @@
 package gonuts
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -15,6 +17,64 @@ const (
 	StateHalfOpen
 )
 
+// String returns "closed", "open" or "half-open"
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitBreakerState(%d)", int(s))
+}
+
+// CircuitBreakerCounts is a snapshot of the counters of a CircuitBreaker
+type CircuitBreakerCounts struct {
+	ConsecutiveFailures  uint
+	ConsecutiveSuccesses uint
+	TotalRequests        uint64 // calls that ran the function; calls rejected while open are not counted
+	TotalFailures        uint64
+	StateSince           time.Time // when the current state was entered
+}
+
+// CircuitBreakerOption configures a CircuitBreaker created with NewCircuitBreaker
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// WithHalfOpenProbes sets how many requests may be in flight while the breaker is half-open
+// (default 1). Other calls are rejected with an error matching both ErrCircuitOpen and
+// ErrTooManyProbes, so a still broken dependency is probed instead of flooded.
+func WithHalfOpenProbes(n uint) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		if n == 0 {
+			n = 1
+		}
+		cb.maxProbes = n
+	}
+}
+
+// WithCancellationAsFailure makes ExecuteWithContext count a function that failed because its
+// context was cancelled or timed out as a failure. By default such calls count as neither
+// success nor failure, as the caller gave up rather than the dependency.
+func WithCancellationAsFailure(enabled bool) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.cancellationAsFailure = enabled
+	}
+}
+
+// WithOnStateChange sets the callback of state changes at construction (see OnStateChange)
+func WithOnStateChange(fn func(from, to CircuitBreakerState)) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.onStateChange = fn
+	}
+}
+
+// circuitStateChange is a state change waiting to be passed to the callback
+type circuitStateChange struct {
+	from, to CircuitBreakerState
+}
+
 // CircuitBreaker implements the Circuit Breaker pattern
 type CircuitBreaker struct {
 	mu sync.Mutex
@@ -28,10 +88,34 @@ type CircuitBreaker struct {
 	state     CircuitBreakerState
 	lastError error
 	expiry    time.Time
+
+	maxProbes             uint   // requests allowed in flight while half-open
+	probes                uint   // requests in flight while half-open
+	generation            uint64 // incremented on every state change, so late results of an earlier state are ignored
+	cancellationAsFailure bool
+	window                *circuitRateWindow // outcomes of the closed state of a rate based breaker, nil otherwise
+
+	counts        CircuitBreakerCounts
+	onStateChange func(from, to CircuitBreakerState)
 }
 
-// ErrCircuitOpen is returned when the circuit breaker is in the open state
-var ErrCircuitOpen = errors.New("circuit breaker is open")
+var (
+	// ErrCircuitOpen is returned when the circuit breaker is in the open state
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrTooManyProbes is returned while the breaker is half-open and all probe requests are in flight
+	ErrTooManyProbes = errors.New("circuit breaker is half-open and all probes are in flight")
+
+	errHalfOpenRejected = fmt.Errorf("%w: %w", ErrCircuitOpen, ErrTooManyProbes)
+)
+
+// circuitOutcome is how a request affects the breaker
+type circuitOutcome int
+
+const (
+	circuitSuccess circuitOutcome = iota
+	circuitFailure
+	circuitIgnored // e.g. cancelled by the caller
+)
 
 // NewCircuitBreaker creates a new CircuitBreaker
 //
@@ -39,6 +123,7 @@ var ErrCircuitOpen = errors.New("circuit breaker is open")
 //   - failureThreshold: number of failures before opening the circuit
 //   - resetTimeout: duration to wait before attempting to close the circuit
 //   - halfOpenSuccess: number of successes in half-open state to close the circuit
+//   - opts: options like WithOnStateChange
 //
 // Returns:
 //   - *CircuitBreaker: a new instance of CircuitBreaker
@@ -58,65 +143,247 @@ var ErrCircuitOpen = errors.New("circuit breaker is open")
 //	        log.Printf("Operation failed: %v", err)
 //	    }
 //	}
-func NewCircuitBreaker(failureThreshold uint, resetTimeout time.Duration, halfOpenSuccess uint) *CircuitBreaker {
-	return &CircuitBreaker{
+func NewCircuitBreaker(failureThreshold uint, resetTimeout time.Duration, halfOpenSuccess uint, opts ...CircuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
 		failureThreshold: failureThreshold,
 		resetTimeout:     resetTimeout,
 		halfOpenSuccess:  halfOpenSuccess,
+		maxProbes:        1,
 		state:            StateClosed,
+		counts:           CircuitBreakerCounts{StateSince: time.Now()},
+	}
+	for _, opt := range opts {
+		opt(cb)
 	}
+	return cb
+}
+
+// OnStateChange sets a callback that is called whenever the breaker changes its state, e.g. to
+// page someone when it opens. It replaces the callback set before; nil removes it. The callback
+// runs on the goroutine of the call that caused the change, after the breaker was
+// unlocked, so it may call back into the breaker. Changes caused by concurrent calls may be
+// reported out of order.
+//
+// Returns:
+//   - *CircuitBreaker: the breaker for method chaining
+//
+// Example usage:
+//
+//	cb.OnStateChange(func(from, to gonuts.CircuitBreakerState) {
+//	    L.Warnf("[payments] circuit breaker %s -> %s", from, to)
+//	    if to == gonuts.StateOpen {
+//	        pager.Trigger("payments circuit open", cb.LastError())
+//	    }
+//	})
+func (cb *CircuitBreaker) OnStateChange(fn func(from, to CircuitBreakerState)) *CircuitBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onStateChange = fn
+	return cb
 }
 
-// Execute runs the given function if the circuit is closed or half-open
+// Execute runs the given function if the circuit is closed or half-open. The function runs
+// without holding the breaker's lock, so protected calls run concurrently; a panic counts as a
+// failure and is re-raised.
 //
 // Parameters:
 //   - f: the function to execute
 //
 // Returns:
-//   - error: nil if the function succeeds, ErrCircuitOpen if the circuit is open,
-//     or the error returned by the function
+//   - error: nil if the function succeeds, ErrCircuitOpen if the circuit is open (also matching
+//     ErrTooManyProbes if it is half-open and all probes are in flight), or the error returned by the function
 func (cb *CircuitBreaker) Execute(f func() error) error {
+	return cb.run(func() (circuitOutcome, error) {
+		if err := f(); err != nil {
+			return circuitFailure, err
+		}
+		return circuitSuccess, nil
+	})
+}
+
+// ExecuteWithContext is like Execute, but passes ctx to the function. It returns the context's
+// error without calling the function if ctx is already done. A function that fails with the
+// error of its cancelled context counts as neither success nor failure, unless the breaker was
+// created with WithCancellationAsFailure(true).
+//
+// Parameters:
+//   - ctx: the context of the call
+//   - f: the function to execute
+//
+// Returns:
+//   - error: like Execute, or ctx.Err() if ctx was done before the call
+//
+// Example usage:
+//
+//	err := cb.ExecuteWithContext(ctx, func(ctx context.Context) error {
+//	    return client.Charge(ctx, order)
+//	})
+func (cb *CircuitBreaker) ExecuteWithContext(ctx context.Context, f func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return cb.run(func() (circuitOutcome, error) {
+		err := f(ctx)
+		switch {
+		case err == nil:
+			return circuitSuccess, nil
+		case !cb.cancellationAsFailure && ctx.Err() != nil && errors.Is(err, ctx.Err()):
+			return circuitIgnored, err
+		}
+		return circuitFailure, err
+	})
+}
+
+// ExecuteResult runs f through the circuit breaker like Execute and returns its value.
+// The zero value is returned if the circuit is open.
+//
+// Example usage:
+//
+//	user, err := gonuts.ExecuteResult(cb, func() (*User, error) {
+//	    return api.GetUser(id)
+//	})
+func ExecuteResult[T any](cb *CircuitBreaker, f func() (T, error)) (T, error) {
+	var result T
+	err := cb.Execute(func() error {
+		var err error
+		result, err = f()
+		return err
+	})
+	return result, err
+}
+
+// run admits a request, runs f outside the lock and records its outcome
+func (cb *CircuitBreaker) run(f func() (circuitOutcome, error)) error {
+	generation, err := cb.beforeRequest()
+	if err != nil {
+		return err
+	}
+	outcome := circuitFailure // if f panics
+	defer func() {
+		cb.afterRequest(generation, outcome, err)
+	}()
+	outcome, err = f()
+	return err
+}
+
+// beforeRequest checks whether a request may run and returns the generation it runs in
+func (cb *CircuitBreaker) beforeRequest() (uint64, error) {
+	var changes []circuitStateChange
+	defer func() { cb.notifyStateChanges(changes) }()
+
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	now := time.Now()
-
 	if cb.state == StateOpen {
-		if now.After(cb.expiry) {
-			cb.state = StateHalfOpen
-			cb.failures = 0
-			cb.successes = 0
-		} else {
-			return ErrCircuitOpen
+		if !now.After(cb.expiry) {
+			return 0, ErrCircuitOpen
 		}
+		cb.setState(StateHalfOpen, now, &changes)
+		cb.failures = 0
+		cb.successes = 0
 	}
+	if cb.state == StateHalfOpen {
+		if cb.probes >= cb.maxProbes {
+			return 0, errHalfOpenRejected
+		}
+		cb.probes++
+	}
+	cb.counts.TotalRequests++
+	return cb.generation, nil
+}
 
-	err := f()
+// afterRequest records the outcome of a request. Outcomes of requests admitted in an earlier
+// generation only update the counts.
+func (cb *CircuitBreaker) afterRequest(generation uint64, outcome circuitOutcome, err error) {
+	var changes []circuitStateChange
+	defer func() { cb.notifyStateChanges(changes) }()
 
-	if err != nil {
-		cb.failures++
-		cb.lastError = err
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 
-		if cb.failures >= cb.failureThreshold {
-			cb.state = StateOpen
+	current := generation == cb.generation
+	if current && cb.state == StateHalfOpen {
+		cb.probes--
+	}
+	now := time.Now()
+
+	switch outcome {
+	case circuitFailure:
+		if err != nil { // nil if f panicked
+			cb.lastError = err
+		}
+		cb.counts.TotalFailures++
+		cb.counts.ConsecutiveFailures++
+		cb.counts.ConsecutiveSuccesses = 0
+		if !current {
+			return
+		}
+		cb.failures++
+		if cb.tripped(now) {
+			cb.setState(StateOpen, now, &changes)
 			cb.expiry = now.Add(cb.resetTimeout)
 		}
-
-		return err
+	case circuitSuccess:
+		cb.counts.ConsecutiveSuccesses++
+		cb.counts.ConsecutiveFailures = 0
+		if !current {
+			return
+		}
+		if cb.state == StateHalfOpen {
+			cb.successes++
+			if cb.successes >= cb.halfOpenSuccess {
+				cb.setState(StateClosed, now, &changes)
+			}
+		} else {
+			// Reset failures on success in closed state
+			cb.failures = 0
+			if cb.window != nil {
+				cb.window.record(now, false)
+			}
+		}
 	}
+}
 
-	if cb.state == StateHalfOpen {
-		cb.successes++
+// tripped records a failure in the window of a rate based breaker and reports whether the
+// breaker has to open. The caller must hold cb.mu.
+func (cb *CircuitBreaker) tripped(now time.Time) bool {
+	if cb.window != nil && cb.state == StateClosed {
+		cb.window.record(now, true)
+		return cb.window.exceeded(now)
+	}
+	return cb.failures >= cb.failureThreshold
+}
 
-		if cb.successes >= cb.halfOpenSuccess {
-			cb.state = StateClosed
-		}
-	} else {
-		// Reset failures on success in closed state
-		cb.failures = 0
+// setState changes the state and records the change for the callback. The caller must hold cb.mu.
+func (cb *CircuitBreaker) setState(to CircuitBreakerState, now time.Time, changes *[]circuitStateChange) {
+	if cb.state == to {
+		return
 	}
+	*changes = append(*changes, circuitStateChange{from: cb.state, to: to})
+	cb.state = to
+	if to == StateClosed && cb.window != nil {
+		cb.window.reset()
+	}
+	cb.generation++
+	cb.probes = 0
+	cb.counts.StateSince = now
+}
 
-	return nil
+// notifyStateChanges passes state changes to the callback; it must be called without holding cb.mu
+func (cb *CircuitBreaker) notifyStateChanges(changes []circuitStateChange) {
+	if len(changes) == 0 {
+		return
+	}
+	cb.mu.Lock()
+	fn := cb.onStateChange
+	cb.mu.Unlock()
+	if fn == nil {
+		return
+	}
+	for _, change := range changes {
+		fn(change.from, change.to)
+	}
 }
 
 // State returns the current state of the circuit breaker
@@ -126,6 +393,19 @@ func (cb *CircuitBreaker) State() CircuitBreakerState {
 	return cb.state
 }
 
+// Counts returns a snapshot of the counters. The consecutive counts span state changes: a
+// success resets ConsecutiveFailures and a failure resets ConsecutiveSuccesses.
+//
+// Example usage:
+//
+//	counts := cb.Counts()
+//	L.Infof("[payments] %s since %s, %d/%d requests failed", cb.State(), counts.StateSince.Format(time.RFC3339), counts.TotalFailures, counts.TotalRequests)
+func (cb *CircuitBreaker) Counts() CircuitBreakerCounts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.counts
+}
+
 // LastError returns the last error that occurred
 func (cb *CircuitBreaker) LastError() error {
 	cb.mu.Lock()
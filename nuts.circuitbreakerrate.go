@@ -0,0 +1,87 @@
+package gonuts
+
+import "time"
+
+// circuitRateBuckets is the number of buckets the window of a rate based breaker is divided into
+const circuitRateBuckets = 10
+
+// NewCircuitBreakerWithRate creates a CircuitBreaker that opens when the ratio of failed
+// requests over a sliding window exceeds failureRatio, instead of after a number of consecutive
+// failures. Outcomes are counted in a ring of time buckets, each a tenth of the window, so old
+// outcomes fall out of the window one bucket at a time. The window starts empty whenever the
+// breaker closes. While half-open, a single failure opens the breaker again.
+//
+// Parameters:
+//   - minRequests: the number of requests the window must hold before the ratio is evaluated
+//   - failureRatio: the ratio of failures (0 to 1) the window must exceed to open the circuit
+//   - window: the duration of the sliding window (one minute if <= 0)
+//   - resetTimeout: duration to wait before attempting to close the circuit
+//   - halfOpenSuccess: number of successes in half-open state to close the circuit
+//   - opts: options like WithOnStateChange
+//
+// Returns:
+//   - *CircuitBreaker: a new instance of CircuitBreaker
+//
+// Example usage:
+//
+//	// open when more than 5% of at least 100 requests within 30 seconds failed
+//	cb := gonuts.NewCircuitBreakerWithRate(100, 0.05, 30*time.Second, 10*time.Second, 2)
+func NewCircuitBreakerWithRate(minRequests int, failureRatio float64, window, resetTimeout time.Duration, halfOpenSuccess uint, opts ...CircuitBreakerOption) *CircuitBreaker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	if minRequests < 1 {
+		minRequests = 1
+	}
+	cb := NewCircuitBreaker(1, resetTimeout, halfOpenSuccess, opts...)
+	cb.window = &circuitRateWindow{
+		bucketWidth:  max(int64(window/circuitRateBuckets), 1),
+		minRequests:  uint64(minRequests),
+		failureRatio: failureRatio,
+	}
+	return cb
+}
+
+// circuitRateWindow counts the outcomes of a sliding window in a ring of time buckets
+type circuitRateWindow struct {
+	bucketWidth  int64 // nanoseconds
+	minRequests  uint64
+	failureRatio float64
+	buckets      [circuitRateBuckets]circuitRateBucket
+}
+
+type circuitRateBucket struct {
+	slot     int64 // unix nanos / bucketWidth of the counted outcomes
+	requests uint64
+	failures uint64
+}
+
+// record counts an outcome in the bucket of now, clearing the bucket if it holds an outdated slot
+func (w *circuitRateWindow) record(now time.Time, failure bool) {
+	slot := now.UnixNano() / w.bucketWidth
+	b := &w.buckets[slot%circuitRateBuckets]
+	if b.slot != slot {
+		*b = circuitRateBucket{slot: slot}
+	}
+	b.requests++
+	if failure {
+		b.failures++
+	}
+}
+
+// exceeded reports whether the failure ratio of the window ending at now exceeds the threshold
+func (w *circuitRateWindow) exceeded(now time.Time) bool {
+	slot := now.UnixNano() / w.bucketWidth
+	var requests, failures uint64
+	for _, b := range w.buckets {
+		if slot-b.slot < circuitRateBuckets {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests >= w.minRequests && float64(failures)/float64(requests) > w.failureRatio
+}
+
+func (w *circuitRateWindow) reset() {
+	w.buckets = [circuitRateBuckets]circuitRateBucket{}
+}
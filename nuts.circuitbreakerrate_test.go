@@ -0,0 +1,100 @@
+package gonuts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitRateWindowSlides(t *testing.T) {
+	const bucket = int64(time.Second)
+	w := &circuitRateWindow{bucketWidth: bucket, minRequests: 4, failureRatio: 0.5}
+	at := func(buckets int) time.Time { return time.Unix(0, int64(1000+buckets)*bucket) }
+
+	// Bucket 0: 2 failures, bucket 5: 1 success and 2 failures
+	w.record(at(0), true)
+	w.record(at(0), true)
+	w.record(at(5), false)
+	if w.exceeded(at(5)) {
+		t.Fatal("exceeded with 3 requests, want minRequests 4 to be required")
+	}
+	w.record(at(5), true)
+	if !w.exceeded(at(5)) {
+		t.Fatal("not exceeded with 3 failures of 4 requests")
+	}
+
+	// At bucket 9 the window still holds bucket 0, at bucket 10 it has dropped out
+	if !w.exceeded(at(9)) {
+		t.Error("bucket 0 dropped out of the window at bucket 9")
+	}
+	if w.exceeded(at(10)) {
+		t.Error("bucket 0 is still counted at bucket 10")
+	}
+
+	// A bucket that is reused for a later slot starts empty
+	w.record(at(10), false)
+	w.record(at(10), false)
+	w.record(at(10), false)
+	if w.exceeded(at(10)) {
+		t.Error("exceeded with 1 failure of 5 requests: the reused bucket kept its old outcomes")
+	}
+	if got := w.buckets[(1000+10)%circuitRateBuckets]; got.requests != 3 || got.failures != 0 {
+		t.Errorf("reused bucket = %+v, want 3 requests and no failures", got)
+	}
+
+	// Equal to the ratio is not exceeded
+	w.reset()
+	w.record(at(0), true)
+	w.record(at(0), true)
+	w.record(at(0), false)
+	w.record(at(0), false)
+	if w.exceeded(at(0)) {
+		t.Error("exceeded with a failure ratio equal to the threshold")
+	}
+}
+
+func TestCircuitBreakerWithRateTrips(t *testing.T) {
+	cb := NewCircuitBreakerWithRate(10, 0.5, time.Minute, time.Hour, 1)
+	failure := errors.New("upstream down")
+	succeed := func() error { return nil }
+	fail := func() error { return failure }
+
+	// Unlike a consecutive failure threshold, failures interleaved with successes count
+	for i := 0; i < 5; i++ {
+		_ = cb.Execute(succeed)
+		_ = cb.Execute(fail)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("state = %s with 5 failures of 10 requests, want closed", state)
+	}
+	_ = cb.Execute(fail)
+	if state := cb.State(); state != StateOpen {
+		t.Fatalf("state = %s with 6 failures of 11 requests, want open", state)
+	}
+	if err := cb.Execute(succeed); err == nil {
+		t.Error("Execute succeeded while the circuit is open")
+	}
+}
+
+func TestCircuitBreakerWithRateForgetsOldFailures(t *testing.T) {
+	const window = 100 * time.Millisecond
+	cb := NewCircuitBreakerWithRate(4, 0.5, window, time.Hour, 1)
+	fail := func() error { return errors.New("upstream down") }
+
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(fail)
+	}
+	time.Sleep(window + window/circuitRateBuckets)
+
+	// The 3 old failures have left the window, so the ratio is not evaluated yet
+	_ = cb.Execute(fail)
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("state = %s after 1 failure within the window, want closed", state)
+	}
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(fail)
+	}
+	if state := cb.State(); state != StateOpen {
+		t.Errorf("state = %s after 4 failures within the window, want open", state)
+	}
+}
@@ -1,22 +1,31 @@
 package gonuts
 
 import (
-	"fmt"
+	"reflect"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ConcurrentMap is a thread-safe map implementation
 type ConcurrentMap[K comparable, V any] struct {
-	shards    []*mapShard[K, V]
-	numShards int
+	table   atomic.Pointer[mapTable[K, V]]
+	reshard mapReshard
+	subs    mapSubscriptions[K, V]
+	ttl     mapTTL[K, V]
 }
 
 type mapShard[K comparable, V any] struct {
-	items map[K]V
-	mu    sync.RWMutex
+	items   map[K]V
+	expires map[K]int64 // unix nanos of the entries set with a TTL, nil until the first SetWithTTL
+	moved   bool        // the entries were migrated to the next table (see mapTable)
+	mu      sync.RWMutex
 }
 
 // NewConcurrentMap creates a new ConcurrentMap with the specified number of shards.
+// The shard count stays fixed unless AutoReshard is enabled or ReshardNow is called.
 //
 // Example:
 //
@@ -25,33 +34,32 @@ func NewConcurrentMap[K comparable, V any](numShards int) *ConcurrentMap[K, V] {
 	if numShards <= 0 {
 		numShards = 32 // Default number of shards
 	}
-	cm := &ConcurrentMap[K, V]{
-		shards:    make([]*mapShard[K, V], numShards),
-		numShards: numShards,
-	}
-	for i := 0; i < numShards; i++ {
-		cm.shards[i] = &mapShard[K, V]{
-			items: make(map[K]V),
-		}
-	}
+	cm := &ConcurrentMap[K, V]{}
+	cm.table.Store(newMapTable[K, V](numShards))
 	return cm
 }
 
-func (cm *ConcurrentMap[K, V]) getShard(key K) *mapShard[K, V] {
-	hash := fnv32(fmt.Sprintf("%v", key))
-	return cm.shards[hash%uint32(cm.numShards)]
-}
-
 // Set adds a key-value pair to the map or updates the value if the key already exists.
+// The entry never expires, even if it replaces an entry set with SetWithTTL.
 //
 // Example:
 //
 //	cm.Set("key", 42)
 func (cm *ConcurrentMap[K, V]) Set(key K, value V) {
-	shard := cm.getShard(key)
-	shard.mu.Lock()
-	defer shard.mu.Unlock()
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
+	old, hadOld := shard.items[key]
 	shard.items[key] = value
+	delete(shard.expires, key)
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	cm.afterInsert(shardLen)
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, Old: old, HadOld: hadOld, New: value})
+	}
 }
 
 // Get retrieves a value from the map.
@@ -63,9 +71,12 @@ func (cm *ConcurrentMap[K, V]) Set(key K, value V) {
 //	    fmt.Println(value)
 //	}
 func (cm *ConcurrentMap[K, V]) Get(key K) (V, bool) {
-	shard := cm.getShard(key)
-	shard.mu.RLock()
+	shard := cm.rlockShard(key)
 	defer shard.mu.RUnlock()
+	if shard.expired(key) {
+		var zero V
+		return zero, false
+	}
 	val, ok := shard.items[key]
 	return val, ok
 }
@@ -76,10 +87,18 @@ func (cm *ConcurrentMap[K, V]) Get(key K) (V, bool) {
 //
 //	cm.Delete("key")
 func (cm *ConcurrentMap[K, V]) Delete(key K) {
-	shard := cm.getShard(key)
-	shard.mu.Lock()
-	defer shard.mu.Unlock()
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
+	old, hadOld := shard.items[key]
 	delete(shard.items, key)
+	delete(shard.expires, key)
+	shard.mu.Unlock()
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if hadOld && cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeDelete, Key: key, Old: old, HadOld: true})
+	}
 }
 
 // Len returns the total number of items in the map.
@@ -90,28 +109,58 @@ func (cm *ConcurrentMap[K, V]) Delete(key K) {
 //	fmt.Printf("Map contains %d items\n", count)
 func (cm *ConcurrentMap[K, V]) Len() int {
 	count := 0
-	for _, shard := range cm.shards {
-		shard.mu.RLock()
+	now := time.Now().UnixNano()
+	cm.forEachShard(false, func(shard *mapShard[K, V], _ int) bool {
 		count += len(shard.items)
-		shard.mu.RUnlock()
-	}
+		for _, expiry := range shard.expires {
+			if now >= expiry {
+				count--
+			}
+		}
+		return true
+	})
 	return count
 }
 
-// Clear removes all items from the map.
+// Clear removes all items from the map. Subscribers receive a ChangeDelete event for every removed key,
+// expired entries are reported to OnEvict and as ChangeExpire events.
 //
 // Example:
 //
 //	cm.Clear()
 func (cm *ConcurrentMap[K, V]) Clear() {
-	for _, shard := range cm.shards {
-		shard.mu.Lock()
-		shard.items = make(map[K]V)
-		shard.mu.Unlock()
+	type cleared struct {
+		items   map[K]V
+		expires map[K]int64
+	}
+	var removed []cleared
+	cm.forEachShard(true, func(shard *mapShard[K, V], _ int) bool {
+		if len(shard.items) > 0 {
+			removed = append(removed, cleared{items: shard.items, expires: shard.expires})
+			shard.items = make(map[K]V)
+			shard.expires = nil
+		}
+		return true
+	})
+	// Events are sent after the shard locks are released
+	for _, old := range removed {
+		now := time.Now().UnixNano()
+		for key, expiry := range old.expires {
+			if now >= expiry {
+				cm.evict(key, old.items[key])
+				delete(old.items, key)
+			}
+		}
+		if cm.hasSubscribers() {
+			for key, value := range old.items {
+				cm.notify(ChangeEvent[K, V]{Op: ChangeDelete, Key: key, Old: value, HadOld: true})
+			}
+		}
 	}
 }
 
 // Keys returns a slice of all keys in the map.
+// Each shard is locked once, the slice is sized from the shards as they are visited.
 //
 // Example:
 //
@@ -120,18 +169,36 @@ func (cm *ConcurrentMap[K, V]) Clear() {
 //	    fmt.Println(key)
 //	}
 func (cm *ConcurrentMap[K, V]) Keys() []K {
-	keys := make([]K, 0, cm.Len())
-	for _, shard := range cm.shards {
-		shard.mu.RLock()
+	return cm.KeysInto(nil)
+}
+
+// KeysInto appends all keys of the map to buf[:0] and returns the result, reusing the
+// capacity of buf. This avoids allocations when keys are collected repeatedly in hot loops.
+//
+// Example:
+//
+//	var buf []string
+//	for range ticker.C {
+//	    buf = cm.KeysInto(buf)
+//	    process(buf)
+//	}
+func (cm *ConcurrentMap[K, V]) KeysInto(buf []K) []K {
+	keys := buf[:0]
+	now := time.Now().UnixNano()
+	cm.forEachShard(false, func(shard *mapShard[K, V], remaining int) bool {
+		keys = growForShard(keys, len(shard.items), remaining)
 		for key := range shard.items {
-			keys = append(keys, key)
+			if !shard.expiredAt(key, now) {
+				keys = append(keys, key)
+			}
 		}
-		shard.mu.RUnlock()
-	}
+		return true
+	})
 	return keys
 }
 
 // Values returns a slice of all values in the map.
+// Each shard is locked once, the slice is sized from the shards as they are visited.
 //
 // Example:
 //
@@ -140,17 +207,54 @@ func (cm *ConcurrentMap[K, V]) Keys() []K {
 //	    fmt.Println(value)
 //	}
 func (cm *ConcurrentMap[K, V]) Values() []V {
-	values := make([]V, 0, cm.Len())
-	for _, shard := range cm.shards {
-		shard.mu.RLock()
-		for _, value := range shard.items {
-			values = append(values, value)
+	return cm.ValuesInto(nil)
+}
+
+// ValuesInto appends all values of the map to buf[:0] and returns the result, reusing the
+// capacity of buf.
+//
+// Example:
+//
+//	buf = cm.ValuesInto(buf)
+func (cm *ConcurrentMap[K, V]) ValuesInto(buf []V) []V {
+	values := buf[:0]
+	now := time.Now().UnixNano()
+	cm.forEachShard(false, func(shard *mapShard[K, V], remaining int) bool {
+		values = growForShard(values, len(shard.items), remaining)
+		for key, value := range shard.items {
+			if !shard.expiredAt(key, now) {
+				values = append(values, value)
+			}
 		}
-		shard.mu.RUnlock()
-	}
+		return true
+	})
 	return values
 }
 
+// Items returns all key-value pairs of the map, collected in a single pass over the shards.
+// Each pair is consistent, but like Keys and Values the result is not a snapshot of the
+// whole map when other goroutines write concurrently.
+//
+// Example:
+//
+//	for _, item := range cm.Items() {
+//	    fmt.Printf("%v=%v\n", item.Key, item.Value)
+//	}
+func (cm *ConcurrentMap[K, V]) Items() []KV[K, V] {
+	var items []KV[K, V]
+	now := time.Now().UnixNano()
+	cm.forEachShard(false, func(shard *mapShard[K, V], remaining int) bool {
+		items = growForShard(items, len(shard.items), remaining)
+		for k, v := range shard.items {
+			if !shard.expiredAt(k, now) {
+				items = append(items, KV[K, V]{Key: k, Value: v})
+			}
+		}
+		return true
+	})
+	return items
+}
+
 // Range calls the given function for each key-value pair in the map.
 //
 // Example:
@@ -160,20 +264,22 @@ func (cm *ConcurrentMap[K, V]) Values() []V {
 //	    return true // continue iteration
 //	})
 func (cm *ConcurrentMap[K, V]) Range(f func(K, V) bool) {
-	for _, shard := range cm.shards {
-		shard.mu.RLock()
+	now := time.Now().UnixNano()
+	cm.forEachShard(false, func(shard *mapShard[K, V], _ int) bool {
 		for k, v := range shard.items {
+			if shard.expiredAt(k, now) {
+				continue
+			}
 			if !f(k, v) {
-				shard.mu.RUnlock()
-				return
+				return false
 			}
 		}
-		shard.mu.RUnlock()
-	}
+		return true
+	})
 }
 
 // GetOrSet returns the existing value for the key if present.
-// Otherwise, it sets and returns the given value.
+// Otherwise, it sets and returns the given value. Use Update to modify existing values.
 //
 // Example:
 //
@@ -184,13 +290,22 @@ func (cm *ConcurrentMap[K, V]) Range(f func(K, V) bool) {
 //	    fmt.Println("New key-value pair added")
 //	}
 func (cm *ConcurrentMap[K, V]) GetOrSet(key K, value V) (V, bool) {
-	shard := cm.getShard(key)
-	shard.mu.Lock()
-	defer shard.mu.Unlock()
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
 	if val, ok := shard.items[key]; ok {
+		shard.mu.Unlock()
 		return val, true
 	}
 	shard.items[key] = value
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	cm.afterInsert(shardLen)
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, New: value})
+	}
 	return value, false
 }
 
@@ -205,14 +320,201 @@ func (cm *ConcurrentMap[K, V]) GetOrSet(key K, value V) (V, bool) {
 //	    fmt.Println("Key already existed")
 //	}
 func (cm *ConcurrentMap[K, V]) SetIfAbsent(key K, value V) bool {
-	shard := cm.getShard(key)
-	shard.mu.Lock()
-	defer shard.mu.Unlock()
-	if _, ok := shard.items[key]; !ok {
-		shard.items[key] = value
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
+	if _, ok := shard.items[key]; ok {
+		shard.mu.Unlock()
+		return false
+	}
+	shard.items[key] = value
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	cm.afterInsert(shardLen)
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, New: value})
+	}
+	return true
+}
+
+// Update replaces the value of a key with the result of fn, atomically: fn runs under the lock
+// of the key's shard, so no other write to the key can happen between reading and writing.
+// fn receives the current value (the zero value if exists is false) and returns the new value.
+//
+// fn must not call methods of the same map: the shard lock is not reentrant, so this deadlocks.
+// Keep fn short, it blocks all other access to the shard.
+//
+// Example:
+//
+//	hits := cm.Update("page", func(old int, exists bool) int {
+//	    return old + 1
+//	})
+func (cm *ConcurrentMap[K, V]) Update(key K, fn func(old V, exists bool) V) V {
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
+	old, hadOld := shard.items[key]
+	value := fn(old, hadOld)
+	shard.items[key] = value
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	cm.afterInsert(shardLen)
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, Old: old, HadOld: hadOld, New: value})
+	}
+	return value
+}
+
+// Upsert sets the value of a key to insert if the key is absent, or to the result of update
+// if it exists. Like Update, update runs under the shard lock and must not call methods of the
+// same map.
+//
+// Example:
+//
+//	sessions := cm.Upsert(userID, 1, func(old int) int { return old + 1 })
+func (cm *ConcurrentMap[K, V]) Upsert(key K, insert V, update func(old V) V) V {
+	return cm.Update(key, func(old V, exists bool) V {
+		if !exists {
+			return insert
+		}
+		return update(old)
+	})
+}
+
+// CompareAndSwap sets the value of a key to new if the key exists and its value equals old.
+// Values are compared with equal, or with reflect.DeepEqual if equal is nil; equal runs under
+// the shard lock and must not call methods of the same map.
+//
+// Returns:
+//   - bool: true if the value was swapped
+//
+// Example:
+//
+//	swapped := cm.CompareAndSwap("state", "pending", "running", nil)
+func (cm *ConcurrentMap[K, V]) CompareAndSwap(key K, old, new V, equal func(a, b V) bool) bool {
+	if equal == nil {
+		equal = func(a, b V) bool { return reflect.DeepEqual(a, b) }
+	}
+	shard := cm.lockShard(key)
+	current, ok := shard.items[key]
+	if !ok || shard.expired(key) || !equal(current, old) {
+		shard.mu.Unlock()
+		return false
+	}
+	shard.items[key] = new
+	shard.mu.Unlock()
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, Old: current, HadOld: true, New: new})
+	}
+	return true
+}
+
+// growForShard makes room for shardLen more elements. When s has to grow, the remaining
+// shards are assumed to be about as full as the current one, so a full pass usually
+// allocates only once or twice without a separate counting pass.
+func growForShard[T any](s []T, shardLen, remainingShards int) []T {
+	if cap(s)-len(s) >= shardLen {
+		return s
+	}
+	return slices.Grow(s, shardLen*remainingShards)
+}
+
+// KV is a key-value pair of a ConcurrentMap.
+type KV[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Page returns up to limit entries in key order (as defined by less), starting after afterKey.
+// Pass nil as afterKey to start at the beginning.
+// The returned nextKey is nil when there are no further entries, otherwise it can be passed
+// as afterKey to fetch the next page.
+//
+// Each shard is snapshotted and sorted separately (holding only that shard's read lock),
+// then the shard snapshots are merged lazily until limit entries are collected.
+// Consistency is weak: entries added or removed while paging may or may not be returned,
+// but successive calls always make progress and, without concurrent mutations,
+// visit every entry exactly once.
+//
+// Example:
+//
+//	var after *string
+//	for {
+//	    items, next := cm.Page(after, 100, func(a, b string) bool { return a < b })
+//	    for _, item := range items {
+//	        fmt.Println(item.Key, item.Value)
+//	    }
+//	    if next == nil {
+//	        break
+//	    }
+//	    after = next
+//	}
+func (cm *ConcurrentMap[K, V]) Page(afterKey *K, limit int, less func(a, b K) bool) (items []KV[K, V], nextKey *K) {
+	if limit <= 0 {
+		return []KV[K, V]{}, nil
+	}
+
+	// Take a sorted snapshot of each shard, truncated to limit+1 entries
+	// (one extra entry tells us whether there is another page).
+	var snapshots [][]KV[K, V]
+	cm.forEachShard(false, func(shard *mapShard[K, V], _ int) bool {
+		snapshot := make([]KV[K, V], 0, len(shard.items))
+		now := time.Now().UnixNano()
+		for k, v := range shard.items {
+			if shard.expiredAt(k, now) {
+				continue
+			}
+			if afterKey == nil || less(*afterKey, k) {
+				snapshot = append(snapshot, KV[K, V]{Key: k, Value: v})
+			}
+		}
+		if len(snapshot) > 0 {
+			snapshots = append(snapshots, snapshot)
+		}
 		return true
+	})
+	for i, snapshot := range snapshots {
+		sort.Slice(snapshot, func(i, j int) bool {
+			return less(snapshot[i].Key, snapshot[j].Key)
+		})
+		if len(snapshot) > limit+1 {
+			snapshots[i] = snapshot[:limit+1]
+		}
+	}
+
+	// Merge the shard snapshots until limit+1 entries are found
+	items = make([]KV[K, V], 0, limit)
+	hasMore := false
+	for {
+		minIdx := -1
+		for i, snapshot := range snapshots {
+			if len(snapshot) == 0 {
+				continue
+			}
+			if minIdx == -1 || less(snapshot[0].Key, snapshots[minIdx][0].Key) {
+				minIdx = i
+			}
+		}
+		if minIdx == -1 {
+			break
+		}
+		if len(items) == limit {
+			hasMore = true
+			break
+		}
+		items = append(items, snapshots[minIdx][0])
+		snapshots[minIdx] = snapshots[minIdx][1:]
+	}
+
+	if hasMore {
+		last := items[len(items)-1].Key
+		nextKey = &last
 	}
-	return false
+	return items, nextKey
 }
 
 // fnv32 is a simple hash function based on FNV-1a
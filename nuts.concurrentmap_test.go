@@ -0,0 +1,240 @@
+package gonuts
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentMapKeysValuesItems(t *testing.T) {
+	cm := NewConcurrentMap[int, int](8)
+	for i := 0; i < 1000; i++ {
+		cm.Set(i, i*2)
+	}
+
+	buf := make([]int, 0, 10)
+	keys := cm.KeysInto(buf)
+	values := cm.Values()
+	items := cm.Items()
+	if len(keys) != 1000 || len(values) != 1000 || len(items) != 1000 {
+		t.Fatalf("got %d keys, %d values and %d items, want 1000 each", len(keys), len(values), len(items))
+	}
+	sort.Ints(keys)
+	sort.Ints(values)
+	for i := range keys {
+		if keys[i] != i || values[i] != i*2 {
+			t.Fatalf("keys[%d] = %d, values[%d] = %d, want %d and %d", i, keys[i], i, values[i], i, i*2)
+		}
+	}
+	for _, item := range items {
+		if item.Value != item.Key*2 {
+			t.Fatalf("item %v is not a consistent pair", item)
+		}
+	}
+
+	// A buffer with enough capacity is reused
+	reused := cm.KeysInto(keys)
+	if &reused[0] != &keys[0] {
+		t.Error("KeysInto did not reuse the buffer")
+	}
+}
+
+var (
+	benchmarkMapOnce sync.Once
+	benchmarkMap     *ConcurrentMap[int, int]
+)
+
+// millionEntryMap returns a shared map with 1M entries
+func millionEntryMap() *ConcurrentMap[int, int] {
+	benchmarkMapOnce.Do(func() {
+		benchmarkMap = NewConcurrentMap[int, int](32)
+		for i := 0; i < 1_000_000; i++ {
+			benchmarkMap.Set(i, i)
+		}
+	})
+	return benchmarkMap
+}
+
+// BenchmarkConcurrentMapKeys compares collecting the keys of a 1M-entry map. The legacy
+// variant is how Keys worked before: Len locks every shard to pre-size the slice, then every
+// shard is locked again to collect, so it takes twice the shard locks of Keys and KeysInto.
+func BenchmarkConcurrentMapKeys(b *testing.B) {
+	cm := millionEntryMap()
+
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			keys := make([]int, 0, cm.Len())
+			cm.Range(func(key, _ int) bool {
+				keys = append(keys, key)
+				return true
+			})
+		}
+	})
+	b.Run("Keys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cm.Keys()
+		}
+	})
+	b.Run("KeysInto", func(b *testing.B) {
+		buf := cm.Keys()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buf = cm.KeysInto(buf)
+		}
+	})
+}
+
+// BenchmarkConcurrentMapItems compares collecting the pairs of a 1M-entry map with Items
+// against collecting keys and looking up every value
+func BenchmarkConcurrentMapItems(b *testing.B) {
+	cm := millionEntryMap()
+
+	b.Run("Keys+Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			keys := cm.Keys()
+			items := make([]KV[int, int], 0, len(keys))
+			for _, key := range keys {
+				if value, ok := cm.Get(key); ok {
+					items = append(items, KV[int, int]{Key: key, Value: value})
+				}
+			}
+		}
+	})
+	b.Run("Items", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = cm.Items()
+		}
+	})
+}
+
+func TestConcurrentMapUpdateIsAtomic(t *testing.T) {
+	const goroutines, increments = 16, 1000
+	cm := NewConcurrentMap[string, int](4)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				cm.Update("update", func(old int, _ bool) int { return old + 1 })
+				cm.Upsert("upsert", 1, func(old int) int { return old + 1 })
+				cm.Set("other", i) // writes to other keys interleave
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A lost update would leave the counters below the number of increments
+	for _, key := range []string{"update", "upsert"} {
+		if v, _ := cm.Get(key); v != goroutines*increments {
+			t.Errorf("Get(%s) = %d, want %d", key, v, goroutines*increments)
+		}
+	}
+}
+
+func TestConcurrentMapUpdateReportsExistence(t *testing.T) {
+	cm := NewConcurrentMap[string, []string](4)
+	appendTag := func(tag string) func(old []string, exists bool) []string {
+		return func(old []string, exists bool) []string {
+			if !exists {
+				return []string{"created", tag}
+			}
+			return append(old, tag)
+		}
+	}
+	cm.Update("k", appendTag("a"))
+	got := cm.Update("k", appendTag("b"))
+	if want := []string{"created", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("Update = %v, want %v", got, want)
+	}
+
+	if v := NewConcurrentMap[string, int](4).Upsert("new", 5, func(old int) int { return old * 10 }); v != 5 {
+		t.Errorf("Upsert on a missing key = %d, want the insert value 5", v)
+	}
+}
+
+// pageAll pages through cm with the given page size, calling between after every page
+func pageAll(cm *ConcurrentMap[int, int], limit int, between func()) (keys []int, pages int) {
+	less := func(a, b int) bool { return a < b }
+	var after *int
+	for {
+		items, next := cm.Page(after, limit, less)
+		pages++
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		if next == nil {
+			return keys, pages
+		}
+		after = next
+		between()
+	}
+}
+
+func TestConcurrentMapPageVisitsEveryEntryOnce(t *testing.T) {
+	cm := NewConcurrentMap[int, int](8)
+	for i := 0; i < 1000; i++ {
+		cm.Set(i, i)
+	}
+
+	keys, pages := pageAll(cm, 37, func() {})
+	if pages != 28 {
+		t.Errorf("%d pages of 37 for 1000 entries, want 28", pages)
+	}
+	if len(keys) != 1000 {
+		t.Fatalf("%d keys visited, want 1000", len(keys))
+	}
+	for i, key := range keys {
+		if key != i {
+			t.Fatalf("key %d is %d, want the keys in order", i, key)
+		}
+	}
+
+	// An exact multiple of the page size ends without an empty page
+	if _, pages := pageAll(cm, 100, func() {}); pages != 10 {
+		t.Errorf("%d pages of 100 for 1000 entries, want 10", pages)
+	}
+	if items, next := cm.Page(nil, 0, func(a, b int) bool { return a < b }); len(items) != 0 || next != nil {
+		t.Errorf("Page with limit 0 = %v, %v, want no entries", items, next)
+	}
+	if items, next := NewConcurrentMap[int, int](8).Page(nil, 10, func(a, b int) bool { return a < b }); len(items) != 0 || next != nil {
+		t.Errorf("Page of an empty map = %v, %v, want no entries", items, next)
+	}
+}
+
+func TestConcurrentMapPageWithMutationsBetweenPages(t *testing.T) {
+	cm := NewConcurrentMap[int, int](8)
+	for i := 0; i < 1000; i += 2 {
+		cm.Set(i, i) // even keys are never touched
+	}
+	mutation := 0
+	keys, _ := pageAll(cm, 50, func() {
+		// Between pages, odd keys are added before and after the current page
+		for i := 0; i < 10; i++ {
+			cm.Set(2*(mutation*37%500)+1, 0)
+			mutation++
+		}
+	})
+
+	seen := make(map[int]bool)
+	for i, key := range keys {
+		if seen[key] {
+			t.Fatalf("key %d visited twice", key)
+		}
+		if i > 0 && key <= keys[i-1] {
+			t.Fatalf("key %d visited after %d: paging made no progress", key, keys[i-1])
+		}
+		seen[key] = true
+	}
+	for i := 0; i < 1000; i += 2 {
+		if !seen[i] {
+			t.Errorf("key %d present during the whole iteration was not visited", i)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package gonuts
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// maxMapShards bounds the shard count reached by resharding
+const maxMapShards = 1 << 16
+
+// mapTable is the set of shards of a ConcurrentMap. While the map reshards, next is the table
+// with twice as many shards the entries migrate to: the entries of shard i move to shards i and
+// i+len(shards) of next, after which shard i is marked as moved.
+type mapTable[K comparable, V any] struct {
+	shards   []*mapShard[K, V]
+	next     atomic.Pointer[mapTable[K, V]]
+	claimed  atomic.Int64 // shards claimed for migration by writers
+	migrated atomic.Int64 // shards whose entries moved to next
+}
+
+// mapReshard is the resharding configuration of a ConcurrentMap
+type mapReshard struct {
+	mu        sync.Mutex   // serializes starting reshards and ReshardNow
+	threshold atomic.Int64 // average entries per shard that trigger a reshard, 0 if disabled
+}
+
+func newMapTable[K comparable, V any](numShards int) *mapTable[K, V] {
+	t := &mapTable[K, V]{shards: make([]*mapShard[K, V], numShards)}
+	for i := range t.shards {
+		t.shards[i] = &mapShard[K, V]{items: make(map[K]V)}
+	}
+	return t
+}
+
+// AutoReshard enables doubling the shard count whenever a shard holds more than
+// maxEntriesPerShard entries, so a map that grows by orders of magnitude keeps its contention
+// low. Entries are migrated incrementally: every write moves the entries of at most one shard,
+// and reads and writes of all other shards proceed meanwhile. Values <= 0 disable it (the
+// default).
+//
+// Returns:
+//   - *ConcurrentMap[K, V]: the map for method chaining
+//
+// Example:
+//
+//	cm := NewConcurrentMap[string, Session](32).AutoReshard(4096)
+func (cm *ConcurrentMap[K, V]) AutoReshard(maxEntriesPerShard int) *ConcurrentMap[K, V] {
+	if maxEntriesPerShard < 0 {
+		maxEntriesPerShard = 0
+	}
+	cm.reshard.threshold.Store(int64(maxEntriesPerShard))
+	return cm
+}
+
+// ReshardNow doubles the shard count and migrates all entries before it returns. If a reshard
+// is already in progress, it completes that one instead. Other goroutines can keep using the
+// map meanwhile; only the shard being migrated is locked.
+//
+// Example:
+//
+//	cm.ReshardNow()
+//	fmt.Println(cm.ShardCount()) // 64
+func (cm *ConcurrentMap[K, V]) ReshardNow() {
+	cm.reshard.mu.Lock()
+	defer cm.reshard.mu.Unlock()
+	t := cm.table.Load()
+	if t.next.Load() == nil && !cm.startReshardLocked(t) {
+		return
+	}
+	for i := range t.shards {
+		cm.migrateShard(t, i)
+	}
+}
+
+// ShardCount returns the current number of shards. During a reshard it is the count of the
+// table being migrated from.
+func (cm *ConcurrentMap[K, V]) ShardCount() int {
+	return len(cm.table.Load().shards)
+}
+
+// afterInsert helps a running reshard by migrating one shard, or starts a reshard when a shard
+// has grown past the AutoReshard threshold. shardLen is the length of the shard written to.
+// It must be called without holding a shard lock.
+func (cm *ConcurrentMap[K, V]) afterInsert(shardLen int) {
+	t := cm.table.Load()
+	if t.next.Load() == nil {
+		threshold := cm.reshard.threshold.Load()
+		if threshold <= 0 || int64(shardLen) <= threshold {
+			return
+		}
+		cm.reshard.mu.Lock()
+		started := cm.table.Load() == t && t.next.Load() == nil && cm.startReshardLocked(t)
+		cm.reshard.mu.Unlock()
+		if !started {
+			return
+		}
+	}
+	if i := t.claimed.Add(1) - 1; i < int64(len(t.shards)) {
+		cm.migrateShard(t, int(i))
+	}
+}
+
+// startReshardLocked creates the next table of t; the caller holds cm.reshard.mu
+func (cm *ConcurrentMap[K, V]) startReshardLocked(t *mapTable[K, V]) bool {
+	if len(t.shards)*2 > maxMapShards {
+		return false
+	}
+	t.next.Store(newMapTable[K, V](len(t.shards) * 2))
+	return true
+}
+
+// migrateShard moves the entries of shard i of t to the next table, unless they were moved
+// already. The migration of the last shard makes the next table the table of the map.
+func (cm *ConcurrentMap[K, V]) migrateShard(t *mapTable[K, V], i int) {
+	shard := t.shards[i]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.moved {
+		return
+	}
+	next := t.next.Load()
+	n := uint32(len(next.shards))
+	low, high := next.shards[i], next.shards[i+len(t.shards)]
+	// No other goroutine uses low and high before shard is marked as moved.
+	low.mu.Lock()
+	high.mu.Lock()
+	for key, value := range shard.items {
+		dst := next.shards[mapKeyHash(key)%n]
+		dst.items[key] = value
+		if expiry, ok := shard.expires[key]; ok {
+			if dst.expires == nil {
+				dst.expires = make(map[K]int64)
+			}
+			dst.expires[key] = expiry
+		}
+	}
+	high.mu.Unlock()
+	low.mu.Unlock()
+	shard.items, shard.expires = nil, nil
+	shard.moved = true
+	// Counted under the lock, so ReshardNow returns only after the table was replaced.
+	if t.migrated.Add(1) == int64(len(t.shards)) {
+		cm.table.CompareAndSwap(t, next)
+	}
+}
+
+// lockShard returns the shard holding key, locked for writing
+func (cm *ConcurrentMap[K, V]) lockShard(key K) *mapShard[K, V] {
+	hash := mapKeyHash(key)
+	for t := cm.table.Load(); ; t = t.next.Load() {
+		shard := t.shards[hash%uint32(len(t.shards))]
+		shard.mu.Lock()
+		if !shard.moved {
+			return shard
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// rlockShard returns the shard holding key, locked for reading
+func (cm *ConcurrentMap[K, V]) rlockShard(key K) *mapShard[K, V] {
+	hash := mapKeyHash(key)
+	for t := cm.table.Load(); ; t = t.next.Load() {
+		shard := t.shards[hash%uint32(len(t.shards))]
+		shard.mu.RLock()
+		if !shard.moved {
+			return shard
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// forEachShard calls fn for every shard holding entries, locked for writing if write is set and
+// for reading otherwise, until fn returns false. remaining is the estimated number of shards
+// left including the current one. Shards moved by a concurrent reshard are replaced by their
+// halves in the next table, so every entry is visited once.
+func (cm *ConcurrentMap[K, V]) forEachShard(write bool, fn func(shard *mapShard[K, V], remaining int) bool) {
+	t := cm.table.Load()
+	for i := range t.shards {
+		if !visitMapShard(t, i, write, len(t.shards)-i, fn) {
+			return
+		}
+	}
+}
+
+func visitMapShard[K comparable, V any](t *mapTable[K, V], i int, write bool, remaining int, fn func(shard *mapShard[K, V], remaining int) bool) bool {
+	shard := t.shards[i]
+	if write {
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+	} else {
+		shard.mu.RLock()
+		defer shard.mu.RUnlock()
+	}
+	if !shard.moved {
+		return fn(shard, remaining)
+	}
+	next := t.next.Load()
+	return visitMapShard(next, i, write, remaining, fn) && visitMapShard(next, i+len(t.shards), write, remaining, fn)
+}
+
+// mapKeyHash returns the shard hash of a key
+func mapKeyHash[K comparable](key K) uint32 {
+	return fnv32(fmt.Sprintf("%v", key))
+}
@@ -0,0 +1,191 @@
+package gonuts
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// shardLens returns the number of entries of every shard of the current table
+func shardLens[K comparable, V any](cm *ConcurrentMap[K, V]) []int {
+	var lens []int
+	cm.forEachShard(false, func(shard *mapShard[K, V], _ int) bool {
+		lens = append(lens, len(shard.items))
+		return true
+	})
+	return lens
+}
+
+func TestAutoReshardGrowsShardCount(t *testing.T) {
+	const entries, threshold = 10000, 64
+	cm := NewConcurrentMap[int, int](4).AutoReshard(threshold)
+	for i := 0; i < entries; i++ {
+		cm.Set(i, i)
+	}
+	if cm.table.Load().next.Load() != nil {
+		cm.ReshardNow() // complete the reshard in progress
+	}
+
+	if n := cm.ShardCount(); n < entries/threshold {
+		t.Errorf("ShardCount() = %d for %d entries, want at least %d", n, entries, entries/threshold)
+	}
+	if n := cm.Len(); n != entries {
+		t.Fatalf("Len() = %d after resharding, want %d", n, entries)
+	}
+	for i := 0; i < entries; i++ {
+		if v, ok := cm.Get(i); !ok || v != i {
+			t.Fatalf("Get(%d) = %d, %v after resharding", i, v, ok)
+		}
+	}
+	// The entries are spread over all shards, so none of them holds a large share
+	lens := shardLens(cm)
+	if len(lens) != cm.ShardCount() {
+		t.Fatalf("%d shards visited, want %d", len(lens), cm.ShardCount())
+	}
+	for i, n := range lens {
+		if n > 4*entries/len(lens) {
+			t.Errorf("shard %d holds %d entries, want about %d", i, n, entries/len(lens))
+		}
+	}
+}
+
+func TestReshardNowDoublesShardCount(t *testing.T) {
+	cm := NewConcurrentMap[string, int](8)
+	cm.Set("a", 1)
+	cm.ReshardNow()
+	if n := cm.ShardCount(); n != 16 {
+		t.Errorf("ShardCount() = %d, want 16", n)
+	}
+	if v, ok := cm.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v after ReshardNow", v, ok)
+	}
+
+	// AutoReshard is off by default: the shard count stays fixed
+	fixed := NewConcurrentMap[int, int](2)
+	for i := 0; i < 10000; i++ {
+		fixed.Set(i, i)
+	}
+	if n := fixed.ShardCount(); n != 2 {
+		t.Errorf("ShardCount() = %d without AutoReshard, want 2", n)
+	}
+}
+
+// TestReshardWhileInUse is meant to run with -race: writers, readers and iterators keep using
+// the map while it reshards, and no entry is lost or seen twice
+func TestReshardWhileInUse(t *testing.T) {
+	const writers, perWriter = 8, 2000
+	cm := NewConcurrentMap[int, int](2).AutoReshard(16)
+	var done atomic.Bool
+	var wg sync.WaitGroup
+
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for !done.Load() {
+			seen := make(map[int]bool)
+			cm.Range(func(key, _ int) bool {
+				if seen[key] {
+					t.Errorf("Range visited %d twice", key)
+				}
+				seen[key] = true
+				return true
+			})
+		}
+	}()
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := w*perWriter + i
+				cm.Set(key, key)
+				if v, ok := cm.Get(key); !ok || v != key {
+					t.Errorf("Get(%d) = %d, %v right after Set", key, v, ok)
+					return
+				}
+				cm.Update(key, func(old int, _ bool) int { return old + 1 })
+			}
+		}(w)
+	}
+	wg.Wait()
+	done.Store(true)
+	readers.Wait()
+
+	if n := cm.Len(); n != writers*perWriter {
+		t.Fatalf("Len() = %d, want %d", n, writers*perWriter)
+	}
+	for key := 0; key < writers*perWriter; key++ {
+		if v, ok := cm.Get(key); !ok || v != key+1 {
+			t.Fatalf("Get(%d) = %d, %v, want %d", key, v, ok, key+1)
+		}
+	}
+	if n := cm.ShardCount(); n <= 2 {
+		t.Errorf("ShardCount() = %d, want the map resharded", n)
+	}
+}
+
+// BenchmarkConcurrentMapGrowth fills a map from empty to 1M entries from parallel goroutines,
+// with the shard count fixed at 32 and with AutoReshard starting at 32 shards
+func BenchmarkConcurrentMapGrowth(b *testing.B) {
+	const entries = 1 << 20
+	for _, bm := range []struct {
+		name      string
+		threshold int
+	}{{"fixed", 0}, {"auto", 1024}} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cm := NewConcurrentMap[int, int](32).AutoReshard(bm.threshold)
+				var next atomic.Int64
+				var wg sync.WaitGroup
+				for g := 0; g < 8; g++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for key := next.Add(1); key <= entries; key = next.Add(1) {
+							cm.Set(int(key), int(key))
+						}
+					}()
+				}
+				wg.Wait()
+				b.ReportMetric(float64(cm.ShardCount()), "shards")
+			}
+		})
+	}
+}
+
+// BenchmarkConcurrentMapGetSetLarge measures mixed parallel reads and writes on a 1M-entry map
+// with 32 shards and after AutoReshard grew it to about 1024 entries per shard
+func BenchmarkConcurrentMapGetSetLarge(b *testing.B) {
+	const entries = 1 << 20
+	for _, bm := range []struct {
+		name      string
+		threshold int
+	}{{"fixed", 0}, {"auto", 1024}} {
+		b.Run(bm.name, func(b *testing.B) {
+			cm := NewConcurrentMap[int, int](32).AutoReshard(bm.threshold)
+			for key := 0; key < entries; key++ {
+				cm.Set(key, key)
+			}
+			if cm.table.Load().next.Load() != nil {
+				cm.ReshardNow() // complete the reshard in progress
+			}
+			var seed atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				key := int(seed.Add(7919))
+				for pb.Next() {
+					key = (key*31 + 17) % entries
+					if key%4 == 0 {
+						cm.Set(key, key)
+					} else {
+						cm.Get(key)
+					}
+				}
+			})
+		})
+	}
+}
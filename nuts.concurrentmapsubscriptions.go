@@ -0,0 +1,187 @@
+package gonuts
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ChangeOp is the kind of mutation reported by a ChangeEvent
+type ChangeOp int
+
+const (
+	ChangeSet    ChangeOp = iota // a key was added or overwritten
+	ChangeDelete                 // a key was deleted (also by Clear)
+	ChangeExpire                 // a key was removed because it expired
+)
+
+// String returns "set", "delete" or "expire"
+func (op ChangeOp) String() string {
+	switch op {
+	case ChangeSet:
+		return "set"
+	case ChangeDelete:
+		return "delete"
+	case ChangeExpire:
+		return "expire"
+	}
+	return "unknown"
+}
+
+// ChangeEvent describes a mutation of a ConcurrentMap delivered to subscribers
+type ChangeEvent[K comparable, V any] struct {
+	Op     ChangeOp
+	Key    K
+	Old    V    // the previous value, the zero value if HadOld is false
+	HadOld bool // whether the key had a value before the mutation
+	New    V    // the new value for ChangeSet, the zero value otherwise
+	// Missed is the number of events dropped for this subscriber since the previous delivered
+	// event, because its buffer was full.
+	Missed uint64
+}
+
+// mapSubscriptions holds the subscribers of a ConcurrentMap
+type mapSubscriptions[K comparable, V any] struct {
+	mu     sync.RWMutex
+	byKey  map[K]map[uint64]*mapSubscriber[K, V]
+	all    map[uint64]*mapSubscriber[K, V]
+	nextID uint64
+	count  atomic.Int64 // number of subscribers, read by writers without locking
+}
+
+type mapSubscriber[K comparable, V any] struct {
+	ch     chan ChangeEvent[K, V]
+	mu     sync.Mutex
+	missed uint64
+	closed bool
+}
+
+// Subscribe returns a channel receiving the changes of a single key, and a function that
+// cancels the subscription and closes the channel.
+//
+// Events are sent after the shard lock is released, so subscribers may call back into the map.
+// Writers never block on subscribers: when the buffer is full, the event is dropped and
+// counted in the Missed field of the next delivered event. Under concurrent writes to the
+// same key, events may be delivered in a different order than the writes were applied.
+//
+// Parameters:
+//   - key: the key to watch
+//   - buffer: the channel capacity
+//
+// Returns:
+//   - <-chan ChangeEvent[K, V]: the change events of the key
+//   - func(): cancels the subscription; safe to call more than once
+//
+// Example:
+//
+//	changes, cancel := cm.Subscribe("config", 16)
+//	defer cancel()
+//	for ev := range changes {
+//	    if ev.Op == gonuts.ChangeSet {
+//	        reload(ev.New)
+//	    }
+//	}
+func (cm *ConcurrentMap[K, V]) Subscribe(key K, buffer int) (<-chan ChangeEvent[K, V], func()) {
+	return cm.subscribe(&key, buffer)
+}
+
+// SubscribeAll returns a channel receiving every change of the map, and a function that
+// cancels the subscription and closes the channel. Delivery works like Subscribe.
+//
+// Example:
+//
+//	changes, cancel := cm.SubscribeAll(1024)
+//	defer cancel()
+//	go func() {
+//	    for ev := range changes {
+//	        invalidate(ev.Key)
+//	    }
+//	}()
+func (cm *ConcurrentMap[K, V]) SubscribeAll(buffer int) (<-chan ChangeEvent[K, V], func()) {
+	return cm.subscribe(nil, buffer)
+}
+
+func (cm *ConcurrentMap[K, V]) subscribe(key *K, buffer int) (<-chan ChangeEvent[K, V], func()) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	subs := &cm.subs
+	sub := &mapSubscriber[K, V]{ch: make(chan ChangeEvent[K, V], buffer)}
+
+	subs.mu.Lock()
+	subs.nextID++
+	id := subs.nextID
+	if key == nil {
+		if subs.all == nil {
+			subs.all = make(map[uint64]*mapSubscriber[K, V])
+		}
+		subs.all[id] = sub
+	} else {
+		if subs.byKey == nil {
+			subs.byKey = make(map[K]map[uint64]*mapSubscriber[K, V])
+		}
+		if subs.byKey[*key] == nil {
+			subs.byKey[*key] = make(map[uint64]*mapSubscriber[K, V])
+		}
+		subs.byKey[*key][id] = sub
+	}
+	subs.count.Add(1)
+	subs.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			subs.mu.Lock()
+			if key == nil {
+				delete(subs.all, id)
+			} else if keySubs := subs.byKey[*key]; keySubs != nil {
+				delete(keySubs, id)
+				if len(keySubs) == 0 {
+					delete(subs.byKey, *key)
+				}
+			}
+			subs.count.Add(-1)
+			subs.mu.Unlock()
+
+			sub.mu.Lock()
+			sub.closed = true
+			close(sub.ch)
+			sub.mu.Unlock()
+		})
+	}
+	return sub.ch, cancel
+}
+
+// hasSubscribers reports whether change events need to be built at all
+func (cm *ConcurrentMap[K, V]) hasSubscribers() bool {
+	return cm.subs.count.Load() > 0
+}
+
+// notify delivers a change event to the subscribers of its key and of all keys.
+// It must be called without holding a shard lock.
+func (cm *ConcurrentMap[K, V]) notify(ev ChangeEvent[K, V]) {
+	subs := &cm.subs
+	subs.mu.RLock()
+	defer subs.mu.RUnlock()
+	for _, sub := range subs.byKey[ev.Key] {
+		sub.send(ev)
+	}
+	for _, sub := range subs.all {
+		sub.send(ev)
+	}
+}
+
+// send delivers an event without blocking, counting it as missed if the buffer is full
+func (s *mapSubscriber[K, V]) send(ev ChangeEvent[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	ev.Missed = s.missed
+	select {
+	case s.ch <- ev:
+		s.missed = 0
+	default:
+		s.missed++
+	}
+}
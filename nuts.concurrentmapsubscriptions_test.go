@@ -0,0 +1,139 @@
+package gonuts
+
+import (
+	"testing"
+)
+
+// drainChanges returns the events buffered in ch
+func drainChanges[K comparable, V any](ch <-chan ChangeEvent[K, V]) []ChangeEvent[K, V] {
+	var events []ChangeEvent[K, V]
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+func TestConcurrentMapSubscribeDelivers(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	keyChanges, cancelKey := cm.Subscribe("a", 16)
+	defer cancelKey()
+	allChanges, cancelAll := cm.SubscribeAll(16)
+	defer cancelAll()
+
+	cm.Set("a", 1)
+	cm.Set("b", 10)
+	cm.Set("a", 2)
+	cm.Update("a", func(old int, _ bool) int { return old + 1 })
+	cm.CompareAndSwap("a", 3, 4, nil)
+	cm.CompareAndSwap("a", 99, 5, nil) // not swapped, no event
+	cm.Delete("a")
+	cm.Delete("a") // already gone, no event
+	cm.SetIfAbsent("a", 7)
+	cm.Clear()
+
+	want := []ChangeEvent[string, int]{
+		{Op: ChangeSet, Key: "a", New: 1},
+		{Op: ChangeSet, Key: "a", Old: 1, HadOld: true, New: 2},
+		{Op: ChangeSet, Key: "a", Old: 2, HadOld: true, New: 3},
+		{Op: ChangeSet, Key: "a", Old: 3, HadOld: true, New: 4},
+		{Op: ChangeDelete, Key: "a", Old: 4, HadOld: true},
+		{Op: ChangeSet, Key: "a", New: 7},
+		{Op: ChangeDelete, Key: "a", Old: 7, HadOld: true},
+	}
+	got := drainChanges(keyChanges)
+	if len(got) != len(want) {
+		t.Fatalf("key subscriber got %d events %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The subscriber of all keys also sees b, in the order of the writes
+	all := drainChanges(allChanges)
+	if len(all) != len(want)+2 {
+		t.Fatalf("subscriber of all keys got %d events %v, want %d", len(all), all, len(want)+2)
+	}
+	if all[1] != (ChangeEvent[string, int]{Op: ChangeSet, Key: "b", New: 10}) {
+		t.Errorf("second event = %+v, want the set of b", all[1])
+	}
+}
+
+func TestConcurrentMapUnsubscribe(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	changes, cancel := cm.Subscribe("a", 16)
+	all, cancelAll := cm.SubscribeAll(16)
+
+	cm.Set("a", 1)
+	cancel()
+	cancel() // safe to call more than once
+	cm.Set("a", 2)
+
+	if got := drainChanges(changes); len(got) != 1 || got[0].New != 1 {
+		t.Errorf("events after cancel = %v, want only the set before", got)
+	}
+	if _, ok := <-changes; ok {
+		t.Error("channel not closed by cancel")
+	}
+	if len(drainChanges(all)) != 2 {
+		t.Error("cancelling one subscription affected another one")
+	}
+
+	cancelAll()
+	if cm.hasSubscribers() {
+		t.Error("map still has subscribers after all subscriptions were cancelled")
+	}
+	cm.Set("a", 3) // no subscribers: must not send on the closed channels
+}
+
+func TestConcurrentMapSubscribeCountsMissed(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	changes, cancel := cm.Subscribe("a", 1)
+	defer cancel()
+
+	// The writer never blocks: with a full buffer, events are dropped and counted
+	for i := 1; i <= 4; i++ {
+		cm.Set("a", i)
+	}
+	if ev := <-changes; ev.New != 1 || ev.Missed != 0 {
+		t.Fatalf("first event = %+v, want the first set", ev)
+	}
+	cm.Set("a", 5)
+	if ev := <-changes; ev.New != 5 || ev.Missed != 3 {
+		t.Fatalf("next event = %+v, want the fifth set with 3 missed", ev)
+	}
+	cm.Set("a", 6)
+	if ev := <-changes; ev.Missed != 0 {
+		t.Errorf("Missed = %d after a delivered event, want it reset", ev.Missed)
+	}
+}
+
+func TestConcurrentMapSubscriberCallsBackIntoMap(t *testing.T) {
+	cm := NewConcurrentMap[string, int](1) // one shard, so a held lock would deadlock
+	changes, cancel := cm.Subscribe("a", 0)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range changes {
+			if ev.Op == ChangeSet {
+				cm.Set("mirror", ev.New) // events are sent after the shard lock is released
+			}
+		}
+	}()
+	// With an unbuffered channel events are only delivered while the subscriber is receiving
+	for v, ok := cm.Get("mirror"); !ok || v != 1; v, ok = cm.Get("mirror") {
+		cm.Set("a", 1)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,207 @@
+package gonuts
+
+import (
+	"sync"
+	"time"
+)
+
+// mapTTL is the expiry state of a ConcurrentMap, set up by the first SetWithTTL
+type mapTTL[K comparable, V any] struct {
+	mu       sync.Mutex
+	interval time.Duration
+	onEvict  func(K, V)
+	started  bool
+	closed   bool
+	stop     chan struct{}
+	wake     chan struct{} // interrupts the wait of the sweeper when the interval changes
+}
+
+// SetWithTTL adds a key-value pair that expires after ttl, replacing an existing value and its
+// expiry. Expired entries are treated as absent by all methods and removed by a background
+// sweeper, which is started by the first call (see SetSweepInterval and Close). Update, Upsert
+// and CompareAndSwap keep the expiry of an entry, Set removes it. A ttl <= 0 stores the entry
+// without expiry, like Set.
+//
+// Example:
+//
+//	sessions := NewConcurrentMap[string, Session](32)
+//	defer sessions.Close()
+//	sessions.OnEvict(func(id string, s Session) {
+//	    L.Infof("session %s of user %s expired", id, s.UserID)
+//	})
+//	sessions.SetWithTTL(sessionID, session, 30*time.Minute)
+func (cm *ConcurrentMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		cm.Set(key, value)
+		return
+	}
+	shard := cm.lockShard(key)
+	expiredValue, expired := shard.takeExpired(key)
+	old, hadOld := shard.items[key]
+	shard.items[key] = value
+	if shard.expires == nil {
+		shard.expires = make(map[K]int64)
+	}
+	shard.expires[key] = time.Now().Add(ttl).UnixNano()
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	cm.afterInsert(shardLen)
+	cm.ttl.start(cm)
+	if expired {
+		cm.evict(key, expiredValue)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeSet, Key: key, Old: old, HadOld: hadOld, New: value})
+	}
+}
+
+// OnEvict sets a callback that is called for every entry removed because it expired, after the
+// entry was removed. It is called by the sweeper, or by the write that replaces an expired
+// entry first, and must not block for long. Subscribers receive a ChangeExpire event as well.
+//
+// Example:
+//
+//	cache.OnEvict(func(key string, value []byte) {
+//	    L.Debugf("evicted %s (%s)", key, BytesToNiceString(int64(len(value))))
+//	})
+func (cm *ConcurrentMap[K, V]) OnEvict(fn func(key K, value V)) {
+	cm.ttl.mu.Lock()
+	defer cm.ttl.mu.Unlock()
+	cm.ttl.onEvict = fn
+}
+
+// SetSweepInterval sets how often the background sweeper removes expired entries (default one
+// minute). Expired entries are invisible immediately; the interval only bounds how long they
+// occupy memory and when OnEvict is called. Values <= 0 restore the default.
+func (cm *ConcurrentMap[K, V]) SetSweepInterval(interval time.Duration) {
+	cm.ttl.mu.Lock()
+	cm.ttl.interval = interval
+	wake := cm.ttl.wake
+	cm.ttl.mu.Unlock()
+	if wake != nil {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SweepExpired removes all expired entries now. It runs automatically in the background once
+// SetWithTTL was called.
+//
+// Returns:
+//   - int: the number of removed entries
+func (cm *ConcurrentMap[K, V]) SweepExpired() int {
+	var evicted []KV[K, V]
+	cm.forEachShard(true, func(shard *mapShard[K, V], _ int) bool {
+		if len(shard.expires) > 0 {
+			now := time.Now().UnixNano()
+			for key, expiry := range shard.expires {
+				if now >= expiry {
+					evicted = append(evicted, KV[K, V]{Key: key, Value: shard.items[key]})
+					delete(shard.items, key)
+					delete(shard.expires, key)
+				}
+			}
+		}
+		return true
+	})
+	// Reported after the shard locks are released
+	for _, entry := range evicted {
+		cm.evict(entry.Key, entry.Value)
+	}
+	return len(evicted)
+}
+
+// Close stops the background sweeper of expired entries. Entries keep expiring, but are only
+// removed when they are overwritten or SweepExpired is called. It is safe to call Close more
+// than once, and on maps that never used SetWithTTL.
+func (cm *ConcurrentMap[K, V]) Close() {
+	cm.ttl.mu.Lock()
+	defer cm.ttl.mu.Unlock()
+	if cm.ttl.closed {
+		return
+	}
+	cm.ttl.closed = true
+	if cm.ttl.started {
+		close(cm.ttl.stop)
+	}
+}
+
+// start starts the sweeper unless it is running or the map was closed
+func (t *mapTTL[K, V]) start(cm *ConcurrentMap[K, V]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started || t.closed {
+		return
+	}
+	t.started = true
+	t.stop = make(chan struct{})
+	t.wake = make(chan struct{}, 1)
+	go cm.sweepLoop(t.stop, t.wake)
+}
+
+func (cm *ConcurrentMap[K, V]) sweepLoop(stop, wake chan struct{}) {
+	for {
+		cm.ttl.mu.Lock()
+		interval := cm.ttl.interval
+		cm.ttl.mu.Unlock()
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+			cm.SweepExpired()
+		}
+	}
+}
+
+// evict reports an expired entry to OnEvict and the subscribers
+func (cm *ConcurrentMap[K, V]) evict(key K, value V) {
+	cm.ttl.mu.Lock()
+	onEvict := cm.ttl.onEvict
+	cm.ttl.mu.Unlock()
+	if onEvict != nil {
+		onEvict(key, value)
+	}
+	if cm.hasSubscribers() {
+		cm.notify(ChangeEvent[K, V]{Op: ChangeExpire, Key: key, Old: value, HadOld: true})
+	}
+}
+
+// expired reports whether the entry of key has expired; the caller holds the shard lock
+func (s *mapShard[K, V]) expired(key K) bool {
+	if len(s.expires) == 0 {
+		return false
+	}
+	return s.expiredAt(key, time.Now().UnixNano())
+}
+
+// expiredAt reports whether the entry of key has expired at now (unix nanoseconds)
+func (s *mapShard[K, V]) expiredAt(key K, now int64) bool {
+	if len(s.expires) == 0 {
+		return false
+	}
+	expiry, ok := s.expires[key]
+	return ok && now >= expiry
+}
+
+// takeExpired removes the entry of key if it has expired and returns its value, so writes treat
+// it as absent; the caller holds the shard write lock and reports the value with evict after
+// unlocking
+func (s *mapShard[K, V]) takeExpired(key K) (V, bool) {
+	var zero V
+	if !s.expired(key) {
+		return zero, false
+	}
+	value := s.items[key]
+	delete(s.items, key)
+	delete(s.expires, key)
+	return value, true
+}
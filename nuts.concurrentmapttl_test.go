@@ -0,0 +1,172 @@
+package gonuts
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitUntil polls cond until it holds or the deadline passes
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentMapExpiredEntriesAreAbsent(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	defer cm.Close()
+	cm.SetSweepInterval(time.Hour) // only the reads hide the entry
+	cm.SetWithTTL("short", 1, 10*time.Millisecond)
+	cm.SetWithTTL("long", 2, time.Hour)
+	cm.SetWithTTL("none", 3, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cm.Get("short"); ok {
+		t.Error("Get returned an expired entry")
+	}
+	if n := cm.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2", n)
+	}
+	if keys := cm.Keys(); len(keys) != 2 {
+		t.Errorf("Keys() = %v, want long and none", keys)
+	}
+	if items := cm.Items(); len(items) != 2 {
+		t.Errorf("Items() = %v, want long and none", items)
+	}
+	cm.Range(func(key string, _ int) bool {
+		if key == "short" {
+			t.Error("Range visited an expired entry")
+		}
+		return true
+	})
+	if cm.CompareAndSwap("short", 1, 5, nil) {
+		t.Error("CompareAndSwap swapped an expired entry")
+	}
+	if v, loaded := cm.GetOrSet("short", 4); loaded || v != 4 {
+		t.Errorf("GetOrSet = %d, %v on an expired entry, want 4, false", v, loaded)
+	}
+}
+
+func TestConcurrentMapSweeperEvicts(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	defer cm.Close()
+	var mu sync.Mutex
+	evicted := map[string]int{}
+	cm.OnEvict(func(key string, value int) {
+		mu.Lock()
+		evicted[key] = value
+		mu.Unlock()
+	})
+	changes, cancel := cm.Subscribe("a", 4)
+	defer cancel()
+
+	cm.SetSweepInterval(5 * time.Millisecond)
+	cm.SetWithTTL("a", 1, 10*time.Millisecond)
+	cm.SetWithTTL("b", 2, time.Hour)
+	waitUntil(t, "the sweeper", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(evicted) > 0
+	})
+
+	mu.Lock()
+	if len(evicted) != 1 || evicted["a"] != 1 {
+		t.Errorf("evicted %v, want only a", evicted)
+	}
+	mu.Unlock()
+	events := drainChanges(changes)
+	if len(events) != 2 || events[1] != (ChangeEvent[string, int]{Op: ChangeExpire, Key: "a", Old: 1, HadOld: true}) {
+		t.Errorf("events = %+v, want the set and the expiry of a", events)
+	}
+	if n := cm.SweepExpired(); n != 0 {
+		t.Errorf("SweepExpired() = %d after the sweeper ran, want 0", n)
+	}
+}
+
+func TestConcurrentMapWritesAndExpiry(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	defer cm.Close()
+	cm.SetSweepInterval(time.Hour)
+	var evicted []string
+	cm.OnEvict(func(key string, _ int) { evicted = append(evicted, key) })
+
+	// Set removes the expiry, Update and CompareAndSwap keep it
+	cm.SetWithTTL("set", 1, 10*time.Millisecond)
+	cm.Set("set", 2)
+	cm.SetWithTTL("update", 1, 10*time.Millisecond)
+	cm.Update("update", func(old int, _ bool) int { return old + 1 })
+	cm.SetWithTTL("cas", 1, 10*time.Millisecond)
+	cm.CompareAndSwap("cas", 1, 2, nil)
+	time.Sleep(20 * time.Millisecond)
+
+	if v, ok := cm.Get("set"); !ok || v != 2 {
+		t.Errorf("Get(set) = %d, %v, want 2 without expiry", v, ok)
+	}
+	for _, key := range []string{"update", "cas"} {
+		if _, ok := cm.Get(key); ok {
+			t.Errorf("Get(%s) found the entry, want the expiry kept", key)
+		}
+	}
+
+	// The write replacing an expired entry reports it, so it is not lost to OnEvict
+	if v := cm.Upsert("update", 10, func(old int) int { return old + 1 }); v != 10 {
+		t.Errorf("Upsert on an expired entry = %d, want the insert value 10", v)
+	}
+	if len(evicted) != 1 || evicted[0] != "update" {
+		t.Errorf("evicted %v, want update", evicted)
+	}
+	if n := cm.SweepExpired(); n != 1 || len(evicted) != 2 || evicted[1] != "cas" {
+		t.Errorf("SweepExpired() = %d, evicted %v, want cas", n, evicted)
+	}
+}
+
+func TestConcurrentMapCloseStopsSweeper(t *testing.T) {
+	cm := NewConcurrentMap[string, int](4)
+	var evictions atomic.Int32
+	cm.OnEvict(func(string, int) { evictions.Add(1) })
+	cm.SetSweepInterval(5 * time.Millisecond)
+	cm.SetWithTTL("a", 1, time.Millisecond)
+	cm.Close()
+	cm.Close() // safe to call more than once
+
+	// SetWithTTL does not restart the sweeper of a closed map
+	cm.SetWithTTL("b", 2, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if n := cm.Len(); n != 0 {
+		t.Errorf("Len() = %d, want the expired entries hidden after Close", n)
+	}
+	if n := evictions.Load(); n != 0 {
+		t.Errorf("%d entries evicted after Close, want the sweeper stopped", n)
+	}
+	if n := cm.SweepExpired(); n != 2 || evictions.Load() != 2 {
+		t.Errorf("SweepExpired() = %d with %d evictions after Close, want both entries still in memory", n, evictions.Load())
+	}
+}
+
+func TestConcurrentMapExpiryKeptByReshard(t *testing.T) {
+	cm := NewConcurrentMap[int, int](2)
+	defer cm.Close()
+	cm.SetSweepInterval(time.Hour)
+	for i := 0; i < 100; i++ {
+		cm.SetWithTTL(i, i, 20*time.Millisecond)
+		cm.Set(i+100, i)
+	}
+	cm.ReshardNow()
+	if n := cm.Len(); n != 200 {
+		t.Fatalf("Len() = %d after resharding, want 200", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := cm.Len(); n != 100 {
+		t.Errorf("Len() = %d after the TTL, want 100: expiries were lost by resharding", n)
+	}
+	if n := cm.SweepExpired(); n != 100 {
+		t.Errorf("SweepExpired() = %d, want 100", n)
+	}
+}
@@ -1,14 +1,23 @@
 package gonuts
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
 // Condition represents a function that returns a boolean
 type Condition func() bool
 
+// ConditionE represents a context-aware condition that can fail, e.g. a feature-flag lookup
+type ConditionE func(ctx context.Context) (bool, error)
+
 // Action represents a function that performs some action
 type Action func()
 
 // ConditionalExecution executes actions based on conditions
 type ConditionalExecution struct {
-	conditions []Condition
+	conditions []ConditionE
 	actions    []Action
 	elseAction Action
 }
@@ -39,6 +48,21 @@ func NewConditionalExecution() *ConditionalExecution {
 // Returns:
 //   - *ConditionalExecution: the ConditionalExecution instance for method chaining
 func (ce *ConditionalExecution) If(condition Condition) *ConditionalExecution {
+	return ce.IfE(func(context.Context) (bool, error) {
+		return condition(), nil
+	})
+}
+
+// IfE adds a context-aware condition that can fail to the execution chain.
+// Execute and ExecuteWithFallthrough treat a failed condition as false;
+// ExecuteParallel aborts with the error.
+//
+// Parameters:
+//   - condition: a function that returns a boolean or an error
+//
+// Returns:
+//   - *ConditionalExecution: the ConditionalExecution instance for method chaining
+func (ce *ConditionalExecution) IfE(condition ConditionE) *ConditionalExecution {
 	ce.conditions = append(ce.conditions, condition)
 	return ce
 }
@@ -66,6 +90,17 @@ func (ce *ConditionalExecution) ElseIf(condition Condition) *ConditionalExecutio
 	return ce.If(condition)
 }
 
+// ElseIfE is an alias for IfE to improve readability
+//
+// Parameters:
+//   - condition: a function that returns a boolean or an error
+//
+// Returns:
+//   - *ConditionalExecution: the ConditionalExecution instance for method chaining
+func (ce *ConditionalExecution) ElseIfE(condition ConditionE) *ConditionalExecution {
+	return ce.IfE(condition)
+}
+
 // Else adds an action to be executed if all conditions are false
 //
 // Parameters:
@@ -85,7 +120,7 @@ func (ce *ConditionalExecution) Else(action Action) *ConditionalExecution {
 // action is defined, it executes the Else action.
 func (ce *ConditionalExecution) Execute() {
 	for i, condition := range ce.conditions {
-		if condition() {
+		if ok, err := condition(context.Background()); ok && err == nil {
 			if i < len(ce.actions) {
 				ce.actions[i]()
 			}
@@ -104,7 +139,7 @@ func (ce *ConditionalExecution) Execute() {
 // that returns false or reaches the end of the chain.
 func (ce *ConditionalExecution) ExecuteWithFallthrough() {
 	for i, condition := range ce.conditions {
-		if condition() {
+		if ok, err := condition(context.Background()); ok && err == nil {
 			if i < len(ce.actions) {
 				ce.actions[i]()
 			}
@@ -117,6 +152,91 @@ func (ce *ConditionalExecution) ExecuteWithFallthrough() {
 	}
 }
 
+// ExecuteParallel evaluates all conditions concurrently and then behaves like Execute: the
+// action of the first condition in declaration order that returned true is executed, no matter
+// which condition finished first. Once that condition is known, the context passed to the
+// remaining conditions is cancelled.
+//
+// Parameters:
+//   - ctx: the context passed to the conditions; evaluation stops when it is done
+//
+// Returns:
+//   - error: ctx.Err() if ctx is done before a decision is made, or the errors of all failed
+//     conditions (joined) if a condition before the selected one failed; no action is executed then
+//
+// Example usage:
+//
+//	err := gonuts.NewConditionalExecution().
+//	    IfE(func(ctx context.Context) (bool, error) { return flags.Enabled(ctx, "new-checkout") }).
+//	    Then(newCheckout).
+//	    ElseIfE(func(ctx context.Context) (bool, error) { return flags.Enabled(ctx, "beta-checkout") }).
+//	    Then(betaCheckout).
+//	    Else(classicCheckout).
+//	    ExecuteParallel(ctx)
+func (ce *ConditionalExecution) ExecuteParallel(ctx context.Context) error {
+	evalCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan conditionResult, len(ce.conditions))
+	for i, condition := range ce.conditions {
+		results[i] = make(chan conditionResult, 1)
+		go func(condition ConditionE, out chan<- conditionResult) {
+			ok, err := condition(evalCtx)
+			out <- conditionResult{ok: ok, err: err}
+		}(condition, results[i])
+	}
+
+	for i := range ce.conditions {
+		var r conditionResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return ce.collectConditionErrors(ctx, cancel, i, r.err, results)
+		}
+		if r.ok {
+			cancel()
+			if i < len(ce.actions) {
+				ce.actions[i]()
+			}
+			return nil
+		}
+	}
+	if ce.elseAction != nil {
+		ce.elseAction()
+	}
+	return nil
+}
+
+// conditionResult is the outcome of a condition evaluated by ExecuteParallel
+type conditionResult struct {
+	ok  bool
+	err error
+}
+
+// collectConditionErrors cancels the remaining conditions, waits for them (until ctx is done)
+// and joins the errors of all conditions that failed on their own, starting with the failed
+// condition at index first.
+func (ce *ConditionalExecution) collectConditionErrors(ctx context.Context, cancel context.CancelFunc, first int, firstErr error, results []chan conditionResult) error {
+	cancel()
+	errs := []error{fmt.Errorf("condition %d: %w", first, firstErr)}
+	for i := first + 1; i < len(results); i++ {
+		var r conditionResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		if r.err == nil || (errors.Is(r.err, context.Canceled) && ctx.Err() == nil) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("condition %d: %w", i, r.err))
+	}
+	return errors.Join(errs...)
+}
+
 // IfThen is a convenience function for simple if-then execution
 //
 // Parameters:
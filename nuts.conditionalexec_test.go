@@ -0,0 +1,123 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForCancel is a condition that only returns once its context is cancelled
+func waitForCancel(cancelled chan<- struct{}) ConditionE {
+	return func(ctx context.Context) (bool, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return false, ctx.Err()
+	}
+}
+
+func TestConditionalExecutionExecuteParallel(t *testing.T) {
+	var executed []string
+	action := func(name string) Action {
+		return func() { executed = append(executed, name) }
+	}
+	cancelled := make(chan struct{})
+
+	// The slow first condition wins over the fast second one, and the undecided third is cancelled
+	err := NewConditionalExecution().
+		IfE(func(context.Context) (bool, error) {
+			time.Sleep(20 * time.Millisecond)
+			return true, nil
+		}).
+		Then(action("slow")).
+		ElseIf(func() bool { return true }).
+		Then(action("fast")).
+		ElseIfE(waitForCancel(cancelled)).
+		Then(action("blocked")).
+		Else(action("else")).
+		ExecuteParallel(context.Background())
+	if err != nil || len(executed) != 1 || executed[0] != "slow" {
+		t.Errorf("ExecuteParallel = %v after executing %v, want only the first true condition", err, executed)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the remaining condition was not cancelled after the decision")
+	}
+
+	executed = nil
+	err = NewConditionalExecution().
+		If(func() bool { return false }).
+		Then(action("first")).
+		IfE(func(context.Context) (bool, error) { return false, nil }).
+		Then(action("second")).
+		Else(action("else")).
+		ExecuteParallel(context.Background())
+	if err != nil || len(executed) != 1 || executed[0] != "else" {
+		t.Errorf("ExecuteParallel = %v after executing %v, want the else action", err, executed)
+	}
+}
+
+func TestConditionalExecutionExecuteParallelErrors(t *testing.T) {
+	errFlags, errQuota := errors.New("flag service down"), errors.New("quota lookup failed")
+	executed := false
+	cancelled := make(chan struct{})
+
+	// Both failures before a decision are reported, the cancelled condition is not
+	err := NewConditionalExecution().
+		IfE(func(context.Context) (bool, error) { return false, errFlags }).
+		Then(func() { executed = true }).
+		IfE(func(context.Context) (bool, error) { return true, nil }).
+		Then(func() { executed = true }).
+		IfE(func(context.Context) (bool, error) { return false, errQuota }).
+		IfE(waitForCancel(cancelled)).
+		Else(func() { executed = true }).
+		ExecuteParallel(context.Background())
+	if !errors.Is(err, errFlags) || !errors.Is(err, errQuota) || errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteParallel = %v, want both condition errors without the cancellation", err)
+	}
+	if executed {
+		t.Error("an action was executed although a condition before the decision failed")
+	}
+
+	// A failure after the selected condition does not matter
+	err = NewConditionalExecution().
+		If(func() bool { return true }).
+		Then(func() { executed = true }).
+		IfE(func(context.Context) (bool, error) { return false, errFlags }).
+		ExecuteParallel(context.Background())
+	if err != nil || !executed {
+		t.Errorf("ExecuteParallel = %v, executed = %v, want the first action", err, executed)
+	}
+
+	// Execute treats a failed condition as false
+	executed = false
+	NewConditionalExecution().
+		IfE(func(context.Context) (bool, error) { return true, errFlags }).
+		Then(func() { t.Error("the action of a failed condition was executed") }).
+		Else(func() { executed = true }).
+		Execute()
+	if !executed {
+		t.Error("Execute did not fall back to the else action")
+	}
+}
+
+func TestConditionalExecutionExecuteParallelContextDone(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// The condition ignores its context, ExecuteParallel still returns when ctx is done
+	err := NewConditionalExecution().
+		IfE(func(context.Context) (bool, error) {
+			<-release
+			return true, nil
+		}).
+		Then(func() { t.Error("an action was executed after the context was done") }).
+		Else(func() { t.Error("the else action was executed after the context was done") }).
+		ExecuteParallel(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExecuteParallel = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -6,54 +6,257 @@ import (
 	"time"
 )
 
+// DebounceOption configures Debounce and NewDebounced
+type DebounceOption func(*debounceConfig)
+
+type debounceConfig struct {
+	onResult func(results []any)
+	mode     DebounceMode
+	maxWait  time.Duration
+}
+
+// DebounceMode selects the calls of a burst that execute the debounced function
+type DebounceMode int
+
+const (
+	// DebounceBoth executes the first call of a burst immediately and the last call when the burst ends (default)
+	DebounceBoth DebounceMode = iota
+	// DebounceLeadingOnly only executes the first call of a burst
+	DebounceLeadingOnly
+	// DebounceTrailingOnly only executes the last call of a burst, when the burst ends
+	DebounceTrailingOnly
+)
+
+// WithDebounceResult delivers the return values of every execution of a debounced function
+// that has return values. onResult is called in the goroutine that executed the function.
+//
+// Example usage:
+//
+//	save := gonuts.Debounce(store.Save, time.Second, nil, gonuts.WithDebounceResult(func(results []any) {
+//	    if err, _ := results[0].(error); err != nil {
+//	        L.Errorf("debounced save failed: %v", err)
+//	    }
+//	}))
+func WithDebounceResult(onResult func(results []any)) DebounceOption {
+	return func(c *debounceConfig) {
+		c.onResult = onResult
+	}
+}
+
+// WithDebounceMode selects whether the first call, the last call or both calls of a burst are executed
+func WithDebounceMode(mode DebounceMode) DebounceOption {
+	return func(c *debounceConfig) {
+		c.mode = mode
+	}
+}
+
+// WithDebounceMaxWait ends a burst after maxWait even if calls keep arriving, so a function that
+// is called continuously is still executed periodically. The next call starts a new burst.
+func WithDebounceMaxWait(maxWait time.Duration) DebounceOption {
+	return func(c *debounceConfig) {
+		c.maxWait = maxWait
+	}
+}
+
+// Debounced is a debounced function with controls, created by NewDebounced. Calls that follow
+// each other within the debounce duration form a burst; depending on the DebounceMode the first
+// call of a burst is executed immediately and the last one when the burst ends.
+type Debounced struct {
+	fn       reflect.Value
+	duration time.Duration
+	callback func(int)
+	config   debounceConfig
+
+	mu        sync.Mutex
+	timer     *time.Timer
+	maxTimer  *time.Timer
+	seq       uint64 // identifies the armed timer, so that a timer that could not be stopped does nothing
+	burst     uint64 // identifies the burst of the max wait timer
+	callCount int
+	args      []reflect.Value // arguments of the last call
+	pending   bool            // whether the last call still has to be executed at the end of the burst
+}
+
 // Debounce that executes first call immediately and last call after delays in calls
-func Debounce(fn any, duration time.Duration, callback func(int)) func(...any) {
-	var timer *time.Timer
-	var args []reflect.Value
-	var callCount int
-	var firstCall bool = true
-	var mutex sync.Mutex
+//
+// The arguments of every call are captured separately, so the trailing execution always sees
+// the complete arguments of the last call. fn is executed without holding the debouncer's
+// lock, so it may call the debounced function again. Debounce panics if fn is not a function.
+// Use NewDebounced to cancel or flush a pending call.
+//
+// Parameters:
+//   - fn: the function to debounce
+//   - duration: the time without calls after which the last call is executed
+//   - callback: called with the number of calls after the trailing execution (may be nil)
+//   - opts: options like WithDebounceResult, WithDebounceMode or WithDebounceMaxWait
+func Debounce(fn any, duration time.Duration, callback func(int), opts ...DebounceOption) func(...any) {
+	return NewDebounced(fn, duration, callback, opts...).Call
+}
+
+// NewDebounced creates a debounced function like Debounce, returning a handle that can also
+// cancel or flush the pending call, e.g. on shutdown or at the end of a test.
+//
+// Parameters:
+//   - fn: the function to debounce; NewDebounced panics if it is not a function
+//   - duration: the time without calls after which a burst ends
+//   - callback: called with the number of calls of a burst when it ends (may be nil)
+//   - opts: options like WithDebounceResult, WithDebounceMode or WithDebounceMaxWait
+//
+// Returns:
+//   - *Debounced: the debounced function
+//
+// Example usage:
+//
+//	save := gonuts.NewDebounced(store.Save, time.Second, nil,
+//	    gonuts.WithDebounceMode(gonuts.DebounceTrailingOnly),
+//	    gonuts.WithDebounceMaxWait(10*time.Second))
+//	defer save.Flush()
+//
+//	save.Call(doc)
+func NewDebounced(fn any, duration time.Duration, callback func(int), opts ...DebounceOption) *Debounced {
 	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		panic("gonuts.Debounce: fn must be a function")
+	}
+	d := &Debounced{fn: fnVal, duration: duration, callback: callback}
+	for _, opt := range opts {
+		opt(&d.config)
+	}
+	return d
+}
 
-	return func(callArgs ...any) {
-		mutex.Lock()
-		defer mutex.Unlock()
-		callCount++
+// Call calls the debounced function. Arguments that are nil become the zero value of the parameter.
+func (d *Debounced) Call(callArgs ...any) {
+	args := debounceArgs(d.fn.Type(), callArgs)
 
-		// Convert call arguments to reflect.Value
-		args = make([]reflect.Value, len(callArgs))
-		for i, arg := range callArgs {
-			args[i] = reflect.ValueOf(arg)
-		}
+	d.mu.Lock()
+	immediate := d.callCount == 0 && d.config.mode != DebounceTrailingOnly
+	if d.callCount == 0 && d.config.maxWait > 0 {
+		burst := d.burst
+		d.maxTimer = time.AfterFunc(d.config.maxWait, func() {
+			d.mu.Lock()
+			if burst != d.burst {
+				d.mu.Unlock()
+				return
+			}
+			d.endBurst()
+		})
+	}
+	d.callCount++
+	d.args = args
+	d.pending = d.config.mode != DebounceLeadingOnly
 
-		if firstCall {
-			// Execute the function immediately on the first call
-			fnVal.Call(args)
-			firstCall = false
+	// Reset the timer if it's already set
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.seq++
+	armed := d.seq
+	d.timer = time.AfterFunc(d.duration, func() {
+		d.mu.Lock()
+		if armed != d.seq {
+			d.mu.Unlock()
+			return
 		}
+		d.endBurst()
+	})
+	d.mu.Unlock()
 
-		// Reset the timer if it's already set
-		if timer != nil {
-			timer.Stop()
-		}
+	if immediate {
+		// Execute the function immediately on the first call
+		d.call(args)
+	}
+}
 
-		timer = time.AfterFunc(duration, func() {
-			mutex.Lock()
-			defer mutex.Unlock()
+// Cancel drops the pending call and ends the current burst without executing the function or
+// calling the callback. The next call starts a new burst.
+func (d *Debounced) Cancel() {
+	d.mu.Lock()
+	d.reset()
+	d.mu.Unlock()
+}
 
-			// Call the function with the latest arguments after the duration elapses
-			fnVal.Call(args)
+// Flush ends the current burst now: the pending call, if any, is executed and the callback is
+// called before Flush returns. It does nothing if there were no calls since the last burst ended.
+func (d *Debounced) Flush() {
+	d.mu.Lock()
+	if d.callCount == 0 {
+		d.mu.Unlock()
+		return
+	}
+	d.endBurst()
+}
 
-			// If a callback is provided, call it with the number of accumulated calls
-			if callback != nil {
-				callback(callCount)
-			}
+// endBurst ends the current burst and executes the pending call. The caller must hold d.mu,
+// which is released before the function is executed.
+func (d *Debounced) endBurst() {
+	args, pending, count := d.args, d.pending, d.callCount
+	d.reset()
+	d.mu.Unlock()
 
-			// Reset call count and first call state after executing
-			callCount = 0
-			firstCall = true
-		})
+	// Call the function with the arguments of the last call
+	if pending {
+		d.call(args)
+	}
+	// If a callback is provided, call it with the number of accumulated calls
+	if d.callback != nil {
+		d.callback(count)
+	}
+}
+
+// reset stops the timers and forgets the calls of the current burst. The caller must hold d.mu.
+func (d *Debounced) reset() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.maxTimer != nil {
+		d.maxTimer.Stop()
+		d.maxTimer = nil
+	}
+	d.seq++
+	d.burst++
+	d.callCount = 0
+	d.args = nil
+	d.pending = false
+}
+
+// call executes the function and delivers its results
+func (d *Debounced) call(args []reflect.Value) {
+	results := d.fn.Call(args)
+	if d.config.onResult == nil || len(results) == 0 {
+		return
+	}
+	values := make([]any, len(results))
+	for i, result := range results {
+		values[i] = result.Interface()
+	}
+	d.config.onResult(values)
+}
+
+// debounceArgs converts call arguments to reflect values; nil becomes the zero value of the parameter type
+func debounceArgs(fnType reflect.Type, callArgs []any) []reflect.Value {
+	if len(callArgs) == 0 {
+		return nil
+	}
+	args := make([]reflect.Value, len(callArgs))
+	for i, arg := range callArgs {
+		if arg != nil {
+			args[i] = reflect.ValueOf(arg)
+			continue
+		}
+		var paramType reflect.Type
+		switch {
+		case fnType.IsVariadic() && i >= fnType.NumIn()-1:
+			paramType = fnType.In(fnType.NumIn() - 1).Elem()
+		case i < fnType.NumIn():
+			paramType = fnType.In(i)
+		default:
+			paramType = reflect.TypeOf((*any)(nil)).Elem()
+		}
+		args[i] = reflect.Zero(paramType)
 	}
+	return args
 }
 
 /*
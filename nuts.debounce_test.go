@@ -0,0 +1,115 @@
+package gonuts
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncedConcurrentCallsSeeCompleteArguments(t *testing.T) {
+	var executions, torn atomic.Int32
+	var results sync.Map
+	d := NewDebounced(func(a, b int, label string) int {
+		executions.Add(1)
+		if a != b || label != "pair" {
+			torn.Add(1)
+		}
+		return a
+	}, 5*time.Millisecond, nil, WithDebounceResult(func(values []any) {
+		results.Store(values[0], true)
+	}))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				n := g*100 + i
+				d.Call(n, n, "pair")
+			}
+		}(g)
+	}
+	wg.Wait()
+	d.Flush()
+
+	if executions.Load() == 0 {
+		t.Fatal("the debounced function was never executed")
+	}
+	if n := torn.Load(); n > 0 {
+		t.Errorf("%d executions saw arguments of different calls", n)
+	}
+	count := 0
+	results.Range(func(any, any) bool {
+		count++
+		return true
+	})
+	if count == 0 {
+		t.Error("no results were delivered to the result callback")
+	}
+}
+
+func TestDebouncedZeroArgumentFunction(t *testing.T) {
+	var executions atomic.Int32
+	var calls atomic.Int32
+	d := NewDebounced(func() { executions.Add(1) }, 5*time.Millisecond, func(n int) { calls.Add(int32(n)) })
+
+	var wg sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Call()
+		}()
+	}
+	wg.Wait()
+	d.Flush()
+
+	// The leading and the trailing call of the burst, or more if the burst was split
+	if n := executions.Load(); n < 2 {
+		t.Errorf("%d executions, want at least the leading and the trailing call", n)
+	}
+	if n := calls.Load(); n != 20 {
+		t.Errorf("callback counted %d calls, want 20", n)
+	}
+}
+
+func TestDebouncedModesAndControls(t *testing.T) {
+	var got []int
+	var mu sync.Mutex
+	record := func(n int) {
+		mu.Lock()
+		got = append(got, n)
+		mu.Unlock()
+	}
+
+	trailing := NewDebounced(record, time.Hour, nil, WithDebounceMode(DebounceTrailingOnly))
+	trailing.Call(1)
+	trailing.Call(2)
+	trailing.Flush()
+	trailing.Flush() // nothing pending
+
+	leading := NewDebounced(record, time.Hour, nil, WithDebounceMode(DebounceLeadingOnly))
+	leading.Call(3)
+	leading.Call(4)
+	leading.Flush()
+
+	both := NewDebounced(record, time.Hour, nil)
+	both.Call(5)
+	both.Call(6)
+	both.Cancel()
+	both.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []int{2, 3, 5}
+	if len(got) != len(want) {
+		t.Fatalf("executions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("executions = %v, want %v", got, want)
+		}
+	}
+}
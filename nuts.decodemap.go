@@ -0,0 +1,538 @@
+package gonuts
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDecodeMap is returned (wrapped) by DecodeMap when the input cannot be converted into the target
+var ErrDecodeMap = errors.New("failed to decode map")
+
+// DecodeMapOptions configures DecodeMap
+type DecodeMapOptions struct {
+	// WeaklyTypedInput enables lenient conversions: strings to numbers and bools ("42" -> 42,
+	// "true" -> true), numbers and bools to strings, and numbers to bools (non-zero is true).
+	WeaklyTypedInput bool
+	// ErrorUnused reports input keys that do not match any struct field as errors.
+	ErrorUnused bool
+}
+
+// DecodeMap converts a decoded map (e.g. from json.Unmarshal into map[string]any) into a struct
+// using reflection instead of a JSON round-trip.
+//
+// Keys are matched against the json tags of the target fields (exact match first, then
+// case-insensitive, like encoding/json); fields tagged "-" are skipped and embedded structs
+// are flattened. Nested structs, maps with string keys, slices and pointer fields are decoded
+// recursively. Integers keep their full precision when the input holds int64 or json.Number
+// values, and floats are only accepted for integer fields if they have no fractional part.
+// time.Time fields accept RFC3339 strings and Unix epoch seconds; types implementing
+// encoding.TextUnmarshaler accept strings.
+//
+// All field errors are collected and returned together as a single 400 *ErrorPlus that wraps
+// ErrDecodeMap; its context holds the problems by field path under the key "fields".
+//
+// Parameters:
+//   - input: the map to decode
+//   - target: a non-nil pointer to a struct
+//   - opts: decoding options
+//
+// Returns:
+//   - error: nil on success, a *ErrorPlus listing all field errors, or an error wrapping
+//     ErrDecodeMap if target is not a pointer to a struct
+//
+// Example usage:
+//
+//	type Order struct {
+//	    ID       int64     `json:"id"`
+//	    Created  time.Time `json:"created"`
+//	    Items    []Item    `json:"items"`
+//	    Customer *Customer `json:"customer"`
+//	}
+//
+//	var order Order
+//	if err := gonuts.DecodeMap(payload, &order, gonuts.DecodeMapOptions{WeaklyTypedInput: true}); err != nil {
+//	    return err
+//	}
+func DecodeMap(input map[string]any, target any, opts DecodeMapOptions) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: target must be a non-nil pointer to a struct, got %T", ErrDecodeMap, target)
+	}
+
+	d := &mapDecoder{opts: opts, problems: make(map[string]string)}
+	d.decodeStruct("", input, rv.Elem())
+	if len(d.problems) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(d.problems))
+	for path := range d.problems {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	list := make([]string, len(paths))
+	for i, path := range paths {
+		list[i] = path + ": " + d.problems[path]
+	}
+	err := fmt.Errorf("%w: %s", ErrDecodeMap, strings.Join(list, "; "))
+	return NewBadRequestError(fmt.Sprintf("%d field(s) could not be decoded", len(paths)), err).
+		WithContext("fields", d.problems)
+}
+
+type mapDecoder struct {
+	opts     DecodeMapOptions
+	problems map[string]string // field path -> problem
+}
+
+func (d *mapDecoder) fail(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "."
+	}
+	d.problems[path] = fmt.Sprintf(format, args...)
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decode converts in into out, recording problems under path
+func (d *mapDecoder) decode(path string, in interface{}, out reflect.Value) {
+	if in == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return
+	}
+
+	if out.Kind() == reflect.Pointer {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		d.decode(path, in, out.Elem())
+		return
+	}
+
+	inValue := reflect.ValueOf(in)
+	if inValue.Type().AssignableTo(out.Type()) {
+		out.Set(inValue)
+		return
+	}
+
+	if out.Type() == timeType {
+		d.decodeTime(path, in, out)
+		return
+	}
+	if s, ok := in.(string); ok && reflect.PointerTo(out.Type()).Implements(textUnmarshalerType) {
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			d.fail(path, "%v", err)
+		}
+		return
+	}
+
+	switch out.Kind() {
+	case reflect.String:
+		d.decodeString(path, in, out)
+	case reflect.Bool:
+		d.decodeBool(path, in, out)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.decodeInt(path, in, out)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		d.decodeUint(path, in, out)
+	case reflect.Float32, reflect.Float64:
+		d.decodeFloat(path, in, out)
+	case reflect.Struct:
+		m, ok := in.(map[string]interface{})
+		if !ok {
+			d.fail(path, "expected an object, got %T", in)
+			return
+		}
+		d.decodeStruct(path, m, out)
+	case reflect.Map:
+		d.decodeMapValue(path, inValue, out)
+	case reflect.Slice:
+		d.decodeSlice(path, inValue, out)
+	default:
+		d.fail(path, "cannot decode %T into %s", in, out.Type())
+	}
+}
+
+func (d *mapDecoder) decodeStruct(path string, in map[string]interface{}, out reflect.Value) {
+	fields := cachedDecodeFields(out.Type())
+	for key, value := range in {
+		field, ok := fields.lookup(key)
+		if !ok {
+			if d.opts.ErrorUnused {
+				d.fail(joinDecodePath(path, key), "no matching field")
+			}
+			continue
+		}
+		target, ok := fieldByIndexAlloc(out, field.index)
+		if !ok {
+			d.fail(joinDecodePath(path, key), "cannot set field of unexported embedded struct")
+			continue
+		}
+		d.decode(joinDecodePath(path, key), value, target)
+	}
+}
+
+func (d *mapDecoder) decodeMapValue(path string, in reflect.Value, out reflect.Value) {
+	if in.Kind() != reflect.Map || in.Type().Key().Kind() != reflect.String || out.Type().Key().Kind() != reflect.String {
+		d.fail(path, "cannot decode %s into %s", in.Type(), out.Type())
+		return
+	}
+	result := reflect.MakeMapWithSize(out.Type(), in.Len())
+	elemType := out.Type().Elem()
+	iter := in.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		elem := reflect.New(elemType).Elem()
+		d.decode(joinDecodePath(path, key), iter.Value().Interface(), elem)
+		result.SetMapIndex(reflect.ValueOf(key).Convert(out.Type().Key()), elem)
+	}
+	out.Set(result)
+}
+
+func (d *mapDecoder) decodeSlice(path string, in reflect.Value, out reflect.Value) {
+	if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+		d.fail(path, "expected an array, got %s", in.Type())
+		return
+	}
+	result := reflect.MakeSlice(out.Type(), in.Len(), in.Len())
+	for i := 0; i < in.Len(); i++ {
+		d.decode(path+"["+strconv.Itoa(i)+"]", in.Index(i).Interface(), result.Index(i))
+	}
+	out.Set(result)
+}
+
+func (d *mapDecoder) decodeTime(path string, in interface{}, out reflect.Value) {
+	switch v := in.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			d.fail(path, "invalid RFC3339 time %q", v)
+			return
+		}
+		out.Set(reflect.ValueOf(t))
+		return
+	case json.Number:
+		if seconds, err := v.Int64(); err == nil {
+			out.Set(reflect.ValueOf(time.Unix(seconds, 0)))
+			return
+		}
+	}
+	seconds, ok := decodeNumberAsFloat(in)
+	if !ok || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		d.fail(path, "expected an RFC3339 string or epoch seconds, got %T", in)
+		return
+	}
+	whole, frac := math.Modf(seconds)
+	out.Set(reflect.ValueOf(time.Unix(int64(whole), int64(frac*1e9))))
+}
+
+func (d *mapDecoder) decodeString(path string, in interface{}, out reflect.Value) {
+	if s, ok := in.(string); ok {
+		out.SetString(s)
+		return
+	}
+	if d.opts.WeaklyTypedInput {
+		switch v := in.(type) {
+		case bool:
+			out.SetString(strconv.FormatBool(v))
+			return
+		case json.Number:
+			out.SetString(v.String())
+			return
+		case float32, float64:
+			f, _ := decodeNumberAsFloat(v)
+			out.SetString(strconv.FormatFloat(f, 'f', -1, 64))
+			return
+		}
+		if i, ok := decodeNumberAsInt(in); ok {
+			out.SetString(strconv.FormatInt(i, 10))
+			return
+		}
+	}
+	d.fail(path, "expected a string, got %T", in)
+}
+
+func (d *mapDecoder) decodeBool(path string, in interface{}, out reflect.Value) {
+	if d.opts.WeaklyTypedInput {
+		if s, ok := in.(string); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				d.fail(path, "invalid bool %q", s)
+				return
+			}
+			out.SetBool(b)
+			return
+		}
+		if f, ok := decodeNumberAsFloat(in); ok {
+			out.SetBool(f != 0)
+			return
+		}
+	}
+	d.fail(path, "expected a bool, got %T", in)
+}
+
+func (d *mapDecoder) decodeInt(path string, in interface{}, out reflect.Value) {
+	i, ok := decodeNumberAsInt(in)
+	if !ok {
+		if f, isFloat := decodeNumberAsFloat(in); isFloat {
+			d.fail(path, "%v is not an integer", f)
+			return
+		}
+		s, isString := in.(string)
+		if !isString || !d.opts.WeaklyTypedInput {
+			d.fail(path, "expected an integer, got %T", in)
+			return
+		}
+		var err error
+		if i, err = strconv.ParseInt(s, 10, 64); err != nil {
+			d.fail(path, "invalid integer %q", s)
+			return
+		}
+	}
+	if out.OverflowInt(i) {
+		d.fail(path, "%d overflows %s", i, out.Type())
+		return
+	}
+	out.SetInt(i)
+}
+
+func (d *mapDecoder) decodeUint(path string, in interface{}, out reflect.Value) {
+	var u uint64
+	switch v := in.(type) {
+	case uint:
+		u = uint64(v)
+	case uint8:
+		u = uint64(v)
+	case uint16:
+		u = uint64(v)
+	case uint32:
+		u = uint64(v)
+	case uint64:
+		u = v
+	case json.Number:
+		var err error
+		if u, err = strconv.ParseUint(v.String(), 10, 64); err != nil {
+			d.fail(path, "invalid unsigned integer %s", v)
+			return
+		}
+	case string:
+		if !d.opts.WeaklyTypedInput {
+			d.fail(path, "expected an unsigned integer, got string")
+			return
+		}
+		var err error
+		if u, err = strconv.ParseUint(v, 10, 64); err != nil {
+			d.fail(path, "invalid unsigned integer %q", v)
+			return
+		}
+	default:
+		i, ok := decodeNumberAsInt(in)
+		if !ok || i < 0 {
+			d.fail(path, "expected an unsigned integer, got %v", in)
+			return
+		}
+		u = uint64(i)
+	}
+	if out.OverflowUint(u) {
+		d.fail(path, "%d overflows %s", u, out.Type())
+		return
+	}
+	out.SetUint(u)
+}
+
+func (d *mapDecoder) decodeFloat(path string, in interface{}, out reflect.Value) {
+	f, ok := decodeNumberAsFloat(in)
+	if !ok {
+		s, isString := in.(string)
+		if !isString || !d.opts.WeaklyTypedInput {
+			d.fail(path, "expected a number, got %T", in)
+			return
+		}
+		var err error
+		if f, err = strconv.ParseFloat(s, 64); err != nil {
+			d.fail(path, "invalid number %q", s)
+			return
+		}
+	}
+	if out.OverflowFloat(f) {
+		d.fail(path, "%v overflows %s", f, out.Type())
+		return
+	}
+	out.SetFloat(f)
+}
+
+// decodeNumberAsInt returns in as int64 if it is an integer or an integral float or json.Number
+func decodeNumberAsInt(in interface{}) (int64, bool) {
+	switch v := in.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case float32:
+		return decodeNumberAsInt(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// decodeNumberAsFloat returns in as float64 if it is any kind of number
+func decodeNumberAsFloat(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	if i, ok := decodeNumberAsInt(in); ok {
+		return float64(i), true
+	}
+	if u, ok := in.(uint64); ok {
+		return float64(u), true
+	}
+	return 0, false
+}
+
+func joinDecodePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// decodeField is a settable struct field with the index path to reach it through embedded structs
+type decodeField struct {
+	name  string
+	index []int
+}
+
+type decodeFields struct {
+	byName  map[string]decodeField
+	byLower map[string]decodeField
+}
+
+func (f *decodeFields) lookup(key string) (decodeField, bool) {
+	if field, ok := f.byName[key]; ok {
+		return field, true
+	}
+	field, ok := f.byLower[strings.ToLower(key)]
+	return field, ok
+}
+
+var decodeFieldCache sync.Map // reflect.Type -> *decodeFields
+
+// cachedDecodeFields returns the fields of a struct type by their json names
+func cachedDecodeFields(t reflect.Type) *decodeFields {
+	if cached, ok := decodeFieldCache.Load(t); ok {
+		return cached.(*decodeFields)
+	}
+	fields := &decodeFields{
+		byName:  make(map[string]decodeField),
+		byLower: make(map[string]decodeField),
+	}
+	collectDecodeFields(t, nil, fields, 0)
+	cached, _ := decodeFieldCache.LoadOrStore(t, fields)
+	return cached.(*decodeFields)
+}
+
+// collectDecodeFields adds the fields of t; fields of shallower embedding depth win, like in encoding/json
+func collectDecodeFields(t reflect.Type, index []int, fields *decodeFields, depth int) {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var nested []embedded
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				nested = append(nested, embedded{t: ft, index: fieldIndex})
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		field := decodeField{name: name, index: fieldIndex}
+		if _, exists := fields.byName[name]; !exists {
+			fields.byName[name] = field
+		}
+		if _, exists := fields.byLower[strings.ToLower(name)]; !exists {
+			fields.byLower[strings.ToLower(name)] = field
+		}
+	}
+
+	if depth < 8 {
+		for _, e := range nested {
+			collectDecodeFields(e.t, e.index, fields, depth+1)
+		}
+	}
+}
+
+// fieldByIndexAlloc returns the field at index, allocating nil embedded struct pointers on the way
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
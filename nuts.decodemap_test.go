@@ -0,0 +1,187 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeTestBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type decodeTestItem struct {
+	SKU      string  `json:"sku"`
+	Quantity uint16  `json:"qty"`
+	Price    float32 `json:"price"`
+}
+
+type decodeTestCustomer struct {
+	Name string `json:"name"`
+	VIP  bool   `json:"vip"`
+}
+
+type decodeTestOrder struct {
+	decodeTestBase
+	Items    []decodeTestItem    `json:"items"`
+	Customer *decodeTestCustomer `json:"customer"`
+	Labels   map[string]int      `json:"labels"`
+	Address  net.IP              `json:"address"`
+	Note     *string             `json:"note"`
+	Secret   string              `json:"-"`
+	Updated  time.Time           `json:"updated"`
+	Status   string
+}
+
+func TestDecodeMap(t *testing.T) {
+	// Decode from JSON with UseNumber, so the large id keeps its precision
+	payload := `{
+		"id": 9007199254740993,
+		"created": "2024-05-01T10:00:00.5Z",
+		"updated": 1714557600,
+		"items": [{"sku": "a-1", "qty": 2, "price": 9.5}, {"sku": "b-2", "qty": 1}],
+		"customer": {"name": "Jane", "vip": true},
+		"labels": {"priority": 1},
+		"address": "10.0.0.1",
+		"note": null,
+		"-": "hidden",
+		"STATUS": "open",
+		"unknown": true
+	}`
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var input map[string]any
+	if err := decoder.Decode(&input); err != nil {
+		t.Fatal(err)
+	}
+
+	note := "stale"
+	order := decodeTestOrder{Note: &note, Secret: "keep"}
+	if err := DecodeMap(input, &order, DecodeMapOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := decodeTestOrder{
+		decodeTestBase: decodeTestBase{ID: 9007199254740993, Created: time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)},
+		Items:          []decodeTestItem{{"a-1", 2, 9.5}, {"b-2", 1, 0}},
+		Customer:       &decodeTestCustomer{Name: "Jane", VIP: true},
+		Labels:         map[string]int{"priority": 1},
+		Address:        net.ParseIP("10.0.0.1"),
+		Secret:         "keep",
+		Status:         "open", // matched case-insensitively
+	}
+	if !order.Updated.Equal(time.Unix(1714557600, 0)) {
+		t.Errorf("Updated = %s, want the epoch seconds", order.Updated)
+	}
+	order.Updated = time.Time{}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("DecodeMap = %+v\nwant %+v", order, want)
+	}
+}
+
+func TestDecodeMapWeaklyTypedInput(t *testing.T) {
+	input := map[string]any{
+		"id":       "42",
+		"items":    []any{map[string]any{"sku": 7, "qty": "3", "price": "1.25"}},
+		"customer": map[string]any{"name": true, "vip": "true"},
+		"Status":   2.5,
+	}
+
+	var strict decodeTestOrder
+	err := DecodeMap(input, &strict, DecodeMapOptions{})
+	var ep *ErrorPlus
+	if !errors.As(err, &ep) || !errors.Is(err, ErrDecodeMap) || ep.Code() != 400 {
+		t.Fatalf("DecodeMap without weak typing = %v, want a 400 ErrorPlus wrapping ErrDecodeMap", err)
+	}
+	if fields, _ := ep.Context()["fields"].(map[string]string); len(fields) != 7 {
+		t.Errorf("%d field problems without weak typing, want all 7 conversions rejected: %v", len(fields), fields)
+	}
+
+	var weak decodeTestOrder
+	if err := DecodeMap(input, &weak, DecodeMapOptions{WeaklyTypedInput: true}); err != nil {
+		t.Fatal(err)
+	}
+	if weak.ID != 42 || weak.Status != "2.5" || weak.Customer.Name != "true" || !weak.Customer.VIP {
+		t.Errorf("DecodeMap with weak typing = %+v, %+v", weak, weak.Customer)
+	}
+	if item := weak.Items[0]; item != (decodeTestItem{"7", 3, 1.25}) {
+		t.Errorf("Items[0] = %+v", item)
+	}
+}
+
+func TestDecodeMapCollectsFieldErrors(t *testing.T) {
+	input := map[string]any{
+		"id":       1.5,
+		"created":  "yesterday",
+		"items":    []any{map[string]any{"qty": -1}, map[string]any{"qty": 70000}, "not an object"},
+		"customer": "Jane",
+		"labels":   map[string]any{"a": 1, "b": "two"},
+		"address":  "not an ip",
+		"extra":    1,
+	}
+	var order decodeTestOrder
+	err := DecodeMap(input, &order, DecodeMapOptions{ErrorUnused: true, WeaklyTypedInput: true})
+	var ep *ErrorPlus
+	if !errors.As(err, &ep) {
+		t.Fatalf("DecodeMap error = %v, want an ErrorPlus", err)
+	}
+	fields, _ := ep.Context()["fields"].(map[string]string)
+	var paths []string
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	want := []string{"address", "created", "customer", "extra", "id", "items[0].qty", "items[1].qty", "items[2]", "labels.b"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("problem paths = %v, want %v", paths, want)
+	}
+	if !strings.Contains(fields["id"], "not an integer") || !strings.Contains(fields["items[1].qty"], "overflows") {
+		t.Errorf("problems = %v", fields)
+	}
+	// The message lists the problems sorted by path
+	if msg := err.Error(); strings.Index(msg, "address:") > strings.Index(msg, "labels.b:") {
+		t.Errorf("error %q does not list the problems in order", msg)
+	}
+}
+
+func TestDecodeMapNumbers(t *testing.T) {
+	var target struct {
+		Small int8    `json:"small"`
+		Big   uint64  `json:"big"`
+		Ratio float32 `json:"ratio"`
+	}
+	if err := DecodeMap(map[string]any{"small": -128.0, "big": uint64(math.MaxUint64), "ratio": json.Number("0.5")}, &target, DecodeMapOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if target.Small != -128 || target.Big != math.MaxUint64 || target.Ratio != 0.5 {
+		t.Errorf("DecodeMap = %+v", target)
+	}
+
+	for _, input := range []map[string]any{
+		{"small": 128},
+		{"small": json.Number("1e3")},
+		{"big": -1},
+		{"ratio": math.MaxFloat64},
+	} {
+		if err := DecodeMap(input, &target, DecodeMapOptions{}); !errors.Is(err, ErrDecodeMap) {
+			t.Errorf("DecodeMap(%v) = %v, want ErrDecodeMap", input, err)
+		}
+	}
+}
+
+func TestDecodeMapInvalidTargets(t *testing.T) {
+	var order decodeTestOrder
+	for _, target := range []any{nil, order, (*decodeTestOrder)(nil), new(map[string]any)} {
+		err := DecodeMap(map[string]any{}, target, DecodeMapOptions{})
+		var ep *ErrorPlus
+		if !errors.Is(err, ErrDecodeMap) || errors.As(err, &ep) {
+			t.Errorf("DecodeMap(%T) = %v, want a plain error wrapping ErrDecodeMap", target, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrEnvMissing is wrapped by the errors of EnvRequired and LoadEnvStruct for required variables that are not set
+	ErrEnvMissing = errors.New("missing environment variable")
+	// ErrEnvInvalid is wrapped by the error of LoadEnvStruct for variables that cannot be converted
+	ErrEnvInvalid = errors.New("invalid environment variable")
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// lookupEnv returns the value of an environment variable; empty values count as not set
+func lookupEnv(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	return value, ok && value != ""
+}
+
+// EnvString returns the value of an environment variable, or fallback if it is not set or empty
+//
+// Example usage:
+//
+//	addr := gonuts.EnvString("LISTEN_ADDR", ":8080")
+func EnvString(key, fallback string) string {
+	if value, ok := lookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// EnvInt returns the value of an environment variable as int. If the variable is not set,
+// empty or not an integer, fallback is returned; malformed values are logged as warnings.
+//
+// Example usage:
+//
+//	workers := gonuts.EnvInt("WORKERS", runtime.NumCPU())
+func EnvInt(key string, fallback int) int {
+	return envParse(key, fallback, strconv.Atoi)
+}
+
+// EnvBool returns the value of an environment variable as bool, accepting the values of
+// strconv.ParseBool ("1", "t", "true", "0", "f", "false", ...). If the variable is not set,
+// empty or malformed, fallback is returned; malformed values are logged as warnings.
+//
+// Example usage:
+//
+//	debug := gonuts.EnvBool("DEBUG", false)
+func EnvBool(key string, fallback bool) bool {
+	return envParse(key, fallback, strconv.ParseBool)
+}
+
+// EnvDuration returns the value of an environment variable as time.Duration, accepting the
+// values of time.ParseDuration ("30s", "5m", "1h30m"). If the variable is not set, empty or
+// malformed, fallback is returned; malformed values are logged as warnings.
+//
+// Example usage:
+//
+//	timeout := gonuts.EnvDuration("HTTP_TIMEOUT", 30*time.Second)
+func EnvDuration(key string, fallback time.Duration) time.Duration {
+	return envParse(key, fallback, time.ParseDuration)
+}
+
+// EnvStringSlice returns the value of an environment variable split by sep, with the elements
+// trimmed and empty elements dropped. If the variable is not set or holds no elements,
+// fallback is returned.
+//
+// Example usage:
+//
+//	origins := gonuts.EnvStringSlice("CORS_ORIGINS", ",", []string{"http://localhost:3000"})
+func EnvStringSlice(key, sep string, fallback []string) []string {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	if elements := splitEnvList(value, sep); len(elements) > 0 {
+		return elements
+	}
+	return fallback
+}
+
+// EnvRequired returns the value of an environment variable that must be set
+//
+// Returns:
+//   - string: the value
+//   - error: an internal *ErrorPlus wrapping ErrEnvMissing, with the variable name in the context
+//     under "variable", if the variable is not set or empty
+//
+// Example usage:
+//
+//	dsn, err := gonuts.EnvRequired("DATABASE_URL")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func EnvRequired(key string) (string, error) {
+	if value, ok := lookupEnv(key); ok {
+		return value, nil
+	}
+	return "", NewInternalError(fmt.Sprintf("environment variable %s is required", key), ErrEnvMissing).
+		WithContext("variable", key)
+}
+
+// envParse parses an environment variable, falling back on missing and malformed values
+func envParse[T any](key string, fallback T, parse func(string) (T, error)) T {
+	raw, ok := lookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	value, err := parse(strings.TrimSpace(raw))
+	if err != nil {
+		L.Warnf("[env] ignoring malformed %s=%q, using %v: %v", key, raw, fallback, err)
+		return fallback
+	}
+	return value
+}
+
+// splitEnvList splits a list value, trimming the elements and dropping empty ones
+func splitEnvList(value, sep string) []string {
+	var elements []string
+	for _, element := range strings.Split(value, sep) {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// LoadEnvStruct fills the fields of a struct tagged `env:"NAME"` from the environment
+// variables prefix+NAME. Values are converted with the weakly typed rules of DecodeMap
+// ("42" -> 42, "true" -> true, RFC3339 strings for time.Time, encoding.TextUnmarshaler);
+// additionally time.Duration fields accept time.ParseDuration values ("30s", "5m"), and slice
+// fields take comma-separated lists. Untagged struct fields are walked with the same prefix.
+//
+// Fields whose variable is not set or empty keep their value, so defaults can be set on the
+// struct before loading; `env:"NAME,required"` makes the variable mandatory.
+//
+// Parameters:
+//   - prefix: prepended to every variable name, e.g. "APP_"
+//   - target: a non-nil pointer to a struct
+//
+// Returns:
+//   - error: nil on success; otherwise an internal *ErrorPlus wrapping ErrEnvMissing and/or
+//     ErrEnvInvalid, whose context holds the problems by variable name under "variables";
+//     no fields are changed then
+//
+// Example usage:
+//
+//	type Config struct {
+//	    Port     int           `env:"PORT"`
+//	    DSN      string        `env:"DATABASE_URL,required"`
+//	    Timeout  time.Duration `env:"TIMEOUT"`
+//	    Origins  []string      `env:"CORS_ORIGINS"`
+//	}
+//
+//	cfg := Config{Port: 8080, Timeout: 30 * time.Second}
+//	if err := gonuts.LoadEnvStruct("APP_", &cfg); err != nil {
+//	    log.Fatal(err)
+//	}
+func LoadEnvStruct(prefix string, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return NewInternalError("failed to load environment", fmt.Errorf("%w: target must be a non-nil pointer to a struct, got %T", ErrEnvInvalid, target))
+	}
+
+	// Decode into a copy, so nothing is changed if a variable is missing or malformed
+	loaded := reflect.New(rv.Elem().Type()).Elem()
+	loaded.Set(rv.Elem())
+	l := &envLoader{
+		prefix:   prefix,
+		decoder:  &mapDecoder{opts: DecodeMapOptions{WeaklyTypedInput: true}, problems: make(map[string]string)},
+		problems: make(map[string]string),
+	}
+	l.loadStruct(loaded, 0)
+
+	for name, problem := range l.decoder.problems {
+		l.problems[name] = problem
+	}
+	if len(l.problems) == 0 {
+		rv.Elem().Set(loaded)
+		return nil
+	}
+
+	var errs []error
+	if len(l.missing) > 0 {
+		sort.Strings(l.missing)
+		errs = append(errs, fmt.Errorf("%w: %s", ErrEnvMissing, strings.Join(l.missing, ", ")))
+	}
+	if invalid := len(l.problems) - len(l.missing); invalid > 0 {
+		names := make([]string, 0, invalid)
+		for name, problem := range l.problems {
+			if problem != envMissingProblem {
+				names = append(names, name+": "+problem)
+			}
+		}
+		sort.Strings(names)
+		errs = append(errs, fmt.Errorf("%w: %s", ErrEnvInvalid, strings.Join(names, "; ")))
+	}
+	return NewInternalError(fmt.Sprintf("%d environment variable(s) could not be loaded", len(l.problems)), errors.Join(errs...)).
+		WithContext("variables", l.problems)
+}
+
+const envMissingProblem = "required but not set"
+
+type envLoader struct {
+	prefix   string
+	decoder  *mapDecoder
+	problems map[string]string // variable name -> problem
+	missing  []string
+}
+
+func (l *envLoader) loadStruct(v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag, tagged := sf.Tag.Lookup("env")
+		if !tagged || tag == "" {
+			if sf.Type.Kind() == reflect.Struct && sf.Type != timeType && depth < 8 {
+				l.loadStruct(v.Field(i), depth+1)
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		name = l.prefix + name
+		raw, ok := lookupEnv(name)
+		if !ok {
+			if options == "required" {
+				l.problems[name] = envMissingProblem
+				l.missing = append(l.missing, name)
+			}
+			continue
+		}
+		l.load(name, strings.TrimSpace(raw), v.Field(i))
+	}
+}
+
+// load converts a variable value into a field
+func (l *envLoader) load(name, raw string, field reflect.Value) {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			l.problems[name] = fmt.Sprintf("invalid duration %q", raw)
+			return
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8:
+		elements := splitEnvList(raw, ",")
+		result := reflect.MakeSlice(field.Type(), len(elements), len(elements))
+		for i, element := range elements {
+			l.load(name, element, result.Index(i))
+		}
+		field.Set(result)
+	default:
+		l.decoder.decode(name, raw, field)
+	}
+}
@@ -0,0 +1,150 @@
+package gonuts
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvAccessors(t *testing.T) {
+	t.Setenv("ENVTEST_STRING", "value")
+	t.Setenv("ENVTEST_EMPTY", "")
+	t.Setenv("ENVTEST_INT", " 42 ")
+	t.Setenv("ENVTEST_BAD_INT", "42abc")
+	t.Setenv("ENVTEST_BOOL", "t")
+	t.Setenv("ENVTEST_DURATION", "1h30m")
+	t.Setenv("ENVTEST_BAD_DURATION", "90")
+	t.Setenv("ENVTEST_LIST", " a, b ,,c ")
+	t.Setenv("ENVTEST_EMPTY_LIST", " , ")
+
+	if got := EnvString("ENVTEST_STRING", "fallback"); got != "value" {
+		t.Errorf("EnvString = %q", got)
+	}
+	if got := EnvString("ENVTEST_EMPTY", "fallback"); got != "fallback" {
+		t.Errorf("EnvString of an empty variable = %q, want the fallback", got)
+	}
+	if got := EnvString("ENVTEST_UNSET", "fallback"); got != "fallback" {
+		t.Errorf("EnvString of an unset variable = %q, want the fallback", got)
+	}
+
+	if got := EnvInt("ENVTEST_INT", 1); got != 42 {
+		t.Errorf("EnvInt = %d, want 42 with spaces trimmed", got)
+	}
+	if got := EnvInt("ENVTEST_BAD_INT", 1); got != 1 {
+		t.Errorf("EnvInt of a malformed value = %d, want the fallback", got)
+	}
+	if got := EnvBool("ENVTEST_BOOL", false); !got {
+		t.Error("EnvBool(t) = false")
+	}
+	if got := EnvBool("ENVTEST_STRING", true); !got {
+		t.Error("EnvBool of a malformed value = false, want the fallback")
+	}
+	if got := EnvDuration("ENVTEST_DURATION", time.Second); got != 90*time.Minute {
+		t.Errorf("EnvDuration = %s", got)
+	}
+	if got := EnvDuration("ENVTEST_BAD_DURATION", time.Second); got != time.Second {
+		t.Errorf("EnvDuration without a unit = %s, want the fallback", got)
+	}
+
+	if got := EnvStringSlice("ENVTEST_LIST", ",", nil); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("EnvStringSlice = %q, want trimmed elements without empty ones", got)
+	}
+	fallback := []string{"default"}
+	if got := EnvStringSlice("ENVTEST_EMPTY_LIST", ",", fallback); !slices.Equal(got, fallback) {
+		t.Errorf("EnvStringSlice without elements = %q, want the fallback", got)
+	}
+
+	if got, err := EnvRequired("ENVTEST_STRING"); err != nil || got != "value" {
+		t.Errorf("EnvRequired = %q, %v", got, err)
+	}
+	_, err := EnvRequired("ENVTEST_EMPTY")
+	var ep *ErrorPlus
+	if !errors.Is(err, ErrEnvMissing) || !errors.As(err, &ep) || ep.Context()["variable"] != "ENVTEST_EMPTY" {
+		t.Errorf("EnvRequired of an empty variable = %v, want ErrEnvMissing naming the variable", err)
+	}
+}
+
+type envTestDatabase struct {
+	DSN      string `env:"DATABASE_URL,required"`
+	MaxConns int    `env:"DB_MAX_CONNS"`
+}
+
+type envTestConfig struct {
+	Port     int           `env:"PORT"`
+	Debug    bool          `env:"DEBUG"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Started  time.Time     `env:"STARTED"`
+	Origins  []string      `env:"CORS_ORIGINS"`
+	Ports    []int         `env:"EXTRA_PORTS"`
+	Ignored  string        `env:"-"`
+	Database envTestDatabase
+}
+
+func TestLoadEnvStruct(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_TIMEOUT", "5s")
+	t.Setenv("APP_STARTED", "2024-05-01T10:00:00Z")
+	t.Setenv("APP_CORS_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("APP_EXTRA_PORTS", "8081,8082")
+	t.Setenv("APP_DATABASE_URL", "postgres://db")
+	t.Setenv("APP_DB_MAX_CONNS", "")
+	t.Setenv("APP_-", "ignored")
+
+	cfg := envTestConfig{Timeout: time.Minute, Ignored: "keep", Database: envTestDatabase{MaxConns: 10}}
+	if err := LoadEnvStruct("APP_", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9090 || !cfg.Debug || cfg.Timeout != 5*time.Second || cfg.Ignored != "keep" {
+		t.Errorf("LoadEnvStruct = %+v", cfg)
+	}
+	if !cfg.Started.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Started = %s", cfg.Started)
+	}
+	if !slices.Equal(cfg.Origins, []string{"https://a.example.com", "https://b.example.com"}) || !slices.Equal(cfg.Ports, []int{8081, 8082}) {
+		t.Errorf("Origins = %q, Ports = %v", cfg.Origins, cfg.Ports)
+	}
+	// Nested structs are loaded with the same prefix, empty variables keep the default
+	if cfg.Database.DSN != "postgres://db" || cfg.Database.MaxConns != 10 {
+		t.Errorf("Database = %+v", cfg.Database)
+	}
+}
+
+func TestLoadEnvStructProblems(t *testing.T) {
+	t.Setenv("BAD_PORT", "http")
+	t.Setenv("BAD_TIMEOUT", "soon")
+	t.Setenv("BAD_EXTRA_PORTS", "8081,x")
+	t.Setenv("BAD_DEBUG", "true")
+
+	cfg := envTestConfig{Port: 8080}
+	err := LoadEnvStruct("BAD_", &cfg)
+	if !errors.Is(err, ErrEnvMissing) || !errors.Is(err, ErrEnvInvalid) {
+		t.Fatalf("LoadEnvStruct error = %v, want ErrEnvMissing and ErrEnvInvalid", err)
+	}
+	var ep *ErrorPlus
+	errors.As(err, &ep)
+	problems, _ := ep.Context()["variables"].(map[string]string)
+	var names []string
+	for name := range problems {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"BAD_DATABASE_URL", "BAD_EXTRA_PORTS", "BAD_PORT", "BAD_TIMEOUT"}; !slices.Equal(names, want) {
+		t.Errorf("problems = %v, want the variables %v", problems, want)
+	}
+	if !strings.Contains(err.Error(), "BAD_DATABASE_URL") {
+		t.Errorf("error %q does not name the missing variable", err)
+	}
+	// Nothing is changed, not even the valid variables
+	if cfg.Port != 8080 || cfg.Debug {
+		t.Errorf("cfg changed to %+v although loading failed", cfg)
+	}
+
+	for _, target := range []any{nil, cfg, (*envTestConfig)(nil)} {
+		if err := LoadEnvStruct("", target); !errors.Is(err, ErrEnvInvalid) {
+			t.Errorf("LoadEnvStruct(%T) = %v, want ErrEnvInvalid", target, err)
+		}
+	}
+}
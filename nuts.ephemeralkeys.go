@@ -0,0 +1,142 @@
+package gonuts
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Key kinds supported by GenerateEphemeralKeys
+const (
+	KeyKindRSA2048 = "rsa2048"
+	KeyKindEC256   = "ec256"
+	KeyKindEd25519 = "ed25519"
+)
+
+var (
+	// ErrUnsupportedKeyKind is returned for key kinds other than rsa2048, ec256 and ed25519
+	ErrUnsupportedKeyKind = errors.New("unsupported key kind")
+	// ErrInvalidKeySet is returned by ExportPEM for a KeySet without keys
+	ErrInvalidKeySet = errors.New("invalid key set")
+)
+
+// KeySet is an asymmetric key pair held in memory
+type KeySet struct {
+	Kind       string // one of the KeyKind constants
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	// Algorithm is the JWS algorithm matching the key kind: RS256, ES256 or EdDSA
+	Algorithm string
+}
+
+// GenerateEphemeralKeys generates a key pair in memory, e.g. for tests and ephemeral
+// environments that should not depend on PEM files on disk.
+//
+// Parameters:
+//   - kind: KeyKindRSA2048 ("rsa2048"), KeyKindEC256 ("ec256") or KeyKindEd25519 ("ed25519")
+//
+// Returns:
+//   - KeySet: the generated keys
+//   - error: ErrUnsupportedKeyKind for unknown kinds, or the key generation error
+//
+// Example usage:
+//
+//	keys, err := gonuts.GenerateEphemeralKeys(gonuts.KeyKindEd25519)
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	signature, err := keys.PrivateKey.Sign(rand.Reader, message, crypto.Hash(0))
+func GenerateEphemeralKeys(kind string) (KeySet, error) {
+	switch kind {
+	case KeyKindRSA2048:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return KeySet{}, fmt.Errorf("generating %s key: %w", kind, err)
+		}
+		return KeySet{Kind: kind, PrivateKey: key, PublicKey: &key.PublicKey, Algorithm: "RS256"}, nil
+	case KeyKindEC256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return KeySet{}, fmt.Errorf("generating %s key: %w", kind, err)
+		}
+		return KeySet{Kind: kind, PrivateKey: key, PublicKey: &key.PublicKey, Algorithm: "ES256"}, nil
+	case KeyKindEd25519:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return KeySet{}, fmt.Errorf("generating %s key: %w", kind, err)
+		}
+		return KeySet{Kind: kind, PrivateKey: private, PublicKey: public, Algorithm: "EdDSA"}, nil
+	}
+	return KeySet{}, fmt.Errorf("%w: %q", ErrUnsupportedKeyKind, kind)
+}
+
+// ExportPEM encodes a KeySet as PEM, the private key in PKCS #8 and the public key in PKIX format
+//
+// Returns:
+//   - privatePEM: the "PRIVATE KEY" PEM block
+//   - publicPEM: the "PUBLIC KEY" PEM block
+//   - err: ErrInvalidKeySet if a key is missing, or the encoding error
+//
+// Example usage:
+//
+//	privatePEM, publicPEM, err := gonuts.ExportPEM(keys)
+//	if err == nil {
+//	    err = os.WriteFile("jwt.key", privatePEM, 0600)
+//	}
+func ExportPEM(ks KeySet) (privatePEM, publicPEM []byte, err error) {
+	if ks.PrivateKey == nil || ks.PublicKey == nil {
+		return nil, nil, fmt.Errorf("%w: missing key", ErrInvalidKeySet)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(ks.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(ks.PublicKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding public key: %w", err)
+	}
+	privatePEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return privatePEM, publicPEM, nil
+}
+
+// KeySetFromPEM loads a KeySet from a PKCS #8 "PRIVATE KEY" PEM block as written by ExportPEM,
+// so keys from files and generated keys can be used interchangeably.
+//
+// Example usage:
+//
+//	data, err := os.ReadFile("jwt.key")
+//	if err != nil {
+//	    return err
+//	}
+//	keys, err := gonuts.KeySetFromPEM(data)
+func KeySetFromPEM(privatePEM []byte) (KeySet, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return KeySet{}, fmt.Errorf("%w: no PEM block found", ErrInvalidKeySet)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return KeySet{}, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		kind := fmt.Sprintf("rsa%d", key.N.BitLen()) // KeyKindRSA2048 for 2048 bit keys
+		return KeySet{Kind: kind, PrivateKey: key, PublicKey: &key.PublicKey, Algorithm: "RS256"}, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return KeySet{}, fmt.Errorf("%w: curve %s", ErrUnsupportedKeyKind, key.Curve.Params().Name)
+		}
+		return KeySet{Kind: KeyKindEC256, PrivateKey: key, PublicKey: &key.PublicKey, Algorithm: "ES256"}, nil
+	case ed25519.PrivateKey:
+		return KeySet{Kind: KeyKindEd25519, PrivateKey: key, PublicKey: key.Public(), Algorithm: "EdDSA"}, nil
+	}
+	return KeySet{}, fmt.Errorf("%w: %T", ErrUnsupportedKeyKind, key)
+}
@@ -0,0 +1,136 @@
+package gonuts
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+// signEphemeralTest signs message the way the key set's JWS algorithm does
+func signEphemeralTest(t *testing.T, ks KeySet, message []byte) []byte {
+	t.Helper()
+	digest, opts := message, crypto.SignerOpts(crypto.Hash(0))
+	if ks.Algorithm != "EdDSA" {
+		sum := sha256.Sum256(message)
+		digest, opts = sum[:], crypto.SHA256
+	}
+	signature, err := ks.PrivateKey.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+// verifyEphemeralTest verifies a signature of signEphemeralTest with public
+func verifyEphemeralTest(public crypto.PublicKey, message, signature []byte) bool {
+	sum := sha256.Sum256(message)
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(public, crypto.SHA256, sum[:], signature) == nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(public, sum[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(public, message, signature)
+	}
+	return false
+}
+
+func TestGenerateEphemeralKeys(t *testing.T) {
+	tests := []struct {
+		kind, algorithm string
+	}{
+		{KeyKindRSA2048, "RS256"},
+		{KeyKindEC256, "ES256"},
+		{KeyKindEd25519, "EdDSA"},
+	}
+	message := []byte(`{"sub":"user-1","exp":1714557600}`)
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			keys, err := GenerateEphemeralKeys(tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys.Kind != tt.kind || keys.Algorithm != tt.algorithm {
+				t.Errorf("key set = %s/%s, want %s/%s", keys.Kind, keys.Algorithm, tt.kind, tt.algorithm)
+			}
+			signature := signEphemeralTest(t, keys, message)
+			if !verifyEphemeralTest(keys.PublicKey, message, signature) {
+				t.Error("the signature does not verify with the public key")
+			}
+
+			privatePEM, publicPEM, err := ExportPEM(keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block, _ := pem.Decode(publicPEM); block == nil || block.Type != "PUBLIC KEY" {
+				t.Fatalf("public PEM = %s", publicPEM)
+			} else if public, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil || !public.(interface{ Equal(crypto.PublicKey) bool }).Equal(keys.PublicKey) {
+				t.Errorf("the public PEM holds another key: %v", err)
+			}
+
+			// The loaded keys are interchangeable with the generated ones
+			loaded, err := KeySetFromPEM(privatePEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Kind != keys.Kind || loaded.Algorithm != keys.Algorithm {
+				t.Errorf("loaded key set = %s/%s", loaded.Kind, loaded.Algorithm)
+			}
+			if !verifyEphemeralTest(keys.PublicKey, message, signEphemeralTest(t, loaded, message)) {
+				t.Error("a signature of the loaded key does not verify with the generated public key")
+			}
+			if !verifyEphemeralTest(loaded.PublicKey, message, signature) {
+				t.Error("a signature of the generated key does not verify with the loaded public key")
+			}
+		})
+	}
+
+	other, _ := GenerateEphemeralKeys(KeyKindEd25519)
+	first, _ := GenerateEphemeralKeys(KeyKindEd25519)
+	if first.PublicKey.(ed25519.PublicKey).Equal(other.PublicKey) {
+		t.Error("two generated key sets are equal")
+	}
+}
+
+func TestEphemeralKeysErrors(t *testing.T) {
+	for _, kind := range []string{"", "rsa4096", "EC256", "ec384"} {
+		if _, err := GenerateEphemeralKeys(kind); !errors.Is(err, ErrUnsupportedKeyKind) {
+			t.Errorf("GenerateEphemeralKeys(%q) = %v, want ErrUnsupportedKeyKind", kind, err)
+		}
+	}
+
+	keys, _ := GenerateEphemeralKeys(KeyKindEC256)
+	for _, ks := range []KeySet{{}, {PrivateKey: keys.PrivateKey}, {PublicKey: keys.PublicKey}} {
+		if _, _, err := ExportPEM(ks); !errors.Is(err, ErrInvalidKeySet) {
+			t.Errorf("ExportPEM without a key = %v, want ErrInvalidKeySet", err)
+		}
+	}
+
+	_, publicPEM, _ := ExportPEM(keys)
+	for name, data := range map[string][]byte{
+		"empty":      nil,
+		"not PEM":    []byte("-----BEGIN nothing"),
+		"public key": publicPEM,
+	} {
+		if _, err := KeySetFromPEM(data); !errors.Is(err, ErrInvalidKeySet) {
+			t.Errorf("KeySetFromPEM(%s) = %v, want ErrInvalidKeySet", name, err)
+		}
+	}
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(p384)
+	if _, err := KeySetFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); !errors.Is(err, ErrUnsupportedKeyKind) {
+		t.Errorf("KeySetFromPEM(P-384) = %v, want ErrUnsupportedKeyKind", err)
+	}
+}
@@ -0,0 +1,105 @@
+package gonuts
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Error kinds passed to the error metrics hook, naming the constructor that created the error.
+const (
+	ErrorKindCustom       = "custom"       // NewErrorPlus
+	ErrorKindWrap         = "wrap"         // Wrap
+	ErrorKindNotFound     = "not_found"    // NewNotFoundError
+	ErrorKindInternal     = "internal"     // NewInternalError
+	ErrorKindUnauthorized = "unauthorized" // NewUnauthorizedError
+	ErrorKindBadRequest   = "bad_request"  // NewBadRequestError
+	ErrorKindValidation   = "validation"   // ValidationErrors.Err
+)
+
+var errorMetricsHook atomic.Pointer[func(code int, kind string)]
+
+// SetErrorMetricsHook sets a function that is called for every ErrorPlus created by NewErrorPlus,
+// Wrap, the helper constructors (NewNotFoundError, ...) and ValidationErrors.Err, with the code
+// and kind (ErrorKindCustom, ErrorKindNotFound, ...) of the error. Derived errors (WithMsg,
+// WithCode, ...) are not counted. A nil hook disables it; when unset, creating an error costs a
+// single atomic load.
+//
+// The hook is called synchronously on the goroutine creating the error, so it must not block;
+// panics of the hook are recovered and logged. ErrorCounter is a ready-made hook.
+//
+// Example usage:
+//
+//	counter := gonuts.NewErrorCounter()
+//	gonuts.SetErrorMetricsHook(counter.Record)
+//	...
+//	for key, count := range counter.Snapshot() {
+//		gonuts.L.Infof("errors %s: %d", key, count) // "errors 404:not_found: 12"
+//	}
+func SetErrorMetricsHook(hook func(code int, kind string)) {
+	if hook == nil {
+		errorMetricsHook.Store(nil)
+		return
+	}
+	errorMetricsHook.Store(&hook)
+}
+
+// callErrorMetricsHook calls the hook, recovering its panics so error creation never fails
+func callErrorMetricsHook(hook func(code int, kind string), code int, kind string) {
+	defer func() {
+		if r := recover(); r != nil {
+			L.Errorf("[errormetrics] hook panicked for %d/%s: %v", code, kind, r)
+		}
+	}()
+	hook(code, kind)
+}
+
+// ErrorCounter is an in-memory, thread-safe counter of created errors by code and kind, meant to
+// be installed with SetErrorMetricsHook.
+type ErrorCounter struct {
+	counts sync.Map // errorCounterKey -> *atomic.Int64
+}
+
+type errorCounterKey struct {
+	code int
+	kind string
+}
+
+// NewErrorCounter creates an empty ErrorCounter
+func NewErrorCounter() *ErrorCounter {
+	return &ErrorCounter{}
+}
+
+// Record counts an error; it has the signature of the hook of SetErrorMetricsHook.
+// Counting an already seen code and kind does not allocate or lock.
+func (c *ErrorCounter) Record(code int, kind string) {
+	key := errorCounterKey{code: code, kind: kind}
+	count, ok := c.counts.Load(key)
+	if !ok {
+		count, _ = c.counts.LoadOrStore(key, new(atomic.Int64))
+	}
+	count.(*atomic.Int64).Add(1)
+}
+
+// Snapshot returns the current counts keyed by "code:kind", e.g. "500:internal".
+// Codes and kinds that were reset to zero are left out.
+func (c *ErrorCounter) Snapshot() map[string]int64 {
+	snapshot := make(map[string]int64)
+	c.counts.Range(func(k, v any) bool {
+		if count := v.(*atomic.Int64).Load(); count > 0 {
+			key := k.(errorCounterKey)
+			snapshot[strconv.Itoa(key.code)+":"+key.kind] = count
+		}
+		return true
+	})
+	return snapshot
+}
+
+// Reset sets all counts to zero. The counts are reset one after another, so errors recorded
+// during Reset may survive it for some codes and not for others.
+func (c *ErrorCounter) Reset() {
+	c.counts.Range(func(_, v any) bool {
+		v.(*atomic.Int64).Store(0)
+		return true
+	})
+}
@@ -0,0 +1,109 @@
+package gonuts
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// installErrorCounter installs a fresh ErrorCounter as the metrics hook for the rest of the test
+func installErrorCounter(t testing.TB) *ErrorCounter {
+	counter := NewErrorCounter()
+	SetErrorMetricsHook(counter.Record)
+	t.Cleanup(func() { SetErrorMetricsHook(nil) })
+	return counter
+}
+
+func TestErrorMetricsHookCountsConstructors(t *testing.T) {
+	counter := installErrorCounter(t)
+	cause := errors.New("cause")
+
+	NewNotFoundError("missing", cause)
+	NewNotFoundError("missing", cause)
+	NewInternalError("broken", cause)
+	NewBadRequestError("invalid", cause)
+	NewErrorPlus(cause, "custom", 418)
+	NewInternalError("derived", cause).WithCode(503) // derived errors are not counted
+
+	want := map[string]int64{"404:not_found": 2, "500:internal": 2, "400:bad_request": 1, "418:custom": 1}
+	got := counter.Snapshot()
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	for key, count := range want {
+		if got[key] != count {
+			t.Errorf("Snapshot()[%q] = %d, want %d", key, got[key], count)
+		}
+	}
+
+	counter.Reset()
+	if got := counter.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot() after Reset = %v, want empty", got)
+	}
+}
+
+func TestErrorMetricsHookPanicIsRecovered(t *testing.T) {
+	SetErrorMetricsHook(func(int, string) { panic("broken hook") })
+	t.Cleanup(func() { SetErrorMetricsHook(nil) })
+
+	if err := NewInternalError("still created", nil); err == nil || err.Code() != 500 {
+		t.Errorf("NewInternalError = %v, want an internal error despite the panicking hook", err)
+	}
+}
+
+func TestErrorCounterConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 64, 500
+	counter := NewErrorCounter()
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			kind := []string{ErrorKindNotFound, ErrorKindInternal}[g%2]
+			for i := 0; i < perGoroutine; i++ {
+				counter.Record(400+g%2, kind)
+				if i%100 == 0 {
+					_ = counter.Snapshot()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	got := counter.Snapshot()
+	for _, key := range []string{"400:not_found", "401:internal"} {
+		if want := int64(goroutines / 2 * perGoroutine); got[key] != want {
+			t.Errorf("Snapshot()[%q] = %d, want %d", key, got[key], want)
+		}
+	}
+}
+
+// BenchmarkErrorMetricsHook measures the cost of the hook when creating errors: unset it is a
+// single atomic load, so "unset" should be indistinguishable from creating errors before the hook
+func BenchmarkErrorMetricsHook(b *testing.B) {
+	cause := errors.New("cause")
+
+	b.Run("unset", func(b *testing.B) {
+		SetErrorMetricsHook(nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = NewInternalError("broken", cause)
+		}
+	})
+	b.Run("counter", func(b *testing.B) {
+		installErrorCounter(b)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = NewInternalError("broken", cause)
+		}
+	})
+	b.Run("check only", func(b *testing.B) {
+		SetErrorMetricsHook(nil)
+		for i := 0; i < b.N; i++ {
+			if hook := errorMetricsHook.Load(); hook != nil {
+				callErrorMetricsHook(*hook, 500, ErrorKindInternal)
+			}
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Wrap creates a new ErrorPlus layer around err, capturing its own stack trace.
+// If err is (or wraps) an *ErrorPlus, the layers form a chain: Error() shows the full cause
+// chain, Chain returns every layer, AllContext merges their context and %+v prints each layer
+// with its own stack trace.
+//
+// Parameters:
+//   - err: the cause, typically an *ErrorPlus from a lower layer
+//   - msg: the message of the new layer
+//   - code: the code of the new layer
+//
+// Returns:
+//   - *ErrorPlus: the outer layer
+//
+// Example usage:
+//
+//	user, err := repo.Find(id) // returns NewNotFoundError("user not found", sql.ErrNoRows)
+//	if err != nil {
+//		return gonuts.Wrap(err, "loading profile failed", 404).WithContext("userID", id)
+//	}
+//	// Error(): "loading profile failed: user not found: sql: no rows in result set"
+func Wrap(err error, msg string, code int) *ErrorPlus {
+	return newErrorPlus(err, msg, code, ErrorKindWrap)
+}
+
+// Chain returns the ErrorPlus layers of the error, starting with e itself and followed by
+// every *ErrorPlus found by unwrapping the cause, outermost first.
+//
+// Example usage:
+//
+//	for _, layer := range err.Chain() {
+//		fmt.Println(layer.Code(), layer.Msg())
+//	}
+func (e *ErrorPlus) Chain() []*ErrorPlus {
+	chain := []*ErrorPlus{e}
+	for current := e; ; {
+		var next *ErrorPlus
+		if current.err == nil || !errors.As(current.err, &next) {
+			return chain
+		}
+		chain = append(chain, next)
+		current = next
+	}
+}
+
+// AllContext merges the context of all layers of the chain. Outer layers keep their keys; an
+// inner value whose key is already taken is kept under "cause<N>.<key>", where N is the index
+// of its layer in Chain. Redacted values stay redacted.
+//
+// Example usage:
+//
+//	inner := gonuts.NewNotFoundError("user not found", nil).WithContext("id", 7)
+//	outer := gonuts.Wrap(inner, "loading profile failed", 404).WithContext("id", "req-1")
+//	outer.AllContext() // map[id:req-1 cause1.id:7]
+func (e *ErrorPlus) AllContext() map[string]interface{} {
+	_, merged := e.allContext()
+	return merged
+}
+
+// allContext merges the context of all layers like AllContext and also returns the merged
+// keys in order: layer by layer, outermost first, each in insertion order.
+func (e *ErrorPlus) allContext() ([]string, map[string]interface{}) {
+	var keys []string
+	merged := make(map[string]interface{})
+	for i, layer := range e.Chain() {
+		ordered := layer.orderedContext()
+		for _, key := range ordered.keys {
+			value, ok := ordered.values[key]
+			if !ok {
+				continue
+			}
+			if _, taken := merged[key]; taken {
+				key = fmt.Sprintf("cause%d.%s", i, key)
+				if _, taken := merged[key]; taken {
+					continue
+				}
+			}
+			keys = append(keys, key)
+			merged[key] = value
+		}
+	}
+	return keys, merged
+}
+
+// formatChain writes the %+v representation of a chain with more than one layer. Each layer
+// shows its own stack trace, without the frames it shares with the layer above.
+func formatChain(w io.Writer, chain []*ErrorPlus) {
+	fmt.Fprintf(w, "ErrorPlus chain (%d layers):\n", len(chain))
+	for i, layer := range chain {
+		function, file, line := layer.Caller()
+		fmt.Fprintf(w, "  [%d] Msg: %s\n      Code: %d\n      Context: %v\n      Caller: %s (%s:%d)\n      StackTrace:\n",
+			i, layer.msg, layer.code, layer.orderedContext(), function, file, line)
+		pcs := layer.stackTrace
+		if i > 0 {
+			pcs = trimSharedFrames(pcs, chain[i-1].stackTrace)
+		}
+		if frames := renderStackTrace(pcs); len(frames) > 0 {
+			fmt.Fprintln(w, strings.Join(frames, "\n"))
+		}
+	}
+	fmt.Fprintf(w, "  Cause: %+v", chain[len(chain)-1].err)
+}
+
+// trimSharedFrames removes the outermost frames of pcs that are also the outermost frames of
+// outer, i.e. the part of the call stack both layers were created in.
+func trimSharedFrames(pcs, outer []uintptr) []uintptr {
+	i, j := len(pcs)-1, len(outer)-1
+	for i >= 0 && j >= 0 && pcs[i] == outer[j] {
+		i--
+		j--
+	}
+	return pcs[:i+1]
+}
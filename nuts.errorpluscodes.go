@@ -0,0 +1,148 @@
+package gonuts
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// Error categories used by RegisterErrorCode and ErrorPlus.Category
+const (
+	ErrorCategoryBadRequest   = "bad_request"
+	ErrorCategoryUnauthorized = "unauthorized"
+	ErrorCategoryNotFound     = "not_found"
+	ErrorCategoryClient       = "client" // other client errors
+	ErrorCategoryServer       = "server"
+)
+
+// clientErrorCategories are the categories reported as client errors by IsClientError
+var clientErrorCategories = map[string]bool{
+	ErrorCategoryBadRequest:   true,
+	ErrorCategoryUnauthorized: true,
+	ErrorCategoryNotFound:     true,
+	ErrorCategoryClient:       true,
+}
+
+// ErrorCodeInfo describes a code registered with RegisterErrorCode
+type ErrorCodeInfo struct {
+	Code     int
+	Name     string
+	Category string
+}
+
+var errorCodes = struct {
+	mu    sync.RWMutex
+	codes map[int]ErrorCodeInfo
+}{codes: make(map[int]ErrorCodeInfo)}
+
+// RegisterErrorCode registers a symbolic name and a category for an error code. CodeStr returns
+// the name of registered codes, and the predicates like IsNotFound and IsClientError use the
+// category, so custom codes are classified like their HTTP counterparts. Registering a code
+// again replaces its name and category.
+//
+// Parameters:
+//   - code: the error code
+//   - name: the symbolic name, e.g. "ORDER_NOT_FOUND"
+//   - category: one of the ErrorCategory constants, or a custom category
+//
+// Example usage:
+//
+//	gonuts.RegisterErrorCode(404, "NOT_FOUND", gonuts.ErrorCategoryNotFound)
+//	gonuts.RegisterErrorCode(4041, "ORDER_NOT_FOUND", gonuts.ErrorCategoryNotFound)
+//	err := gonuts.NewErrorPlus(sql.ErrNoRows, "order not found", 4041)
+//	err.IsNotFound() // true
+//	err.CodeStr()    // "ORDER_NOT_FOUND"
+func RegisterErrorCode(code int, name string, category string) {
+	errorCodes.mu.Lock()
+	defer errorCodes.mu.Unlock()
+	errorCodes.codes[code] = ErrorCodeInfo{Code: code, Name: name, Category: category}
+}
+
+// LookupErrorCode returns the registration of an error code
+func LookupErrorCode(code int) (ErrorCodeInfo, bool) {
+	errorCodes.mu.RLock()
+	defer errorCodes.mu.RUnlock()
+	info, ok := errorCodes.codes[code]
+	return info, ok
+}
+
+// FromError returns the first *ErrorPlus in the Unwrap chain of err, including err itself.
+//
+// Example usage:
+//
+//	if errPlus, ok := gonuts.FromError(err); ok && errPlus.IsNotFound() {
+//		http.Error(w, errPlus.Msg(), http.StatusNotFound)
+//	}
+func FromError(err error) (*ErrorPlus, bool) {
+	var errPlus *ErrorPlus
+	if err == nil || !errors.As(err, &errPlus) {
+		return nil, false
+	}
+	return errPlus, true
+}
+
+// Category returns the category of the error code: the registered category (see
+// RegisterErrorCode), otherwise "bad_request", "unauthorized" and "not_found" for 400, 401 and
+// 404, "client" for other 4xx codes, "server" for 5xx codes and an empty string for other codes.
+func (e *ErrorPlus) Category() string {
+	if e == nil {
+		return ""
+	}
+	if info, ok := LookupErrorCode(e.code); ok {
+		return info.Category
+	}
+	switch {
+	case e.code == 400:
+		return ErrorCategoryBadRequest
+	case e.code == 401:
+		return ErrorCategoryUnauthorized
+	case e.code == 404:
+		return ErrorCategoryNotFound
+	case e.code >= 400 && e.code <= 499:
+		return ErrorCategoryClient
+	case e.code >= 500 && e.code <= 599:
+		return ErrorCategoryServer
+	}
+	return ""
+}
+
+// IsBadRequest reports whether the error is in the "bad_request" category (code 400 by default)
+func (e *ErrorPlus) IsBadRequest() bool {
+	return e.Category() == ErrorCategoryBadRequest
+}
+
+// IsUnauthorized reports whether the error is in the "unauthorized" category (code 401 by default)
+func (e *ErrorPlus) IsUnauthorized() bool {
+	return e.Category() == ErrorCategoryUnauthorized
+}
+
+// IsNotFound reports whether the error is in the "not_found" category (code 404 by default)
+func (e *ErrorPlus) IsNotFound() bool {
+	return e.Category() == ErrorCategoryNotFound
+}
+
+// IsClientError reports whether the error is a client error: a 4xx code, or a code registered
+// with a client category (bad_request, unauthorized, not_found or client)
+func (e *ErrorPlus) IsClientError() bool {
+	if e == nil {
+		return false
+	}
+	return (e.code >= 400 && e.code <= 499) || clientErrorCategories[e.Category()]
+}
+
+// IsServerError reports whether the error is a server error: a 5xx code, or a code registered
+// with the server category
+func (e *ErrorPlus) IsServerError() bool {
+	if e == nil {
+		return false
+	}
+	return (e.code >= 500 && e.code <= 599) || e.Category() == ErrorCategoryServer
+}
+
+// codeString returns the registered name of a code, or the code as a decimal string
+func codeString(code int) string {
+	if info, ok := LookupErrorCode(code); ok && info.Name != "" {
+		return info.Name
+	}
+	return strconv.Itoa(code)
+}
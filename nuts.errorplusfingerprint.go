@@ -0,0 +1,157 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// errorFingerprintVersion is part of every fingerprint, so a change of the rules below changes
+// all fingerprints at once instead of silently regrouping some of them
+const errorFingerprintVersion = "v1"
+
+// errorNormalizationRules are applied in order by NormalizeErrorMessage
+var errorNormalizationRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?`), "<time>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+\b`), "<duration>"},
+	{regexp.MustCompile(`\b([A-Za-z]+)_[0-9A-Za-z]{8,}\b`), "${1}_<id>"},
+	{regexp.MustCompile(`\b[0-9A-Za-z]*([0-9][A-Za-z]|[A-Za-z][0-9])[0-9A-Za-z]*\b`), "<id>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// NormalizeErrorMessage removes the variable parts of an error message, so messages of the
+// same failure compare equal. The rules are applied in this order:
+//
+//  1. UUIDs become "<uuid>"
+//  2. dates and times ("2024-05-01", "2024-05-01T10:00:00.123Z", "10:00:00") become "<time>"
+//  3. hexadecimal numbers with a 0x prefix become "<hex>"
+//  4. durations ("250ms", "1.5s", "1h30m") become "<duration>"
+//  5. prefixed IDs like "user_V1StGXR8Z5jd" (see NanoID) become "user_<id>"
+//  6. words mixing letters and digits, like "a1b2c3" or "v2", become "<id>"
+//  7. remaining numbers ("42", "3.5") become "<n>"
+//  8. runs of whitespace become a single space, and the result is trimmed
+//
+// The rules are part of the fingerprint format: changing them changes the version of
+// Fingerprint, so fingerprints of different versions never collide.
+//
+// Example usage:
+//
+//	gonuts.NormalizeErrorMessage(`order 12345 of user_V1StGXR8Z5jd failed at 2024-05-01T10:00:00Z`)
+//	// "order <n> of user_<id> failed at <time>"
+func NormalizeErrorMessage(msg string) string {
+	for _, rule := range errorNormalizationRules {
+		msg = rule.pattern.ReplaceAllString(msg, rule.replacement)
+	}
+	return strings.TrimSpace(msg)
+}
+
+// Fingerprint returns a stable key for grouping errors of the same failure, e.g. in alerting.
+// It is a hash over the code, the kind of the constructor (NewNotFoundError, Wrap, ...), the
+// normalized message including the wrapped errors (see NormalizeErrorMessage) and the function
+// of the top application stack frame: the first frame outside the runtime, testing and gonuts
+// packages. Line numbers are not included, so fingerprints survive unrelated code changes.
+//
+// Two errors that only differ in embedded IDs, numbers, durations or timestamps have the same
+// fingerprint.
+//
+// Returns:
+//   - string: 16 hex characters, prefixed with the version of the fingerprint rules ("v1:...")
+//
+// Example usage:
+//
+//	err := gonuts.NewNotFoundError(fmt.Sprintf("order %d not found", id), sql.ErrNoRows)
+//	alerts.Group(err.Fingerprint(), err)
+func (e *ErrorPlus) Fingerprint() string {
+	if e == nil {
+		return ""
+	}
+	message := e.msg
+	if e.err != nil {
+		message += ": " + errorChainMessage(e.err)
+	}
+	return errorFingerprint(e.code, e.kind, message, e.topApplicationFrame())
+}
+
+// ErrorFingerprint returns the fingerprint of any error. For an *ErrorPlus it is its
+// Fingerprint; for other errors the code, kind and stack frame are taken from the first
+// *ErrorPlus they wrap, if any, together with their normalized full message. Errors that wrap
+// no *ErrorPlus are fingerprinted by their type and normalized message.
+//
+// Returns:
+//   - string: the fingerprint, empty for a nil error
+//
+// Example usage:
+//
+//	if err := job.Run(); err != nil {
+//	    L.Errorw("job failed", "fingerprint", gonuts.ErrorFingerprint(err), "error", err)
+//	}
+func ErrorFingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	if ep, ok := err.(*ErrorPlus); ok {
+		return ep.Fingerprint()
+	}
+	var ep *ErrorPlus
+	if errors.As(err, &ep) && ep != nil {
+		return errorFingerprint(ep.code, ep.kind, errorChainMessage(err), ep.topApplicationFrame())
+	}
+	return errorFingerprint(0, fmt.Sprintf("%T", err), err.Error(), "")
+}
+
+// errorChainMessage returns the message of an error without the caller that ErrorPlus may add
+// to it (see SetErrorCallerInMessage), as the caller contains file names and line numbers
+func errorChainMessage(err error) string {
+	var parts []string
+	for err != nil {
+		ep, ok := err.(*ErrorPlus)
+		if !ok {
+			parts = append(parts, err.Error())
+			break
+		}
+		parts = append(parts, ep.msg)
+		err = ep.err
+	}
+	return strings.Join(parts, ": ")
+}
+
+// topApplicationFrame returns the function of the first stack frame outside the runtime,
+// testing and gonuts packages, or of the first frame if there is none
+func (e *ErrorPlus) topApplicationFrame() string {
+	if len(e.stackTrace) == 0 {
+		if e.remoteCaller != nil {
+			return e.remoteCaller.Function
+		}
+		return ""
+	}
+	frames := runtime.CallersFrames(e.stackTrace)
+	first := ""
+	for {
+		frame, more := frames.Next()
+		if first == "" {
+			first = frame.Function
+		}
+		if !skipStackFrame(frame.Function, []string{"runtime.", "testing.", StackFilterGonutsPackage}) {
+			return frame.Function
+		}
+		if !more {
+			return first
+		}
+	}
+}
+
+// errorFingerprint hashes the components of a fingerprint
+func errorFingerprint(code int, kind, message, frame string) string {
+	data := strings.Join([]string{strconv.Itoa(code), kind, NormalizeErrorMessage(message), frame}, "\n")
+	return errorFingerprintVersion + ":" + sha256Hex([]byte(data))[:16]
+}
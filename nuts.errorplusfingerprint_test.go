@@ -0,0 +1,95 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"order 12345 of user_V1StGXR8Z5jd failed at 2024-05-01T10:00:00Z", "order <n> of user_<id> failed at <time>"},
+		{"request 3f2b8c1e-9d4a-4f6b-8e2a-1c5d7e9f0a3b timed out after 1.5s", "request <uuid> timed out after <duration>"},
+		{"retry 3 in 1h30m at 10:00:00.123", "retry <n> in <duration> at <time>"},
+		{"bad pointer 0xC000123ABC on 2024-05-01", "bad pointer <hex> on <time>"},
+		{"api v2 rejected token a1b2c3", "api <id> rejected token <id>"},
+		{"  price   3.50 \n exceeds\t100 ", "price <n> exceeds <n>"},
+		{"connection refused", "connection refused"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeErrorMessage(tt.msg); got != tt.want {
+			t.Errorf("NormalizeErrorMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+// TestFingerprintGolden pins the fingerprints, so a change of the rules or the hashed
+// components shows up as a changed version instead of silently regrouping errors
+func TestFingerprintGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"plain error", errors.New("dial tcp 10.0.0.1:5432: connection refused"), "v1:b51dfd5aea8aebae"},
+		{"ErrorPlus", NewNotFoundError("order 12345 not found", errors.New("no rows")), "v1:e6fc65c38549dcea"},
+		{"chain", Wrap(NewNotFoundError("order 12345 not found", errors.New("no rows")), "loading order failed", 404), "v1:d9f0a03a7504d945"},
+	}
+	for _, tt := range tests {
+		if got := ErrorFingerprint(tt.err); got != tt.want {
+			t.Errorf("%s: ErrorFingerprint = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// orderNotFound creates the same failure for different orders, like repeated requests would
+func orderNotFound(id int) *ErrorPlus {
+	return NewNotFoundError(fmt.Sprintf("order %d not found at 2024-05-0%dT10:00:00Z", id, id%9+1), errors.New("no rows"))
+}
+
+func TestFingerprintStableAcrossChains(t *testing.T) {
+	first, second := orderNotFound(12345), orderNotFound(67890)
+	if first.Fingerprint() != second.Fingerprint() {
+		t.Errorf("errors differing in IDs have fingerprints %s and %s", first.Fingerprint(), second.Fingerprint())
+	}
+
+	// Derived errors keep the fingerprint unless the hashed components change
+	if got := first.WithContext("orderID", 12345).Fingerprint(); got != first.Fingerprint() {
+		t.Errorf("fingerprint changed by WithContext: %s, want %s", got, first.Fingerprint())
+	}
+	if got := first.WithCode(410).Fingerprint(); got == first.Fingerprint() {
+		t.Error("fingerprint did not change with the code")
+	}
+
+	// Wrapping layers are fingerprinted the same for every order, whichever way they are built
+	wrapped := []error{
+		Wrap(first, "loading order 12345 failed", 404),
+		Wrap(second, "loading order 67890 failed", 404),
+	}
+	if a, b := ErrorFingerprint(wrapped[0]), ErrorFingerprint(wrapped[1]); a != b {
+		t.Errorf("wrapped errors have fingerprints %s and %s", a, b)
+	}
+	stdWrapped := []error{
+		fmt.Errorf("handler for order %d: %w", 12345, first),
+		fmt.Errorf("handler for order %d: %w", 67890, second),
+	}
+	if a, b := ErrorFingerprint(stdWrapped[0]), ErrorFingerprint(stdWrapped[1]); a != b {
+		t.Errorf("errors wrapped with fmt.Errorf have fingerprints %s and %s", a, b)
+	}
+	if ErrorFingerprint(stdWrapped[0]) == first.Fingerprint() || ErrorFingerprint(wrapped[0]) == first.Fingerprint() {
+		t.Error("wrapping layers did not change the fingerprint of the inner error")
+	}
+
+	// The caller in the message contains the line number, which is not part of the fingerprint
+	SetErrorCallerInMessage(true)
+	t.Cleanup(func() { SetErrorCallerInMessage(false) })
+	a := fmt.Errorf("handler: %w", NewNotFoundError("order 12345 not found", nil))
+	b := fmt.Errorf("handler: %w", NewNotFoundError("order 67890 not found", nil))
+	if a.Error() == b.Error() || ErrorFingerprint(a) != ErrorFingerprint(b) {
+		t.Errorf("errors created on different lines: %q and %q have fingerprints %s and %s, want them equal",
+			a, b, ErrorFingerprint(a), ErrorFingerprint(b))
+	}
+}
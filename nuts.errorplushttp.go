@@ -0,0 +1,116 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// errorResponse is the JSON body written by ToHTTPResponse
+type errorResponse struct {
+	Error   string        `json:"error"`
+	Code    int           `json:"code"`
+	Details *errorContext `json:"details,omitempty"`
+	Fields  []FieldError  `json:"fields,omitempty"`
+}
+
+// WithPublicContext returns a new ErrorPlus with an additional context value that is also
+// included in HTTP responses (see ToHTTPResponse), preserving immutability. Values added with
+// WithContext stay internal and are only available for logging.
+//
+// Example usage:
+//
+//	err := NewNotFoundError("order not found", sql.ErrNoRows).
+//		WithPublicContext("orderID", orderID).
+//		WithContext("query", query) // never sent to the client
+func (e *ErrorPlus) WithPublicContext(key string, value interface{}) *ErrorPlus {
+	c := e.clone()
+	c.setContext(key, value)
+	if c.publicKeys == nil {
+		c.publicKeys = make(map[string]bool)
+	}
+	c.publicKeys[key] = true
+	return c
+}
+
+// HTTPStatus returns the HTTP status of the error: its code if it is a valid HTTP status,
+// 500 otherwise (including a code of 0 and a nil receiver).
+func (e *ErrorPlus) HTTPStatus() int {
+	if e == nil || e.code < 100 || e.code > 599 {
+		return http.StatusInternalServerError
+	}
+	return e.code
+}
+
+// ToHTTPResponse converts the error into an HTTP status and a JSON body that is safe to send
+// to clients: {"error": msg, "code": code, "details": {...}}. The body contains the message
+// but neither the wrapped error, the stack trace nor internal context; "details" only holds
+// serializable values added with WithPublicContext and is omitted if there are none. Errors
+// built by ValidationErrors.Err additionally get their "fields" array of {field, rule, message}
+// objects.
+//
+// A nil receiver or a code of 0 results in a 500 response.
+//
+// Returns:
+//   - status: the HTTP status (see HTTPStatus)
+//   - body: the JSON body
+//
+// Example usage:
+//
+//	status, body := err.ToHTTPResponse()
+//	// 404 {"error":"order not found","code":404,"details":{"orderID":"o-1"}}
+func (e *ErrorPlus) ToHTTPResponse() (status int, body []byte) {
+	status = e.HTTPStatus()
+	response := errorResponse{Error: http.StatusText(status), Code: status}
+	if e != nil {
+		if e.msg != "" {
+			response.Error = e.msg
+		}
+		if e.code != 0 {
+			response.Code = e.code
+		}
+		response.Details = e.publicContext()
+		response.Fields, _ = e.context["fields"].([]FieldError)
+	}
+
+	body, _ = json.Marshal(response)
+	return status, body
+}
+
+// WriteHTTP writes the response of ToHTTPResponse with a JSON content type
+//
+// Example usage:
+//
+//	func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+//		order, err := h.orders.Find(r.Context(), r.PathValue("id"))
+//		if err != nil {
+//			err.LogWithContext(r.Context())
+//			err.WriteHTTP(w)
+//			return
+//		}
+//		...
+//	}
+func (e *ErrorPlus) WriteHTTP(w http.ResponseWriter) {
+	status, body := e.ToHTTPResponse()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// publicContext returns the context values added with WithPublicContext, nil if there are none.
+// Values that cannot be serialized are skipped, so they cannot break the response.
+func (e *ErrorPlus) publicContext() *errorContext {
+	var keys []string
+	for _, key := range e.contextKeys {
+		if !e.publicKeys[key] {
+			continue
+		}
+		if _, err := json.Marshal(e.context[key]); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return &errorContext{keys: keys, values: e.context}
+}
@@ -1,31 +1,191 @@
 package gonuts
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
 )
 
+var (
+	// ErrEmitterClosed is returned when subscribing to or emitting on a closed EventEmitter
+	ErrEmitterClosed = errors.New("event emitter is closed")
+	// ErrEventSignature is returned when a handler or emitted arguments do not match the event definition
+	ErrEventSignature = errors.New("event signature mismatch")
+	// ErrUndefinedEvent is returned in strict mode when subscribing to or emitting an event that was not defined
+	ErrUndefinedEvent = errors.New("event is not defined")
+	// ErrListenerPanic is wrapped by the ListenerError of a listener that panicked
+	ErrListenerPanic = errors.New("event listener panicked")
+)
+
+// ListenerError is the error of a single listener call: a panic, an error returned by the
+// listener, or arguments that do not match the listener. Emit joins the errors of all listeners.
+//
+// Example usage:
+//
+//	var lerr *gonuts.ListenerError
+//	if errors.As(err, &lerr) && errors.Is(lerr, gonuts.ErrListenerPanic) {
+//	    L.Errorf("listener %s of %s panicked: %v", lerr.Listener, lerr.Event, lerr.Err)
+//	}
+type ListenerError struct {
+	Event    string
+	Listener string
+	Err      error
+}
+
+func (e *ListenerError) Error() string {
+	return fmt.Sprintf("listener %q of event %q: %v", e.Listener, e.Event, e.Err)
+}
+
+func (e *ListenerError) Unwrap() error {
+	return e.Err
+}
+
+// PanicHandler is called with the recovered value and stack trace when a listener panics
+type PanicHandler func(event, listener string, recovered interface{}, stack []byte)
+
 // EventEmitter is a flexible publish-subscribe event system with named listeners
 type EventEmitter struct {
-	listeners map[string]map[string]reflect.Value
-	mu        sync.RWMutex
+	listeners    map[string]map[string]*listener
+	pausedGroups map[string]bool
+	mu           sync.RWMutex
+
+	closed   bool
+	inflight sync.WaitGroup // running Emit and EmitConcurrent deliveries
+
+	definitions map[string][]reflect.Type // expected argument types per event (see DefineEvent)
+	strict      bool                      // reject events without definition
+
+	serialQueues map[string]chan serialDelivery // per-event delivery queues, fixed after construction
+	queuesDone   chan struct{}                  // closed when Close has drained all deliveries
+
+	panicHandler PanicHandler // called for recovered listener panics, fixed after construction
+
+	bridge *emitterBridge // attached transport (see AttachTransport), nil if none
+
+	scheduled    map[uint64]*time.Timer // pending emissions of EmitAfter and EmitAt
+	scheduledSeq uint64
+}
+
+// EmitterOption configures an EventEmitter created with NewEventEmitter
+type EmitterOption func(*EventEmitter)
+
+// PerEventSerialQueue delivers the event through a dedicated queue: Emit and EmitConcurrent
+// enqueue the delivery and return, and a single consumer goroutine calls the listeners
+// sequentially in emit order. At most one delivery of the event is in flight at any time,
+// while other events are unaffected. Emits block while the queue holds bufferSize deliveries.
+//
+// Argument errors are still returned by Emit, but listener errors of queued deliveries
+// are dropped; panics are recovered and reported to the WithPanicHandler hook. Use Flush to wait for queued deliveries and Close to stop the consumer goroutines.
+//
+// Example usage:
+//
+//	emitter := gonuts.NewEventEmitter(gonuts.PerEventSerialQueue("ledger.entry", 1024))
+//	defer emitter.Close(context.Background())
+func PerEventSerialQueue(event string, bufferSize int) EmitterOption {
+	return func(ee *EventEmitter) {
+		if bufferSize < 0 {
+			bufferSize = 0
+		}
+		ee.serialQueues[event] = make(chan serialDelivery, bufferSize)
+	}
+}
+
+// WithPanicHandler sets a hook that is called when a listener panics, e.g. for telemetry.
+// Panics are recovered either way and returned as a ListenerError wrapping ErrListenerPanic.
+// The handler may be called concurrently.
+//
+// Example usage:
+//
+//	emitter := gonuts.NewEventEmitter(gonuts.WithPanicHandler(func(event, listener string, recovered interface{}, stack []byte) {
+//	    L.Errorw("event listener panicked", "event", event, "listener", listener, "panic", recovered, "stack", string(stack))
+//	}))
+func WithPanicHandler(handler PanicHandler) EmitterOption {
+	return func(ee *EventEmitter) {
+		ee.panicHandler = handler
+	}
+}
+
+// serialDelivery is a queued delivery of a serial event, or a Flush marker if flushed is set
+type serialDelivery struct {
+	listeners []*listener
+	args      []interface{}
+	flushed   chan struct{}
+}
+
+// listener is a registered event handler with its options
+type listener struct {
+	name        string
+	fn          reflect.Value
+	group       string
+	pauseBuffer int // max deliveries buffered while the group is paused (0 drops them)
+
+	mu        sync.Mutex
+	pending   [][]interface{} // buffered deliveries while paused or replaying
+	replaying bool            // ResumeGroup is delivering pending, new deliveries queue behind it
+}
+
+// ListenerOption configures a listener registered via OnWithOptions
+type ListenerOption func(*listener)
+
+// WithGroup assigns the listener to a named group that can be paused and resumed together
+func WithGroup(name string) ListenerOption {
+	return func(l *listener) {
+		l.group = name
+	}
+}
+
+// WithPauseBuffer buffers up to n deliveries while the listener's group is paused and
+// replays them in emit order on resume. Without it, deliveries to a paused listener are dropped.
+func WithPauseBuffer(n int) ListenerOption {
+	return func(l *listener) {
+		l.pauseBuffer = n
+	}
+}
+
+// ListenerState describes a registered listener, see ListenerNames
+type ListenerState struct {
+	Name     string
+	Group    string
+	Paused   bool
+	Buffered int
 }
 
 // NewEventEmitter creates a new EventEmitter
 //
+// Parameters:
+//   - opts: emitter options like PerEventSerialQueue
+//
 // Returns:
 //   - *EventEmitter: a new instance of EventEmitter
 //
 // Example usage:
 //
 //	emitter := gonuts.NewEventEmitter()
-func NewEventEmitter() *EventEmitter {
-	return &EventEmitter{
-		listeners: make(map[string]map[string]reflect.Value),
+func NewEventEmitter(opts ...EmitterOption) *EventEmitter {
+	ee := &EventEmitter{
+		listeners:    make(map[string]map[string]*listener),
+		pausedGroups: make(map[string]bool),
+		definitions:  make(map[string][]reflect.Type),
+		serialQueues: make(map[string]chan serialDelivery),
+		queuesDone:   make(chan struct{}),
+		scheduled:    make(map[uint64]*time.Timer),
+	}
+	ee.definitions[ErrorEvent] = []reflect.Type{errorPlusType}
+	for _, opt := range opts {
+		opt(ee)
 	}
+	for event, queue := range ee.serialQueues {
+		go ee.runSerialQueue(event, queue)
+	}
+	return ee
 }
 
 // On subscribes a named function to an event
@@ -48,6 +208,27 @@ func NewEventEmitter() *EventEmitter {
 //	    log.Printf("Error subscribing to event: %v", err)
 //	}
 func (ee *EventEmitter) On(event, name string, fn interface{}) (string, error) {
+	return ee.OnWithOptions(event, name, fn)
+}
+
+// OnWithOptions subscribes a named function to an event with additional listener options
+//
+// Parameters:
+//   - event: the name of the event to subscribe to
+//   - name: a unique name for this listener (if empty, a unique ID will be generated)
+//   - fn: the function to be called when the event is emitted
+//   - opts: listener options like WithGroup and WithPauseBuffer
+//
+// Returns:
+//   - string: the name or generated ID of the listener
+//   - error: any error that occurred during subscription
+//
+// Example usage:
+//
+//	id, err := emitter.OnWithOptions("order.created", "notifyWebhook", func(orderID string) {
+//	    sendWebhook(orderID)
+//	}, gonuts.WithGroup("webhooks"), gonuts.WithPauseBuffer(100))
+func (ee *EventEmitter) OnWithOptions(event, name string, fn interface{}, opts ...ListenerOption) (string, error) {
 	if reflect.TypeOf(fn).Kind() != reflect.Func {
 		return "", fmt.Errorf("third argument to On must be a function")
 	}
@@ -55,8 +236,14 @@ func (ee *EventEmitter) On(event, name string, fn interface{}) (string, error) {
 	ee.mu.Lock()
 	defer ee.mu.Unlock()
 
+	if ee.closed {
+		return "", ErrEmitterClosed
+	}
+	if err := ee.checkHandler(event, reflect.TypeOf(fn)); err != nil {
+		return "", err
+	}
 	if ee.listeners[event] == nil {
-		ee.listeners[event] = make(map[string]reflect.Value)
+		ee.listeners[event] = make(map[string]*listener)
 	}
 
 	if name == "" {
@@ -67,7 +254,11 @@ func (ee *EventEmitter) On(event, name string, fn interface{}) (string, error) {
 		}
 	}
 
-	ee.listeners[event][name] = reflect.ValueOf(fn)
+	l := &listener{name: name, fn: reflect.ValueOf(fn)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	ee.listeners[event][name] = l
 	return name, nil
 }
 
@@ -92,26 +283,157 @@ func (ee *EventEmitter) Off(event, name string) error {
 	return fmt.Errorf("listener not found for event: %s, name: %s", event, name)
 }
 
+// OffAll unsubscribes all listeners from an event. Their buffered deliveries are dropped.
+//
+// Parameters:
+//   - event: the name of the event
+func (ee *EventEmitter) OffAll(event string) {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	delete(ee.listeners, event)
+}
+
+// RemoveAllListeners unsubscribes all listeners from all events. Their buffered deliveries
+// are dropped; event definitions, paused groups and serial queues are kept.
+func (ee *EventEmitter) RemoveAllListeners() {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	ee.listeners = make(map[string]map[string]*listener)
+}
+
+// OffByPrefix unsubscribes all listeners of an event whose name starts with namePrefix
+//
+// Parameters:
+//   - event: the name of the event
+//   - namePrefix: the listener name prefix, e.g. a module prefix like "billing:"
+//
+// Returns:
+//   - int: the number of removed listeners
+//
+// Example usage:
+//
+//	emitter.On("order.created", "billing:invoice", createInvoice)
+//	emitter.On("order.created", "billing:ledger", bookOrder)
+//	removed := emitter.OffByPrefix("order.created", "billing:") // 2
+func (ee *EventEmitter) OffByPrefix(event, namePrefix string) int {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+
+	removed := 0
+	for name := range ee.listeners[event] {
+		if strings.HasPrefix(name, namePrefix) {
+			delete(ee.listeners[event], name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// ListenerSignature describes the function of a registered listener
+type ListenerSignature struct {
+	Event     string
+	Name      string
+	Group     string
+	Signature string         // the function type, e.g. "func(string, int) error"
+	Arity     int            // number of parameters, the variadic one counting as one
+	Params    []reflect.Type // parameter types; the last one is a slice type if Variadic
+	Variadic  bool
+	Results   []reflect.Type
+}
+
+// ListenerInfo returns the signature of a registered listener, to debug argument mismatches
+//
+// Parameters:
+//   - event: the name of the event
+//   - name: the name or ID of the listener
+//
+// Returns:
+//   - ListenerSignature: the listener's function signature
+//   - bool: false if the listener is not registered
+//
+// Example usage:
+//
+//	if info, ok := emitter.ListenerInfo("userLoggedIn", "logLoginTime"); ok {
+//	    fmt.Println(info.Signature) // func(string, time.Time)
+//	}
+func (ee *EventEmitter) ListenerInfo(event, name string) (ListenerSignature, bool) {
+	ee.mu.RLock()
+	defer ee.mu.RUnlock()
+
+	l, ok := ee.listeners[event][name]
+	if !ok {
+		return ListenerSignature{}, false
+	}
+	fnType := l.fn.Type()
+	info := ListenerSignature{
+		Event:     event,
+		Name:      name,
+		Group:     l.group,
+		Signature: fnType.String(),
+		Arity:     fnType.NumIn(),
+		Params:    make([]reflect.Type, fnType.NumIn()),
+		Variadic:  fnType.IsVariadic(),
+		Results:   make([]reflect.Type, fnType.NumOut()),
+	}
+	for i := range info.Params {
+		info.Params[i] = fnType.In(i)
+	}
+	for i := range info.Results {
+		info.Results[i] = fnType.Out(i)
+	}
+	return info, true
+}
+
 // Emit triggers an event with the given arguments
 //
+// All listeners are called, even if some of them fail. Panics are recovered, and listeners
+// whose last return value is an error can report failures.
+//
 // Parameters:
 //   - event: the name of the event to emit
 //   - args: the arguments to pass to the event listeners
 //
 // Returns:
-//   - error: any error that occurred during emission
+//   - error: ErrEmitterClosed after Close, an argument error, or the ListenerErrors of all failed listeners
+//     and the ErrTransportPublish error of a bridged event (see AttachTransport) joined with errors.Join
+//
+// Example usage:
+//
+//	emitter.On("order.created", "reserveStock", func(orderID string) error {
+//	    return stock.Reserve(orderID)
+//	})
+//	if err := emitter.Emit("order.created", orderID); err != nil {
+//	    L.Errorf("order.created: %v", err)
+//	}
 func (ee *EventEmitter) Emit(event string, args ...interface{}) error {
-	ee.mu.RLock()
-	defer ee.mu.RUnlock()
+	return ee.emit(event, args, true)
+}
 
-	if listeners, ok := ee.listeners[event]; ok {
-		for _, listener := range listeners {
-			if err := ee.callListener(listener, args); err != nil {
-				return err
-			}
+// emit calls the listeners sequentially and, if publish is set, publishes bridged events
+// afterwards (see AttachTransport)
+func (ee *EventEmitter) emit(event string, args []interface{}, publish bool) error {
+	if queue, ok := ee.serialQueues[event]; ok {
+		if err := ee.enqueue(queue, event, args); err != nil || !publish {
+			return err
 		}
+		return ee.publish(event, args)
 	}
-	return nil
+	listeners, err := ee.beginEmit(event, args)
+	if err != nil {
+		return err
+	}
+	defer ee.inflight.Done()
+
+	var errs []error
+	for _, l := range listeners {
+		if err := ee.callListener(event, l, args); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if publish {
+		errs = append(errs, ee.publish(event, args))
+	}
+	return errors.Join(errs...)
 }
 
 // EmitConcurrent triggers an event with the given arguments, calling listeners concurrently
@@ -121,38 +443,81 @@ func (ee *EventEmitter) Emit(event string, args ...interface{}) error {
 //   - args: the arguments to pass to the event listeners
 //
 // Returns:
-//   - error: any error that occurred during emission
+//   - error: ErrEmitterClosed after Close, an argument error, or the ListenerErrors of all failed listeners
+//     and the ErrTransportPublish error of a bridged event (see AttachTransport) joined with errors.Join
+//
+// Events with a PerEventSerialQueue are queued like with Emit.
 func (ee *EventEmitter) EmitConcurrent(event string, args ...interface{}) error {
-	ee.mu.RLock()
-	listeners := ee.listeners[event]
-	ee.mu.RUnlock()
+	if queue, ok := ee.serialQueues[event]; ok {
+		if err := ee.enqueue(queue, event, args); err != nil {
+			return err
+		}
+		return ee.publish(event, args)
+	}
+	listeners, err := ee.beginEmit(event, args)
+	if err != nil {
+		return err
+	}
+	defer ee.inflight.Done()
 
 	if len(listeners) == 0 {
-		return nil
+		return ee.publish(event, args)
 	}
 
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(listeners))
 
-	for _, listener := range listeners {
+	for _, l := range listeners {
 		wg.Add(1)
-		go func(l reflect.Value) {
+		go func(l *listener) {
 			defer wg.Done()
-			if err := ee.callListener(l, args); err != nil {
+			if err := ee.callListener(event, l, args); err != nil {
 				errChan <- err
 			}
-		}(listener)
+		}(l)
 	}
 
 	wg.Wait()
 	close(errChan)
 
+	var errs []error
 	for err := range errChan {
-		if err != nil {
-			return err
-		}
+		errs = append(errs, err)
+	}
+	errs = append(errs, ee.publish(event, args))
+	return errors.Join(errs...)
+}
+
+// Flush waits until all deliveries of a PerEventSerialQueue event that were queued before the
+// call have been processed. It must not be called from a listener of the same event.
+//
+// Parameters:
+//   - event: the name of the event
+//
+// Returns:
+//   - error: ErrEmitterClosed after Close, or an error if the event has no serial queue
+//
+// Example usage:
+//
+//	emitter.Emit("ledger.entry", entry)
+//	emitter.Flush("ledger.entry") // all listeners have seen entry
+func (ee *EventEmitter) Flush(event string) error {
+	queue, ok := ee.serialQueues[event]
+	if !ok {
+		return fmt.Errorf("event %q has no serial queue", event)
+	}
+
+	ee.mu.RLock()
+	if ee.closed {
+		ee.mu.RUnlock()
+		return ErrEmitterClosed
 	}
+	ee.inflight.Add(1)
+	ee.mu.RUnlock()
 
+	flushed := make(chan struct{})
+	queue <- serialDelivery{flushed: flushed}
+	<-flushed
 	return nil
 }
 
@@ -170,9 +535,8 @@ func (ee *EventEmitter) EmitConcurrent(event string, args ...interface{}) error
 // The function will be automatically unsubscribed after it is called once.
 func (ee *EventEmitter) Once(event, name string, fn interface{}) (string, error) {
 	wrapper := reflect.MakeFunc(reflect.TypeOf(fn), func(args []reflect.Value) []reflect.Value {
-		reflect.ValueOf(fn).Call(args)
-		ee.Off(event, name)
-		return nil
+		defer ee.Off(event, name)
+		return reflect.ValueOf(fn).Call(args)
 	})
 	return ee.On(event, name, wrapper.Interface())
 }
@@ -191,22 +555,38 @@ func (ee *EventEmitter) ListenerCount(event string) int {
 	return len(ee.listeners[event])
 }
 
-// ListenerNames returns a list of all listener names for a given event
+// ListenerNames returns the listeners of a given event with their group, whether the group is
+// paused and how many deliveries they have buffered (see WithPauseBuffer)
 //
 // Parameters:
 //   - event: the name of the event
 //
 // Returns:
-//   - []string: a slice containing all listener names for the event
-func (ee *EventEmitter) ListenerNames(event string) []string {
+//   - []ListenerState: a slice describing each listener of the event, sorted by name
+//
+// Example usage:
+//
+//	for _, l := range emitter.ListenerNames("order.created") {
+//	    fmt.Printf("%s (group %q, paused %t, %d buffered)\n", l.Name, l.Group, l.Paused, l.Buffered)
+//	}
+func (ee *EventEmitter) ListenerNames(event string) []ListenerState {
 	ee.mu.RLock()
 	defer ee.mu.RUnlock()
 
-	names := make([]string, 0, len(ee.listeners[event]))
-	for name := range ee.listeners[event] {
-		names = append(names, name)
+	states := make([]ListenerState, 0, len(ee.listeners[event]))
+	for name, l := range ee.listeners[event] {
+		l.mu.Lock()
+		buffered := len(l.pending)
+		l.mu.Unlock()
+		states = append(states, ListenerState{
+			Name:     name,
+			Group:    l.group,
+			Paused:   l.group != "" && ee.pausedGroups[l.group],
+			Buffered: buffered,
+		})
 	}
-	return names
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
 }
 
 // Events returns a list of all events that have listeners
@@ -224,22 +604,431 @@ func (ee *EventEmitter) Events() []string {
 	return events
 }
 
-func (ee *EventEmitter) callListener(listener reflect.Value, args []interface{}) error {
-	listenerType := listener.Type()
+// PauseGroup pauses delivery to all listeners in the given group without unsubscribing them.
+// Deliveries while paused are dropped, or buffered for listeners registered with WithPauseBuffer.
+//
+// Parameters:
+//   - name: the name of the group to pause
+func (ee *EventEmitter) PauseGroup(name string) {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	ee.pausedGroups[name] = true
+}
+
+// ResumeGroup resumes delivery to all listeners in the given group and replays
+// buffered deliveries, per listener in the order they were emitted. Deliveries emitted while a
+// listener is replaying queue behind its buffered ones, within the same WithPauseBuffer limit
+// (further ones are dropped), so a listener never sees a newer event before an older one.
+//
+// ResumeGroup delivers the buffered deliveries and up to one buffer of those queued meanwhile.
+// If an emitter keeps the listener busy beyond that, the rest of the replay continues in the
+// background and its errors are reported with EmitError (source "eventemitter.replay").
+// Close waits for the replay.
+//
+// Parameters:
+//   - name: the name of the group to resume
+//
+// Returns:
+//   - error: the errors of the deliveries replayed before returning joined with errors.Join
+func (ee *EventEmitter) ResumeGroup(name string) error {
+	type replay struct {
+		event string
+		l     *listener
+		limit int
+	}
+
+	ee.mu.Lock()
+	delete(ee.pausedGroups, name)
+	var replays []replay
+	for event, listeners := range ee.listeners {
+		for _, l := range listeners {
+			if l.group != name {
+				continue
+			}
+			l.mu.Lock()
+			if len(l.pending) > 0 && !l.replaying {
+				l.replaying = true
+				replays = append(replays, replay{event: event, l: l, limit: len(l.pending) + l.pauseBuffer})
+				ee.inflight.Add(1)
+			}
+			l.mu.Unlock()
+		}
+	}
+	ee.mu.Unlock()
+
+	var errs []error
+	for _, r := range replays {
+		errs = append(errs, ee.replayPending(r.event, r.l, r.limit))
+	}
+	return errors.Join(errs...)
+}
+
+// replayPending delivers the pending deliveries of a listener one after another until none are
+// left, its group is paused again or the emitter is closed. After limit deliveries (no limit if
+// negative) it hands the rest of the replay to a goroutine and returns. The caller must have set
+// l.replaying and added the replay to ee.inflight.
+func (ee *EventEmitter) replayPending(event string, l *listener, limit int) error {
+	var errs []error
+	for delivered := 0; ; delivered++ {
+		ee.mu.RLock()
+		l.mu.Lock()
+		if ee.closed {
+			l.pending = nil
+		}
+		if len(l.pending) == 0 || ee.pausedGroups[l.group] {
+			l.replaying = false
+			l.mu.Unlock()
+			ee.mu.RUnlock()
+			ee.inflight.Done()
+			return errors.Join(errs...)
+		}
+		if delivered == limit {
+			l.mu.Unlock()
+			ee.mu.RUnlock()
+			go ee.replayInBackground(event, l)
+			return errors.Join(errs...)
+		}
+		args := l.pending[0]
+		l.pending[0] = nil
+		l.pending = l.pending[1:]
+		l.mu.Unlock()
+		ee.mu.RUnlock()
+
+		if err := ee.callListener(event, l, args); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// replayInBackground continues a replay that ResumeGroup handed off, reporting its errors
+func (ee *EventEmitter) replayInBackground(event string, l *listener) {
+	err := ee.replayPending(event, l, -1)
+	if err == nil {
+		return
+	}
+	if event == ErrorEvent {
+		L.Errorf("[eventemitter] replay of %q failed: %v", event, err)
+		return
+	}
+	ee.EmitError("eventemitter.replay", err)
+}
+
+// IsGroupPaused reports whether the given listener group is paused
+func (ee *EventEmitter) IsGroupPaused(name string) bool {
+	ee.mu.RLock()
+	defer ee.mu.RUnlock()
+	return ee.pausedGroups[name]
+}
+
+// Close stops the EventEmitter: new subscriptions and emits fail with ErrEmitterClosed,
+// in-flight and queued deliveries are awaited until they finish or ctx expires,
+// and all listeners and buffered deliveries are released. An attached transport is detached and
+// pending scheduled emissions (see EmitAfter) are cancelled. Serial queue goroutines exit once
+// all deliveries are done. Calling Close again returns nil.
+//
+// Parameters:
+//   - ctx: bounds how long to wait for in-flight deliveries
+//
+// Returns:
+//   - error: ctx.Err() if in-flight deliveries did not finish in time
+//
+// Example usage:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := emitter.Close(ctx); err != nil {
+//	    log.Printf("event deliveries still running: %v", err)
+//	}
+func (ee *EventEmitter) Close(ctx context.Context) error {
+	ee.mu.Lock()
+	if ee.closed {
+		ee.mu.Unlock()
+		return nil
+	}
+	ee.closed = true
+	ee.cancelScheduled()
+	ee.listeners = make(map[string]map[string]*listener)
+	ee.pausedGroups = make(map[string]bool)
+	bridge := ee.bridge
+	ee.bridge = nil
+	ee.mu.Unlock()
+	if bridge != nil {
+		bridge.unsubscribeAll()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		ee.inflight.Wait()
+		close(ee.queuesDone)
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsClosed reports whether Close has been called
+func (ee *EventEmitter) IsClosed() bool {
+	ee.mu.RLock()
+	defer ee.mu.RUnlock()
+	return ee.closed
+}
+
+// DefineEvent registers the expected argument types of an event. Afterwards, On calls with
+// incompatible handlers and Emit calls with mismatched arguments fail immediately with
+// ErrEventSignature. Events without a definition are not checked unless strict mode is enabled.
+//
+// Parameters:
+//   - event: the name of the event
+//   - argTypes: the types of the arguments passed to Emit, in order
+//
+// Returns:
+//   - error: ErrEventSignature if an already registered listener does not match the definition
+//
+// Example usage:
+//
+//	err := emitter.DefineEvent("userLoggedIn", reflect.TypeOf(""), reflect.TypeOf(time.Time{}))
+func (ee *EventEmitter) DefineEvent(event string, argTypes ...reflect.Type) error {
+	for i, t := range argTypes {
+		if t == nil {
+			return fmt.Errorf("%w: argument %d of event %q has no type", ErrEventSignature, i, event)
+		}
+	}
+
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+
+	previous, hadDefinition := ee.definitions[event]
+	ee.definitions[event] = append([]reflect.Type(nil), argTypes...)
+	for name, l := range ee.listeners[event] {
+		if err := ee.checkHandler(event, l.fn.Type()); err != nil {
+			if hadDefinition {
+				ee.definitions[event] = previous
+			} else {
+				delete(ee.definitions, event)
+			}
+			return fmt.Errorf("listener %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// DefineEventT registers an event that carries a single argument of type T (see DefineEvent).
+//
+// Example usage:
+//
+//	err := gonuts.DefineEventT[OrderCreated](emitter, "order.created")
+func DefineEventT[T any](ee *EventEmitter, event string) error {
+	return ee.DefineEvent(event, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// SetStrictMode enables or disables strict mode. In strict mode, subscribing to or emitting
+// an event without a definition fails with ErrUndefinedEvent.
+func (ee *EventEmitter) SetStrictMode(enabled bool) {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	ee.strict = enabled
+}
+
+// checkHandler verifies a handler type against the event definition. The caller must hold ee.mu.
+func (ee *EventEmitter) checkHandler(event string, handler reflect.Type) error {
+	argTypes, ok := ee.definitions[event]
+	if !ok {
+		if ee.strict {
+			return fmt.Errorf("%w: %q", ErrUndefinedEvent, event)
+		}
+		return nil
+	}
+	if handler.NumIn() != len(argTypes) {
+		return fmt.Errorf("%w: handler for %q takes %d arguments, event defines %s",
+			ErrEventSignature, event, handler.NumIn(), formatTypes(argTypes))
+	}
+	for i, t := range argTypes {
+		if !t.AssignableTo(handler.In(i)) {
+			return fmt.Errorf("%w: handler for %q expects %v as argument %d, event defines %v",
+				ErrEventSignature, event, handler.In(i), i, t)
+		}
+	}
+	return nil
+}
+
+// checkArgs verifies emitted arguments against the event definition. The caller must hold ee.mu.
+func (ee *EventEmitter) checkArgs(event string, args []interface{}) error {
+	argTypes, ok := ee.definitions[event]
+	if !ok {
+		if ee.strict {
+			return fmt.Errorf("%w: %q", ErrUndefinedEvent, event)
+		}
+		return nil
+	}
+	if len(args) != len(argTypes) {
+		return fmt.Errorf("%w: %q emitted with %d arguments, event defines %s",
+			ErrEventSignature, event, len(args), formatTypes(argTypes))
+	}
+	for i, arg := range args {
+		expected := argTypes[i]
+		if arg == nil {
+			if isNillable(expected) {
+				continue
+			}
+			return fmt.Errorf("%w: %q argument %d is nil, event defines %v", ErrEventSignature, event, i, expected)
+		}
+		if actual := reflect.TypeOf(arg); !actual.AssignableTo(expected) {
+			return fmt.Errorf("%w: %q argument %d has type %v, event defines %v", ErrEventSignature, event, i, actual, expected)
+		}
+	}
+	return nil
+}
+
+// isNillable reports whether nil is a valid value of type t
+func isNillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+// formatTypes renders a list of types like "(string, int)"
+func formatTypes(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// beginEmit registers an in-flight delivery and returns the listeners that are not paused.
+// Listeners are called outside the lock, so they may subscribe or unsubscribe themselves.
+// On success the caller must call ee.inflight.Done when the delivery is finished.
+func (ee *EventEmitter) beginEmit(event string, args []interface{}) ([]*listener, error) {
+	ee.mu.RLock()
+	defer ee.mu.RUnlock()
+
+	if ee.closed {
+		return nil, ErrEmitterClosed
+	}
+	if err := ee.checkArgs(event, args); err != nil {
+		return nil, err
+	}
+	listeners := make([]*listener, 0, len(ee.listeners[event]))
+	for _, l := range ee.listeners[event] {
+		if !ee.holdIfPaused(l, args) {
+			listeners = append(listeners, l)
+		}
+	}
+	ee.inflight.Add(1)
+	return listeners, nil
+}
+
+// enqueue queues a delivery of a serial event. The delivery stays in flight until the
+// consumer goroutine has processed it, so Close waits for queued deliveries.
+func (ee *EventEmitter) enqueue(queue chan serialDelivery, event string, args []interface{}) error {
+	listeners, err := ee.beginEmit(event, args)
+	if err != nil {
+		return err
+	}
+	queue <- serialDelivery{listeners: listeners, args: args}
+	return nil
+}
+
+// runSerialQueue calls the listeners of queued deliveries one after another until Close has drained the queue
+func (ee *EventEmitter) runSerialQueue(event string, queue chan serialDelivery) {
+	for {
+		select {
+		case d := <-queue:
+			for _, l := range d.listeners {
+				_ = ee.callListener(event, l, d.args)
+			}
+			if d.flushed != nil {
+				close(d.flushed)
+			}
+			ee.inflight.Done()
+		case <-ee.queuesDone:
+			return
+		}
+	}
+}
+
+// holdIfPaused buffers or drops a delivery if the listener's group is paused, and queues it
+// behind the buffered deliveries while ResumeGroup replays them, within the same pause buffer.
+// It reports whether the delivery was held back. The caller must hold ee.mu.
+func (ee *EventEmitter) holdIfPaused(l *listener, args []interface{}) bool {
+	if l.group == "" {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ee.pausedGroups[l.group] {
+		if len(l.pending) < l.pauseBuffer {
+			l.pending = append(l.pending, args)
+		}
+		return true
+	}
+	if l.replaying {
+		if len(l.pending) < l.pauseBuffer {
+			l.pending = append(l.pending, args)
+		}
+		return true
+	}
+	return false
+}
+
+// callListener calls a listener, recovering panics. Argument mismatches, panics and returned
+// errors are reported as ListenerError.
+func (ee *EventEmitter) callListener(event string, l *listener, args []interface{}) (err error) {
+	callArgs, err := listenerArgs(l.fn.Type(), args)
+	if err != nil {
+		return &ListenerError{Event: event, Listener: l.name, Err: err}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if ee.panicHandler != nil {
+				ee.panicHandler(event, l.name, r, debug.Stack())
+			}
+			err = &ListenerError{Event: event, Listener: l.name, Err: fmt.Errorf("%w: %v", ErrListenerPanic, r)}
+		}
+	}()
+
+	results := l.fn.Call(callArgs)
+	if len(results) == 0 {
+		return nil
+	}
+	last := results[len(results)-1]
+	if last.Type() != errorType || last.IsNil() {
+		return nil
+	}
+	return &ListenerError{Event: event, Listener: l.name, Err: last.Interface().(error)}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// listenerArgs converts emitted arguments to the parameters of a listener
+func listenerArgs(listenerType reflect.Type, args []interface{}) ([]reflect.Value, error) {
 	if listenerType.NumIn() != len(args) {
-		return fmt.Errorf("event handler expects %d arguments, but got %d", listenerType.NumIn(), len(args))
+		return nil, fmt.Errorf("event handler expects %d arguments, but got %d", listenerType.NumIn(), len(args))
 	}
 
 	callArgs := make([]reflect.Value, len(args))
 	for i, arg := range args {
 		expectedType := listenerType.In(i)
+		if arg == nil {
+			if isNillable(expectedType) {
+				callArgs[i] = reflect.Zero(expectedType)
+				continue
+			}
+			return nil, fmt.Errorf("argument %d is nil, want %v", i, expectedType)
+		}
 		argValue := reflect.ValueOf(arg)
 		if !argValue.Type().AssignableTo(expectedType) {
-			return fmt.Errorf("argument %d has wrong type: got %v, want %v", i, argValue.Type(), expectedType)
+			return nil, fmt.Errorf("argument %d has wrong type: got %v, want %v", i, argValue.Type(), expectedType)
 		}
 		callArgs[i] = argValue
 	}
-
-	listener.Call(callArgs)
-	return nil
+	return callArgs, nil
 }
@@ -0,0 +1,406 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResumeGroupKeepsOrderWithConcurrentEmits(t *testing.T) {
+	ee := NewEventEmitter()
+	var mu sync.Mutex
+	var got []int
+	_, err := ee.OnWithOptions("tick", "recorder", func(n int) {
+		mu.Lock()
+		got = append(got, n)
+		mu.Unlock()
+		time.Sleep(10 * time.Microsecond) // widen the window for concurrent emits
+	}, WithGroup("workers"), WithPauseBuffer(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ee.PauseGroup("workers")
+	for n := 0; n < 50; n++ {
+		if err := ee.Emit("tick", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := 50; n < 100; n++ {
+			if err := ee.Emit("tick", n); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	if err := ee.ResumeGroup("workers"); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	// Deliveries queued behind the replay are delivered before ResumeGroup returns, emits
+	// after the replay directly, so all of them have arrived now
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 100 {
+		t.Fatalf("got %d deliveries, want 100", len(got))
+	}
+	for i, n := range got {
+		if n != i {
+			t.Fatalf("delivery %d is event %d, want events in emit order: %v", i, n, got)
+		}
+	}
+}
+
+func TestResumeGroupPausedAgainKeepsRemainder(t *testing.T) {
+	ee := NewEventEmitter()
+	var got []int
+	_, err := ee.OnWithOptions("tick", "recorder", func(n int) {
+		got = append(got, n)
+		if n == 1 {
+			ee.PauseGroup("workers")
+		}
+	}, WithGroup("workers"), WithPauseBuffer(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ee.PauseGroup("workers")
+	for n := 0; n < 4; n++ {
+		_ = ee.Emit("tick", n)
+	}
+	_ = ee.ResumeGroup("workers")
+	if len(got) != 2 {
+		t.Fatalf("got %v before the second pause, want [0 1]", got)
+	}
+	_ = ee.ResumeGroup("workers")
+	if len(got) != 4 || got[2] != 2 || got[3] != 3 {
+		t.Fatalf("got %v, want [0 1 2 3]", got)
+	}
+}
+
+func TestCloseWaitsForResumeGroupReplay(t *testing.T) {
+	ee := NewEventEmitter()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	_, err := ee.OnWithOptions("tick", "slow", func(n int) {
+		if n == 0 {
+			close(started)
+			<-release
+		}
+	}, WithGroup("workers"), WithPauseBuffer(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ee.PauseGroup("workers")
+	_ = ee.Emit("tick", 0)
+	_ = ee.Emit("tick", 1)
+	go ee.ResumeGroup("workers")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := ee.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close during replay = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := ee.Close(context.Background()); err != nil {
+		t.Fatalf("second Close = %v", err)
+	}
+}
+
+func TestResumeGroupReturnsWhileEmitterKeepsListenerBusy(t *testing.T) {
+	const pauseBuffer = 5
+	ee := NewEventEmitter()
+	var mu sync.Mutex
+	var got []int
+	_, err := ee.OnWithOptions("tick", "slow", func(n int) {
+		mu.Lock()
+		got = append(got, n)
+		mu.Unlock()
+		time.Sleep(200 * time.Microsecond)
+	}, WithGroup("workers"), WithPauseBuffer(pauseBuffer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ee.PauseGroup("workers")
+	for n := 0; n < pauseBuffer; n++ {
+		_ = ee.Emit("tick", n)
+	}
+	stop := make(chan struct{})
+	emitted := make(chan int)
+	go func() {
+		n := pauseBuffer
+		for ; ; n++ {
+			select {
+			case <-stop:
+				emitted <- n
+				return
+			default:
+			}
+			_ = ee.Emit("tick", n) // far faster than the listener
+			for _, l := range ee.ListenerNames("tick") {
+				if l.Buffered > pauseBuffer {
+					t.Errorf("%d deliveries buffered, want at most %d", l.Buffered, pauseBuffer)
+				}
+			}
+		}
+	}()
+
+	resumed := make(chan error)
+	go func() { resumed <- ee.ResumeGroup("workers") }()
+	select {
+	case err := <-resumed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ResumeGroup did not return while the emitter kept the listener busy")
+	}
+	close(stop)
+	total := <-emitted
+
+	// Close waits for the rest of the replay; deliveries beyond the buffer were dropped, the
+	// others arrived in emit order
+	if err := ee.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) < 2*pauseBuffer || len(got) >= total {
+		t.Errorf("%d of %d emitted deliveries arrived, want the replay and some drops", len(got), total)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("event %d delivered after event %d", got[i], got[i-1])
+		}
+	}
+}
+
+func TestListenerNamesExposeGroupAndPauseState(t *testing.T) {
+	ee := NewEventEmitter()
+	_, _ = ee.OnWithOptions("order.created", "webhook", func(string) {}, WithGroup("webhooks"), WithPauseBuffer(10))
+	_, _ = ee.OnWithOptions("order.created", "audit", func(string) {}, WithGroup("audit"))
+	_, _ = ee.On("order.created", "mailer", func(string) {})
+
+	ee.PauseGroup("webhooks")
+	_ = ee.Emit("order.created", "o-1")
+	_ = ee.Emit("order.created", "o-2")
+
+	want := []ListenerState{
+		{Name: "audit", Group: "audit"},
+		{Name: "mailer"},
+		{Name: "webhook", Group: "webhooks", Paused: true, Buffered: 2},
+	}
+	if got := ee.ListenerNames("order.created"); !slices.Equal(got, want) {
+		t.Errorf("ListenerNames() = %+v, want %+v", got, want)
+	}
+
+	_ = ee.ResumeGroup("webhooks")
+	want[2].Paused, want[2].Buffered = false, 0
+	if got := ee.ListenerNames("order.created"); !slices.Equal(got, want) {
+		t.Errorf("ListenerNames() after ResumeGroup = %+v, want %+v", got, want)
+	}
+}
+
+func TestPerEventSerialQueueDeliversInOrder(t *testing.T) {
+	const events = 10_000
+	ee := NewEventEmitter(PerEventSerialQueue("ledger.entry", 64))
+	defer ee.Close(context.Background())
+
+	var inflight, maxInflight atomic.Int32
+	got := make([]int, 0, events)
+	_, err := ee.On("ledger.entry", "ledger", func(n int) {
+		if current := inflight.Add(1); current > maxInflight.Load() {
+			maxInflight.Store(current)
+		}
+		got = append(got, n) // the consumer goroutine is the only writer
+		inflight.Add(-1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := 0; n < events; n++ {
+		emit := ee.Emit
+		if n%2 == 1 {
+			emit = ee.EmitConcurrent
+		}
+		if err := emit("ledger.entry", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ee.Flush("ledger.entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != events {
+		t.Fatalf("%d deliveries after Flush, want %d", len(got), events)
+	}
+	for i, n := range got {
+		if n != i {
+			t.Fatalf("delivery %d is event %d, want emit order", i, n)
+		}
+	}
+	if n := maxInflight.Load(); n != 1 {
+		t.Errorf("%d deliveries in flight at once, want 1", n)
+	}
+}
+
+func TestCloseRejectsEmitsAndSubscriptions(t *testing.T) {
+	ee := NewEventEmitter(PerEventSerialQueue("serial", 4))
+	calls := 0
+	_, _ = ee.On("tick", "counter", func() { calls++ })
+	if err := ee.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, onErr := ee.On("tick", "late", func() {})
+	for name, err := range map[string]error{
+		"Emit":           ee.Emit("tick"),
+		"EmitConcurrent": ee.EmitConcurrent("tick"),
+		"Emit serial":    ee.Emit("serial"),
+		"EmitError":      ee.EmitError("test", errors.New("boom")),
+		"On":             onErr,
+	} {
+		if !errors.Is(err, ErrEmitterClosed) {
+			t.Errorf("%s after Close = %v, want ErrEmitterClosed", name, err)
+		}
+	}
+	if ee.EmitAfter(0, "tick")() {
+		t.Error("EmitAfter after Close scheduled an emission")
+	}
+	if err := ee.Close(context.Background()); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+	if calls != 0 || !ee.IsClosed() || ee.ListenerCount("tick") != 0 || ee.PendingScheduled() != 0 {
+		t.Errorf("after Close: %d calls, IsClosed %v, %d listeners, %d scheduled; want 0, true, 0, 0",
+			calls, ee.IsClosed(), ee.ListenerCount("tick"), ee.PendingScheduled())
+	}
+}
+
+func TestCloseDrainsInflightListeners(t *testing.T) {
+	ee := NewEventEmitter(PerEventSerialQueue("serial", 16))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+	_, _ = ee.On("tick", "slow", func() {
+		close(started)
+		<-release
+		finished.Store(true)
+	})
+	var serial atomic.Int32
+	_, _ = ee.On("serial", "queued", func() {
+		<-release
+		serial.Add(1)
+	})
+	go ee.Emit("tick")
+	<-started
+	for i := 0; i < 10; i++ {
+		_ = ee.Emit("serial")
+	}
+
+	closed := make(chan error)
+	go func() { closed <- ee.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v while a listener was still running", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if !finished.Load() || serial.Load() != 10 {
+		t.Errorf("Close returned with the in-flight listener finished %v and %d of 10 queued deliveries, want all",
+			finished.Load(), serial.Load())
+	}
+}
+
+type orderCreated struct{ ID string }
+
+func TestDefinedEventsPermissiveMode(t *testing.T) {
+	ee := NewEventEmitter()
+	if err := DefineEventT[orderCreated](ee, "order.created"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Defined events are checked on subscription and emit
+	if _, err := ee.On("order.created", "wrong", func(string) {}); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("On with a mismatched handler = %v, want ErrEventSignature", err)
+	}
+	var got []string
+	if _, err := ee.On("order.created", "store", func(o orderCreated) { got = append(got, o.ID) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := ee.Emit("order.created", "o-1"); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("Emit with a mismatched argument = %v, want ErrEventSignature", err)
+	}
+	if err := ee.Emit("order.created"); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("Emit without arguments = %v, want ErrEventSignature", err)
+	}
+	if err := ee.Emit("order.created", orderCreated{ID: "o-2"}); err != nil || !slices.Equal(got, []string{"o-2"}) {
+		t.Errorf("Emit = %v with deliveries %v, want only o-2 delivered", err, got)
+	}
+
+	// Undefined events are not checked
+	if _, err := ee.On("anything", "loose", func(int) {}); err != nil {
+		t.Errorf("On for an undefined event = %v", err)
+	}
+	if err := ee.Emit("nobody.listens", 1, "two"); err != nil {
+		t.Errorf("Emit of an undefined event = %v", err)
+	}
+
+	// A definition that an existing listener does not match is rejected and not applied
+	if err := ee.DefineEvent("anything", reflect.TypeOf("")); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("DefineEvent against an existing listener = %v, want ErrEventSignature", err)
+	}
+	if err := ee.Emit("anything", 42); err != nil {
+		t.Errorf("Emit after the rejected definition = %v", err)
+	}
+}
+
+func TestDefinedEventsStrictMode(t *testing.T) {
+	ee := NewEventEmitter()
+	ee.SetStrictMode(true)
+	if err := DefineEventT[orderCreated](ee, "order.created"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ee.On("order.cancelled", "store", func(orderCreated) {}); !errors.Is(err, ErrUndefinedEvent) {
+		t.Errorf("On for an undefined event = %v, want ErrUndefinedEvent", err)
+	}
+	if err := ee.Emit("order.cancelled", orderCreated{}); !errors.Is(err, ErrUndefinedEvent) {
+		t.Errorf("Emit of an undefined event = %v, want ErrUndefinedEvent", err)
+	}
+	if _, err := ee.On("order.created", "wrong", func(int) {}); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("On with a mismatched handler = %v, want ErrEventSignature", err)
+	}
+	delivered := false
+	if _, err := ee.On("order.created", "store", func(*orderCreated) {}); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("On with a pointer handler = %v, want ErrEventSignature", err)
+	}
+	if _, err := ee.On("order.created", "store", func(orderCreated) { delivered = true }); err != nil {
+		t.Fatal(err)
+	}
+	if err := ee.Emit("order.created", orderCreated{ID: "o-1"}); err != nil || !delivered {
+		t.Errorf("Emit of a defined event = %v, delivered %v", err, delivered)
+	}
+
+	// ErrorEvent is always defined
+	if _, err := ee.OnError("log", func(*ErrorPlus) {}); err != nil {
+		t.Errorf("OnError in strict mode = %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package gonuts
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sync"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+var (
+	// ErrTransportAttached is returned by AttachTransport if the emitter already has a transport
+	ErrTransportAttached = errors.New("event emitter already has a transport")
+	// ErrTransportPublish is wrapped by the errors of publishing a bridged event
+	ErrTransportPublish = errors.New("publishing event to transport failed")
+)
+
+// Transport carries events between processes, e.g. over Redis pub/sub or NATS. Publish and
+// the handlers passed to Subscribe may be called concurrently.
+type Transport interface {
+	// Publish sends the payload of an event to all subscribers of matching patterns
+	Publish(event string, payload []byte) error
+	// Subscribe calls handler for every published event matching pattern, until unsubscribe is called
+	Subscribe(pattern string, handler func(event string, payload []byte)) (unsubscribe func(), err error)
+}
+
+// Codec serializes the arguments of bridged events
+type Codec interface {
+	// Encode serializes the arguments of an emitted event
+	Encode(event string, args []interface{}) ([]byte, error)
+	// Decode deserializes the arguments of a received event. argTypes are the types registered
+	// with DefineEvent, nil if the event has no definition.
+	Decode(event string, payload []byte, argTypes []reflect.Type) ([]interface{}, error)
+}
+
+// JSONCodec encodes event arguments as a JSON array. Received arguments are decoded into the
+// types registered with DefineEvent; for events without a definition they are decoded as
+// generic JSON values (string, float64, bool, map[string]interface{}, []interface{}), so
+// define bridged events whose listeners expect other types.
+type JSONCodec struct{}
+
+// Encode implements Codec
+func (JSONCodec) Encode(event string, args []interface{}) ([]byte, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return json.Marshal(args)
+}
+
+// Decode implements Codec
+func (JSONCodec) Decode(event string, payload []byte, argTypes []reflect.Type) ([]interface{}, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, err
+	}
+	if argTypes != nil && len(argTypes) != len(raw) {
+		return nil, fmt.Errorf("%w: event %q expects %d arguments, received %d", ErrEventSignature, event, len(argTypes), len(raw))
+	}
+	args := make([]interface{}, len(raw))
+	for i, data := range raw {
+		if argTypes == nil {
+			if err := json.Unmarshal(data, &args[i]); err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i, err)
+			}
+			continue
+		}
+		value := reflect.New(argTypes[i])
+		if err := json.Unmarshal(data, value.Interface()); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		args[i] = value.Elem().Interface()
+	}
+	return args, nil
+}
+
+// BridgeOption configures AttachTransport
+type BridgeOption func(*emitterBridge)
+
+// WithBridgedEvents opts events in to the bridge: local emits of these events are published
+// to the transport, and events received from the transport are emitted locally.
+func WithBridgedEvents(events ...string) BridgeOption {
+	return func(b *emitterBridge) {
+		b.events = append(b.events, events...)
+	}
+}
+
+// WithBridgeErrorHandler sets the handler for events received from the transport that cannot
+// be decoded or delivered. By default they are logged as warnings.
+func WithBridgeErrorHandler(handler func(event string, err error)) BridgeOption {
+	return func(b *emitterBridge) {
+		b.onError = handler
+	}
+}
+
+// emitterBridge is the transport attached to an EventEmitter
+type emitterBridge struct {
+	transport    Transport
+	codec        Codec
+	origin       string // identifies the emitter in published frames
+	events       []string
+	bridged      map[string]bool
+	onError      func(event string, err error)
+	unsubscribes []func()
+}
+
+// AttachTransport bridges events between this emitter and other processes. Emits of the opted-in
+// events (see WithBridgedEvents) are delivered to the local listeners and then published;
+// Emit and EmitConcurrent return publish errors wrapped in ErrTransportPublish. Events received
+// from the transport are emitted locally, but not published again.
+//
+// Published frames are tagged with a random origin ID of the emitter, so events published by
+// the emitter itself are ignored when the transport echoes them back. A frame is the origin ID
+// prefixed with its length as a uvarint, followed by the codec payload; other publishers can
+// send a 0 byte (an empty origin) followed by the payload.
+//
+// Parameters:
+//   - t: the transport
+//   - codec: the serialization of the event arguments, e.g. JSONCodec{}
+//   - opts: the bridged events and an error handler
+//
+// Returns:
+//   - detach: unsubscribes from the transport and stops publishing
+//   - err: ErrTransportAttached, ErrEmitterClosed or the error of Transport.Subscribe
+//
+// Example usage:
+//
+//	transport := gonuts.NewInMemoryTransport()
+//	a, b := gonuts.NewEventEmitter(), gonuts.NewEventEmitter()
+//	gonuts.DefineEventT[string](b, "user.created")
+//	a.AttachTransport(transport, gonuts.JSONCodec{}, gonuts.WithBridgedEvents("user.created"))
+//	b.AttachTransport(transport, gonuts.JSONCodec{}, gonuts.WithBridgedEvents("user.created"))
+//	b.On("user.created", "welcome", func(id string) { sendWelcome(id) })
+//	a.Emit("user.created", "u-1") // calls welcome on b
+func (ee *EventEmitter) AttachTransport(t Transport, codec Codec, opts ...BridgeOption) (detach func(), err error) {
+	origin, err := gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+	b := &emitterBridge{
+		transport: t,
+		codec:     codec,
+		origin:    origin,
+		bridged:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.onError == nil {
+		b.onError = func(event string, err error) {
+			L.Warnf("[eventemitter.bridge] dropped event %q from transport: %s", event, err)
+		}
+	}
+	for _, event := range b.events {
+		b.bridged[event] = true
+	}
+
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	if ee.closed {
+		return nil, ErrEmitterClosed
+	}
+	if ee.bridge != nil {
+		return nil, ErrTransportAttached
+	}
+	for event := range b.bridged {
+		unsubscribe, err := t.Subscribe(event, func(event string, payload []byte) {
+			ee.receive(b, event, payload)
+		})
+		if err != nil {
+			b.unsubscribeAll()
+			return nil, fmt.Errorf("subscribing to %q: %w", event, err)
+		}
+		b.unsubscribes = append(b.unsubscribes, unsubscribe)
+	}
+	ee.bridge = b
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ee.mu.Lock()
+			if ee.bridge == b {
+				ee.bridge = nil
+			}
+			ee.mu.Unlock()
+			b.unsubscribeAll()
+		})
+	}, nil
+}
+
+func (b *emitterBridge) unsubscribeAll() {
+	for _, unsubscribe := range b.unsubscribes {
+		unsubscribe()
+	}
+	b.unsubscribes = nil
+}
+
+// publish sends a locally emitted event to the transport if the event is bridged
+func (ee *EventEmitter) publish(event string, args []interface{}) error {
+	ee.mu.RLock()
+	b := ee.bridge
+	ee.mu.RUnlock()
+	if b == nil || !b.bridged[event] {
+		return nil
+	}
+	payload, err := b.codec.Encode(event, args)
+	if err != nil {
+		return fmt.Errorf("%w: event %q: %v", ErrTransportPublish, event, err)
+	}
+	frame := make([]byte, 0, binary.MaxVarintLen64+len(b.origin)+len(payload))
+	frame = binary.AppendUvarint(frame, uint64(len(b.origin)))
+	frame = append(append(frame, b.origin...), payload...)
+	if err := b.transport.Publish(event, frame); err != nil {
+		return fmt.Errorf("%w: event %q: %v", ErrTransportPublish, event, err)
+	}
+	return nil
+}
+
+// receive emits an event received from the transport locally, ignoring frames published by this
+// emitter (see AttachTransport for the frame format).
+func (ee *EventEmitter) receive(b *emitterBridge, event string, frame []byte) {
+	originLen, n := binary.Uvarint(frame)
+	if n <= 0 || originLen > uint64(len(frame)-n) {
+		b.onError(event, errors.New("malformed frame: invalid origin length"))
+		return
+	}
+	origin, payload := frame[n:n+int(originLen)], frame[n+int(originLen):]
+	if string(origin) == b.origin {
+		return
+	}
+
+	ee.mu.RLock()
+	argTypes := ee.definitions[event]
+	ee.mu.RUnlock()
+	args, err := b.codec.Decode(event, payload, argTypes)
+	if err != nil {
+		b.onError(event, err)
+		return
+	}
+	if err := ee.emit(event, args, false); err != nil && !errors.Is(err, ErrEmitterClosed) {
+		b.onError(event, err)
+	}
+}
+
+// InMemoryTransport is a Transport within a single process, for tests and for bridging
+// emitters of different components. Patterns are matched with path.Match, so "order.*" matches
+// "order.created". Handlers are called synchronously by Publish.
+type InMemoryTransport struct {
+	mu            sync.RWMutex
+	subscriptions map[uint64]inMemorySubscription
+	nextID        uint64
+}
+
+type inMemorySubscription struct {
+	pattern string
+	handler func(event string, payload []byte)
+}
+
+// NewInMemoryTransport creates an InMemoryTransport
+func NewInMemoryTransport() *InMemoryTransport {
+	return &InMemoryTransport{subscriptions: make(map[uint64]inMemorySubscription)}
+}
+
+// Publish implements Transport. Every handler receives its own copy of the payload.
+func (t *InMemoryTransport) Publish(event string, payload []byte) error {
+	t.mu.RLock()
+	var handlers []func(event string, payload []byte)
+	for _, sub := range t.subscriptions {
+		if matched, _ := path.Match(sub.pattern, event); matched {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event, append([]byte(nil), payload...))
+	}
+	return nil
+}
+
+// Subscribe implements Transport
+func (t *InMemoryTransport) Subscribe(pattern string, handler func(event string, payload []byte)) (func(), error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	id := t.nextID
+	t.subscriptions[id] = inMemorySubscription{pattern: pattern, handler: handler}
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subscriptions, id)
+	}, nil
+}
@@ -0,0 +1,117 @@
+package gonuts
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bytesCodec passes a single []byte argument through unchanged, like a binary codec
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(event string, args []interface{}) ([]byte, error) {
+	return args[0].([]byte), nil
+}
+
+func (bytesCodec) Decode(event string, payload []byte, argTypes []reflect.Type) ([]interface{}, error) {
+	return []interface{}{payload}, nil
+}
+
+// bridgedEmitters returns two emitters bridged by an InMemoryTransport for the given events
+func bridgedEmitters(t *testing.T, transport Transport, codec Codec, events ...string) (a, b *EventEmitter) {
+	t.Helper()
+	a, b = NewEventEmitter(), NewEventEmitter()
+	for _, ee := range []*EventEmitter{a, b} {
+		detach, err := ee.AttachTransport(transport, codec, WithBridgedEvents(events...),
+			WithBridgeErrorHandler(func(event string, err error) { t.Errorf("bridge error on %q: %v", event, err) }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(detach)
+	}
+	return a, b
+}
+
+func TestBridgeDeliversBetweenEmittersWithoutLoops(t *testing.T) {
+	transport := NewInMemoryTransport()
+	a, b := bridgedEmitters(t, transport, JSONCodec{}, "user.created")
+	for _, ee := range []*EventEmitter{a, b} {
+		if err := DefineEventT[string](ee, "user.created"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var onA, onB []string
+	_, _ = a.On("user.created", "a", func(id string) { onA = append(onA, id) })
+	_, _ = b.On("user.created", "b", func(id string) { onB = append(onB, id) })
+	var published int
+	unsubscribe, _ := transport.Subscribe("user.*", func(string, []byte) { published++ })
+	defer unsubscribe()
+
+	if err := a.Emit("user.created", "u-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Emit("user.created", "u-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each emitter gets every event exactly once: its own locally, the other's via the
+	// transport, and neither the echo nor the received event is published again
+	if want := []string{"u-1", "u-2"}; !slices.Equal(onA, want) || !slices.Equal(onB, want) {
+		t.Errorf("deliveries a %v and b %v, want %v on both", onA, onB, want)
+	}
+	if published != 2 {
+		t.Errorf("%d frames published, want 2", published)
+	}
+
+	// Events that are not opted in stay local
+	_ = a.Emit("user.deleted", "u-1")
+	if published != 2 {
+		t.Errorf("%d frames published after a local event, want 2", published)
+	}
+}
+
+func TestBridgeFramesBinaryPayloads(t *testing.T) {
+	transport := NewInMemoryTransport()
+	a, b := bridgedEmitters(t, transport, bytesCodec{}, "blob")
+	var got [][]byte
+	_, _ = b.On("blob", "store", func(data []byte) { got = append(got, data) })
+
+	payloads := [][]byte{{0}, {0, 1, 0, 2}, {}, []byte("plain")}
+	for _, payload := range payloads {
+		if err := a.Emit("blob", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != len(payloads) {
+		t.Fatalf("%d payloads received, want %d", len(got), len(payloads))
+	}
+	for i := range payloads {
+		if !slices.Equal(got[i], payloads[i]) {
+			t.Errorf("payload %d = %v, want %v", i, got[i], payloads[i])
+		}
+	}
+}
+
+func TestBridgeFramesFromOtherPublishers(t *testing.T) {
+	transport := NewInMemoryTransport()
+	ee := NewEventEmitter()
+	var errs []error
+	_, err := ee.AttachTransport(transport, bytesCodec{}, WithBridgedEvents("blob"),
+		WithBridgeErrorHandler(func(event string, err error) { errs = append(errs, err) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]byte
+	_, _ = ee.On("blob", "store", func(data []byte) { got = append(got, data) })
+
+	// A frame with an empty origin is accepted, a truncated one is reported
+	_ = transport.Publish("blob", []byte{0, 7, 0, 7})
+	_ = transport.Publish("blob", []byte{5, 'a', 'b'})
+	_ = transport.Publish("blob", nil)
+	if len(got) != 1 || !slices.Equal(got[0], []byte{7, 0, 7}) {
+		t.Errorf("received %v, want only [7 0 7]", got)
+	}
+	if len(errs) != 2 {
+		t.Errorf("bridge errors = %v, want 2 malformed frames", errs)
+	}
+}
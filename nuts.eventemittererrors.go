@@ -0,0 +1,89 @@
+package gonuts
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// ErrorEvent is the reserved event on which EmitError reports errors. Its only argument is
+// an *ErrorPlus; every EventEmitter defines it, so listeners with another signature are rejected.
+const ErrorEvent = "gonuts.error"
+
+var errorPlusType = reflect.TypeOf((*ErrorPlus)(nil))
+
+var defaultEmitter atomic.Pointer[EventEmitter]
+
+// DefaultEmitter returns the package-level EventEmitter that gonuts components like StatesMan
+// report asynchronous errors to when no emitter was injected. It is created on first use.
+//
+// Example usage:
+//
+//	gonuts.DefaultEmitter().OnError("log", func(err *gonuts.ErrorPlus) {
+//	    source, _ := err.RevealContext("source")
+//	    L.Errorf("[%v] %v", source, err)
+//	})
+func DefaultEmitter() *EventEmitter {
+	if ee := defaultEmitter.Load(); ee != nil {
+		return ee
+	}
+	defaultEmitter.CompareAndSwap(nil, NewEventEmitter())
+	return defaultEmitter.Load()
+}
+
+// SetDefaultEmitter replaces the package-level EventEmitter returned by DefaultEmitter.
+// Components that had an emitter injected keep using it. A nil emitter resets it, so a new one
+// is created on the next use.
+func SetDefaultEmitter(ee *EventEmitter) {
+	defaultEmitter.Store(ee)
+}
+
+// EmitError reports an error on ErrorEvent. The error is wrapped into an internal ErrorPlus
+// with source as message, unless it already is an *ErrorPlus, and gets the context
+// {"source": source}. Errors emitted while the event has no listeners are only logged at debug
+// level.
+//
+// Parameters:
+//   - source: the component that failed, e.g. "statesman.OrderFlow"
+//   - err: the error; nil is ignored
+//
+// Returns:
+//   - error: ErrEmitterClosed after Close, or the ListenerErrors of failed error listeners
+//
+// Example usage:
+//
+//	if err := sync(); err != nil {
+//	    emitter.EmitError("inventory.sync", err)
+//	}
+func (ee *EventEmitter) EmitError(source string, err error) error {
+	if err == nil {
+		return nil
+	}
+	ep, ok := err.(*ErrorPlus)
+	if !ok {
+		ep = newErrorPlus(err, source, 500, ErrorKindInternal)
+	}
+	ep = ep.WithContext("source", source)
+	if ee.ListenerCount(ErrorEvent) == 0 {
+		L.Debugf("[%s] unhandled error: %v", source, ep)
+	}
+	return ee.Emit(ErrorEvent, ep)
+}
+
+// OnError subscribes a named function to the errors reported with EmitError
+//
+// Parameters:
+//   - name: a unique name for this listener (if empty, a unique ID will be generated)
+//   - fn: the function to be called with each reported error
+//
+// Returns:
+//   - string: the name or generated ID of the listener
+//   - error: any error that occurred during subscription
+//
+// Example usage:
+//
+//	emitter.OnError("alerting", func(err *gonuts.ErrorPlus) {
+//	    alerts.Send(err.Msg(), err.Context())
+//	})
+func (ee *EventEmitter) OnError(name string, fn func(*ErrorPlus)) (string, error) {
+	return ee.On(ErrorEvent, name, fn)
+}
@@ -0,0 +1,84 @@
+package gonuts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newUnhandledTestMachine returns a running machine in state A that has no transition for "bogus"
+func newUnhandledTestMachine(t *testing.T, ee *EventEmitter) *StatesMan {
+	t.Helper()
+	sm := NewStatesMan("OrderFlow")
+	if ee != nil {
+		sm.SetErrorEmitter(ee)
+	}
+	sm.AddState("A", "A", nil, nil)
+	if err := sm.SetInitialState("A"); err != nil {
+		t.Fatal(err)
+	}
+	runTestMachine(t, sm)
+	return sm
+}
+
+// waitForReport returns the next error reported to the OnError listener feeding reported
+func waitForReport(t *testing.T, reported chan *ErrorPlus) *ErrorPlus {
+	t.Helper()
+	select {
+	case ep := <-reported:
+		return ep
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error reported")
+		return nil
+	}
+}
+
+func TestStatesManReportsUnhandledEventToErrorEmitter(t *testing.T) {
+	ee := NewEventEmitter()
+	reported := make(chan *ErrorPlus, 1)
+	if _, err := ee.OnError("test", func(ep *ErrorPlus) { reported <- ep }); err != nil {
+		t.Fatal(err)
+	}
+	sm := newUnhandledTestMachine(t, ee)
+
+	sm.TriggerEvent("bogus", nil)
+	ep := waitForReport(t, reported)
+	if !errors.Is(ep, ErrUnhandledEvent) {
+		t.Errorf("reported %v, want ErrUnhandledEvent", ep)
+	}
+	if source, _ := ep.RevealContext("source"); source != "statesman.OrderFlow" {
+		t.Errorf("source = %v, want statesman.OrderFlow", source)
+	}
+	if state := sm.GetCurrentState(); state != "A" {
+		t.Errorf("state = %s after an unhandled event, want A", state)
+	}
+}
+
+func TestStatesManReportsToDefaultEmitter(t *testing.T) {
+	ee := NewEventEmitter()
+	SetDefaultEmitter(ee)
+	t.Cleanup(func() { SetDefaultEmitter(nil) })
+	reported := make(chan *ErrorPlus, 1)
+	if _, err := DefaultEmitter().OnError("test", func(ep *ErrorPlus) { reported <- ep }); err != nil {
+		t.Fatal(err)
+	}
+	sm := newUnhandledTestMachine(t, nil)
+
+	sm.TriggerEvent("bogus", nil)
+	if ep := waitForReport(t, reported); !errors.Is(ep, ErrUnhandledEvent) {
+		t.Errorf("reported %v, want ErrUnhandledEvent", ep)
+	}
+}
+
+func TestErrorEventRejectsOtherSignatures(t *testing.T) {
+	ee := NewEventEmitter()
+	if _, err := ee.On(ErrorEvent, "message", func(msg string) {}); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("subscribing a listener taking a string: error = %v, want ErrEventSignature", err)
+	}
+	if _, err := ee.On(ErrorEvent, "error", func(err error) {}); err != nil {
+		t.Errorf("subscribing a listener taking an error: %v, want it accepted", err)
+	}
+	if err := ee.Emit(ErrorEvent, errors.New("plain")); !errors.Is(err, ErrEventSignature) {
+		t.Errorf("emitting a plain error on ErrorEvent: error = %v, want ErrEventSignature", err)
+	}
+}
@@ -0,0 +1,95 @@
+package gonuts
+
+import (
+	"time"
+)
+
+// CancelFunc cancels a scheduled emission (see EmitAfter). It reports whether the emission was
+// still pending, i.e. it is now cancelled and will not happen. Calling it again returns false.
+type CancelFunc func() bool
+
+// EmitAfter schedules a one-shot emission of an event after delay. The emission behaves like
+// Emit on a timer goroutine; if it fails, the error is reported with EmitError (source
+// "eventemitter.scheduled"), or logged if the scheduled event is ErrorEvent itself.
+//
+// Close cancels all pending scheduled emissions and stops their timers; scheduling on a closed
+// emitter does nothing.
+//
+// Parameters:
+//   - delay: the time until the emission; <= 0 emits as soon as possible
+//   - event: the name of the event to emit
+//   - args: the arguments to pass to the event listeners
+//
+// Returns:
+//   - CancelFunc: cancels the emission if it has not happened yet
+//
+// Example usage:
+//
+//	cancel := emitter.EmitAfter(30*time.Minute, "cart.abandoned", cartID)
+//	// the customer checked out in time
+//	cancel()
+func (ee *EventEmitter) EmitAfter(delay time.Duration, event string, args ...interface{}) CancelFunc {
+	ee.mu.Lock()
+	defer ee.mu.Unlock()
+	if ee.closed {
+		return func() bool { return false }
+	}
+
+	ee.scheduledSeq++
+	id := ee.scheduledSeq
+	ee.scheduled[id] = time.AfterFunc(delay, func() {
+		ee.mu.Lock()
+		_, pending := ee.scheduled[id]
+		delete(ee.scheduled, id)
+		ee.mu.Unlock()
+		if !pending {
+			return // cancelled while the timer fired
+		}
+
+		if err := ee.Emit(event, args...); err != nil {
+			if event == ErrorEvent {
+				L.Errorf("[eventemitter] scheduled emission of %q failed: %v", event, err)
+				return
+			}
+			ee.EmitError("eventemitter.scheduled", err)
+		}
+	})
+
+	return func() bool {
+		ee.mu.Lock()
+		defer ee.mu.Unlock()
+		timer, pending := ee.scheduled[id]
+		if pending {
+			timer.Stop()
+			delete(ee.scheduled, id)
+		}
+		return pending
+	}
+}
+
+// EmitAt schedules a one-shot emission of an event at t, like EmitAfter. A time in the past
+// emits as soon as possible.
+//
+// Example usage:
+//
+//	cancel := emitter.EmitAt(subscription.RenewsAt.Add(-72*time.Hour), "subscription.renewalReminder", subscription.ID)
+//	defer cancel()
+func (ee *EventEmitter) EmitAt(t time.Time, event string, args ...interface{}) CancelFunc {
+	return ee.EmitAfter(time.Until(t), event, args...)
+}
+
+// PendingScheduled returns the number of scheduled emissions that have neither happened nor
+// been cancelled
+func (ee *EventEmitter) PendingScheduled() int {
+	ee.mu.RLock()
+	defer ee.mu.RUnlock()
+	return len(ee.scheduled)
+}
+
+// cancelScheduled stops all pending scheduled emissions; the caller must hold ee.mu
+func (ee *EventEmitter) cancelScheduled() {
+	for id, timer := range ee.scheduled {
+		timer.Stop()
+		delete(ee.scheduled, id)
+	}
+}
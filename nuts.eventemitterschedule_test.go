@@ -0,0 +1,148 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// scheduleRecorder subscribes to event and sends every delivered argument to the returned channel
+func scheduleRecorder(t *testing.T, ee *EventEmitter, event string) chan string {
+	t.Helper()
+	got := make(chan string, 16)
+	if _, err := ee.On(event, "recorder", func(s string) { got <- s }); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestEmitAfterCancel(t *testing.T) {
+	ee := NewEventEmitter()
+	got := scheduleRecorder(t, ee, "tick")
+
+	cancelled := ee.EmitAfter(50*time.Millisecond, "tick", "cancelled")
+	ee.EmitAfter(50*time.Millisecond, "tick", "kept")
+	if n := ee.PendingScheduled(); n != 2 {
+		t.Fatalf("PendingScheduled() = %d, want 2", n)
+	}
+	if !cancelled() {
+		t.Fatal("first cancel returned false for a pending emission")
+	}
+	if cancelled() {
+		t.Error("second cancel returned true")
+	}
+	if n := ee.PendingScheduled(); n != 1 {
+		t.Errorf("PendingScheduled() = %d after cancel, want 1", n)
+	}
+
+	select {
+	case s := <-got:
+		if s != "kept" {
+			t.Fatalf("delivered %q, want kept", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the scheduled emission did not happen")
+	}
+	select {
+	case s := <-got:
+		t.Fatalf("delivered %q after it was cancelled", s)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := ee.PendingScheduled(); n != 0 {
+		t.Errorf("PendingScheduled() = %d after the emission, want 0", n)
+	}
+}
+
+func TestEmitAfterCancelAfterEmission(t *testing.T) {
+	ee := NewEventEmitter()
+	got := scheduleRecorder(t, ee, "tick")
+
+	cancel := ee.EmitAt(time.Now().Add(-time.Hour), "tick", "past")
+	if s := <-got; s != "past" {
+		t.Fatalf("delivered %q, want past", s)
+	}
+	if cancel() {
+		t.Error("cancel returned true after the emission happened")
+	}
+	if n := ee.PendingScheduled(); n != 0 {
+		t.Errorf("PendingScheduled() = %d, want 0", n)
+	}
+}
+
+func TestEmitAtOrder(t *testing.T) {
+	ee := NewEventEmitter()
+	got := scheduleRecorder(t, ee, "tick")
+
+	now := time.Now()
+	ee.EmitAt(now.Add(60*time.Millisecond), "tick", "second")
+	ee.EmitAt(now.Add(20*time.Millisecond), "tick", "first")
+	for _, want := range []string{"first", "second"} {
+		select {
+		case s := <-got:
+			if s != want {
+				t.Fatalf("delivered %q, want %q", s, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%q was not delivered", want)
+		}
+	}
+	if elapsed := time.Since(now); elapsed < 60*time.Millisecond {
+		t.Errorf("both emissions happened after %s, want at the scheduled times", elapsed)
+	}
+}
+
+func TestCloseCancelsScheduled(t *testing.T) {
+	ee := NewEventEmitter()
+	got := scheduleRecorder(t, ee, "tick")
+
+	cancel := ee.EmitAfter(50*time.Millisecond, "tick", "pending")
+	if err := ee.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := ee.PendingScheduled(); n != 0 {
+		t.Errorf("PendingScheduled() = %d after Close, want 0", n)
+	}
+	if cancel() {
+		t.Error("cancel returned true after Close cancelled the emission")
+	}
+
+	// Scheduling on a closed emitter does nothing
+	cancel = ee.EmitAfter(0, "tick", "closed")
+	if n := ee.PendingScheduled(); n != 0 {
+		t.Errorf("PendingScheduled() = %d after scheduling on a closed emitter, want 0", n)
+	}
+	if cancel() {
+		t.Error("cancel returned true for an emission scheduled on a closed emitter")
+	}
+	select {
+	case s := <-got:
+		t.Fatalf("delivered %q after Close", s)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmitAfterReportsFailures(t *testing.T) {
+	ee := NewEventEmitter()
+	failure := errors.New("out of stock")
+	if _, err := ee.On("order.created", "reserveStock", func(string) error { return failure }); err != nil {
+		t.Fatal(err)
+	}
+	reported := make(chan *ErrorPlus, 1)
+	if _, err := ee.OnError("test", func(ep *ErrorPlus) { reported <- ep }); err != nil {
+		t.Fatal(err)
+	}
+
+	ee.EmitAfter(0, "order.created", "o-1")
+	select {
+	case ep := <-reported:
+		if !errors.Is(ep, failure) {
+			t.Errorf("reported %v, want it to wrap the listener error", ep)
+		}
+		if source, _ := ep.RevealContext("source"); source != "eventemitter.scheduled" {
+			t.Errorf("source = %v, want eventemitter.scheduled", source)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the failed scheduled emission was not reported")
+	}
+}
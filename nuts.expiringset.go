@@ -0,0 +1,147 @@
+package gonuts
+
+import (
+	"sync"
+	"time"
+)
+
+// ConcurrentExpiringSet is a thread-safe set whose items expire after a per-item TTL,
+// e.g. for "have I seen this ID in the last 10 minutes" deduplication.
+//
+// It is built on the sharded ConcurrentMap internals, storing the expiry time per item.
+// Expired items are invisible immediately and removed by a background cleanup that
+// visits one shard at a time. Because Go maps never shrink, a shard's map is rebuilt
+// when cleanup removed most of its entries, so memory follows the number of live items
+// rather than the peak.
+type ConcurrentExpiringSet[T comparable] struct {
+	items *ConcurrentMap[T, time.Time]
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// NewConcurrentExpiringSet creates a new ConcurrentExpiringSet and starts its background cleanup.
+// Call Close to stop the cleanup goroutine when the set is no longer needed.
+//
+// Parameters:
+//   - numShards: the number of shards (<= 0 uses the ConcurrentMap default)
+//   - cleanupInterval: how often expired items are removed (<= 0 defaults to one minute)
+//
+// Example:
+//
+//	seen := NewConcurrentExpiringSet[string](32, time.Minute)
+//	defer seen.Close()
+//	if !seen.Add(messageID, 10*time.Minute) {
+//	    return // duplicate
+//	}
+func NewConcurrentExpiringSet[T comparable](numShards int, cleanupInterval time.Duration) *ConcurrentExpiringSet[T] {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	s := &ConcurrentExpiringSet[T]{
+		items: NewConcurrentMap[T, time.Time](numShards),
+		stop:  make(chan struct{}),
+	}
+	go s.cleanupLoop(cleanupInterval)
+	return s
+}
+
+// Add adds an item that expires after ttl.
+//
+// Returns:
+//   - bool: false if the item already exists and has not expired (its expiry is left unchanged)
+func (s *ConcurrentExpiringSet[T]) Add(item T, ttl time.Duration) bool {
+	now := time.Now()
+	shard := s.items.lockShard(item)
+	if expiry, ok := shard.items[item]; ok && now.Before(expiry) {
+		shard.mu.Unlock()
+		return false
+	}
+	shard.items[item] = now.Add(ttl)
+	shardLen := len(shard.items)
+	shard.mu.Unlock()
+	s.items.afterInsert(shardLen)
+	return true
+}
+
+// Contains checks if an item exists and has not expired.
+func (s *ConcurrentExpiringSet[T]) Contains(item T) bool {
+	expiry, ok := s.items.Get(item)
+	return ok && time.Now().Before(expiry)
+}
+
+// Remove removes an item from the set.
+func (s *ConcurrentExpiringSet[T]) Remove(item T) {
+	s.items.Delete(item)
+}
+
+// Len returns the number of unexpired items. It visits every item, so it is O(n).
+func (s *ConcurrentExpiringSet[T]) Len() int {
+	now := time.Now()
+	count := 0
+	s.items.forEachShard(false, func(shard *mapShard[T, time.Time], _ int) bool {
+		for _, expiry := range shard.items {
+			if now.Before(expiry) {
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+// Cleanup removes all expired items. It runs automatically in the background.
+//
+// Returns:
+//   - int: the number of removed items
+func (s *ConcurrentExpiringSet[T]) Cleanup() int {
+	removed := 0
+	s.items.forEachShard(true, func(shard *mapShard[T, time.Time], _ int) bool {
+		removed += s.cleanupShard(shard)
+		return true
+	})
+	return removed
+}
+
+// Close stops the background cleanup. It is safe to call Close more than once.
+func (s *ConcurrentExpiringSet[T]) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *ConcurrentExpiringSet[T]) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Cleanup()
+		}
+	}
+}
+
+// cleanupShard removes expired items from a shard and rebuilds its map if it shrank considerably.
+// The caller holds the shard lock.
+func (s *ConcurrentExpiringSet[T]) cleanupShard(shard *mapShard[T, time.Time]) int {
+	now := time.Now()
+
+	removed := 0
+	for item, expiry := range shard.items {
+		if !now.Before(expiry) {
+			delete(shard.items, item)
+			removed++
+		}
+	}
+
+	// Go maps keep their buckets after deletes; reallocate when most entries are gone.
+	if removed >= 1024 && removed > 2*len(shard.items) {
+		items := make(map[T]time.Time, len(shard.items))
+		for item, expiry := range shard.items {
+			items[item] = expiry
+		}
+		shard.items = items
+	}
+	return removed
+}
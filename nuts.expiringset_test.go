@@ -0,0 +1,130 @@
+package gonuts
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentExpiringSetAddDeduplicates(t *testing.T) {
+	s := NewConcurrentExpiringSet[string](4, time.Hour)
+	defer s.Close()
+
+	if !s.Add("msg-1", 20*time.Millisecond) {
+		t.Fatal("first Add returned false")
+	}
+	if s.Add("msg-1", time.Hour) {
+		t.Error("Add of an unexpired item returned true")
+	}
+	s.Add("msg-2", time.Hour)
+	if !s.Contains("msg-1") || s.Len() != 2 {
+		t.Fatalf("Contains(msg-1) = %v, Len() = %d, want true and 2", s.Contains("msg-1"), s.Len())
+	}
+
+	// The failed Add left the expiry unchanged, so msg-1 expires after its first TTL
+	time.Sleep(30 * time.Millisecond)
+	if s.Contains("msg-1") || s.Len() != 1 {
+		t.Errorf("Contains(msg-1) = %v, Len() = %d after the TTL, want false and 1", s.Contains("msg-1"), s.Len())
+	}
+	if !s.Add("msg-1", time.Hour) {
+		t.Error("Add of an expired item returned false")
+	}
+
+	s.Remove("msg-1")
+	if s.Contains("msg-1") {
+		t.Error("Contains returned true after Remove")
+	}
+}
+
+func TestConcurrentExpiringSetConcurrentAdd(t *testing.T) {
+	const goroutines, items = 16, 500
+	s := NewConcurrentExpiringSet[int](8, time.Hour)
+	defer s.Close()
+
+	var added atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < items; i++ {
+				if s.Add(i, time.Hour) {
+					added.Add(1)
+				}
+				s.Contains(i)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := added.Load(); n != items {
+		t.Errorf("Add returned true %d times for %d items, want once per item", n, items)
+	}
+}
+
+func TestConcurrentExpiringSetCleanup(t *testing.T) {
+	s := NewConcurrentExpiringSet[int](1, 5*time.Millisecond)
+	defer s.Close()
+	s.Add(-1, time.Hour)
+	s.Add(-2, time.Millisecond)
+
+	// The background cleanup removes the expired item from memory
+	waitUntil(t, "the background cleanup", func() bool {
+		shard := s.items.rlockShard(-2)
+		defer shard.mu.RUnlock()
+		_, ok := shard.items[-2]
+		return !ok
+	})
+	if !s.Contains(-1) {
+		t.Error("the cleanup removed an unexpired item")
+	}
+}
+
+func TestConcurrentExpiringSetCleanupRebuildsShrunkShards(t *testing.T) {
+	s := NewConcurrentExpiringSet[int](1, time.Hour)
+	defer s.Close()
+	for i := 0; i < 5000; i++ {
+		s.Add(i, time.Millisecond)
+	}
+	s.Add(-1, time.Hour)
+	shard := s.items.rlockShard(-1)
+	before := reflect.ValueOf(shard.items).Pointer()
+	shard.mu.RUnlock()
+	time.Sleep(5 * time.Millisecond)
+
+	if n := s.Cleanup(); n != 5000 {
+		t.Fatalf("Cleanup() = %d, want 5000", n)
+	}
+	shard = s.items.rlockShard(-1)
+	after := reflect.ValueOf(shard.items).Pointer()
+	live := len(shard.items)
+	shard.mu.RUnlock()
+	if after == before {
+		t.Error("the shard map was not rebuilt after most of its items were removed")
+	}
+	if live != 1 || !s.Contains(-1) {
+		t.Errorf("%d items left after Cleanup, want only the unexpired one", live)
+	}
+
+	// Few removals keep the map
+	s.Add(-2, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	s.Cleanup()
+	shard = s.items.rlockShard(-1)
+	defer shard.mu.RUnlock()
+	if reflect.ValueOf(shard.items).Pointer() != after {
+		t.Error("the shard map was rebuilt after removing a single item")
+	}
+}
+
+func TestConcurrentExpiringSetClose(t *testing.T) {
+	s := NewConcurrentExpiringSet[int](1, time.Millisecond)
+	s.Close()
+	s.Close() // safe to call more than once
+	s.Add(1, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if n := s.Cleanup(); n != 1 {
+		t.Errorf("Cleanup() = %d after Close, want the item still in memory", n)
+	}
+}
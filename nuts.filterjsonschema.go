@@ -0,0 +1,135 @@
+package gonuts
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSchemaSource is returned by GenerateFilteredSchema for sources that are not structs
+var ErrSchemaSource = errors.New("schema source must be a struct or a pointer to a struct")
+
+var (
+	schemaTimeType          = reflect.TypeOf(time.Time{})
+	schemaJSONMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	schemaTextMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenerateFilteredSchema generates a JSON Schema of the JSON encoding of a struct, limited to the
+// fields that SelectJsonFields (keep) and RemoveJsonFields (remove) would leave in the output, so
+// the documentation of a filtered response matches the response. Fields are named by their json
+// tags like in encoding/json, and fields tagged `validate:"required"` are listed as required.
+// Nested structs produce nested object schemas; keep and remove apply to the top-level fields.
+//
+// Parameters:
+//   - source: a struct value or a pointer to a struct (a nil pointer is fine)
+//   - keep: the json names of the fields to keep, all fields if empty
+//   - remove: the json names of the fields to remove
+//
+// Returns:
+//   - map[string]interface{}: the schema, ready to be marshaled as JSON
+//   - error: ErrSchemaSource if source is not a struct
+//
+// Example usage:
+//
+//	type User struct {
+//		ID       string    `json:"id" validate:"required"`
+//		Email    string    `json:"email" validate:"required,email"`
+//		Password string    `json:"password"`
+//		Created  time.Time `json:"created"`
+//	}
+//	schema, err := gonuts.GenerateFilteredSchema((*User)(nil), nil, []string{"password"})
+//	// {"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "User", "type": "object",
+//	//  "properties": {"id": {"type": "string"}, "email": {"type": "string"},
+//	//                 "created": {"type": "string", "format": "date-time"}},
+//	//  "required": ["email", "id"]}
+func GenerateFilteredSchema(source any, keep, remove []string) (map[string]interface{}, error) {
+	t := reflect.TypeOf(source)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrSchemaSource, source)
+	}
+
+	include := func(name string) bool {
+		return (len(keep) == 0 || StringSliceContains(keep, name)) && !StringSliceContains(remove, name)
+	}
+	schema := structSchema(t, include, map[reflect.Type]bool{})
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if t.Name() != "" {
+		schema["title"] = t.Name()
+	}
+	return schema, nil
+}
+
+// structSchema returns the object schema of a struct type with the fields accepted by include
+func structSchema(t reflect.Type, include func(name string) bool, visiting map[reflect.Type]bool) map[string]interface{} {
+	if visiting[t] {
+		return map[string]interface{}{"type": "object"} // recursive type
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	for name, field := range cachedDecodeFields(t).byName {
+		if include != nil && !include(name) {
+			continue
+		}
+		sf := t.FieldByIndex(field.index)
+		properties[name] = typeSchema(sf.Type, visiting)
+		if validate, ok := sf.Tag.Lookup("validate"); ok && StringSliceContains(strings.Split(validate, ","), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// typeSchema returns the schema of the JSON encoding of a Go type
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == schemaTimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Implements(schemaJSONMarshalerType) || reflect.PointerTo(t).Implements(schemaJSONMarshalerType):
+		return map[string]interface{}{} // custom encoding, any value
+	case t.Implements(schemaTextMarshalerType) || reflect.PointerTo(t).Implements(schemaTextMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		return structSchema(t, nil, visiting)
+	}
+	return map[string]interface{}{} // interfaces and other kinds: any value
+}
@@ -0,0 +1,158 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type schemaTestAudit struct {
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type schemaTestCategory struct {
+	Name   string              `json:"name" validate:"required"`
+	Parent *schemaTestCategory `json:"parent,omitempty"`
+}
+
+type schemaTestProduct struct {
+	schemaTestAudit
+	ID       string             `json:"id" validate:"required"`
+	Price    float64            `json:"price" validate:"gte=0,required"`
+	Stock    *int               `json:"stock,omitempty"`
+	Active   bool               `json:"active"`
+	Tags     []string           `json:"tags"`
+	Image    []byte             `json:"image"`
+	Attrs    map[string]int     `json:"attrs"`
+	Category schemaTestCategory `json:"category"`
+	Address  net.IP             `json:"address"`
+	Raw      json.RawMessage    `json:"raw"`
+	Extra    interface{}        `json:"extra"`
+	Secret   string             `json:"-"`
+	internal string
+}
+
+// schemaPropertyNames returns the sorted property names of an object schema
+func schemaPropertyNames(schema map[string]interface{}) []string {
+	var names []string
+	for name := range schema["properties"].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGenerateFilteredSchema(t *testing.T) {
+	schema, err := GenerateFilteredSchema((*schemaTestProduct)(nil), nil, []string{"image", "raw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" || schema["title"] != "schemaTestProduct" || schema["type"] != "object" {
+		t.Errorf("schema header = %v, %v, %v", schema["$schema"], schema["title"], schema["type"])
+	}
+	if required := schema["required"]; !reflect.DeepEqual(required, []string{"id", "price"}) {
+		t.Errorf("required = %v, want [id price]", required)
+	}
+
+	category := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":   map[string]interface{}{"type": "string"},
+			"parent": map[string]interface{}{"type": "object"}, // the recursion stops
+		},
+		"required": []string{"name"},
+	}
+	want := map[string]interface{}{
+		"created_by": map[string]interface{}{"type": "string"},
+		"created_at": map[string]interface{}{"type": "string", "format": "date-time"},
+		"id":         map[string]interface{}{"type": "string"},
+		"price":      map[string]interface{}{"type": "number"},
+		"stock":      map[string]interface{}{"type": "integer"},
+		"active":     map[string]interface{}{"type": "boolean"},
+		"tags":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"attrs":      map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}},
+		"category":   category,
+		"address":    map[string]interface{}{"type": "string"},
+		"extra":      map[string]interface{}{},
+	}
+	properties := schema["properties"].(map[string]interface{})
+	for name, wantProperty := range want {
+		if !reflect.DeepEqual(properties[name], wantProperty) {
+			t.Errorf("property %s = %v, want %v", name, properties[name], wantProperty)
+		}
+	}
+	if len(properties) != len(want) {
+		t.Errorf("properties = %v, want only %d", schemaPropertyNames(schema), len(want))
+	}
+
+	// The schema marshals to JSON
+	if _, err := json.Marshal(schema); err != nil {
+		t.Errorf("json.Marshal(schema) = %v", err)
+	}
+
+	// Unfiltered, []byte is a base64 string and json.RawMessage any value
+	schema, _ = GenerateFilteredSchema(schemaTestProduct{}, nil, nil)
+	properties = schema["properties"].(map[string]interface{})
+	if image := properties["image"]; !reflect.DeepEqual(image, map[string]interface{}{"type": "string", "contentEncoding": "base64"}) {
+		t.Errorf("property image = %v", image)
+	}
+	if raw := properties["raw"]; !reflect.DeepEqual(raw, map[string]interface{}{}) {
+		t.Errorf("property raw = %v, want any value", raw)
+	}
+}
+
+// TestGenerateFilteredSchemaMatchesFilteredJSON checks that the schema describes exactly the
+// fields SelectJsonFields and RemoveJsonFields leave in the output
+func TestGenerateFilteredSchemaMatchesFilteredJSON(t *testing.T) {
+	stock := 3
+	product := schemaTestProduct{ID: "p-1", Stock: &stock, Secret: "hidden"}
+	tests := []struct {
+		name         string
+		keep, remove []string
+		filtered     func() (string, error)
+	}{
+		{"select", []string{"id", "price", "created_by", "secret", "unknown"}, nil, func() (string, error) {
+			return SelectJsonFields(product, []string{"id", "price", "created_by", "secret", "unknown"})
+		}},
+		{"remove", nil, []string{"tags", "category", "created_at", "extra"}, func() (string, error) {
+			return RemoveJsonFields(product, []string{"tags", "category", "created_at", "extra"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := GenerateFilteredSchema(&product, tt.keep, tt.remove)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filtered, err := tt.filtered()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output map[string]interface{}
+			if err := json.Unmarshal([]byte(filtered), &output); err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for key := range output {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if names := schemaPropertyNames(schema); !reflect.DeepEqual(names, keys) {
+				t.Errorf("schema properties = %v, filtered JSON keys = %v", names, keys)
+			}
+		})
+	}
+}
+
+func TestGenerateFilteredSchemaRejectsNonStructs(t *testing.T) {
+	for _, source := range []any{nil, 42, "text", []schemaTestProduct{}, new(*int)} {
+		if _, err := GenerateFilteredSchema(source, nil, nil); !errors.Is(err, ErrSchemaSource) {
+			t.Errorf("GenerateFilteredSchema(%T) error = %v, want ErrSchemaSource", source, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package gonuts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GoroutineSnapshot is a snapshot of the goroutines of the process, grouped by creation site
+type GoroutineSnapshot struct {
+	Taken  time.Time
+	Count  int
+	Groups []GoroutineGroup // sorted by descending Count
+}
+
+// GoroutineGroup holds the goroutines started by the same go statement
+type GoroutineGroup struct {
+	// CreatedBy is the function and location of the go statement, e.g.
+	// "net/http.(*Server).Serve at /usr/local/go/src/net/http/server.go:3285", or the function at
+	// the bottom of the stack for goroutines without a creator (like the main goroutine).
+	CreatedBy string
+	Count     int
+	States    map[string]int // number of goroutines per wait state, e.g. "chan receive"
+	Stack     string         // the stack of one goroutine of the group
+}
+
+// GoroutineDelta is the change of a creation site between two snapshots
+type GoroutineDelta struct {
+	CreatedBy string
+	Before    int
+	After     int
+	Growth    int    // After - Before
+	Stack     string // the stack of one goroutine of the group in the later snapshot
+}
+
+// CaptureGoroutineProfile takes a snapshot of all goroutines from the runtime/pprof goroutine
+// profile. It stops the world briefly, like runtime.Stack, so do not call it in hot paths.
+//
+// Example usage:
+//
+//	snapshot := gonuts.CaptureGoroutineProfile()
+//	for _, group := range snapshot.Groups[:3] {
+//		L.Infof("%d goroutines created by %s", group.Count, group.CreatedBy)
+//	}
+func CaptureGoroutineProfile() GoroutineSnapshot {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	snapshot := ParseGoroutineProfile(buf.Bytes())
+	snapshot.Taken = time.Now()
+	return snapshot
+}
+
+// ParseGoroutineProfile parses a goroutine dump in the format of the runtime/pprof goroutine
+// profile with debug=2, which is also the format of runtime.Stack(buf, true) and of the dump of
+// an unrecovered panic. Unknown lines are skipped, so dumps of different Go versions parse alike.
+func ParseGoroutineProfile(data []byte) GoroutineSnapshot {
+	groups := make(map[string]*GoroutineGroup)
+	var snapshot GoroutineSnapshot
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var block []string
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		state, createdBy, stack, ok := parseGoroutineBlock(block)
+		block = block[:0]
+		if !ok {
+			return
+		}
+		snapshot.Count++
+		group, exists := groups[createdBy]
+		if !exists {
+			group = &GoroutineGroup{CreatedBy: createdBy, States: make(map[string]int), Stack: stack}
+			groups[createdBy] = group
+		}
+		group.Count++
+		group.States[state]++
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "goroutine ") {
+			flush()
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	snapshot.Groups = make([]GoroutineGroup, 0, len(groups))
+	for _, group := range groups {
+		snapshot.Groups = append(snapshot.Groups, *group)
+	}
+	sort.Slice(snapshot.Groups, func(i, j int) bool {
+		a, b := snapshot.Groups[i], snapshot.Groups[j]
+		return a.Count > b.Count || (a.Count == b.Count && a.CreatedBy < b.CreatedBy)
+	})
+	return snapshot
+}
+
+// parseGoroutineBlock parses the lines of one goroutine:
+//
+//	goroutine 7 [chan receive, 2 minutes]:
+//	main.worker(...)
+//		/app/main.go:20 +0x3d
+//	created by main.main in goroutine 1
+//		/app/main.go:12 +0x5c
+func parseGoroutineBlock(lines []string) (state, createdBy, stack string, ok bool) {
+	header := lines[0]
+	if !strings.HasPrefix(header, "goroutine ") {
+		return "", "", "", false
+	}
+	if start, end := strings.Index(header, "["), strings.LastIndex(header, "]"); start >= 0 && end > start {
+		state, _, _ = strings.Cut(header[start+1:end], ",")
+	}
+
+	var frames []string
+	lastFunc := ""
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		if rest, found := strings.CutPrefix(line, "created by "); found {
+			// "created by pkg.fn in goroutine 1" since Go 1.21, "created by pkg.fn" before
+			fn, _, _ := strings.Cut(rest, " in goroutine ")
+			createdBy = strings.TrimSpace(fn)
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				createdBy += " at " + trimFrameOffset(lines[i+1])
+			}
+			break
+		}
+		frames = append(frames, line)
+		if !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "...") {
+			lastFunc = line
+		}
+	}
+	if createdBy == "" {
+		// no creator: the main goroutine or a runtime goroutine
+		createdBy = lastFunc
+		if i := strings.LastIndex(lastFunc, "("); i > 0 {
+			createdBy = lastFunc[:i]
+		}
+	}
+	return state, createdBy, strings.Join(frames, "\n"), true
+}
+
+// trimFrameOffset turns "\t/app/main.go:12 +0x5c" into "/app/main.go:12"
+func trimFrameOffset(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.LastIndex(line, " +0x"); i > 0 {
+		line = line[:i]
+	}
+	return line
+}
+
+// DiffGoroutineSnapshots returns the creation sites whose number of goroutines grew from a to b,
+// sorted by descending growth.
+//
+// Example usage:
+//
+//	before := gonuts.CaptureGoroutineProfile()
+//	runLoadTest()
+//	for _, delta := range gonuts.DiffGoroutineSnapshots(before, gonuts.CaptureGoroutineProfile()) {
+//		L.Warnf("+%d goroutines created by %s", delta.Growth, delta.CreatedBy)
+//	}
+func DiffGoroutineSnapshots(a, b GoroutineSnapshot) []GoroutineDelta {
+	before := make(map[string]int, len(a.Groups))
+	for _, group := range a.Groups {
+		before[group.CreatedBy] = group.Count
+	}
+	deltas := []GoroutineDelta{}
+	for _, group := range b.Groups {
+		if growth := group.Count - before[group.CreatedBy]; growth > 0 {
+			deltas = append(deltas, GoroutineDelta{
+				CreatedBy: group.CreatedBy,
+				Before:    before[group.CreatedBy],
+				After:     group.Count,
+				Growth:    growth,
+				Stack:     group.Stack,
+			})
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return deltas[i].Growth > deltas[j].Growth
+	})
+	return deltas
+}
+
+// WatchGoroutines captures a goroutine snapshot every interval and calls onLeak with the creation
+// sites that grew by at least growthThreshold goroutines since the baseline. The baseline is the
+// first snapshot, taken one interval after the call, and it is replaced by the current snapshot
+// after every report, so onLeak only hears about further growth. Watching stops when ctx is done.
+//
+// Example usage:
+//
+//	gonuts.WatchGoroutines(ctx, time.Minute, 100, func(deltas []gonuts.GoroutineDelta) {
+//		for _, delta := range deltas {
+//			L.Warnf("goroutine leak? +%d created by %s\n%s", delta.Growth, delta.CreatedBy, delta.Stack)
+//		}
+//	})
+func WatchGoroutines(ctx context.Context, interval time.Duration, growthThreshold int, onLeak func([]GoroutineDelta)) {
+	if growthThreshold < 1 {
+		growthThreshold = 1
+	}
+	var baseline *GoroutineSnapshot
+	iv := Interval(func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		current := CaptureGoroutineProfile()
+		if baseline == nil {
+			baseline = &current
+			return true
+		}
+		var leaks []GoroutineDelta
+		for _, delta := range DiffGoroutineSnapshots(*baseline, current) {
+			if delta.Growth >= growthThreshold {
+				leaks = append(leaks, delta)
+			}
+		}
+		if len(leaks) > 0 {
+			onLeak(leaks)
+			baseline = &current
+		}
+		return true
+	}, interval, false)
+
+	go func() {
+		<-ctx.Done()
+		iv.Stop()
+	}()
+}
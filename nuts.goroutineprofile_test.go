@@ -0,0 +1,176 @@
+package gonuts
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const goroutineProfileTestDump = `goroutine 1 [running]:
+main.main()
+	/app/main.go:30 +0x1d
+
+goroutine 7 [chan receive, 2 minutes]:
+main.worker(0xc000010000)
+	/app/main.go:20 +0x3d
+created by main.main in goroutine 1
+	/app/main.go:12 +0x5c
+
+goroutine 8 [chan receive]:
+main.worker(0xc000010008)
+	/app/main.go:20 +0x3d
+created by main.main in goroutine 1
+	/app/main.go:12 +0x5c
+
+goroutine 9 [select]:
+main.worker(0xc000010010)
+	/app/main.go:22 +0x51
+created by main.main in goroutine 1
+	/app/main.go:12 +0x5c
+goroutine 12 [IO wait]:
+internal/poll.runtime_pollWait(0x7f, 0x72)
+	/usr/local/go/src/runtime/netpoll.go:351 +0x85
+...additional frames elided...
+created by net/http.(*Server).Serve
+	/usr/local/go/src/net/http/server.go:3285 +0x4b4
+
+garbage line
+`
+
+func TestParseGoroutineProfile(t *testing.T) {
+	snapshot := ParseGoroutineProfile([]byte(goroutineProfileTestDump))
+	if snapshot.Count != 5 || len(snapshot.Groups) != 3 {
+		t.Fatalf("parsed %d goroutines in %d groups, want 5 in 3", snapshot.Count, len(snapshot.Groups))
+	}
+
+	workers := snapshot.Groups[0]
+	if workers.CreatedBy != "main.main at /app/main.go:12" || workers.Count != 3 {
+		t.Errorf("largest group = %s with %d goroutines", workers.CreatedBy, workers.Count)
+	}
+	if workers.States["chan receive"] != 2 || workers.States["select"] != 1 {
+		t.Errorf("worker states = %v", workers.States)
+	}
+	if workers.Stack != "main.worker(0xc000010000)\n\t/app/main.go:20 +0x3d" {
+		t.Errorf("worker stack = %q, want the frames of the first goroutine without the creator", workers.Stack)
+	}
+
+	// Groups of the same size are sorted by creation site
+	if snapshot.Groups[1].CreatedBy != "main.main" || snapshot.Groups[1].States["running"] != 1 {
+		t.Errorf("main goroutine group = %+v, want it named after its bottom frame", snapshot.Groups[1])
+	}
+	if server := snapshot.Groups[2]; server.CreatedBy != "net/http.(*Server).Serve at /usr/local/go/src/net/http/server.go:3285" || server.States["IO wait"] != 1 {
+		t.Errorf("server goroutine group = %+v", server)
+	}
+
+	if empty := ParseGoroutineProfile(nil); empty.Count != 0 || len(empty.Groups) != 0 {
+		t.Errorf("ParseGoroutineProfile(nil) = %+v", empty)
+	}
+}
+
+// goroutineProfileTestLeak starts n goroutines that block until release is closed
+func goroutineProfileTestLeak(n int, release <-chan struct{}, wg *sync.WaitGroup) {
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+}
+
+// goroutineProfileTestDelta returns the delta of the goroutines started by goroutineProfileTestLeak
+func goroutineProfileTestDelta(deltas []GoroutineDelta) (GoroutineDelta, bool) {
+	for _, delta := range deltas {
+		if strings.HasPrefix(delta.CreatedBy, "github.com/vaudience/go-nuts.goroutineProfileTestLeak at ") {
+			return delta, true
+		}
+	}
+	return GoroutineDelta{}, false
+}
+
+func TestDiffGoroutineSnapshots(t *testing.T) {
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		wg.Wait()
+	}()
+
+	before := CaptureGoroutineProfile()
+	goroutineProfileTestLeak(5, release, &wg)
+	after := CaptureGoroutineProfile()
+	if after.Count < before.Count+5 || after.Taken.Before(before.Taken) {
+		t.Errorf("snapshots counted %d and then %d goroutines", before.Count, after.Count)
+	}
+
+	delta, ok := goroutineProfileTestDelta(DiffGoroutineSnapshots(before, after))
+	if !ok || delta.Before != 0 || delta.After != 5 || delta.Growth != 5 {
+		t.Fatalf("delta of the leaking site = %+v, %v", delta, ok)
+	}
+	if !strings.Contains(delta.CreatedBy, "nuts.goroutineprofile_test.go:") || !strings.Contains(delta.Stack, "goroutineProfileTestLeak.func1") {
+		t.Errorf("delta = %s\n%s", delta.CreatedBy, delta.Stack)
+	}
+	if _, ok := goroutineProfileTestDelta(DiffGoroutineSnapshots(after, before)); ok {
+		t.Error("a shrinking creation site is reported")
+	}
+
+	deltas := DiffGoroutineSnapshots(
+		GoroutineSnapshot{Groups: []GoroutineGroup{{CreatedBy: "a", Count: 4}, {CreatedBy: "b", Count: 1}}},
+		GoroutineSnapshot{Groups: []GoroutineGroup{{CreatedBy: "a", Count: 6}, {CreatedBy: "b", Count: 1}, {CreatedBy: "c", Count: 3}}},
+	)
+	if len(deltas) != 2 || deltas[0].CreatedBy != "c" || deltas[1].CreatedBy != "a" || deltas[1].Before != 4 {
+		t.Errorf("deltas = %+v, want c and a sorted by growth", deltas)
+	}
+}
+
+func TestWatchGoroutines(t *testing.T) {
+	var wg sync.WaitGroup
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		wg.Wait()
+	}()
+
+	reports := make(chan GoroutineDelta, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	WatchGoroutines(ctx, 2*time.Millisecond, 3, func(deltas []GoroutineDelta) {
+		if delta, ok := goroutineProfileTestDelta(deltas); ok {
+			reports <- delta
+		}
+	})
+	time.Sleep(20 * time.Millisecond) // the baseline is taken on the first tick
+
+	goroutineProfileTestLeak(2, release, &wg)
+	time.Sleep(20 * time.Millisecond)
+	if len(reports) != 0 {
+		t.Fatal("growth below the threshold was reported")
+	}
+
+	goroutineProfileTestLeak(2, release, &wg)
+	select {
+	case delta := <-reports:
+		if delta.Growth != 4 || delta.After != 4 {
+			t.Errorf("reported delta = %+v, want the growth of 4 since the baseline", delta)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the leak was not reported")
+	}
+
+	// The report became the new baseline
+	goroutineProfileTestLeak(1, release, &wg)
+	time.Sleep(20 * time.Millisecond)
+	if len(reports) != 0 {
+		t.Errorf("reported again without new growth: %+v", <-reports)
+	}
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	goroutineProfileTestLeak(5, release, &wg)
+	time.Sleep(20 * time.Millisecond)
+	if len(reports) != 0 {
+		t.Error("reported after ctx was cancelled")
+	}
+}
@@ -3,9 +3,12 @@ package gonuts
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
@@ -24,6 +27,8 @@ var (
 	ErrIllegalId   = errors.New("illegal id")
 	ErrUnknownId   = errors.New("unknown id")
 	ErrMalformedId = errors.New("malformed id")
+
+	ErrIdTypeRegistered = errors.New("id type already registered")
 )
 
 // NanoID generates a unique ID with a given prefix.
@@ -144,3 +149,122 @@ func GenerateRandomString(chars []rune, length int) string {
 	}
 	return string(b)
 }
+
+// idTypeRegistry maps registered ID type prefixes to the length of their random part.
+var idTypeRegistry = struct {
+	mu      sync.RWMutex
+	lengths map[string]int
+}{lengths: make(map[string]int)}
+
+// RegisterIDType registers an ID type with its prefix and the length of its random part.
+// Registration is safe at init time and at runtime.
+//
+// Parameters:
+//   - prefix: The type name, used as ID prefix (e.g. "usr"). It must consist of legal ID characters and must not contain the prefix separator.
+//   - length: The length of the random part of the ID.
+//
+// Returns:
+//   - ErrBadId if prefix or length are invalid, ErrIdTypeRegistered if the prefix is already registered.
+//
+// Example usage:
+//
+//	func init() {
+//		_ = gonuts.RegisterIDType("usr", 12)
+//		_ = gonuts.RegisterIDType("org", 8)
+//	}
+func RegisterIDType(prefix string, length int) error {
+	if prefix == "" || NotLegalIdCharacters.MatchString(prefix) || strings.Contains(prefix, NID_Prefix_Separator) {
+		return fmt.Errorf("%w: illegal id type prefix %q", ErrBadId, prefix)
+	}
+	if length <= 0 {
+		return fmt.Errorf("%w: id length must be positive, got %d", ErrBadId, length)
+	}
+
+	idTypeRegistry.mu.Lock()
+	defer idTypeRegistry.mu.Unlock()
+	if _, exists := idTypeRegistry.lengths[prefix]; exists {
+		return fmt.Errorf("%w: %s", ErrIdTypeRegistered, prefix)
+	}
+	idTypeRegistry.lengths[prefix] = length
+	return nil
+}
+
+// NewID generates a new ID for a registered ID type.
+//
+// Returns:
+//   - ErrUnknownId if the type is not registered.
+//
+// Example usage:
+//
+//	id, err := gonuts.NewID("usr")
+//	fmt.Println(id) // Output: usr_6ByTSYmGzT2c
+func NewID(typeName string) (string, error) {
+	length, ok := registeredIDLength(typeName)
+	if !ok {
+		return "", fmt.Errorf("%w: id type %q is not registered", ErrUnknownId, typeName)
+	}
+	return NID(typeName, length), nil
+}
+
+// ParseTypedID validates an ID against the registry and returns its type name.
+//
+// Returns:
+//   - ErrMalformedId if the ID has no prefix or its random part has the wrong length or characters,
+//     ErrUnknownId if the prefix is not registered.
+//
+// Example usage:
+//
+//	typeName, err := gonuts.ParseTypedID("usr_6ByTSYmGzT2c")
+//	fmt.Println(typeName) // Output: usr
+func ParseTypedID(id string) (typeName string, err error) {
+	idx := strings.LastIndex(id, NID_Prefix_Separator)
+	if idx <= 0 {
+		return "", fmt.Errorf("%w: missing type prefix in %q", ErrMalformedId, id)
+	}
+	typeName, random := id[:idx], id[idx+len(NID_Prefix_Separator):]
+
+	length, ok := registeredIDLength(typeName)
+	if !ok {
+		return "", fmt.Errorf("%w: id type %q is not registered", ErrUnknownId, typeName)
+	}
+	if len(random) != length {
+		return "", fmt.Errorf("%w: expected %d characters after prefix %q, got %d", ErrMalformedId, length, typeName, len(random))
+	}
+	for _, ch := range random {
+		if !strings.ContainsRune(idAlphabet, ch) {
+			return "", fmt.Errorf("%w: illegal character %q in %q", ErrMalformedId, ch, id)
+		}
+	}
+	return typeName, nil
+}
+
+// AssertIDType checks that an ID is valid and belongs to the given registered type.
+//
+// Returns:
+//   - ErrUnknownId or ErrMalformedId as returned by ParseTypedID, or ErrIllegalId if the ID belongs to a different type.
+//
+// Example usage:
+//
+//	if err := gonuts.AssertIDType(orgID, "org"); err != nil {
+//		return err
+//	}
+func AssertIDType(id, typeName string) error {
+	if _, ok := registeredIDLength(typeName); !ok {
+		return fmt.Errorf("%w: id type %q is not registered", ErrUnknownId, typeName)
+	}
+	parsedType, err := ParseTypedID(id)
+	if err != nil {
+		return err
+	}
+	if parsedType != typeName {
+		return fmt.Errorf("%w: expected id of type %q, got %q", ErrIllegalId, typeName, parsedType)
+	}
+	return nil
+}
+
+func registeredIDLength(typeName string) (int, bool) {
+	idTypeRegistry.mu.RLock()
+	defer idTypeRegistry.mu.RUnlock()
+	length, ok := idTypeRegistry.lengths[typeName]
+	return length, ok
+}
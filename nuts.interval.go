@@ -1,6 +1,9 @@
 package gonuts
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 /*
 	intervalChannel := Interval(time.Duration(time.Second*1), func() { nuts.L.Debugf("tick ", time.Now()) }, true)
@@ -11,50 +14,37 @@ import "time"
 // the call function can trigger a stop of the timer by returning false instead of true
 func Interval(call func() bool, duration time.Duration, runImmediately bool) *GoInterval {
 	var iv GoInterval = GoInterval{
-		tickDuration:     duration,
-		call:             call,
-		cancelChan:       make(chan bool),
-		callHasCancelled: false,
+		tickDuration: duration,
+		call:         call,
 	}
 	iv.Start(duration, runImmediately)
 	return &iv
 }
 
 type GoInterval struct {
+	mu               sync.Mutex
 	active           bool
 	callHasCancelled bool
 	tickDuration     time.Duration
 	call             func() bool
 	ticker           *time.Ticker
-	cancelChan       chan (bool)
+	cancelChan       chan (bool)   // closed by Stop, one per Start
+	done             chan struct{} // closed when the goroutine of the current Start returns
 }
 
+// Start (re)starts the interval with the given duration, stopping a running one first.
 func (iv *GoInterval) Start(duration time.Duration, runImmediately bool) *GoInterval {
-	if iv.ticker != nil {
-		iv.ticker.Stop()
-	}
+	iv.Stop()
+	iv.mu.Lock()
 	iv.tickDuration = duration
 	iv.ticker = time.NewTicker(iv.tickDuration)
+	iv.cancelChan = make(chan bool)
+	iv.done = make(chan struct{})
 	iv.active = true
-	go func() {
-		for {
-			select {
-			case <-iv.cancelChan:
-				return
-			case <-iv.ticker.C:
-				if iv.call != nil {
-					if !iv.call() {
-						if iv.active {
-							iv.callHasCancelled = true
-							iv.Stop()
-						}
-						return
-					}
-				}
-			}
-		}
-	}()
-	if runImmediately {
+	iv.callHasCancelled = false
+	go iv.run(iv.ticker, iv.cancelChan, iv.done)
+	iv.mu.Unlock()
+	if runImmediately && iv.call != nil {
 		if !iv.call() {
 			iv.Stop()
 		}
@@ -62,19 +52,48 @@ func (iv *GoInterval) Start(duration time.Duration, runImmediately bool) *GoInte
 	return iv
 }
 
+func (iv *GoInterval) run(ticker *time.Ticker, cancel chan bool, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-cancel:
+			return
+		case <-ticker.C:
+			if iv.call != nil {
+				if !iv.call() {
+					iv.mu.Lock()
+					if iv.active && iv.cancelChan == cancel {
+						iv.callHasCancelled = true
+						iv.active = false
+						ticker.Stop()
+					}
+					iv.mu.Unlock()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stop stops the interval and waits until a call that is running has returned, so it must not
+// be called from within the call (return false instead).
 func (iv *GoInterval) Stop() *GoInterval {
-	if !iv.active {
-		return iv
+	iv.mu.Lock()
+	if iv.active {
+		iv.ticker.Stop()
+		close(iv.cancelChan)
+		iv.active = false
 	}
-	iv.ticker.Stop()
-	if !iv.callHasCancelled {
-		iv.cancelChan <- true
+	done := iv.done
+	iv.mu.Unlock()
+	if done != nil {
+		<-done
 	}
-	iv.ticker.Reset(iv.tickDuration)
-	iv.active = false
 	return iv
 }
 
 func (iv *GoInterval) State() bool {
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
 	return iv.active
 }
@@ -0,0 +1,226 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrIntervalExists is returned by IntervalGroup.Add for a name that is already in use
+	ErrIntervalExists = errors.New("interval already exists")
+	// ErrIntervalGroupStopped is returned by IntervalGroup.Add after StopAll
+	ErrIntervalGroupStopped = errors.New("interval group stopped")
+	// ErrIntervalPanic is wrapped by the errors reported for panicking interval calls
+	ErrIntervalPanic = errors.New("interval call panicked")
+)
+
+// IntervalStatus describes an interval of an IntervalGroup
+type IntervalStatus struct {
+	Active              bool          // false once the call returned false or the group was stopped
+	LastRun             time.Time     // start of the last (or running) call, zero if it never ran
+	LastDuration        time.Duration // duration of the last completed call
+	ConsecutiveFailures int           // panics since the last call that returned normally
+}
+
+// IntervalGroup manages named intervals (see Interval) so they can be inspected together and
+// stopped in one place on shutdown. Panics of the calls are recovered and reported to the error
+// handler; the interval keeps running.
+//
+// Example usage:
+//
+//	group := gonuts.NewIntervalGroup().OnError(func(name string, err error) {
+//		gonuts.L.Errorf("interval %s: %v", name, err)
+//	})
+//	group.Add("refresh-cache", refreshCache, time.Minute, true)
+//	group.Add("report-metrics", reportMetrics, 10*time.Second, false)
+//
+//	<-shutdown
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := group.StopAll(ctx); err != nil {
+//		gonuts.L.Warnf("shutdown: %v", err)
+//	}
+type IntervalGroup struct {
+	mu        sync.Mutex
+	intervals map[string]*groupInterval
+	onError   func(name string, err error)
+	stopped   bool
+}
+
+type groupInterval struct {
+	iv     *GoInterval
+	mu     sync.Mutex
+	status IntervalStatus
+}
+
+// NewIntervalGroup creates an empty IntervalGroup. Errors are logged until OnError is set.
+func NewIntervalGroup() *IntervalGroup {
+	return &IntervalGroup{intervals: make(map[string]*groupInterval)}
+}
+
+// OnError sets the handler for panics of interval calls. The error wraps ErrIntervalPanic and
+// is an *ErrorPlus with the stack trace of the panic. The handler is called from the goroutine
+// of the interval.
+//
+// Returns:
+//   - *IntervalGroup: the group for method chaining
+func (g *IntervalGroup) OnError(handler func(name string, err error)) *IntervalGroup {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onError = handler
+	return g
+}
+
+// Add starts a named interval, like Interval. The call stops the interval by returning false.
+//
+// Parameters:
+//   - name: the unique name of the interval
+//   - call: the function to run on every tick
+//   - d: the time between ticks
+//   - runImmediately: run the call once in Add before the first tick
+//
+// Returns:
+//   - error: ErrIntervalExists or ErrIntervalGroupStopped
+func (g *IntervalGroup) Add(name string, call func() bool, d time.Duration, runImmediately bool) error {
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return ErrIntervalGroupStopped
+	}
+	if _, exists := g.intervals[name]; exists {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrIntervalExists, name)
+	}
+	entry := &groupInterval{}
+	g.intervals[name] = entry
+	g.mu.Unlock()
+
+	iv := Interval(func() bool { return g.run(name, entry, call) }, d, runImmediately)
+
+	g.mu.Lock()
+	entry.mu.Lock()
+	entry.iv = iv
+	entry.mu.Unlock()
+	stopped := g.stopped
+	g.mu.Unlock()
+	if stopped {
+		iv.Stop()
+	}
+	return nil
+}
+
+// StopAll stops all intervals and waits until their running calls have returned, or until ctx is
+// done. Intervals cannot be added afterwards.
+//
+// Returns:
+//   - error: nil, or the error of ctx listing the intervals whose calls were still running
+func (g *IntervalGroup) StopAll(ctx context.Context) error {
+	g.mu.Lock()
+	g.stopped = true
+	running := make(map[string]*GoInterval, len(g.intervals))
+	for name, entry := range g.intervals {
+		entry.mu.Lock()
+		if entry.iv != nil {
+			running[name] = entry.iv
+		}
+		entry.mu.Unlock()
+	}
+	g.mu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	pending := make(map[string]bool, len(running))
+	for name := range running {
+		pending[name] = true
+	}
+	for name, iv := range running {
+		wg.Add(1)
+		go func(name string, iv *GoInterval) {
+			defer wg.Done()
+			iv.Stop()
+			mu.Lock()
+			delete(pending, name)
+			mu.Unlock()
+		}(name, iv)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		mu.Lock()
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("%w: intervals still running: %s", ctx.Err(), strings.Join(names, ", "))
+	}
+}
+
+// Status returns the status of every interval by name
+func (g *IntervalGroup) Status() map[string]IntervalStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	statuses := make(map[string]IntervalStatus, len(g.intervals))
+	for name, entry := range g.intervals {
+		entry.mu.Lock()
+		status := entry.status
+		iv := entry.iv
+		entry.mu.Unlock()
+		status.Active = iv == nil || iv.State()
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// run calls the function of an interval, recording its status and recovering panics
+func (g *IntervalGroup) run(name string, entry *groupInterval, call func() bool) bool {
+	start := time.Now()
+	entry.mu.Lock()
+	entry.status.LastRun = start
+	entry.mu.Unlock()
+	next, err := callRecovered(name, call)
+
+	entry.mu.Lock()
+	entry.status.LastDuration = time.Since(start)
+	if err != nil {
+		entry.status.ConsecutiveFailures++
+	} else {
+		entry.status.ConsecutiveFailures = 0
+	}
+	entry.mu.Unlock()
+
+	if err != nil {
+		g.mu.Lock()
+		onError := g.onError
+		g.mu.Unlock()
+		if onError != nil {
+			onError(name, err)
+		} else {
+			L.Errorf("[intervalgroup] %v", err)
+		}
+		return true
+	}
+	return next
+}
+
+func callRecovered(name string, call func() bool) (next bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewErrorPlus(fmt.Errorf("%w: %v", ErrIntervalPanic, r), "interval "+name+" panicked", 500)
+		}
+	}()
+	return call(), nil
+}
@@ -0,0 +1,126 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIntervalGroupStopAll(t *testing.T) {
+	group := NewIntervalGroup()
+	var refreshes, reports atomic.Int32
+	if err := group.Add("refresh", func() bool { refreshes.Add(1); return true }, 2*time.Millisecond, true); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes.Load() != 1 {
+		t.Error("runImmediately did not run the call in Add")
+	}
+	if err := group.Add("report", func() bool { reports.Add(1); return true }, 2*time.Millisecond, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := group.Add("report", func() bool { return true }, time.Second, false); !errors.Is(err, ErrIntervalExists) {
+		t.Errorf("Add of an existing name = %v, want ErrIntervalExists", err)
+	}
+	waitUntil(t, "both intervals to tick", func() bool { return refreshes.Load() > 2 && reports.Load() > 1 })
+
+	if err := group.StopAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stoppedAt := refreshes.Load() + reports.Load()
+	time.Sleep(10 * time.Millisecond)
+	if n := refreshes.Load() + reports.Load(); n != stoppedAt {
+		t.Errorf("%d calls after StopAll returned", n-stoppedAt)
+	}
+	for name, status := range group.Status() {
+		if status.Active || status.LastRun.IsZero() {
+			t.Errorf("status of %s after StopAll = %+v", name, status)
+		}
+	}
+	if err := group.Add("late", func() bool { return true }, time.Second, true); !errors.Is(err, ErrIntervalGroupStopped) {
+		t.Errorf("Add after StopAll = %v, want ErrIntervalGroupStopped", err)
+	}
+}
+
+func TestIntervalGroupStopAllTimesOut(t *testing.T) {
+	group := NewIntervalGroup()
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	group.Add("slow", func() bool {
+		once.Do(func() { close(started) })
+		<-release
+		return true
+	}, time.Millisecond, false)
+	group.Add("fast", func() bool { return true }, time.Millisecond, false)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := group.StopAll(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.HasSuffix(err.Error(), "intervals still running: slow") {
+		t.Errorf("StopAll = %v, want the deadline error naming only the slow interval", err)
+	}
+
+	// Once the call returns, the interval is stopped
+	close(release)
+	if err := group.StopAll(context.Background()); err != nil {
+		t.Errorf("StopAll after the call returned = %v", err)
+	}
+	if status := group.Status()["slow"]; status.Active {
+		t.Errorf("slow interval still active: %+v", status)
+	}
+}
+
+func TestIntervalGroupReportsPanics(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	group := NewIntervalGroup().OnError(func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if name != "flaky" {
+			t.Errorf("error reported for %s", name)
+		}
+		reported = append(reported, err)
+	})
+	defer group.StopAll(context.Background())
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	group.Add("flaky", func() bool {
+		switch n := calls.Add(1); {
+		case n <= 2:
+			panic("cache unavailable")
+		case n == 3:
+			<-release
+			return true
+		default:
+			return false // stops the interval
+		}
+	}, time.Millisecond, false)
+
+	// The interval keeps running after panics and counts them until a call returns normally
+	waitUntil(t, "the third call", func() bool { return calls.Load() == 3 })
+	if status := group.Status()["flaky"]; status.ConsecutiveFailures != 2 || !status.Active {
+		t.Errorf("status after two panics = %+v", status)
+	}
+	mu.Lock()
+	for _, err := range reported {
+		var ep *ErrorPlus
+		if !errors.Is(err, ErrIntervalPanic) || !errors.As(err, &ep) || !strings.Contains(err.Error(), "cache unavailable") {
+			t.Errorf("reported error %v, want an ErrorPlus wrapping ErrIntervalPanic with the panic value", err)
+		}
+	}
+	if len(reported) != 2 {
+		t.Errorf("%d errors reported, want 2", len(reported))
+	}
+	mu.Unlock()
+
+	close(release)
+	waitUntil(t, "the interval to stop itself", func() bool { return !group.Status()["flaky"].Active })
+	if status := group.Status()["flaky"]; status.ConsecutiveFailures != 0 || calls.Load() != 4 {
+		t.Errorf("status after returning false = %+v with %d calls", status, calls.Load())
+	}
+}
@@ -0,0 +1,358 @@
+package gonuts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONChangeKind describes how a value differs between two JSON documents
+type JSONChangeKind string
+
+const (
+	JSONChangeAdded   JSONChangeKind = "added"
+	JSONChangeRemoved JSONChangeKind = "removed"
+	JSONChangeChanged JSONChangeKind = "changed"
+)
+
+// JSONChange is a single difference found by DiffJSON
+type JSONChange struct {
+	Path string         // path in JSONPathExtractor syntax, e.g. "items[2].price"
+	Kind JSONChangeKind // added, removed or changed
+	Old  interface{}    // value in the first document (nil for added)
+	New  interface{}    // value in the second document (nil for removed)
+}
+
+// JSONDiffOptions configures DiffJSON
+type JSONDiffOptions struct {
+	// IgnoreArrayOrder lists paths of arrays that are compared as multisets.
+	// Use "[*]" to match any array index, e.g. "orders[*].tags".
+	IgnoreArrayOrder []string
+	// MaxDepth limits how many levels of objects and arrays are descended into.
+	// Containers below the limit are reported as a single change. 0 means unlimited.
+	MaxDepth int
+}
+
+// DiffJSON compares two JSON documents and returns the differences by path.
+//
+// Numbers are compared exactly by their decimal value, so 1 and 1.0 are equal while
+// 9007199254740993 and 9007199254740992 are not; number values in the result are json.Number.
+// Changes are ordered so that they can be replayed onto the first document with
+// JSONPathExtractor.ApplyChanges: removed array elements are reported from the highest
+// index down and added elements at the index they get after the removals.
+//
+// Object keys that cannot be expressed in the path syntax (empty, "*", or containing
+// '.', '[' or ']') result in an error.
+//
+// Example:
+//
+//	changes, err := DiffJSON(`{"a":1,"b":[1,2]}`, `{"a":2,"b":[1]}`, JSONDiffOptions{})
+//	// changes: [{a changed 1 2} {b[1] removed 2 <nil>}]
+func DiffJSON(a, b string, opts JSONDiffOptions) ([]JSONChange, error) {
+	docA, err := decodeJSONPreservingNumbers(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse first JSON document: %w", err)
+	}
+	docB, err := decodeJSONPreservingNumbers(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse second JSON document: %w", err)
+	}
+
+	d := &jsonDiffer{opts: opts}
+	if err := d.diff("", docA, docB, 0); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+// ApplyChanges replays changes produced by DiffJSON onto the document
+//
+// Example:
+//
+//	changes, _ := DiffJSON(before, after, JSONDiffOptions{})
+//	extractor, _ := NewJSONPathExtractor(before)
+//	err := extractor.ApplyChanges(changes)
+func (jpe *JSONPathExtractor) ApplyChanges(changes []JSONChange) error {
+	for _, change := range changes {
+		var err error
+		if change.Kind == JSONChangeRemoved {
+			err = jpe.Delete(change.Path)
+		} else {
+			err = jpe.Set(change.Path, change.New)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonDiffer struct {
+	opts    JSONDiffOptions
+	changes []JSONChange
+}
+
+func (d *jsonDiffer) add(path string, kind JSONChangeKind, oldValue, newValue interface{}) {
+	d.changes = append(d.changes, JSONChange{Path: path, Kind: kind, Old: oldValue, New: newValue})
+}
+
+func (d *jsonDiffer) diff(path string, a, b interface{}, depth int) error {
+	if jsonValuesEqual(a, b) {
+		return nil
+	}
+	if d.opts.MaxDepth > 0 && depth >= d.opts.MaxDepth {
+		d.add(path, JSONChangeChanged, a, b)
+		return nil
+	}
+
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		return d.diffObjects(path, va, vb, depth)
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if d.ignoresArrayOrder(path) {
+			return d.diffArraysUnordered(path, va, vb)
+		}
+		return d.diffArrays(path, va, vb, depth)
+	}
+	d.add(path, JSONChangeChanged, a, b)
+	return nil
+}
+
+func (d *jsonDiffer) diffObjects(path string, a, b map[string]interface{}, depth int) error {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if k == "" || k == "*" || strings.ContainsAny(k, ".[]") {
+			return fmt.Errorf("object key %q at %q cannot be expressed as a path", k, path)
+		}
+		childPath := k
+		if path != "" {
+			childPath = path + "." + k
+		}
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inB:
+			d.add(childPath, JSONChangeRemoved, va, nil)
+		case !inA:
+			d.add(childPath, JSONChangeAdded, nil, vb)
+		default:
+			if err := d.diff(childPath, va, vb, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *jsonDiffer) diffArrays(path string, a, b []interface{}, depth int) error {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+	for i := 0; i < common; i++ {
+		if err := d.diff(jsonIndexPath(path, i), a[i], b[i], depth+1); err != nil {
+			return err
+		}
+	}
+	for i := len(a) - 1; i >= common; i-- {
+		d.add(jsonIndexPath(path, i), JSONChangeRemoved, a[i], nil)
+	}
+	for i := common; i < len(b); i++ {
+		d.add(jsonIndexPath(path, i), JSONChangeAdded, nil, b[i])
+	}
+	return nil
+}
+
+// diffArraysUnordered compares arrays as multisets: unmatched elements of a are removed
+// (highest index first) and unmatched elements of b are appended.
+func (d *jsonDiffer) diffArraysUnordered(path string, a, b []interface{}) error {
+	available := make(map[string]int, len(b))
+	for _, item := range b {
+		available[canonicalJSON(item)]++
+	}
+
+	matched := make(map[string]int, len(a))
+	var removed []int
+	for i, item := range a {
+		key := canonicalJSON(item)
+		if matched[key] < available[key] {
+			matched[key]++
+			continue
+		}
+		removed = append(removed, i)
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		d.add(jsonIndexPath(path, removed[i]), JSONChangeRemoved, a[removed[i]], nil)
+	}
+
+	next := len(a) - len(removed)
+	for _, item := range b {
+		key := canonicalJSON(item)
+		if matched[key] > 0 {
+			matched[key]--
+			continue
+		}
+		d.add(jsonIndexPath(path, next), JSONChangeAdded, nil, item)
+		next++
+	}
+	return nil
+}
+
+// ignoresArrayOrder reports whether path matches one of the IgnoreArrayOrder patterns
+func (d *jsonDiffer) ignoresArrayOrder(path string) bool {
+	for _, pattern := range d.opts.IgnoreArrayOrder {
+		if jsonPathMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPathMatches matches a path against a pattern in which "[*]" matches any index
+func jsonPathMatches(pattern, path string) bool {
+	for {
+		i := strings.Index(pattern, "[*]")
+		if i < 0 {
+			return pattern == path
+		}
+		if !strings.HasPrefix(path, pattern[:i]+"[") {
+			return false
+		}
+		path = path[i+1:]
+		end := strings.IndexByte(path, ']')
+		if end <= 0 {
+			return false
+		}
+		if _, err := strconv.Atoi(path[:end]); err != nil {
+			return false
+		}
+		path = path[end+1:]
+		pattern = pattern[i+3:]
+	}
+}
+
+func jsonIndexPath(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+// decodeJSONPreservingNumbers decodes JSON keeping numbers as json.Number
+func decodeJSONPreservingNumbers(s string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// jsonValuesEqual compares two decoded JSON values, numbers by exact decimal value
+func jsonValuesEqual(a, b interface{}) bool {
+	switch va := a.(type) {
+	case json.Number:
+		vb, ok := b.(json.Number)
+		return ok && normalizeJSONNumber(va) == normalizeJSONNumber(vb)
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, v := range va {
+			w, ok := vb[k]
+			if !ok || !jsonValuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !jsonValuesEqual(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// canonicalJSON returns a representation of v that is equal for equal JSON values
+func canonicalJSON(v interface{}) string {
+	var buf bytes.Buffer
+	writeCanonicalJSON(&buf, v)
+	return buf.String()
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, v interface{}) {
+	switch val := v.(type) {
+	case json.Number:
+		buf.WriteString(normalizeJSONNumber(val))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Quote(k))
+			buf.WriteByte(':')
+			writeCanonicalJSON(buf, val[k])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalJSON(buf, item)
+		}
+		buf.WriteByte(']')
+	case string:
+		buf.WriteString(strconv.Quote(val))
+	default:
+		fmt.Fprintf(buf, "%v", val)
+	}
+}
+
+// normalizeJSONNumber returns the exact rational value of a JSON number as a string
+func normalizeJSONNumber(n json.Number) string {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return n.String()
+	}
+	return r.RatString()
+}
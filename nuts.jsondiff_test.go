@@ -0,0 +1,144 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	n := func(s string) json.Number { return json.Number(s) }
+	tests := []struct {
+		name string
+		a, b string
+		opts JSONDiffOptions
+		want []JSONChange
+	}{
+		{
+			name: "scalars and nested objects",
+			a:    `{"a":1,"b":{"c":"x","d":true},"e":null}`,
+			b:    `{"a":2,"b":{"c":"x","f":false},"e":null}`,
+			want: []JSONChange{
+				{Path: "a", Kind: JSONChangeChanged, Old: n("1"), New: n("2")},
+				{Path: "b.d", Kind: JSONChangeRemoved, Old: true},
+				{Path: "b.f", Kind: JSONChangeAdded, New: false},
+			},
+		},
+		{
+			name: "numbers by exact decimal value",
+			a:    `{"a":1,"b":1e2,"c":9007199254740993}`,
+			b:    `{"a":1.0,"b":100,"c":9007199254740992}`,
+			want: []JSONChange{
+				{Path: "c", Kind: JSONChangeChanged, Old: n("9007199254740993"), New: n("9007199254740992")},
+			},
+		},
+		{
+			name: "array elements removed from the highest index down",
+			a:    `{"items":[1,2,3,4]}`,
+			b:    `{"items":[1,5]}`,
+			want: []JSONChange{
+				{Path: "items[1]", Kind: JSONChangeChanged, Old: n("2"), New: n("5")},
+				{Path: "items[3]", Kind: JSONChangeRemoved, Old: n("4")},
+				{Path: "items[2]", Kind: JSONChangeRemoved, Old: n("3")},
+			},
+		},
+		{
+			name: "nested array paths",
+			a:    `[{"id":1,"tags":["a"]}]`,
+			b:    `[{"id":1,"tags":["a","b"]},{"id":2}]`,
+			want: []JSONChange{
+				{Path: "[0].tags[1]", Kind: JSONChangeAdded, New: "b"},
+				{Path: "[1]", Kind: JSONChangeAdded, New: map[string]interface{}{"id": n("2")}},
+			},
+		},
+		{
+			name: "type change",
+			a:    `{"a":{"b":1}}`,
+			b:    `{"a":[1]}`,
+			want: []JSONChange{
+				{Path: "a", Kind: JSONChangeChanged, Old: map[string]interface{}{"b": n("1")}, New: []interface{}{n("1")}},
+			},
+		},
+		{
+			name: "arrays compared as multisets",
+			a:    `{"orders":[{"tags":["x","y","y","z"]}]}`,
+			b:    `{"orders":[{"tags":["y","w","x","y"]}]}`,
+			opts: JSONDiffOptions{IgnoreArrayOrder: []string{"orders[*].tags"}},
+			want: []JSONChange{
+				{Path: "orders[0].tags[3]", Kind: JSONChangeRemoved, Old: "z"},
+				{Path: "orders[0].tags[3]", Kind: JSONChangeAdded, New: "w"},
+			},
+		},
+		{
+			name: "MaxDepth reports containers below the limit as one change",
+			a:    `{"a":{"b":{"c":1}},"d":1}`,
+			b:    `{"a":{"b":{"c":2}},"d":1}`,
+			opts: JSONDiffOptions{MaxDepth: 1},
+			want: []JSONChange{
+				{Path: "a", Kind: JSONChangeChanged, Old: map[string]interface{}{"b": map[string]interface{}{"c": n("1")}}, New: map[string]interface{}{"b": map[string]interface{}{"c": n("2")}}},
+			},
+		},
+		{
+			name: "equal documents",
+			a:    `{"a":[1,{"b":2}]}`,
+			b:    `{ "a" : [ 1.0, { "b" : 2 } ] }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := DiffJSON(tt.a, tt.b, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("DiffJSON = %v, want %v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffJSONErrors(t *testing.T) {
+	for _, docs := range [][2]string{
+		{`{"a":`, `{}`},
+		{`{}`, `not json`},
+		{`{"a.b":1}`, `{}`},
+		{`{"x":{}}`, `{"x":{"[0]":1}}`},
+		{`{"":1}`, `{"":2}`},
+	} {
+		if changes, err := DiffJSON(docs[0], docs[1], JSONDiffOptions{}); err == nil {
+			t.Errorf("DiffJSON(%s, %s) = %v, want an error", docs[0], docs[1], changes)
+		}
+	}
+}
+
+func TestDiffJSONChangesReplay(t *testing.T) {
+	before := `{"name":"order","items":[{"sku":"a","qty":1},{"sku":"b","qty":2},{"sku":"c","qty":3}],"tags":["x","y"],"meta":{"old":true}}`
+	after := `{"name":"order-2","items":[{"sku":"a","qty":5}],"tags":["y","x","z"],"meta":{"new":1},"total":12.5}`
+
+	for _, opts := range []JSONDiffOptions{{}, {IgnoreArrayOrder: []string{"tags"}}, {MaxDepth: 1}} {
+		changes, err := DiffJSON(before, after, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extractor, err := NewJSONPathExtractor(before)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := extractor.ApplyChanges(changes); err != nil {
+			t.Fatalf("ApplyChanges with %+v: %v", opts, err)
+		}
+		replayed, err := extractor.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// With IgnoreArrayOrder the replayed tags keep the order of before, so compare the same way
+		remaining, err := DiffJSON(replayed, after, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(remaining) != 0 {
+			t.Errorf("replaying the changes with %+v left the differences %v", opts, remaining)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // JSONPathExtractor extracts values from JSON data using a path-like syntax
 type JSONPathExtractor struct {
-	data interface{}
+	data  interface{}
+	cache *jsonPathCache // nil unless created with NewJSONPathExtractorCached
 }
 
 // NewJSONPathExtractor creates a new JSONPathExtractor
@@ -30,6 +32,29 @@ func NewJSONPathExtractor(jsonData string) (*JSONPathExtractor, error) {
 	return &JSONPathExtractor{data: data}, nil
 }
 
+// NewJSONPathExtractorCached creates a JSONPathExtractor that memoizes the nodes it resolves, so
+// paths with shared prefixes ("a.b.c", "a.b.d") walk the shared part of the document only once.
+// Set and Delete invalidate the cached nodes they affect. The cache holds references into the
+// document and grows with the number of distinct paths extracted; Extract takes a lock of the
+// extractor, so concurrent extractions are serialized.
+//
+// Example:
+//
+//	extractor, err := NewJSONPathExtractorCached(dashboardJSON)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	cpu, _ := extractor.ExtractFloat("metrics.hosts[0].cpu.load")
+//	mem, _ := extractor.ExtractFloat("metrics.hosts[0].mem.used") // resumes at metrics.hosts[0]
+func NewJSONPathExtractorCached(jsonData string) (*JSONPathExtractor, error) {
+	jpe, err := NewJSONPathExtractor(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	jpe.cache = newJSONPathCache(jpe.data)
+	return jpe, nil
+}
+
 // Extract retrieves a value from the JSON data using the given path
 //
 // The path can include dot notation for nested objects, bracket notation for array indices,
@@ -43,11 +68,29 @@ func NewJSONPathExtractor(jsonData string) (*JSONPathExtractor, error) {
 //	}
 //	fmt.Println(value) // Output: New York
 func (jpe *JSONPathExtractor) Extract(path string) (interface{}, error) {
-	parts := strings.FieldsFunc(path, func(r rune) bool {
-		return r == '.' || r == '[' || r == ']'
-	})
+	if jpe.cache != nil {
+		jpe.cache.mu.Lock()
+		defer jpe.cache.mu.Unlock()
+	}
+	return jpe.extract(path, jpe.cache)
+}
 
+// extract resolves path, starting at the longest prefix found in cache (if not nil) and adding
+// the nodes it visits to the cache. The caller holds the lock of the cache.
+func (jpe *JSONPathExtractor) extract(path string, cache *jsonPathCache) (interface{}, error) {
 	var current interface{} = jpe.data
+	var node *jsonPathCacheNode
+	start := 0
+	if cache != nil {
+		node, start = cache.lookup(path)
+		if start == len(path) {
+			return node.value, nil
+		}
+		current = node.value
+	}
+
+	parts := splitJSONPath(path[start:])
+	end := start
 	for _, part := range parts {
 		switch v := current.(type) {
 		case map[string]interface{}:
@@ -74,6 +117,10 @@ func (jpe *JSONPathExtractor) Extract(path string) (interface{}, error) {
 		default:
 			return nil, fmt.Errorf("cannot navigate further from %T", v)
 		}
+		if node != nil {
+			end += strings.Index(path[end:], part) + len(part)
+			node = cache.add(node, path[:end], part, current)
+		}
 	}
 
 	return current, nil
@@ -185,7 +232,9 @@ func (jpe *JSONPathExtractor) ExtractArray(path string) ([]interface{}, error) {
 	return arr, nil
 }
 
-// ExtractMultiple extracts multiple values from the JSON data using the given paths
+// ExtractMultiple extracts multiple values from the JSON data using the given paths.
+// The paths are resolved through a tree of their shared prefixes (the cache of the extractor,
+// or a temporary one), so every shared prefix is resolved only once.
 //
 // Example:
 //
@@ -195,9 +244,16 @@ func (jpe *JSONPathExtractor) ExtractArray(path string) ([]interface{}, error) {
 //	}
 //	fmt.Println(values) // Output: map[name:John age:30 address.city:New York]
 func (jpe *JSONPathExtractor) ExtractMultiple(paths ...string) (map[string]interface{}, error) {
+	cache := jpe.cache
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+	} else {
+		cache = newJSONPathCache(jpe.data)
+	}
 	result := make(map[string]interface{})
 	for _, path := range paths {
-		value, err := jpe.Extract(path)
+		value, err := jpe.extract(path, cache)
 		if err != nil {
 			return nil, fmt.Errorf("error extracting path %s: %w", path, err)
 		}
@@ -205,3 +261,274 @@ func (jpe *JSONPathExtractor) ExtractMultiple(paths ...string) (map[string]inter
 	}
 	return result, nil
 }
+
+// Set assigns a value at the given path, creating missing intermediate objects.
+//
+// Array indices must exist, except that an index equal to the array length appends
+// an element. The empty path replaces the whole document. Wildcards are not supported.
+//
+// Example:
+//
+//	err := extractor.Set("address.zip", "10001")
+//	err = extractor.Set("tags[0]", "admin")
+func (jpe *JSONPathExtractor) Set(path string, value interface{}) error {
+	parts := splitJSONPath(path)
+	if jpe.cache != nil {
+		jpe.cache.mu.Lock()
+		defer jpe.cache.mu.Unlock()
+		jpe.cache.invalidate(parts)
+	}
+	updated, err := setJSONPath(jpe.data, parts, value)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", path, err)
+	}
+	jpe.setData(updated)
+	return nil
+}
+
+// Delete removes the object key or array element at the given path.
+// Later array elements shift down by one.
+//
+// Example:
+//
+//	err := extractor.Delete("address.zip")
+func (jpe *JSONPathExtractor) Delete(path string) error {
+	parts := splitJSONPath(path)
+	if len(parts) == 0 {
+		return fmt.Errorf("cannot delete the document root")
+	}
+	if jpe.cache != nil {
+		jpe.cache.mu.Lock()
+		defer jpe.cache.mu.Unlock()
+		jpe.cache.invalidate(parts)
+	}
+	updated, err := deleteJSONPath(jpe.data, parts)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	jpe.setData(updated)
+	return nil
+}
+
+// setData replaces the document after a mutation; the caller holds the lock of the cache
+func (jpe *JSONPathExtractor) setData(data interface{}) {
+	jpe.data = data
+	if jpe.cache != nil {
+		jpe.cache.root.value = data
+	}
+}
+
+// JSON returns the current document as a JSON string
+func (jpe *JSONPathExtractor) JSON() (string, error) {
+	if jpe.cache != nil {
+		jpe.cache.mu.Lock()
+		defer jpe.cache.mu.Unlock()
+	}
+	b, err := json.Marshal(jpe.data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(b), nil
+}
+
+// splitJSONPath splits a path in dot/bracket syntax into its parts
+func splitJSONPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+}
+
+// setJSONPath returns current with value assigned at parts
+func setJSONPath(current interface{}, parts []string, value interface{}) (interface{}, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	part := parts[0]
+	if part == "*" {
+		return nil, fmt.Errorf("wildcards are not supported")
+	}
+	switch v := current.(type) {
+	case map[string]interface{}:
+		child, err := setJSONPath(v[part], parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[part] = child
+		return v, nil
+	case []interface{}:
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid array index: %s", part)
+		}
+		if index < 0 || index > len(v) {
+			return nil, fmt.Errorf("array index out of bounds: %d", index)
+		}
+		var existing interface{}
+		if index < len(v) {
+			existing = v[index]
+		}
+		child, err := setJSONPath(existing, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		if index == len(v) {
+			return append(v, child), nil
+		}
+		v[index] = child
+		return v, nil
+	case nil:
+		child, err := setJSONPath(nil, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{part: child}, nil
+	default:
+		return nil, fmt.Errorf("cannot navigate further from %T", v)
+	}
+}
+
+// deleteJSONPath returns current with the element at parts removed
+func deleteJSONPath(current interface{}, parts []string) (interface{}, error) {
+	part := parts[0]
+	switch v := current.(type) {
+	case map[string]interface{}:
+		child, ok := v[part]
+		if !ok {
+			return nil, fmt.Errorf("key not found: %s", part)
+		}
+		if len(parts) == 1 {
+			delete(v, part)
+			return v, nil
+		}
+		updated, err := deleteJSONPath(child, parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		v[part] = updated
+		return v, nil
+	case []interface{}:
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid array index: %s", part)
+		}
+		if index < 0 || index >= len(v) {
+			return nil, fmt.Errorf("array index out of bounds: %d", index)
+		}
+		if len(parts) == 1 {
+			return append(v[:index], v[index+1:]...), nil
+		}
+		updated, err := deleteJSONPath(v[index], parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		v[index] = updated
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot navigate further from %T", v)
+	}
+}
+
+// jsonPathCache memoizes resolved nodes of a document in a tree of path parts, which mirrors
+// the document for invalidation, and an index of the path prefixes spelled in extracted paths
+type jsonPathCache struct {
+	mu     sync.Mutex
+	root   *jsonPathCacheNode
+	byPath map[string]*jsonPathCacheNode
+}
+
+type jsonPathCacheNode struct {
+	value    interface{}
+	children map[string]*jsonPathCacheNode
+	stale    bool // removed from the tree, its index entries are dropped on lookup
+}
+
+func newJSONPathCache(data interface{}) *jsonPathCache {
+	return &jsonPathCache{
+		root:   &jsonPathCacheNode{value: data},
+		byPath: make(map[string]*jsonPathCacheNode),
+	}
+}
+
+// lookup returns the node of the longest cached prefix of path (the root if there is none)
+// and the length of the prefix. Prefixes end where a part of the path ends.
+func (c *jsonPathCache) lookup(path string) (*jsonPathCacheNode, int) {
+	end := len(path)
+	for end > 0 {
+		if node, ok := c.byPath[path[:end]]; ok {
+			if !node.stale {
+				return node, end
+			}
+			delete(c.byPath, path[:end])
+		}
+		end--
+		for end > 0 && !(isJSONPathSeparator(path[end]) && !isJSONPathSeparator(path[end-1])) {
+			end--
+		}
+	}
+	return c.root, 0
+}
+
+// add caches the value of a child of parent, indexed by the path prefix that resolves it.
+// A child cached for another spelling of the prefix ("a[0]" and "a.0") is reused.
+func (c *jsonPathCache) add(parent *jsonPathCacheNode, prefix string, part string, value interface{}) *jsonPathCacheNode {
+	if child, ok := parent.children[part]; ok {
+		c.byPath[prefix] = child
+		return child
+	}
+	if parent.children == nil {
+		parent.children = make(map[string]*jsonPathCacheNode)
+	}
+	child := &jsonPathCacheNode{value: value}
+	parent.children[part] = child
+	c.byPath[prefix] = child
+	return child
+}
+
+// invalidate removes the cached nodes that a mutation at parts may change: the target and
+// everything below it. Objects are modified in place, so their nodes stay valid, but an array
+// on the path may be reallocated by an append or shift its elements, so the node of the array
+// is removed as well.
+func (c *jsonPathCache) invalidate(parts []string) {
+	var parent *jsonPathCacheNode
+	node := c.root
+	for i, part := range parts {
+		child, ok := node.children[part]
+		if ok && i < len(parts)-1 && child.value != nil {
+			parent, node = node, child
+			continue
+		}
+		if _, isArray := node.value.([]interface{}); !isArray {
+			node.removeChild(part)
+		} else if parent == nil {
+			c.reset()
+		} else {
+			parent.removeChild(parts[i-1])
+		}
+		return
+	}
+	c.reset()
+}
+
+// reset removes all cached nodes below the root
+func (c *jsonPathCache) reset() {
+	c.root.children = nil
+	c.byPath = make(map[string]*jsonPathCacheNode)
+}
+
+func (n *jsonPathCacheNode) removeChild(part string) {
+	if child, ok := n.children[part]; ok {
+		child.markStale()
+		delete(n.children, part)
+	}
+}
+
+func (n *jsonPathCacheNode) markStale() {
+	n.stale = true
+	for _, child := range n.children {
+		child.markStale()
+	}
+}
+
+func isJSONPathSeparator(c byte) bool {
+	return c == '.' || c == '[' || c == ']'
+}
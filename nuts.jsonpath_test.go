@@ -0,0 +1,152 @@
+package gonuts
+
+import (
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+const jsonPathTestDocument = `{
+	"metrics": {
+		"hosts": [
+			{"name": "a", "cpu": {"load": 0.5}, "mem": {"used": 10}},
+			{"name": "b", "cpu": {"load": 0.7}, "mem": {"used": 20}}
+		],
+		"region": "eu"
+	},
+	"tags": ["x", "y"]
+}`
+
+func TestJSONPathExtractorCachedResumesAtSharedPrefix(t *testing.T) {
+	extractor, err := NewJSONPathExtractorCached(jsonPathTestDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load, err := extractor.ExtractFloat("metrics.hosts[0].cpu.load"); err != nil || load != 0.5 {
+		t.Fatalf("ExtractFloat(cpu.load) = %v, %v", load, err)
+	}
+	node, end := extractor.cache.lookup("metrics.hosts[0].mem.used")
+	if rest := "metrics.hosts[0].mem.used"[end:]; rest != "].mem.used" {
+		t.Fatalf("lookup leaves %q to resolve, want only the parts after metrics.hosts[0]", rest)
+	}
+	if name := node.value.(map[string]interface{})["name"]; name != "a" {
+		t.Errorf("the cached node of metrics.hosts[0] holds host %v", name)
+	}
+	if used, err := extractor.ExtractInt("metrics.hosts[0].mem.used"); err != nil || used != 10 {
+		t.Errorf("ExtractInt(mem.used) = %v, %v", used, err)
+	}
+
+	// Another spelling of the same element reuses the cached node
+	extractor.Extract("metrics.hosts.1.cpu")
+	extractor.Extract("metrics.hosts[1].mem")
+	if hosts := extractor.cache.root.children["metrics"].children["hosts"]; len(hosts.children) != 2 {
+		t.Errorf("%d cached host nodes for two spellings of 2 hosts, want 2", len(hosts.children))
+	}
+}
+
+func TestJSONPathExtractorCachedInvalidation(t *testing.T) {
+	extractor, err := NewJSONPathExtractorCached(jsonPathTestDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extractor.Extract("metrics.hosts[1].name")
+	extractor.Extract("metrics.region")
+
+	// Deleting hosts[0] shifts hosts[1] down, so the cached hosts[1] must not be returned
+	if err := extractor.Delete("metrics.hosts[0]"); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := extractor.ExtractString("metrics.hosts[0].name"); err != nil || name != "b" {
+		t.Errorf("hosts[0].name after deleting hosts[0] = %q, %v, want b", name, err)
+	}
+	if _, err := extractor.Extract("metrics.hosts[1].name"); err == nil {
+		t.Error("hosts[1] is still found after the array shrank to 1 element")
+	}
+	if region, _ := extractor.ExtractString("metrics.region"); region != "eu" {
+		t.Errorf("region = %q, want the unaffected sibling", region)
+	}
+
+	// Setting an object replaces the cached subtree
+	extractor.Extract("metrics.hosts[0].cpu.load")
+	if err := extractor.Set("metrics.hosts[0].cpu", map[string]interface{}{"load": 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	if load, _ := extractor.ExtractFloat("metrics.hosts[0].cpu.load"); load != 0.9 {
+		t.Errorf("cpu.load after Set(cpu) = %v, want 0.9", load)
+	}
+
+	// Appending may reallocate the array
+	extractor.Extract("tags[1]")
+	if err := extractor.Set("tags[2]", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ := extractor.ExtractArray("tags"); len(tags) != 3 {
+		t.Errorf("tags after appending = %v", tags)
+	}
+}
+
+// TestJSONPathExtractorCachedMatchesUncached applies the same random mutations to a cached and
+// an uncached extractor and compares every extraction
+func TestJSONPathExtractorCachedMatchesUncached(t *testing.T) {
+	paths := []string{
+		"metrics", "metrics.region", "metrics.hosts", "metrics.hosts[0]", "metrics.hosts.0.name",
+		"metrics.hosts[1].cpu.load", "metrics.hosts[2].mem.used", "metrics.hosts[*].name", "tags[0]", "tags[2]",
+	}
+	// Containers are created per extractor, so the two documents never share them
+	values := []func() interface{}{
+		func() interface{} { return "v" },
+		func() interface{} { return 1.5 },
+		func() interface{} { return nil },
+		func() interface{} {
+			return map[string]interface{}{"name": "c", "cpu": map[string]interface{}{"load": 0.1}}
+		},
+		func() interface{} { return []interface{}{"t"} },
+	}
+	rng := rand.New(rand.NewSource(3))
+
+	cached, _ := NewJSONPathExtractorCached(jsonPathTestDocument)
+	plain, _ := NewJSONPathExtractor(jsonPathTestDocument)
+	for step := 0; step < 500; step++ {
+		path := paths[rng.Intn(len(paths))]
+		switch rng.Intn(4) {
+		case 0:
+			value := values[rng.Intn(len(values))]
+			errCached, errPlain := cached.Set(path, value()), plain.Set(path, value())
+			if (errCached == nil) != (errPlain == nil) {
+				t.Fatalf("step %d: Set(%s) = %v cached, %v uncached", step, path, errCached, errPlain)
+			}
+		case 1:
+			errCached, errPlain := cached.Delete(path), plain.Delete(path)
+			if (errCached == nil) != (errPlain == nil) {
+				t.Fatalf("step %d: Delete(%s) = %v cached, %v uncached", step, path, errCached, errPlain)
+			}
+		}
+		for _, p := range paths {
+			got, errCached := cached.Extract(p)
+			want, errPlain := plain.Extract(p)
+			if (errCached == nil) != (errPlain == nil) || !reflect.DeepEqual(got, want) {
+				t.Fatalf("step %d: Extract(%s) = %v, %v cached, want %v, %v", step, p, got, errCached, want, errPlain)
+			}
+		}
+	}
+}
+
+func TestJSONPathExtractorCachedConcurrentExtract(t *testing.T) {
+	extractor, _ := NewJSONPathExtractorCached(jsonPathTestDocument)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				path := []string{"metrics.hosts[0].cpu.load", "metrics.hosts[1].mem.used", "metrics.region"}[(g+i)%3]
+				if _, err := extractor.Extract(path); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,186 @@
+package gonuts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// KeyedMutex provides a read/write lock per logical key (named mutexes), e.g. to serialize
+// all work on one user across several ConcurrentMap operations.
+//
+// Like ConcurrentMap, the lock table is sharded to reduce contention. A key's mutex only
+// exists while it is held or waited for: it is removed as soon as the last holder or waiter
+// releases it, so the table does not grow with the number of keys ever locked.
+type KeyedMutex[K comparable] struct {
+	shards    []*keyedMutexShard[K]
+	numShards int
+}
+
+type keyedMutexShard[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+// keyedLock is the mutex of a single key. refs counts holders and waiters and is guarded by the shard mutex.
+type keyedLock struct {
+	mu   sync.RWMutex
+	refs int
+}
+
+// NewKeyedMutex creates a new KeyedMutex with the specified number of shards.
+//
+// Example:
+//
+//	km := NewKeyedMutex[string](16)
+//	unlock := km.Lock("user-42")
+//	defer unlock()
+func NewKeyedMutex[K comparable](numShards int) *KeyedMutex[K] {
+	if numShards <= 0 {
+		numShards = 32 // Default number of shards
+	}
+	km := &KeyedMutex[K]{
+		shards:    make([]*keyedMutexShard[K], numShards),
+		numShards: numShards,
+	}
+	for i := 0; i < numShards; i++ {
+		km.shards[i] = &keyedMutexShard[K]{
+			locks: make(map[K]*keyedLock),
+		}
+	}
+	return km
+}
+
+func (km *KeyedMutex[K]) getShard(key K) *keyedMutexShard[K] {
+	hash := fnv32(fmt.Sprintf("%v", key))
+	return km.shards[hash%uint32(km.numShards)]
+}
+
+// acquire returns the lock for key, creating it if needed, and registers the caller as a user.
+func (km *KeyedMutex[K]) acquire(key K) *keyedLock {
+	shard := km.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	l, ok := shard.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		shard.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+// release unregisters a user of the lock for key and removes the lock once it is unused.
+func (km *KeyedMutex[K]) release(key K, l *keyedLock) {
+	shard := km.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(shard.locks, key)
+	}
+}
+
+// Lock acquires the exclusive lock for key and returns the function that releases it.
+// The returned function is safe to call more than once.
+//
+// Example:
+//
+//	unlock := km.Lock(userID)
+//	defer unlock()
+func (km *KeyedMutex[K]) Lock(key K) (unlock func()) {
+	l := km.acquire(key)
+	l.mu.Lock()
+	return km.unlocker(key, l, l.mu.Unlock)
+}
+
+// TryLock tries to acquire the exclusive lock for key without blocking.
+//
+// Returns:
+//   - unlock: releases the lock, nil if the lock was not acquired
+//   - bool: true if the lock was acquired
+func (km *KeyedMutex[K]) TryLock(key K) (unlock func(), ok bool) {
+	l := km.acquire(key)
+	if !l.mu.TryLock() {
+		km.release(key, l)
+		return nil, false
+	}
+	return km.unlocker(key, l, l.mu.Unlock), true
+}
+
+// RLock acquires a shared lock for key and returns the function that releases it.
+func (km *KeyedMutex[K]) RLock(key K) (unlock func()) {
+	l := km.acquire(key)
+	l.mu.RLock()
+	return km.unlocker(key, l, l.mu.RUnlock)
+}
+
+// TryRLock tries to acquire a shared lock for key without blocking.
+//
+// Returns:
+//   - unlock: releases the lock, nil if the lock was not acquired
+//   - bool: true if the lock was acquired
+func (km *KeyedMutex[K]) TryRLock(key K) (unlock func(), ok bool) {
+	l := km.acquire(key)
+	if !l.mu.TryRLock() {
+		km.release(key, l)
+		return nil, false
+	}
+	return km.unlocker(key, l, l.mu.RUnlock), true
+}
+
+// LockMany acquires the exclusive locks for all keys and returns a function that releases them.
+//
+// To avoid deadlocks between goroutines locking overlapping key sets, the keys are
+// deduplicated and always acquired in the same order (sorted by their "%v" representation).
+// Do not combine LockMany with individual Lock calls held at the same time.
+//
+// Example:
+//
+//	unlock := km.LockMany(fromAccount, toAccount)
+//	defer unlock()
+func (km *KeyedMutex[K]) LockMany(keys ...K) (unlock func()) {
+	ordered := Unique(keys)
+	names := make(map[K]string, len(ordered))
+	for _, key := range ordered {
+		names[key] = fmt.Sprintf("%v", key)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return names[ordered[i]] < names[ordered[j]]
+	})
+
+	unlocks := make([]func(), 0, len(ordered))
+	for _, key := range ordered {
+		unlocks = append(unlocks, km.Lock(key))
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for i := len(unlocks) - 1; i >= 0; i-- {
+				unlocks[i]()
+			}
+		})
+	}
+}
+
+// Len returns the number of keys that are currently locked or waited for.
+func (km *KeyedMutex[K]) Len() int {
+	count := 0
+	for _, shard := range km.shards {
+		shard.mu.Lock()
+		count += len(shard.locks)
+		shard.mu.Unlock()
+	}
+	return count
+}
+
+// unlocker returns an idempotent function that unlocks l and releases it.
+func (km *KeyedMutex[K]) unlocker(key K, l *keyedLock, unlockFn func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unlockFn()
+			km.release(key, l)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package gonuts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutexSerializesPerKey(t *testing.T) {
+	const goroutines, keys, increments = 2000, 16, 10
+	km := NewKeyedMutex[string](4)
+	// Plain ints guarded only by the keyed locks, so the race detector reports any overlap
+	counters := make([]int, keys)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			key := g % keys
+			for i := 0; i < increments; i++ {
+				unlock := km.Lock(fmt.Sprintf("key-%d", key))
+				counters[key]++
+				unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for key, count := range counters {
+		if want := goroutines / keys * increments; count != want {
+			t.Errorf("counter %d = %d, want %d", key, count, want)
+		}
+	}
+	if n := km.Len(); n != 0 {
+		t.Errorf("Len() = %d after all locks were released, want 0", n)
+	}
+}
+
+func TestKeyedMutexReadersShareWritersExclude(t *testing.T) {
+	km := NewKeyedMutex[int](0)
+
+	unlockRead := km.RLock(1)
+	unlockRead2, ok := km.TryRLock(1)
+	if !ok {
+		t.Fatal("TryRLock failed while only readers hold the key")
+	}
+	if _, ok := km.TryLock(1); ok {
+		t.Fatal("TryLock succeeded while readers hold the key")
+	}
+	unlockOther, ok := km.TryLock(2)
+	if !ok {
+		t.Fatal("TryLock of another key failed")
+	}
+	unlockOther()
+	unlockRead()
+	unlockRead2()
+	unlockRead2() // unlock functions are idempotent
+
+	unlock, ok := km.TryLock(1)
+	if !ok {
+		t.Fatal("TryLock failed after all readers released the key")
+	}
+	if _, ok := km.TryRLock(1); ok {
+		t.Fatal("TryRLock succeeded while a writer holds the key")
+	}
+	unlock()
+	if n := km.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0: failed TryLock calls must not leak locks", n)
+	}
+}
+
+func TestKeyedMutexLockManyNoDeadlock(t *testing.T) {
+	const goroutines, accounts, transfers = 1000, 8, 20
+	km := NewKeyedMutex[int](2)
+	balances := make([]int, accounts)
+	for i := range balances {
+		balances[i] = 1000
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < transfers; i++ {
+					// Opposite orders across goroutines would deadlock without sorting
+					from, to := (g+i)%accounts, (g+i+1)%accounts
+					if g%2 == 0 {
+						from, to = to, from
+					}
+					unlock := km.LockMany(from, to, from)
+					balances[from]--
+					balances[to]++
+					unlock()
+				}
+			}(g)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("LockMany deadlocked")
+	}
+
+	total := 0
+	for _, balance := range balances {
+		total += balance
+	}
+	if total != accounts*1000 {
+		t.Errorf("total balance = %d, want %d", total, accounts*1000)
+	}
+	if n := km.Len(); n != 0 {
+		t.Errorf("Len() = %d after all locks were released, want 0", n)
+	}
+}
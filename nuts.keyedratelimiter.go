@@ -0,0 +1,176 @@
+package gonuts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const keyedRateLimiterShards = 32
+
+// KeyedRateLimiter is a set of token bucket rate limiters keyed by e.g. API key or client IP.
+// The bucket of a key is created with the shared rate and bucket size on its first use and
+// evicted once it has been idle for the idle timeout, so memory stays bounded by the number of
+// active keys. Buckets are spread over shards like ConcurrentMap, so requests for different keys
+// rarely contend.
+type KeyedRateLimiter struct {
+	rate        float64
+	bucketSize  float64
+	idleTimeout time.Duration
+	shards      [keyedRateLimiterShards]keyedRateLimiterShard
+}
+
+type keyedRateLimiterShard struct {
+	mu        sync.Mutex
+	buckets   map[string]*keyedBucket
+	lastSweep time.Time
+}
+
+type keyedBucket struct {
+	limiter  *RateLimiter
+	lastUsed time.Time // guarded by the shard lock
+}
+
+// NewKeyedRateLimiter creates a new KeyedRateLimiter
+//
+// Parameters:
+//   - rate: the rate at which tokens are added to each bucket (per second)
+//   - bucketSize: the maximum number of tokens each bucket can hold
+//   - idleTimeout: how long a bucket may be unused before it is evicted. An evicted key starts
+//     again with a full bucket, so a timeout of at least bucketSize/rate never lets a key exceed
+//     its limit. Values <= 0 use bucketSize/rate, but at least one minute.
+//
+// Returns:
+//   - *KeyedRateLimiter: a new instance of KeyedRateLimiter
+//
+// Example usage:
+//
+//	limiter := gonuts.NewKeyedRateLimiter(5, 20, 10*time.Minute) // 5 requests per second per client, bursts of 20
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//	    if !limiter.Allow(r.Header.Get("X-API-Key")) {
+//	        http.Error(w, "too many requests", http.StatusTooManyRequests)
+//	        return
+//	    }
+//	    // handle the request
+//	}
+func NewKeyedRateLimiter(rate, bucketSize float64, idleTimeout time.Duration) *KeyedRateLimiter {
+	if idleTimeout <= 0 {
+		idleTimeout = time.Minute
+		if rate > 0 {
+			if refill := time.Duration(bucketSize / rate * float64(time.Second)); refill > idleTimeout {
+				idleTimeout = refill
+			}
+		}
+	}
+	k := &KeyedRateLimiter{rate: rate, bucketSize: bucketSize, idleTimeout: idleTimeout}
+	now := time.Now()
+	for i := range k.shards {
+		k.shards[i].buckets = make(map[string]*keyedBucket)
+		k.shards[i].lastSweep = now
+	}
+	return k
+}
+
+// Allow checks if a request for key is allowed under the rate limit
+//
+// Returns:
+//   - bool: true if the request is allowed, false otherwise
+func (k *KeyedRateLimiter) Allow(key string) bool {
+	return k.AllowN(key, 1)
+}
+
+// AllowN checks if n requests for key are allowed under the rate limit
+//
+// Parameters:
+//   - key: the key to limit, e.g. an API key or client IP
+//   - n: the number of tokens to request
+//
+// Returns:
+//   - bool: true if the requests are allowed, false otherwise
+func (k *KeyedRateLimiter) AllowN(key string, n float64) bool {
+	return k.limiter(key).AllowN(n)
+}
+
+// Wait blocks until a request for key is allowed or the context is cancelled
+//
+// Returns:
+//   - error: nil if a token was acquired, or an error if the context was cancelled
+func (k *KeyedRateLimiter) Wait(ctx context.Context, key string) error {
+	return k.WaitN(ctx, key, 1)
+}
+
+// WaitN blocks until n requests for key are allowed or the context is cancelled
+//
+// Returns:
+//   - error: nil if tokens were acquired, the context's error if it was cancelled, or
+//     ErrRateLimited if n exceeds the bucket size
+func (k *KeyedRateLimiter) WaitN(ctx context.Context, key string, n float64) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// look the bucket up on every try, so waiting keeps it from being evicted
+		delay, err := k.limiter(key).acquireOrDelay(n)
+		if err != nil || delay == 0 {
+			return err
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Len returns the number of tracked keys, after evicting idle buckets
+func (k *KeyedRateLimiter) Len() int {
+	now := time.Now()
+	count := 0
+	for i := range k.shards {
+		shard := &k.shards[i]
+		shard.mu.Lock()
+		k.sweep(shard, now)
+		count += len(shard.buckets)
+		shard.mu.Unlock()
+	}
+	return count
+}
+
+// Reset removes the bucket of key, so its next request starts with a full bucket
+func (k *KeyedRateLimiter) Reset(key string) {
+	shard := k.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	delete(shard.buckets, key)
+}
+
+// limiter returns the bucket of key, creating it if needed, and marks it as used
+func (k *KeyedRateLimiter) limiter(key string) *RateLimiter {
+	shard := k.shard(key)
+	now := time.Now()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if now.Sub(shard.lastSweep) >= k.idleTimeout/2 {
+		k.sweep(shard, now)
+	}
+	bucket, exists := shard.buckets[key]
+	if !exists {
+		bucket = &keyedBucket{limiter: NewRateLimiter(k.rate, k.bucketSize)}
+		shard.buckets[key] = bucket
+	}
+	bucket.lastUsed = now
+	return bucket.limiter
+}
+
+// sweep evicts the idle buckets of a shard; the caller holds the shard lock
+func (k *KeyedRateLimiter) sweep(shard *keyedRateLimiterShard, now time.Time) {
+	shard.lastSweep = now
+	for key, bucket := range shard.buckets {
+		if now.Sub(bucket.lastUsed) >= k.idleTimeout {
+			delete(shard.buckets, key)
+		}
+	}
+}
+
+func (k *KeyedRateLimiter) shard(key string) *keyedRateLimiterShard {
+	return &k.shards[fnv32(key)%keyedRateLimiterShards]
+}
@@ -16,12 +16,89 @@ const (
 	GO_NUTS_LOGGER_CONFIG_PROD = "prod"
 )
 
-// CHECK https://stackoverflow.com/questions/68472667/how-to-log-to-stdout-or-stderr-based-on-log-level-using-uber-go-zap
 func Init_Logger(targetLevel zapcore.Level, instanceId string, log2file bool, logfilePath string) *zap.SugaredLogger {
-	var LogConfig zap.Config
+	LogConfig := newLogConfig(targetLevel)
 
+	if log2file && logfilePath != "" {
+		logfileName := logfileNameFor(logfilePath, instanceId)
+		LogConfig.OutputPaths = append(LogConfig.OutputPaths, logfileName)
+		fmt.Printf("[nuts.logger] adding logfile: (%s)", logfileName)
+	}
+
+	logger, err := LogConfig.Build()
+	if err != nil {
+		fmt.Printf("[nuts.logger] ERROR! failed to create logger PANIC! \n%s", err)
+		panic(err)
+	}
+	defer logger.Sync() // flushes buffer, if any
+	return logger.Sugar()
+}
+
+// Init_LoggerSplitOutput creates a logger that writes entries below Error level to stdout
+// and entries at Error level and above to stderr, so container log collectors can classify them.
+// It uses the same encoder configuration as Init_Logger (including the GO_NUTS_LOGGER_CONFIG switch)
+// and the same optional file output, which receives all levels. The production config's sampling
+// applies to all outputs.
+// see https://stackoverflow.com/questions/68472667/how-to-log-to-stdout-or-stderr-based-on-log-level-using-uber-go-zap
+func Init_LoggerSplitOutput(targetLevel zapcore.Level, instanceId string, log2file bool, logfilePath string) *zap.SugaredLogger {
+	LogConfig := newLogConfig(targetLevel)
+
+	var encoder zapcore.Encoder
+	if LogConfig.Encoding == "json" {
+		encoder = zapcore.NewJSONEncoder(LogConfig.EncoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(LogConfig.EncoderConfig)
+	}
+
+	lowPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= targetLevel && lvl < zapcore.ErrorLevel
+	})
+	highPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= targetLevel && lvl >= zapcore.ErrorLevel
+	})
+
+	cores := []zapcore.Core{
+		zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), lowPriority),
+		zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), highPriority),
+	}
+
+	if log2file && logfilePath != "" {
+		logfileName := logfileNameFor(logfilePath, instanceId)
+		sink, _, err := zap.Open(logfileName)
+		if err != nil {
+			fmt.Printf("[nuts.logger] ERROR! failed to open logfile PANIC! \n%s", err)
+			panic(err)
+		}
+		cores = append(cores, zapcore.NewCore(encoder, sink, LogConfig.Level))
+		fmt.Printf("[nuts.logger] adding logfile: (%s)", logfileName)
+	}
+
+	options := []zap.Option{zap.AddCaller()}
+	if LogConfig.Development {
+		options = append(options, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	} else {
+		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+
+	core := zapcore.NewTee(cores...)
+	// Sample like LogConfig.Build does for Init_Logger, so both variants log the same volume
+	if sampling := LogConfig.Sampling; sampling != nil {
+		var samplerOptions []zapcore.SamplerOption
+		if sampling.Hook != nil {
+			samplerOptions = append(samplerOptions, zapcore.SamplerHook(sampling.Hook))
+		}
+		core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter, samplerOptions...)
+	}
+
+	logger := zap.New(core, options...)
+	defer logger.Sync() // flushes buffer, if any
+	return logger.Sugar()
+}
+
+// newLogConfig returns the logger config shared by the Init_Logger variants.
+func newLogConfig(targetLevel zapcore.Level) zap.Config {
 	// Set default log config
-	LogConfig = zap.NewDevelopmentConfig()
+	LogConfig := zap.NewDevelopmentConfig()
 	LogConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	LogConfig.EncoderConfig.EncodeTime = SyslogTimeEncoder
 	LogConfig.EncoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
@@ -33,20 +110,11 @@ func Init_Logger(targetLevel zapcore.Level, instanceId string, log2file bool, lo
 		LogConfig = zap.NewProductionConfig()
 		LogConfig.Level = zap.NewAtomicLevelAt(targetLevel)
 	}
+	return LogConfig
+}
 
-	if log2file && logfilePath != "" {
-		logfileName := logfilePath + "log_" + time.Now().Format("2006-01-02T15:04:05Z07:00") + "_" + instanceId + ".txt"
-		LogConfig.OutputPaths = append(LogConfig.OutputPaths, logfileName)
-		fmt.Printf("[nuts.logger] adding logfile: (%s)", logfileName)
-	}
-
-	logger, err := LogConfig.Build()
-	if err != nil {
-		fmt.Printf("[nuts.logger] ERROR! failed to create logger PANIC! \n%s", err)
-		panic(err)
-	}
-	defer logger.Sync() // flushes buffer, if any
-	return logger.Sugar()
+func logfileNameFor(logfilePath string, instanceId string) string {
+	return logfilePath + "log_" + time.Now().Format("2006-01-02T15:04:05Z07:00") + "_" + instanceId + ".txt"
 }
 
 func SyslogTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
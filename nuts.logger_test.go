@@ -0,0 +1,44 @@
+package gonuts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInitLoggerSplitOutputSampling(t *testing.T) {
+	t.Setenv(GO_NUTS_LOGGER_CONFIG, GO_NUTS_LOGGER_CONFIG_PROD)
+
+	// The cores lock os.Stdout and os.Stderr when the logger is created
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	dir := t.TempDir() + string(filepath.Separator)
+	logger := Init_LoggerSplitOutput(zapcore.InfoLevel, "sampling", true, dir)
+	os.Stdout, os.Stderr = stdout, stderr
+
+	for i := 0; i < 300; i++ {
+		logger.Info("same message")
+	}
+	_ = logger.Sync()
+
+	files, err := filepath.Glob(dir + "log_*_sampling.txt")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("log files = %v, %v, want one", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The production sampling logs the first 100 entries per second and every 100th after that
+	if lines := strings.Count(string(data), "\n"); lines != 102 {
+		t.Errorf("logged %d lines, want 102 with sampling", lines)
+	}
+}
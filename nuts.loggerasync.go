@@ -0,0 +1,231 @@
+package gonuts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AsyncDropPolicy decides what an AsyncCore does with an entry when its queue is full
+type AsyncDropPolicy int
+
+const (
+	// AsyncDropNewest drops the entry being logged and counts it (see DroppedCount)
+	AsyncDropNewest AsyncDropPolicy = iota
+	// AsyncBlock makes the logging goroutine wait until the queue has room
+	AsyncBlock
+)
+
+// AsyncCore is a zapcore.Core that hands entries to a dedicated goroutine, which writes them to
+// the wrapped core, so logging on hot paths does not wait for encoding and I/O.
+//
+// Guarantees:
+//   - Entries are written in the order they were queued, so the entries of one goroutine keep
+//     their order; entries of different goroutines are ordered by the time they were queued.
+//   - DPanic, Panic and Fatal entries are written synchronously, after all queued entries, and
+//     the wrapped core is synced before the logger panics or exits.
+//   - With AsyncDropNewest, entries logged while the queue is full are dropped; the remaining
+//     entries keep their order.
+//
+// Fields are encoded on the writer goroutine, so values passed by reference (pointers, maps,
+// slices, zap.Object) must not be modified after logging them.
+//
+// The core takes encoding and I/O off the logging goroutine; it does not raise the sustained
+// throughput, which is that of the single writer goroutine. Bursts are absorbed by the queue,
+// but when entries keep arriving faster than they are written, AsyncBlock slows the logging
+// goroutines down to the writer and AsyncDropNewest drops entries.
+type AsyncCore struct {
+	core  zapcore.Core // the wrapped core, with the fields added by With
+	queue *asyncLogQueue
+}
+
+// asyncLogQueue is shared by an AsyncCore and the cores derived from it with With
+type asyncLogQueue struct {
+	entries chan asyncLogEntry
+	policy  AsyncDropPolicy
+	dropped atomic.Uint64
+	done    chan struct{} // closed when the writer goroutine has exited
+
+	mu     sync.RWMutex // held for reading while sending, so Close can close entries
+	closed bool
+}
+
+// asyncLogEntry is a queued entry, or a Flush marker if flushed is set
+type asyncLogEntry struct {
+	core    zapcore.Core
+	ent     zapcore.Entry
+	fields  []zapcore.Field
+	flushed chan struct{}
+}
+
+// NewAsyncCore wraps a core so entries are written asynchronously from a dedicated goroutine.
+// Call Close (or at least Flush) on shutdown, so queued entries are not lost.
+//
+// Parameters:
+//   - inner: the core that writes the entries
+//   - queueSize: the number of entries that can be queued (at least 1)
+//   - dropPolicy: AsyncDropNewest to drop entries while the queue is full, AsyncBlock to wait
+//
+// Returns:
+//   - *AsyncCore: the core to build the logger with
+//
+// Example usage:
+//
+//	async := gonuts.NewAsyncCore(gonuts.L.Desugar().Core(), 8192, gonuts.AsyncDropNewest)
+//	gonuts.L = zap.New(async, zap.AddCaller()).Sugar()
+//	defer async.Close(context.Background())
+func NewAsyncCore(inner zapcore.Core, queueSize int, dropPolicy AsyncDropPolicy) *AsyncCore {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	q := &asyncLogQueue{
+		entries: make(chan asyncLogEntry, queueSize),
+		policy:  dropPolicy,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncCore{core: inner, queue: q}
+}
+
+// Enabled reports whether the wrapped core logs the level
+func (c *AsyncCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+// With returns a core that adds fields to every entry and shares the queue of c
+func (c *AsyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &AsyncCore{core: c.core.With(fields), queue: c.queue}
+}
+
+// Check adds the core to the checked entry if the level is enabled
+func (c *AsyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write queues the entry, or writes it synchronously if it is a DPanic, Panic or Fatal entry
+// or the core was closed
+func (c *AsyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < zapcore.DPanicLevel && c.queue.send(asyncLogEntry{core: c.core, ent: ent, fields: append([]zapcore.Field(nil), fields...)}) {
+		return nil
+	}
+	// Queued entries first, so the last entries before a crash are complete and in order
+	_ = c.queue.flush(context.Background())
+	if err := c.core.Write(ent, fields); err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		return c.core.Sync()
+	}
+	return nil
+}
+
+// Sync writes the queued entries and syncs the wrapped core
+func (c *AsyncCore) Sync() error {
+	_ = c.queue.flush(context.Background())
+	return c.core.Sync()
+}
+
+// Flush waits until the entries queued before the call are written, or ctx is done
+//
+// Returns:
+//   - error: ctx.Err() if ctx was done first
+func (c *AsyncCore) Flush(ctx context.Context) error {
+	return c.queue.flush(ctx)
+}
+
+// Close writes the queued entries, stops the writer goroutine and syncs the wrapped core.
+// Entries logged afterwards are written synchronously. Calling Close again only syncs.
+//
+// Returns:
+//   - error: ctx.Err() if the queue was not drained before ctx was done, otherwise the error of syncing
+func (c *AsyncCore) Close(ctx context.Context) error {
+	q := c.queue
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return c.core.Sync()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DroppedCount returns the number of entries dropped because the queue was full
+func (c *AsyncCore) DroppedCount() uint64 {
+	return c.queue.dropped.Load()
+}
+
+// send queues an entry according to the drop policy. It returns false if the queue is closed.
+func (q *asyncLogQueue) send(e asyncLogEntry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+	if q.policy == AsyncBlock {
+		q.entries <- e
+		return true
+	}
+	select {
+	case q.entries <- e:
+	default:
+		q.dropped.Add(1)
+	}
+	return true
+}
+
+// flush queues a marker and waits until the writer goroutine reaches it
+func (q *asyncLogQueue) flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		select {
+		case <-q.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	select {
+	case q.entries <- asyncLogEntry{flushed: flushed}:
+		q.mu.RUnlock()
+	case <-ctx.Done():
+		q.mu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes queued entries until the queue is closed and drained
+func (q *asyncLogQueue) run() {
+	defer close(q.done)
+	for e := range q.entries {
+		if e.flushed != nil {
+			close(e.flushed)
+			continue
+		}
+		if err := e.core.Write(e.ent, e.fields); err != nil {
+			// like zap, which reports write errors to its error output
+			fmt.Fprintf(os.Stderr, "%v async log write error: %v\n", time.Now(), err)
+		}
+	}
+}
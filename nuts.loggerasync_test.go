@@ -0,0 +1,155 @@
+package gonuts
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAsyncCoreKeepsOrderPerGoroutine(t *testing.T) {
+	const goroutines, perGoroutine = 16, 500
+	inner, logs := observer.New(zapcore.DebugLevel)
+	async := NewAsyncCore(inner, 64, AsyncBlock)
+	logger := zap.New(async)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				logger.Info("entry", zap.Int("g", g), zap.Int("i", i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.All()
+	if len(entries) != goroutines*perGoroutine {
+		t.Fatalf("%d entries written, want %d", len(entries), goroutines*perGoroutine)
+	}
+	next := make(map[int64]int64)
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		g, i := fields["g"].(int64), fields["i"].(int64)
+		if i != next[g] {
+			t.Fatalf("goroutine %d: entry %d written when %d was expected", g, i, next[g])
+		}
+		next[g]++
+	}
+	if n := async.DroppedCount(); n != 0 {
+		t.Errorf("DroppedCount() = %d with AsyncBlock, want 0", n)
+	}
+}
+
+// gatedCore blocks writes until the gate is closed
+type gatedCore struct {
+	zapcore.Core
+	gate chan struct{}
+}
+
+func (c *gatedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *gatedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	<-c.gate
+	return c.Core.Write(ent, fields)
+}
+
+func TestAsyncCoreDropsNewestWhenFull(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	gate := make(chan struct{})
+	async := NewAsyncCore(&gatedCore{Core: inner, gate: gate}, 2, AsyncDropNewest)
+	logger := zap.New(async)
+
+	// The writer blocks on the first entry, two more fill the queue, the rest is dropped
+	for i := 0; i < 10; i++ {
+		logger.Info("entry", zap.Int("i", i))
+	}
+	dropped := async.DroppedCount()
+	close(gate)
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	written := int64(logs.Len())
+	if dropped == 0 || written+int64(dropped) != 10 {
+		t.Fatalf("%d entries written and %d dropped, want some dropped and 10 in total", written, dropped)
+	}
+	previous := int64(-1)
+	for _, entry := range logs.All() {
+		i := entry.ContextMap()["i"].(int64)
+		if i <= previous {
+			t.Fatalf("entry %d written after entry %d", i, previous)
+		}
+		previous = i
+	}
+}
+
+func TestAsyncCoreWritesDPanicSynchronouslyAfterQueued(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	async := NewAsyncCore(inner, 128, AsyncBlock)
+	defer async.Close(context.Background())
+	logger := zap.New(async) // not in development mode, so DPanic does not panic
+
+	for i := 0; i < 100; i++ {
+		logger.Info("queued")
+	}
+	logger.DPanic("crash")
+
+	// No Flush: the DPanic entry was written synchronously, after all queued entries
+	entries := logs.All()
+	if len(entries) != 101 {
+		t.Fatalf("%d entries written when DPanic returned, want 101", len(entries))
+	}
+	if last := entries[len(entries)-1]; last.Level != zapcore.DPanicLevel {
+		t.Errorf("last entry is %s %q, want the DPanic entry", last.Level, last.Message)
+	}
+}
+
+// BenchmarkAsyncCore compares the time the logging goroutines spend per entry with a JSON core
+// writing synchronously and with the same core behind an AsyncCore
+func BenchmarkAsyncCore(b *testing.B) {
+	newCore := func() zapcore.Core {
+		encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		return zapcore.NewCore(encoder, zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	}
+	fields := []zap.Field{zap.String("method", "GET"), zap.String("path", "/v1/users"), zap.Int("status", 200)}
+
+	b.Run("sync", func(b *testing.B) {
+		logger := zap.New(newCore())
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info("request", fields...)
+			}
+		})
+	})
+	for _, policy := range []struct {
+		name   string
+		policy AsyncDropPolicy
+	}{{"async block", AsyncBlock}, {"async drop", AsyncDropNewest}} {
+		b.Run(policy.name, func(b *testing.B) {
+			async := NewAsyncCore(newCore(), 8192, policy.policy)
+			logger := zap.New(async)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.Info("request", fields...)
+				}
+			})
+			b.StopTimer()
+			_ = async.Close(context.Background())
+			b.ReportMetric(float64(async.DroppedCount())/float64(b.N), "dropped/op")
+		})
+	}
+}
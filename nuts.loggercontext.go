@@ -2,6 +2,8 @@ package gonuts
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"go.uber.org/zap"
 )
@@ -16,12 +18,77 @@ const (
 
 type ctxKey int
 
+// ContextLogFieldExtractor extracts a log field value from a context. It returns false if the value is not present.
+type ContextLogFieldExtractor func(ctx context.Context) (string, bool)
+
+type contextLogField struct {
+	key       string
+	extractor ContextLogFieldExtractor
+}
+
+// contextLogFields holds the registered context log fields in registration order.
+var contextLogFields = struct {
+	mu     sync.RWMutex
+	fields []contextLogField
+}{}
+
 const (
 	requestIdCtxKey ctxKey = iota
 	requestOrgIdCtxKey
 	requestUserIdCtxKey
 )
 
+// RegisterContextLogField registers an additional field that LoggerFieldsFromContext and NewLoggerFromContext
+// include whenever the extractor finds a value in the context. Fields are added in registration order,
+// after the built-in request fields. Registering a key twice (or one of the built-in keys) returns an error.
+//
+// Example usage:
+//
+//	err := gonuts.RegisterContextLogField("tenant", func(ctx context.Context) (string, bool) {
+//		tenant, ok := ctx.Value(tenantCtxKey).(string)
+//		return tenant, ok && tenant != ""
+//	})
+func RegisterContextLogField(key string, extractor ContextLogFieldExtractor) error {
+	if key == "" || extractor == nil {
+		return fmt.Errorf("context log field requires a key and an extractor")
+	}
+	if key == RequestIdFieldKey || key == RequestOrgIdFieldKey || key == RequestUserIdFieldKey {
+		return fmt.Errorf("context log field %q is built in", key)
+	}
+
+	contextLogFields.mu.Lock()
+	defer contextLogFields.mu.Unlock()
+	for _, field := range contextLogFields.fields {
+		if field.key == key {
+			return fmt.Errorf("context log field %q is already registered", key)
+		}
+	}
+	contextLogFields.fields = append(contextLogFields.fields, contextLogField{key: key, extractor: extractor})
+	return nil
+}
+
+// registeredContextLogFields returns the key/value pairs of all registered fields present in ctx.
+func registeredContextLogFields(ctx context.Context) []contextLogValue {
+	if ctx == nil {
+		return nil
+	}
+	contextLogFields.mu.RLock()
+	defer contextLogFields.mu.RUnlock()
+
+	values := make([]contextLogValue, 0, len(contextLogFields.fields))
+	for _, field := range contextLogFields.fields {
+		if value, ok := field.extractor(ctx); ok {
+			values = append(values, contextLogValue{key: field.key, value: value})
+		}
+	}
+	return values
+}
+
+type contextLogValue struct {
+	key   string
+	value string
+}
+
 func GenerateRequestId() string {
 	return NID(requestIdPrefix, requestIdLength)
 }
@@ -88,6 +155,10 @@ func LoggerFieldsFromContext(ctx context.Context) []zap.Field {
 	if requestUserId != "" {
 		fields = append(fields, zap.String(RequestUserIdFieldKey, requestUserId))
 	}
+
+	for _, field := range registeredContextLogFields(ctx) {
+		fields = append(fields, zap.String(field.key, field.value))
+	}
 	return fields
 }
 
@@ -111,6 +182,10 @@ func NewLoggerFromContext(ctx context.Context, logger *zap.SugaredLogger) *zap.S
 		logger = logger.With(RequestUserIdFieldKey, requestUserId)
 	}
 
+	for _, field := range registeredContextLogFields(ctx) {
+		logger = logger.With(field.key, field.value)
+	}
+
 	return logger
 }
 
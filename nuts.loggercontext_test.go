@@ -0,0 +1,81 @@
+package gonuts
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type loggerContextTestKey string
+
+// loggerContextTestExtractor reads a value stored under key, so the registered fields stay out of other tests
+func loggerContextTestExtractor(key loggerContextTestKey) ContextLogFieldExtractor {
+	return func(ctx context.Context) (string, bool) {
+		value, ok := ctx.Value(key).(string)
+		return value, ok && value != ""
+	}
+}
+
+func TestRegisterContextLogField(t *testing.T) {
+	contextLogFields.mu.Lock()
+	registered := contextLogFields.fields
+	contextLogFields.mu.Unlock()
+	t.Cleanup(func() {
+		contextLogFields.mu.Lock()
+		contextLogFields.fields = registered
+		contextLogFields.mu.Unlock()
+	})
+
+	for _, key := range []string{"loggercontexttest-tenant", "loggercontexttest-region"} {
+		if err := RegisterContextLogField(key, loggerContextTestExtractor(loggerContextTestKey(key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extractor := loggerContextTestExtractor("unused")
+	for _, tt := range []struct {
+		key       string
+		extractor ContextLogFieldExtractor
+	}{
+		{"", extractor},
+		{"loggercontexttest-nil", nil},
+		{RequestIdFieldKey, extractor},
+		{RequestUserIdFieldKey, extractor},
+		{"loggercontexttest-tenant", extractor},
+	} {
+		if err := RegisterContextLogField(tt.key, tt.extractor); err == nil {
+			t.Errorf("RegisterContextLogField(%q) succeeded, want an error", tt.key)
+		}
+	}
+
+	ctx := NewContextWithRequestId(context.Background(), "rid-1")
+	ctx = context.WithValue(ctx, loggerContextTestKey("loggercontexttest-region"), "eu")
+	ctx = context.WithValue(ctx, loggerContextTestKey("loggercontexttest-tenant"), "acme")
+
+	// Registered fields follow the built-in ones in registration order, absent ones are left out
+	var keys []string
+	for _, field := range LoggerFieldsFromContext(ctx) {
+		keys = append(keys, field.Key)
+	}
+	want := []string{RequestIdFieldKey, "loggercontexttest-tenant", "loggercontexttest-region"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] || keys[2] != want[2] {
+		t.Errorf("LoggerFieldsFromContext keys = %v, want %v", keys, want)
+	}
+	tenantOnly := context.WithValue(context.Background(), loggerContextTestKey("loggercontexttest-tenant"), "acme")
+	if fields := LoggerFieldsFromContext(tenantOnly); len(fields) != 1 || fields[0].String != "acme" {
+		t.Errorf("LoggerFieldsFromContext = %v, want only the tenant", fields)
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	NewLoggerFromContext(ctx, zap.New(core).Sugar()).Info("handled")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("%d entries logged, want 1", len(entries))
+	}
+	if fields := entries[0].ContextMap(); fields["loggercontexttest-tenant"] != "acme" || fields["loggercontexttest-region"] != "eu" || fields[RequestIdFieldKey] != "rid-1" {
+		t.Errorf("logged fields = %v", fields)
+	}
+}
@@ -0,0 +1,168 @@
+package gonuts
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	errorRateWindowSeconds = 60
+	errorRateLevels        = int(zapcore.FatalLevel-zapcore.DebugLevel) + 1
+)
+
+// ErrorRateGuardOptions configures an ErrorRateGuard
+type ErrorRateGuardOptions struct {
+	Threshold   float64            // Error (and above) entries per minute that trigger the alert
+	Cooldown    time.Duration      // Minimum time between two alerts (default 1 minute)
+	OnThreshold func(rate float64) // Called with the current error rate when the threshold is crossed
+	Emitter     *EventEmitter      // Optional emitter that receives EventName with the rate as argument
+	EventName   string             // Event emitted on Emitter (default "logger.errorRate")
+}
+
+// ErrorRateGuard counts log entries per level in a sliding one-minute window and raises
+// an alert when the rate of Error (and above) entries crosses a threshold.
+//
+// Counting is lock-free: each level has a ring of 60 one-second buckets updated with atomic
+// operations, so the hot path costs a few atomic instructions per log call. Counts are
+// approximate at bucket boundaries under heavy concurrency, which is fine for alerting.
+// Alerts run in their own goroutine, so a callback may log without recursing into the guard.
+type ErrorRateGuard struct {
+	opts      ErrorRateGuardOptions
+	buckets   [errorRateLevels][errorRateWindowSeconds]errorRateBucket
+	lastFired atomic.Int64 // unix nanos of the last alert
+}
+
+type errorRateBucket struct {
+	second atomic.Int64
+	count  atomic.Int64
+}
+
+// NewErrorRateGuard creates a new ErrorRateGuard
+//
+// Example usage:
+//
+//	guard := gonuts.NewErrorRateGuard(gonuts.ErrorRateGuardOptions{
+//	    Threshold: 100,
+//	    OnThreshold: func(rate float64) {
+//	        alerting.Page("error rate at %.0f/min", rate)
+//	    },
+//	})
+//	logger := zap.New(core, guard.Option())
+func NewErrorRateGuard(opts ErrorRateGuardOptions) *ErrorRateGuard {
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = time.Minute
+	}
+	if opts.EventName == "" {
+		opts.EventName = "logger.errorRate"
+	}
+	return &ErrorRateGuard{opts: opts}
+}
+
+// Wrap returns a core that counts the entries passing through core
+func (g *ErrorRateGuard) Wrap(core zapcore.Core) zapcore.Core {
+	return &errorRateCore{Core: core, guard: g}
+}
+
+// Option returns a zap.Option that wraps a logger's core with the guard
+//
+// Example usage:
+//
+//	logger := L.Desugar().WithOptions(guard.Option()).Sugar()
+func (g *ErrorRateGuard) Option() zap.Option {
+	return zap.WrapCore(g.Wrap)
+}
+
+// LevelRates returns the number of entries per level over the last minute, keyed by level name
+func (g *ErrorRateGuard) LevelRates() map[string]float64 {
+	now := time.Now().Unix()
+	rates := make(map[string]float64, errorRateLevels)
+	for i := 0; i < errorRateLevels; i++ {
+		rates[(zapcore.DebugLevel + zapcore.Level(i)).String()] = float64(g.levelCount(i, now))
+	}
+	return rates
+}
+
+// ErrorRate returns the number of Error (and above) entries over the last minute
+func (g *ErrorRateGuard) ErrorRate() float64 {
+	return g.errorRate(time.Now().Unix())
+}
+
+func (g *ErrorRateGuard) errorRate(now int64) float64 {
+	var total int64
+	for i := int(zapcore.ErrorLevel - zapcore.DebugLevel); i < errorRateLevels; i++ {
+		total += g.levelCount(i, now)
+	}
+	return float64(total)
+}
+
+// levelCount sums the buckets of a level that fall into the window ending at now
+func (g *ErrorRateGuard) levelCount(level int, now int64) int64 {
+	var total int64
+	for i := range g.buckets[level] {
+		b := &g.buckets[level][i]
+		if now-b.second.Load() < errorRateWindowSeconds {
+			total += b.count.Load()
+		}
+	}
+	return total
+}
+
+// record counts an entry and checks the threshold for Error (and above) entries
+func (g *ErrorRateGuard) record(ent zapcore.Entry) {
+	level := int(ent.Level - zapcore.DebugLevel)
+	if level < 0 || level >= errorRateLevels {
+		return
+	}
+	second := ent.Time.Unix()
+	b := &g.buckets[level][second%errorRateWindowSeconds]
+	if old := b.second.Load(); old != second && b.second.CompareAndSwap(old, second) {
+		b.count.Store(0)
+	}
+	b.count.Add(1)
+
+	if ent.Level >= zapcore.ErrorLevel && g.opts.Threshold > 0 {
+		g.checkThreshold(ent.Time)
+	}
+}
+
+func (g *ErrorRateGuard) checkThreshold(now time.Time) {
+	rate := g.errorRate(now.Unix())
+	if rate < g.opts.Threshold {
+		return
+	}
+	last := g.lastFired.Load()
+	if last != 0 && now.UnixNano()-last < int64(g.opts.Cooldown) {
+		return
+	}
+	if !g.lastFired.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	go func() {
+		if g.opts.OnThreshold != nil {
+			g.opts.OnThreshold(rate)
+		}
+		if g.opts.Emitter != nil {
+			g.opts.Emitter.Emit(g.opts.EventName, rate)
+		}
+	}()
+}
+
+// errorRateCore counts entries in Check so that sampling and other inner cores are unaffected
+type errorRateCore struct {
+	zapcore.Core
+	guard *ErrorRateGuard
+}
+
+func (c *errorRateCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorRateCore{Core: c.Core.With(fields), guard: c.guard}
+}
+
+func (c *errorRateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Enabled(ent.Level) {
+		c.guard.record(ent)
+	}
+	return c.Core.Check(ent, ce)
+}
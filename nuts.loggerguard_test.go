@@ -0,0 +1,139 @@
+package gonuts
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// errorRateAlert waits for the next alert sent to alerts by an OnThreshold callback
+func errorRateAlert(t *testing.T, alerts <-chan float64) float64 {
+	t.Helper()
+	select {
+	case rate := <-alerts:
+		return rate
+	case <-time.After(time.Second):
+		t.Fatal("no alert within a second")
+		return 0
+	}
+}
+
+func TestErrorRateGuardCountsEnabledEntries(t *testing.T) {
+	guard := NewErrorRateGuard(ErrorRateGuardOptions{})
+	inner, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(inner, guard.Option()).Sugar()
+
+	logger.Debug("disabled, not counted")
+	for i := 0; i < 3; i++ {
+		logger.Info("info")
+	}
+	logger.Warn("warn")
+	logger.With("request", 1).Error("error")
+	logger.Error("error")
+
+	rates := guard.LevelRates()
+	if rates["debug"] != 0 || rates["info"] != 3 || rates["warn"] != 1 || rates["error"] != 2 || rates["fatal"] != 0 {
+		t.Errorf("LevelRates() = %v", rates)
+	}
+	if rate := guard.ErrorRate(); rate != 2 {
+		t.Errorf("ErrorRate() = %v, want 2", rate)
+	}
+	if n := logs.Len(); n != 6 {
+		t.Errorf("the inner core got %d entries, want all 6 enabled ones", n)
+	}
+}
+
+func TestErrorRateGuardSlidingWindow(t *testing.T) {
+	guard := NewErrorRateGuard(ErrorRateGuardOptions{})
+	t0 := time.Unix(1_700_000_000, 0)
+	errorAt := func(offset time.Duration) {
+		guard.record(zapcore.Entry{Level: zapcore.ErrorLevel, Time: t0.Add(offset)})
+	}
+
+	errorAt(0)
+	errorAt(0)
+	errorAt(30 * time.Second)
+	guard.record(zapcore.Entry{Level: zapcore.DPanicLevel, Time: t0})
+	if rate := guard.errorRate(t0.Unix() + 59); rate != 4 {
+		t.Errorf("rate 59s later = %v, want all 4 entries of Error and above", rate)
+	}
+	if rate := guard.errorRate(t0.Unix() + 60); rate != 1 {
+		t.Errorf("rate 60s later = %v, want only the entry at 30s", rate)
+	}
+
+	// A minute later the bucket of t0 is reused and starts from zero
+	errorAt(time.Minute)
+	if rate := guard.errorRate(t0.Unix() + 60); rate != 2 {
+		t.Errorf("rate after reusing a bucket = %v, want 2", rate)
+	}
+}
+
+func TestErrorRateGuardThresholdAndCooldown(t *testing.T) {
+	alerts := make(chan float64, 10)
+	emitted := make(chan float64, 10)
+	emitter := NewEventEmitter()
+	if _, err := emitter.On("alerts.logger", "collect", func(rate float64) { emitted <- rate }); err != nil {
+		t.Fatal(err)
+	}
+	guard := NewErrorRateGuard(ErrorRateGuardOptions{
+		Threshold:   3,
+		Cooldown:    30 * time.Second,
+		OnThreshold: func(rate float64) { alerts <- rate },
+		Emitter:     emitter,
+		EventName:   "alerts.logger",
+	})
+	t0 := time.Unix(1_700_000_000, 0)
+	record := func(level zapcore.Level, offset time.Duration) {
+		guard.record(zapcore.Entry{Level: level, Time: t0.Add(offset)})
+	}
+
+	record(zapcore.ErrorLevel, 0)
+	record(zapcore.ErrorLevel, time.Second)
+	for i := 0; i < 5; i++ {
+		record(zapcore.WarnLevel, time.Second) // below Error, never counted towards the threshold
+	}
+	if guard.lastFired.Load() != 0 {
+		t.Fatal("alert fired below the threshold")
+	}
+
+	record(zapcore.ErrorLevel, 2*time.Second)
+	if rate := errorRateAlert(t, alerts); rate != 3 {
+		t.Errorf("OnThreshold got rate %v, want 3", rate)
+	}
+	if rate := errorRateAlert(t, emitted); rate != 3 {
+		t.Errorf("the emitted event carries rate %v, want 3", rate)
+	}
+
+	// Within the cooldown the threshold is crossed again without an alert
+	fired := guard.lastFired.Load()
+	record(zapcore.ErrorLevel, 10*time.Second)
+	if guard.lastFired.Load() != fired {
+		t.Error("alert fired again within the cooldown")
+	}
+
+	record(zapcore.ErrorLevel, 40*time.Second)
+	if rate := errorRateAlert(t, alerts); rate != 5 {
+		t.Errorf("OnThreshold after the cooldown got rate %v, want 5", rate)
+	}
+	errorRateAlert(t, emitted)
+	if len(alerts) != 0 || len(emitted) != 0 {
+		t.Errorf("%d extra alerts and %d extra events", len(alerts), len(emitted))
+	}
+}
+
+func TestErrorRateGuardDefaults(t *testing.T) {
+	guard := NewErrorRateGuard(ErrorRateGuardOptions{})
+	if guard.opts.Cooldown != time.Minute || guard.opts.EventName != "logger.errorRate" {
+		t.Errorf("defaults = %+v", guard.opts)
+	}
+	// Without a threshold nothing fires
+	for i := 0; i < 100; i++ {
+		guard.record(zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now()})
+	}
+	if guard.lastFired.Load() != 0 {
+		t.Error("alert fired without a threshold")
+	}
+}
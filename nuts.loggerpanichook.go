@@ -0,0 +1,128 @@
+package gonuts
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicHookOption configures InstallPanicHook
+type PanicHookOption func(*panicHook)
+
+// WithPanicExit makes the hook exit the process with the given code after logging, instead of
+// re-panicking. Deferred functions of other goroutines do not run in either case.
+func WithPanicExit(code int) PanicHookOption {
+	return func(h *panicHook) {
+		h.exit = true
+		h.exitCode = code
+	}
+}
+
+// panicHook logs recovered panics before the process dies
+type panicHook struct {
+	logger   *zap.Logger
+	exit     bool
+	exitCode int
+}
+
+var installedPanicHook atomic.Pointer[panicHook]
+
+// InstallPanicHook makes panics in goroutines started with Go, and in functions that defer
+// RecoverPanic, log a structured record before the process dies: the panic value, the stack of
+// the panicking goroutine and GetProcessStats, at Fatal level. The logger is synced afterwards,
+// so buffered entries are not lost. Then the panic is re-raised, or the process exits if
+// WithPanicExit is set. Installing a hook again replaces the previous one.
+//
+// Panics in goroutines started with the go statement cannot be intercepted, so start
+// long-running goroutines with Go and defer RecoverPanic at the top of main.
+//
+// Parameters:
+//   - logger: the logger of the panic records (L if nil)
+//   - opts: WithPanicExit
+//
+// Example usage:
+//
+//	func main() {
+//		gonuts.InstallPanicHook(gonuts.L, gonuts.WithPanicExit(2))
+//		defer gonuts.RecoverPanic()
+//
+//		gonuts.Go(consumeQueue)
+//		serve()
+//	}
+func InstallPanicHook(logger *zap.SugaredLogger, opts ...PanicHookOption) {
+	if logger == nil {
+		logger = L
+	}
+	h := &panicHook{
+		// the hook decides itself whether to exit, after syncing
+		logger: logger.Desugar().WithOptions(zap.WithFatalHook(panicHookNoop{}), zap.AddCallerSkip(3)),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	installedPanicHook.Store(h)
+}
+
+// Go runs fn in a new goroutine whose panics are handled by the installed panic hook (see
+// InstallPanicHook). Without a hook, panics crash the process as usual.
+//
+// Example usage:
+//
+//	gonuts.Go(func() {
+//		for msg := range messages {
+//			handle(msg)
+//		}
+//	})
+func Go(fn func()) {
+	go func() {
+		defer RecoverPanic()
+		fn()
+	}()
+}
+
+// RecoverPanic handles a panic of the calling goroutine with the installed panic hook; it must be
+// called directly by a deferred statement. It does nothing if no hook is installed, so the panic
+// continues unchanged.
+//
+// Example usage:
+//
+//	func main() {
+//		gonuts.InstallPanicHook(logger)
+//		defer gonuts.RecoverPanic()
+//		...
+//	}
+func RecoverPanic() {
+	h := installedPanicHook.Load()
+	if h == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		h.handle(r, debug.Stack())
+	}
+}
+
+// handle logs the panic, syncs the logger and re-panics or exits
+func (h *panicHook) handle(value interface{}, stack []byte) {
+	if ce := h.logger.Check(zapcore.FatalLevel, "panic"); ce != nil {
+		ce.Write(
+			zap.String("panic", fmt.Sprint(value)),
+			zap.String("panic_type", fmt.Sprintf("%T", value)),
+			zap.ByteString("stack", stack),
+			zap.Any("process", GetProcessStats()),
+		)
+	}
+	_ = h.logger.Sync()
+	if h.exit {
+		os.Exit(h.exitCode)
+	}
+	panic(value)
+}
+
+// panicHookNoop keeps Fatal entries of the hook from exiting before the logger is synced
+type panicHookNoop struct{}
+
+func (panicHookNoop) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
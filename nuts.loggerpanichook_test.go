@@ -0,0 +1,111 @@
+package gonuts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// panicHookTestSink records what reaches the underlying writer and how often it is synced
+type panicHookTestSink struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	syncs int
+}
+
+func (s *panicHookTestSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *panicHookTestSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncs++
+	return nil
+}
+
+func (s *panicHookTestSink) contents() (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String(), s.syncs
+}
+
+// panicHookTestPanic panics with value, deferring RecoverPanic like a goroutine started with Go
+func panicHookTestPanic(value interface{}) {
+	defer RecoverPanic()
+	panic(value)
+}
+
+// recoverPanicHookTest returns the value of the panic re-raised by the hook
+func recoverPanicHookTest(fn func()) (recovered interface{}) {
+	defer func() { recovered = recover() }()
+	fn()
+	return nil
+}
+
+func TestPanicHookFlushesBufferedLogs(t *testing.T) {
+	previous := installedPanicHook.Load()
+	t.Cleanup(func() { installedPanicHook.Store(previous) })
+
+	sink := &panicHookTestSink{}
+	// The buffer holds the entries until the logger is synced
+	buffered := &zapcore.BufferedWriteSyncer{WS: sink, Size: 1 << 20, FlushInterval: time.Hour}
+	defer buffered.Stop()
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), buffered, zapcore.InfoLevel), zap.AddCaller())
+
+	logger.Info("before the panic")
+	if written, _ := sink.contents(); written != "" {
+		t.Fatalf("the sink got %q before any sync", written)
+	}
+
+	InstallPanicHook(logger.Sugar())
+	if recovered := recoverPanicHookTest(func() { panicHookTestPanic("boom") }); recovered != "boom" {
+		t.Fatalf("recovered %v, want the panic re-raised with its value", recovered)
+	}
+
+	written, syncs := sink.contents()
+	if syncs == 0 {
+		t.Error("the hook did not sync the logger")
+	}
+	lines := strings.Split(strings.TrimSpace(written), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "before the panic") {
+		t.Fatalf("the sink got %q, want the earlier entry and the panic record flushed", written)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "fatal" || record["msg"] != "panic" || record["panic"] != "boom" || record["panic_type"] != "string" {
+		t.Errorf("panic record = %v", record)
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "panicHookTestPanic") {
+		t.Errorf("stack does not contain the panicking function:\n%s", stack)
+	}
+	if _, ok := record["process"].(map[string]interface{}); !ok {
+		t.Errorf("process = %v, want the process stats", record["process"])
+	}
+	if caller, _ := record["caller"].(string); !strings.Contains(caller, "nuts.loggerpanichook_test.go") {
+		t.Errorf("caller = %q, want the panicking function", caller)
+	}
+}
+
+func TestRecoverPanicWithoutHook(t *testing.T) {
+	previous := installedPanicHook.Load()
+	installedPanicHook.Store(nil)
+	t.Cleanup(func() { installedPanicHook.Store(previous) })
+
+	if recovered := recoverPanicHookTest(func() { panicHookTestPanic("boom") }); recovered != "boom" {
+		t.Errorf("recovered %v, want the panic to continue unchanged", recovered)
+	}
+	recoverPanicHookTest(func() {
+		defer RecoverPanic() // no panic, nothing to do
+	})
+}
@@ -1,23 +1,97 @@
 package gonuts
 
 import (
+	"container/list"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // MemoizedFunc is a wrapper for a memoized function
 type MemoizedFunc struct {
-	mu    sync.RWMutex
-	cache map[string]cacheEntry
-	f     interface{}
-	ttl   time.Duration
+	mu        sync.RWMutex
+	cache     map[string]*cacheEntry
+	tagIndex  map[string]map[string]struct{} // tag -> cache keys, guarded by mu
+	lru       *list.List                     // cache keys, most recently used first, guarded by mu
+	inflight  map[string]*memoCall           // calls of f in progress by cache key, guarded by mu
+	lastPurge time.Time                      // guarded by mu
+	f         interface{}
+	ttl       time.Duration
+	keyFunc   func(args []interface{}) string
+
+	cacheErrors bool // cache results whose error return is non-nil (see WithErrorCaching)
+	maxEntries  int  // evict the least recently used entry beyond this size, 0 for no limit
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// MemoizeStats are the cache statistics of a MemoizedFunc
+type MemoizeStats struct {
+	Hits      uint64 // calls answered without calling the function, including calls that waited for a concurrent call with the same key
+	Misses    uint64 // calls of the function
+	Evictions uint64 // entries removed because they expired or to stay within WithMaxEntries
+	Size      int    // current number of cached entries
+}
+
+// memoCall is a call of the memoized function that concurrent callers with the same key wait for
+type memoCall struct {
+	done    chan struct{}
+	results []interface{}
+	err     error
+}
+
+// MemoizeOption configures a MemoizedFunc created with MemoizeWithOptions
+type MemoizeOption func(*memoizeConfig)
+
+type memoizeConfig struct {
+	ignoredArgs []int
+	keyFunc     func(args []interface{}) string
+	cacheErrors bool
+	maxEntries  int
+}
+
+// WithIgnoredArgs excludes the arguments at the given positions (0-based) from the cache key.
+// The arguments are still passed to the underlying function on a cache miss.
+// This is useful for arguments like context.Context, loggers or trace IDs.
+func WithIgnoredArgs(positions ...int) MemoizeOption {
+	return func(c *memoizeConfig) {
+		c.ignoredArgs = append(c.ignoredArgs, positions...)
+	}
+}
+
+// WithKeyFunc sets a custom function computing the cache key from the call arguments.
+// It takes precedence over WithIgnoredArgs.
+func WithKeyFunc(keyFunc func(args []interface{}) string) MemoizeOption {
+	return func(c *memoizeConfig) {
+		c.keyFunc = keyFunc
+	}
+}
+
+// WithErrorCaching caches results whose last return value is a non-nil error, so failing calls
+// are not retried until the entry expires. By default they are returned but not cached.
+func WithErrorCaching() MemoizeOption {
+	return func(c *memoizeConfig) {
+		c.cacheErrors = true
+	}
+}
+
+// WithMaxEntries limits the cache to n entries, evicting the least recently used entry when
+// a new result is cached. n <= 0 means no limit.
+func WithMaxEntries(n int) MemoizeOption {
+	return func(c *memoizeConfig) {
+		c.maxEntries = n
+	}
 }
 
 type cacheEntry struct {
-	result interface{}
-	expiry time.Time
+	results []interface{}
+	expiry  time.Time
+	tags    []string
+	elem    *list.Element // position in the LRU list
 }
 
 // Memoize creates a memoized version of the given function
@@ -31,7 +105,9 @@ type cacheEntry struct {
 //
 // The memoized function will cache results based on input parameters.
 // Subsequent calls with the same parameters will return the cached result.
-// Cached results expire after the specified TTL (if non-zero).
+// Cached results expire after the specified TTL (if non-zero); expired entries are removed
+// when new results are cached, at most once per TTL. Concurrent calls with the same uncached
+// parameters call the function once and share its results.
 //
 // Example usage:
 //
@@ -51,40 +127,293 @@ type cacheEntry struct {
 //	fmt.Printf("Second call took %v: %v\n", time.Since(start), result)
 func Memoize(f interface{}, ttl time.Duration) *MemoizedFunc {
 	return &MemoizedFunc{
-		cache: make(map[string]cacheEntry),
-		f:     f,
-		ttl:   ttl,
+		cache:     make(map[string]*cacheEntry),
+		tagIndex:  make(map[string]map[string]struct{}),
+		lru:       list.New(),
+		inflight:  make(map[string]*memoCall),
+		lastPurge: time.Now(),
+		f:         f,
+		ttl:       ttl,
 	}
 }
 
-// Call invokes the memoized function with the given arguments
+// MemoizeWithOptions creates a memoized version of the given function with additional options
 //
 // Parameters:
+//   - f: the function to memoize (must be a function type)
+//   - ttl: time-to-live for cached results (use 0 for no expiration)
+//   - opts: options like WithIgnoredArgs, WithKeyFunc or WithMaxEntries
+//
+// Returns:
+//   - *MemoizedFunc: a memoized version of the input function
+//   - error: if f is not a function or an ignored argument position is out of range
+//
+// Example usage:
+//
+//	query := func(ctx context.Context, tenantID string, q string) []Row { ... }
+//
+//	// Only tenantID and q form the cache key, ctx is passed through
+//	memoized, err := gonuts.MemoizeWithOptions(query, time.Minute, gonuts.WithIgnoredArgs(0))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	rows, err := memoized.Call(ctx, "tenant-1", "select ...")
+func MemoizeWithOptions(f interface{}, ttl time.Duration, opts ...MemoizeOption) (*MemoizedFunc, error) {
+	t := reflect.TypeOf(f)
+	if t == nil || t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("memoize: not a function")
+	}
+
+	config := &memoizeConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	m := Memoize(f, ttl)
+	m.cacheErrors = config.cacheErrors
+	m.maxEntries = config.maxEntries
+	if config.keyFunc != nil {
+		m.keyFunc = config.keyFunc
+		return m, nil
+	}
+	if len(config.ignoredArgs) > 0 {
+		ignored := make(map[int]bool, len(config.ignoredArgs))
+		for _, pos := range config.ignoredArgs {
+			if pos < 0 || pos >= t.NumIn() {
+				return nil, fmt.Errorf("memoize: ignored argument position %d out of range (function has %d arguments)", pos, t.NumIn())
+			}
+			ignored[pos] = true
+		}
+		m.keyFunc = func(args []interface{}) string {
+			keyArgs := make([]interface{}, 0, len(args))
+			for i, arg := range args {
+				if !ignored[i] {
+					keyArgs = append(keyArgs, arg)
+				}
+			}
+			return fmt.Sprintf("%v", keyArgs)
+		}
+	}
+	return m, nil
+}
+
+// Call invokes the memoized function with the given arguments. If the last return value of the
+// function is an error, it is returned as the error of Call, and a non-nil error is not cached
+// unless WithErrorCaching is set. Use CallAll for functions with more than one other result,
+// or the typed Memoize1, Memoize1Err, ... to avoid reflection.
+//
+// Parameters:
+//   - args: the arguments to pass to the memoized function
+//
+// Returns:
+//   - interface{}: the first result of the function call, nil if it only returns an error
+//   - error: the error returned by the function, or an error of type checking
+func (m *MemoizedFunc) Call(args ...interface{}) (interface{}, error) {
+	return m.firstResult(m.call(nil, args))
+}
+
+// CallAll invokes the memoized function like Call, but returns all results of the function,
+// including a trailing error.
+//
+// Returns:
+//   - []interface{}: the results of the function call
+//   - error: the error returned by the function, or an error of type checking
+//
+// Example usage:
+//
+//	split := gonuts.Memoize(func(s string) (string, string, error) { ... }, time.Minute)
+//	results, err := split.CallAll("a:b")
+//	left, right := results[0].(string), results[1].(string)
+func (m *MemoizedFunc) CallAll(args ...interface{}) ([]interface{}, error) {
+	return m.call(nil, args)
+}
+
+// CallTagged invokes the memoized function like Call and associates the cached result
+// with the given tags, so that it can be removed with InvalidateTag.
+// Tags are also added to a result that is already cached.
+//
+// Parameters:
+//   - tags: the tags to associate with the cached result
 //   - args: the arguments to pass to the memoized function
 //
 // Returns:
 //   - interface{}: the result of the function call
 //   - error: any error that occurred during the function call or type checking
-func (m *MemoizedFunc) Call(args ...interface{}) (interface{}, error) {
-	key := fmt.Sprintf("%v", args)
+//
+// Example usage:
+//
+//	report, err := memoized.CallTagged([]string{"tenant:" + tenantID}, tenantID, month)
+//
+//	// after the tenant's data changed
+//	removed := memoized.InvalidateTag("tenant:" + tenantID)
+func (m *MemoizedFunc) CallTagged(tags []string, args ...interface{}) (interface{}, error) {
+	return m.firstResult(m.call(tags, args))
+}
+
+// InvalidateTag removes all cached results associated with the given tag
+//
+// Returns:
+//   - int: the number of removed cache entries
+func (m *MemoizedFunc) InvalidateTag(tag string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := m.tagIndex[tag]
+	removed := len(keys)
+	for key := range keys {
+		m.removeEntry(key)
+	}
+	return removed
+}
+
+// PurgeExpired removes all expired cache entries together with their tag associations
+//
+// Returns:
+//   - int: the number of removed cache entries
+func (m *MemoizedFunc) PurgeExpired() int {
+	if m.ttl == 0 {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.purgeExpired(time.Now())
+}
+
+// Invalidate removes the cached result for the given arguments. A call with these arguments
+// that is in progress still returns its result to its callers, but does not cache it.
+//
+// Returns:
+//   - bool: true if a cached result was removed
+//
+// Example usage:
+//
+//	user, err := getUser.Call(42)
+//	// after user 42 was updated
+//	getUser.Invalidate(42)
+func (m *MemoizedFunc) Invalidate(args ...interface{}) bool {
+	key := m.cacheKey(args)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inflight, key)
+	_, found := m.cache[key]
+	m.removeEntry(key)
+	return found
+}
+
+// InvalidateAll removes all cached results, like Invalidate for every key
+//
+// Returns:
+//   - int: the number of removed cache entries
+func (m *MemoizedFunc) InvalidateAll() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	removed := len(m.cache)
+	m.cache = make(map[string]*cacheEntry)
+	m.tagIndex = make(map[string]map[string]struct{})
+	m.inflight = make(map[string]*memoCall)
+	m.lru.Init()
+	return removed
+}
+
+// Stats returns the cache statistics
+//
+// Example usage:
+//
+//	stats := memoized.Stats()
+//	fmt.Printf("hit rate %.2f, %d entries\n", float64(stats.Hits)/float64(stats.Hits+stats.Misses), stats.Size)
+func (m *MemoizedFunc) Stats() MemoizeStats {
 	m.mu.RLock()
-	entry, found := m.cache[key]
+	size := len(m.cache)
 	m.mu.RUnlock()
+	return MemoizeStats{
+		Hits:      m.hits.Load(),
+		Misses:    m.misses.Load(),
+		Evictions: m.evictions.Load(),
+		Size:      size,
+	}
+}
+
+func (m *MemoizedFunc) call(tags []string, args []interface{}) ([]interface{}, error) {
+	key := m.cacheKey(args)
 
-	if found && (m.ttl == 0 || time.Now().Before(entry.expiry)) {
-		return entry.result, nil
+	if m.maxEntries <= 0 {
+		// Without LRU ordering, hits with known tags only need the read lock
+		m.mu.RLock()
+		entry, found := m.cache[key]
+		fresh := found && m.fresh(entry, time.Now()) && containsAllTags(entry.tags, tags)
+		m.mu.RUnlock()
+		if fresh {
+			m.hits.Add(1)
+			return entry.results, m.resultError(entry.results)
+		}
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	if entry, found := m.cache[key]; found && m.fresh(entry, time.Now()) {
+		m.lru.MoveToFront(entry.elem)
+		m.tagEntry(key, tags)
+		m.mu.Unlock()
+		m.hits.Add(1)
+		return entry.results, m.resultError(entry.results)
+	}
+	if c, found := m.inflight[key]; found {
+		m.mu.Unlock()
+		<-c.done
+		if len(tags) > 0 {
+			m.mu.Lock()
+			m.tagEntry(key, tags)
+			m.mu.Unlock()
+		}
+		m.hits.Add(1)
+		return c.results, c.err
+	}
+	c := &memoCall{done: make(chan struct{})}
+	m.inflight[key] = c
+	m.mu.Unlock()
+	m.misses.Add(1)
 
-	// Check again in case another goroutine updated the cache
-	entry, found = m.cache[key]
-	if found && (m.ttl == 0 || time.Now().Before(entry.expiry)) {
-		return entry.result, nil
+	completed := false
+	defer func() {
+		if !completed {
+			// f panicked: release the waiting callers, the panic continues in this one
+			c.err = fmt.Errorf("memoize: function panicked")
+			m.mu.Lock()
+			if m.inflight[key] == c {
+				delete(m.inflight, key)
+			}
+			m.mu.Unlock()
+			close(c.done)
+		}
+	}()
+
+	out, err := m.invoke(args)
+	cacheable := err == nil
+	if err == nil {
+		err = m.resultError(out)
+		cacheable = err == nil || m.cacheErrors
+	}
+	c.results, c.err = out, err
+	completed = true
+
+	m.mu.Lock()
+	// Invalidate may have removed the call, its result must not be cached then
+	if m.inflight[key] == c {
+		delete(m.inflight, key)
+		if cacheable {
+			m.store(key, out, tags)
+		}
 	}
+	m.mu.Unlock()
+	close(c.done)
+
+	return out, err
+}
 
+// invoke type checks the arguments and calls the function
+func (m *MemoizedFunc) invoke(args []interface{}) ([]interface{}, error) {
 	v := reflect.ValueOf(m.f)
 	t := v.Type()
 
@@ -98,23 +427,156 @@ func (m *MemoizedFunc) Call(args ...interface{}) (interface{}, error) {
 
 	var in []reflect.Value
 	for i, arg := range args {
-		if reflect.TypeOf(arg) != t.In(i) {
-			return nil, fmt.Errorf("memoize: argument %d has wrong type: got %T, want %v", i, arg, t.In(i))
+		want := t.In(i)
+		if arg == nil {
+			// A nil interface carries no type, so pass the zero value of nillable parameters
+			switch want.Kind() {
+			case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+				in = append(in, reflect.Zero(want))
+				continue
+			}
+			return nil, fmt.Errorf("memoize: argument %d has wrong type: got nil, want %v", i, want)
+		}
+		// Assignable instead of identical, so e.g. a context.Context parameter accepts any context
+		if !reflect.TypeOf(arg).AssignableTo(want) {
+			return nil, fmt.Errorf("memoize: argument %d has wrong type: got %T, want %v", i, arg, want)
 		}
 		in = append(in, reflect.ValueOf(arg))
 	}
 
 	result := v.Call(in)
 
-	var out interface{}
-	if len(result) > 0 {
-		out = result[0].Interface()
+	out := make([]interface{}, len(result))
+	for i, value := range result {
+		out[i] = value.Interface()
 	}
+	return out, nil
+}
 
-	m.cache[key] = cacheEntry{
-		result: out,
-		expiry: time.Now().Add(m.ttl),
+// store caches the results of a call, purging expired entries at most once per TTL and
+// evicting the least recently used entries beyond maxEntries. The caller must hold m.mu.
+func (m *MemoizedFunc) store(key string, results []interface{}, tags []string) {
+	now := time.Now()
+	if m.ttl > 0 && now.Sub(m.lastPurge) >= m.ttl {
+		m.purgeExpired(now)
 	}
 
-	return out, nil
+	// Drop the tag associations of an expired entry before replacing it
+	m.removeEntry(key)
+	m.cache[key] = &cacheEntry{
+		results: results,
+		expiry:  now.Add(m.ttl),
+		elem:    m.lru.PushFront(key),
+	}
+	m.tagEntry(key, tags)
+
+	for m.maxEntries > 0 && len(m.cache) > m.maxEntries {
+		m.removeEntry(m.lru.Back().Value.(string))
+		m.evictions.Add(1)
+	}
+}
+
+// fresh reports whether a cached entry has not expired
+func (m *MemoizedFunc) fresh(entry *cacheEntry, now time.Time) bool {
+	return m.ttl == 0 || now.Before(entry.expiry)
+}
+
+// purgeExpired removes all expired entries. The caller must hold m.mu.
+func (m *MemoizedFunc) purgeExpired(now time.Time) int {
+	m.lastPurge = now
+	removed := 0
+	for key, entry := range m.cache {
+		if !m.fresh(entry, now) {
+			m.removeEntry(key)
+			removed++
+		}
+	}
+	m.evictions.Add(uint64(removed))
+	return removed
+}
+
+// returnsError reports whether the last return value of the function is an error
+func (m *MemoizedFunc) returnsError() bool {
+	t := reflect.TypeOf(m.f)
+	return t != nil && t.Kind() == reflect.Func && t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+}
+
+// resultError returns the error returned by the function, if it returns one
+func (m *MemoizedFunc) resultError(results []interface{}) error {
+	if len(results) == 0 || !m.returnsError() {
+		return nil
+	}
+	err, _ := results[len(results)-1].(error)
+	return err
+}
+
+// firstResult returns the first result of a call for Call and CallTagged; a trailing error
+// is only returned as the error
+func (m *MemoizedFunc) firstResult(results []interface{}, err error) (interface{}, error) {
+	if len(results) > 0 && m.returnsError() {
+		results = results[:len(results)-1]
+	}
+	if len(results) == 0 {
+		return nil, err
+	}
+	return results[0], err
+}
+
+// tagEntry adds tags to a cached entry and to the tag index. The caller must hold m.mu.
+func (m *MemoizedFunc) tagEntry(key string, tags []string) {
+	entry, ok := m.cache[key]
+	if !ok {
+		return
+	}
+	for _, tag := range tags {
+		if containsAllTags(entry.tags, []string{tag}) {
+			continue
+		}
+		entry.tags = append(entry.tags, tag)
+		if m.tagIndex[tag] == nil {
+			m.tagIndex[tag] = make(map[string]struct{})
+		}
+		m.tagIndex[tag][key] = struct{}{}
+	}
+}
+
+// removeEntry deletes a cached entry and its tag associations. The caller must hold m.mu.
+func (m *MemoizedFunc) removeEntry(key string) {
+	entry, ok := m.cache[key]
+	if !ok {
+		return
+	}
+	for _, tag := range entry.tags {
+		delete(m.tagIndex[tag], key)
+		if len(m.tagIndex[tag]) == 0 {
+			delete(m.tagIndex, tag)
+		}
+	}
+	m.lru.Remove(entry.elem)
+	delete(m.cache, key)
+}
+
+// containsAllTags reports whether every tag in wanted is contained in tags
+func containsAllTags(tags, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey computes the cache key for the given arguments
+func (m *MemoizedFunc) cacheKey(args []interface{}) string {
+	if m.keyFunc != nil {
+		return m.keyFunc(args)
+	}
+	return fmt.Sprintf("%v", args)
 }
@@ -0,0 +1,281 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoTestKey struct{}
+
+func TestMemoizeWithOptionsIgnoredContext(t *testing.T) {
+	calls := 0
+	query := func(ctx context.Context, tenant, q string) string {
+		calls++
+		return tenant + ":" + q
+	}
+	memoized, err := MemoizeWithOptions(query, 0, WithIgnoredArgs(0))
+	if err != nil {
+		t.Fatalf("MemoizeWithOptions: %v", err)
+	}
+
+	first, err := memoized.Call(context.Background(), "t1", "q")
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// A different context of a different concrete type must hit the same entry
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), memoTestKey{}, "trace-1"))
+	defer cancel()
+	second, err := memoized.Call(ctx, "t1", "q")
+	if err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+
+	if first != "t1:q" || second != "t1:q" {
+		t.Errorf("results = %v, %v, want t1:q twice", first, second)
+	}
+	if calls != 1 {
+		t.Errorf("function called %d times, want 1", calls)
+	}
+	if stats := memoized.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 hit and 1 miss", stats)
+	}
+}
+
+func TestMemoizeArgumentTypes(t *testing.T) {
+	describe := func(err error, tags []string) string {
+		if err == nil {
+			return "nil"
+		}
+		return err.Error()
+	}
+	memoized := Memoize(describe, 0)
+
+	// Concrete types are assignable to interface parameters, nil becomes the zero value
+	if got, err := memoized.Call(errors.New("boom"), []string{"a"}); err != nil || got != "boom" {
+		t.Errorf("Call(error) = %v, %v, want boom", got, err)
+	}
+	if got, err := memoized.Call(nil, nil); err != nil || got != "nil" {
+		t.Errorf("Call(nil, nil) = %v, %v, want nil", got, err)
+	}
+
+	if _, err := memoized.Call("boom", nil); err == nil {
+		t.Error("Call(string) succeeded, want a wrong type error")
+	}
+	if _, err := Memoize(func(n int) int { return n }, 0).Call(nil); err == nil {
+		t.Error("Call(nil) for an int parameter succeeded, want a wrong type error")
+	}
+}
+
+func TestMemoizeInvalidateTag(t *testing.T) {
+	calls := map[string]int{}
+	report := func(tenant, month string) string {
+		calls[tenant+"/"+month]++
+		return tenant + "/" + month
+	}
+	memoized := Memoize(report, 0)
+
+	memoized.CallTagged([]string{"tenant:a", "month:01"}, "a", "01")
+	memoized.CallTagged([]string{"tenant:a", "month:02"}, "a", "02")
+	memoized.CallTagged([]string{"tenant:b", "month:01"}, "b", "01")
+	memoized.Call("c", "01") // untagged
+	// A cached result gets the tags of a later call
+	memoized.CallTagged([]string{"region:eu"}, "b", "01")
+
+	if removed := memoized.InvalidateTag("tenant:a"); removed != 2 {
+		t.Errorf("InvalidateTag(tenant:a) = %d, want 2", removed)
+	}
+	memoized.Call("a", "01")
+	memoized.Call("b", "01")
+	if calls["a/01"] != 2 || calls["b/01"] != 1 {
+		t.Errorf("calls = %v, want a/01 computed again and b/01 still cached", calls)
+	}
+
+	// The removed entries left the other tag indexes, the re-added a/01 is untagged
+	if removed := memoized.InvalidateTag("month:01"); removed != 1 {
+		t.Errorf("InvalidateTag(month:01) = %d, want only b/01", removed)
+	}
+	if removed := memoized.InvalidateTag("region:eu"); removed != 0 {
+		t.Errorf("InvalidateTag(region:eu) = %d after b/01 was removed, want 0", removed)
+	}
+	if removed := memoized.InvalidateTag("unknown"); removed != 0 {
+		t.Errorf("InvalidateTag(unknown) = %d", removed)
+	}
+	if n := len(memoized.tagIndex); n != 0 {
+		t.Errorf("%d tags left in the index without tagged entries", n)
+	}
+	if stats := memoized.Stats(); stats.Size != 2 {
+		t.Errorf("Size = %d, want a/01 and c/01", stats.Size)
+	}
+}
+
+func TestMemoizeInvalidateDuringCall(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	slow := func(n int) int {
+		calls++
+		if calls == 1 {
+			close(started)
+			<-release
+		}
+		return n * calls
+	}
+	memoized := Memoize(slow, 0)
+
+	done := make(chan interface{})
+	go func() {
+		result, _ := memoized.Call(2)
+		done <- result
+	}()
+	<-started
+	memoized.Invalidate(2)
+	close(release)
+
+	// The call in progress returns its result, but the result is not cached
+	if result := <-done; result != 2 {
+		t.Errorf("the call in progress returned %v, want 2", result)
+	}
+	if result, _ := memoized.Call(2); result != 4 || calls != 2 {
+		t.Errorf("Call after Invalidate = %v with %d calls, want a new result 4", result, calls)
+	}
+	if !memoized.Invalidate(2) || memoized.Invalidate(2) {
+		t.Error("Invalidate did not report the cached result exactly once")
+	}
+}
+
+func TestMemoizeMaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	calls := map[int]int{}
+	square := func(n int) int {
+		calls[n]++
+		return n * n
+	}
+	memoized, err := MemoizeWithOptions(square, 0, WithMaxEntries(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memoized.Call(1)
+	memoized.Call(2)
+	memoized.Call(1) // 1 is now more recently used than 2
+	memoized.Call(3) // evicts 2
+	memoized.Call(1)
+	memoized.Call(2) // computed again, evicts 3
+	if calls[1] != 1 || calls[2] != 2 || calls[3] != 1 {
+		t.Errorf("calls = %v, want 1 cached throughout and 2 computed twice", calls)
+	}
+
+	want := MemoizeStats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}
+	if stats := memoized.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if memoized.lru.Len() != 2 {
+		t.Errorf("the LRU list holds %d keys for 2 entries", memoized.lru.Len())
+	}
+	if removed := memoized.InvalidateAll(); removed != 2 || memoized.Stats().Size != 0 || memoized.lru.Len() != 0 {
+		t.Errorf("InvalidateAll() = %d, leaving %+v", removed, memoized.Stats())
+	}
+}
+
+func TestMemoizeExpiry(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+	calls := 0
+	now := func(n int) int {
+		calls++
+		return calls
+	}
+	memoized := Memoize(now, ttl)
+
+	memoized.Call(1)
+	memoized.CallTagged([]string{"t"}, 2)
+	if result, _ := memoized.Call(1); result != 1 {
+		t.Errorf("Call(1) within the TTL = %v, want the cached 1", result)
+	}
+	time.Sleep(ttl + 5*time.Millisecond)
+
+	// An expired entry is computed again, even before it was purged
+	if result, _ := memoized.Call(1); result != 3 {
+		t.Errorf("Call(1) after the TTL = %v, want a new result", result)
+	}
+	// Storing the new result purged both expired entries, the tagged one with its tag
+	stats := memoized.Stats()
+	if stats.Size != 1 || stats.Evictions != 2 || len(memoized.tagIndex) != 0 {
+		t.Errorf("Stats() = %+v with %d tags after the purge, want 1 entry, 2 evictions and no tags", stats, len(memoized.tagIndex))
+	}
+
+	time.Sleep(ttl + 5*time.Millisecond)
+	if removed := memoized.PurgeExpired(); removed != 1 || memoized.Stats().Evictions != 3 {
+		t.Errorf("PurgeExpired() = %d with %+v", removed, memoized.Stats())
+	}
+	if removed := Memoize(now, 0).PurgeExpired(); removed != 0 {
+		t.Errorf("PurgeExpired() without a TTL = %d", removed)
+	}
+}
+
+func TestMemoizeSingleFlight(t *testing.T) {
+	const callers = 10
+	var calls atomic.Int32
+	release := make(chan struct{})
+	slow := func(key string) (string, error) {
+		calls.Add(1)
+		<-release
+		return "value of " + key, nil
+	}
+	memoized := Memoize(slow, 0)
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = memoized.Call("k")
+		}(i)
+	}
+	// Give the other callers time to find the call in progress; a late caller hits the cached
+	// result instead, which counts as a hit as well
+	waitUntil(t, "all callers to arrive", func() bool {
+		memoized.mu.RLock()
+		defer memoized.mu.RUnlock()
+		return len(memoized.inflight) == 1
+	})
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("function called %d times by %d concurrent callers, want 1", n, callers)
+	}
+	for i, result := range results {
+		if result != "value of k" {
+			t.Errorf("caller %d got %v", i, result)
+		}
+	}
+	if stats := memoized.Stats(); stats.Misses != 1 || stats.Hits != callers-1 {
+		t.Errorf("Stats() = %+v, want 1 miss and %d hits", stats, callers-1)
+	}
+}
+
+func TestMemoizeErrorCaching(t *testing.T) {
+	failure := errors.New("upstream down")
+	calls := 0
+	fetch := func(id int) (string, error) {
+		calls++
+		return "", failure
+	}
+
+	memoized := Memoize(fetch, 0)
+	memoized.Call(1)
+	if _, err := memoized.Call(1); !errors.Is(err, failure) || calls != 2 {
+		t.Errorf("Call = %v with %d calls, want errors not cached by default", err, calls)
+	}
+
+	calls = 0
+	cached, _ := MemoizeWithOptions(fetch, 0, WithErrorCaching())
+	cached.Call(1)
+	if _, err := cached.Call(1); !errors.Is(err, failure) || calls != 1 {
+		t.Errorf("Call = %v with %d calls, want the error cached with WithErrorCaching", err, calls)
+	}
+}
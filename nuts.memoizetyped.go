@@ -0,0 +1,123 @@
+package gonuts
+
+import (
+	"sync"
+	"time"
+)
+
+// typedMemo is the cache of the typed memoize helpers, keyed by the arguments themselves
+type typedMemo[K comparable, R any] struct {
+	mu        sync.RWMutex
+	entries   map[K]typedMemoEntry[R]
+	ttl       time.Duration
+	lastPurge time.Time
+}
+
+type typedMemoEntry[R any] struct {
+	result R
+	expiry time.Time
+}
+
+func newTypedMemo[K comparable, R any](ttl time.Duration) *typedMemo[K, R] {
+	return &typedMemo[K, R]{entries: make(map[K]typedMemoEntry[R]), ttl: ttl, lastPurge: time.Now()}
+}
+
+// get returns the cached result of key or computes it. Results with a non-nil error are not cached.
+func (m *typedMemo[K, R]) get(key K, compute func() (R, error)) (R, error) {
+	m.mu.RLock()
+	entry, found := m.entries[key]
+	m.mu.RUnlock()
+	if found && (m.ttl == 0 || time.Now().Before(entry.expiry)) {
+		return entry.result, nil
+	}
+
+	result, err := compute()
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttl > 0 && now.Sub(m.lastPurge) >= m.ttl {
+		// Amortized cleanup, so keys that are never asked for again do not pile up
+		m.lastPurge = now
+		for k, e := range m.entries {
+			if !now.Before(e.expiry) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = typedMemoEntry[R]{result: result, expiry: now.Add(m.ttl)}
+	return result, nil
+}
+
+// memoKey2 is the cache key of the two-argument helpers
+type memoKey2[A, B comparable] struct {
+	a A
+	b B
+}
+
+// Memoize1 returns a memoized version of a function of one argument. Unlike Memoize it uses no
+// reflection: the argument itself is the cache key. Concurrent calls with an uncached argument
+// may each call f.
+//
+// Parameters:
+//   - f: the function to memoize
+//   - ttl: time-to-live for cached results (use 0 for no expiration)
+//
+// Returns:
+//   - func(A) R: the memoized function, safe for concurrent use
+//
+// Example usage:
+//
+//	square := gonuts.Memoize1(func(x int) int { return x * x }, time.Minute)
+//	fmt.Println(square(12)) // 144, computed once
+func Memoize1[A comparable, R any](f func(A) R, ttl time.Duration) func(A) R {
+	memo := newTypedMemo[A, R](ttl)
+	return func(a A) R {
+		result, _ := memo.get(a, func() (R, error) { return f(a), nil })
+		return result
+	}
+}
+
+// Memoize1Err returns a memoized version of a function of one argument that can fail.
+// Results are only cached if f returns a nil error, so failing calls are retried.
+//
+// Example usage:
+//
+//	getUser := gonuts.Memoize1Err(func(id int) (User, error) {
+//	    return db.LoadUser(id)
+//	}, 5*time.Minute)
+//	user, err := getUser(42)
+func Memoize1Err[A comparable, R any](f func(A) (R, error), ttl time.Duration) func(A) (R, error) {
+	memo := newTypedMemo[A, R](ttl)
+	return func(a A) (R, error) {
+		return memo.get(a, func() (R, error) { return f(a) })
+	}
+}
+
+// Memoize2 returns a memoized version of a function of two arguments (see Memoize1)
+//
+// Example usage:
+//
+//	price := gonuts.Memoize2(func(sku string, qty int) float64 { ... }, time.Minute)
+func Memoize2[A, B comparable, R any](f func(A, B) R, ttl time.Duration) func(A, B) R {
+	memo := newTypedMemo[memoKey2[A, B], R](ttl)
+	return func(a A, b B) R {
+		result, _ := memo.get(memoKey2[A, B]{a, b}, func() (R, error) { return f(a, b), nil })
+		return result
+	}
+}
+
+// Memoize2Err returns a memoized version of a function of two arguments that can fail (see Memoize1Err)
+//
+// Example usage:
+//
+//	getOrder := gonuts.Memoize2Err(func(tenantID, orderID string) (Order, error) { ... }, time.Minute)
+func Memoize2Err[A, B comparable, R any](f func(A, B) (R, error), ttl time.Duration) func(A, B) (R, error) {
+	memo := newTypedMemo[memoKey2[A, B], R](ttl)
+	return func(a A, b B) (R, error) {
+		return memo.get(memoKey2[A, B]{a, b}, func() (R, error) { return f(a, b) })
+	}
+}
@@ -1,8 +1,15 @@
 package gonuts
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // PaginationInfo contains information about the current pagination state
@@ -17,6 +24,7 @@ type PaginationInfo struct {
 	LastPage     int   `json:"last_page"`
 	NextPage     *int  `json:"next_page"`
 	PreviousPage *int  `json:"previous_page"`
+	Clamped      bool  `json:"clamped,omitempty"` // set by NewPaginationInfoOpts if the request was clamped to its limits
 }
 
 // NewPaginationInfo creates a new PaginationInfo instance
@@ -83,12 +91,105 @@ func NewPaginationInfo(currentPage, perPage int, totalItems int64) *PaginationIn
 	}
 }
 
-// Offset calculates the offset for database queries
+// ErrPageOutOfRange is wrapped by the errors of NewPaginationInfoOpts for requests beyond its limits
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// PaginationOptions limits the pages NewPaginationInfoOpts accepts. Zero values mean no limit.
+type PaginationOptions struct {
+	MaxPage    int  // highest page that can be requested
+	MaxPerPage int  // largest page size
+	MaxOffset  int  // largest offset (first item index, zero-based) of a page, against deep OFFSET scans
+	Reject     bool // reject requests beyond the limits instead of clamping them
+}
+
+// maxPage returns the highest page allowed for the page size, 0 if there is no limit
+func (o PaginationOptions) maxPage(perPage int) int {
+	maxPage := o.MaxPage
+	if o.MaxOffset > 0 {
+		if byOffset := o.MaxOffset/perPage + 1; maxPage <= 0 || byOffset < maxPage {
+			maxPage = byOffset
+		}
+	}
+	return maxPage
+}
+
+// NewPaginationInfoOpts creates a new PaginationInfo like NewPaginationInfo, enforcing limits on
+// the requested page and page size, e.g. so crawlers cannot request page 50000 and force a huge
+// OFFSET on the database.
+//
+// Requests beyond the limits are clamped to them and marked Clamped, or rejected if opts.Reject
+// is set. LastPage and NextPage never point beyond the highest allowed page, while TotalPages
+// still counts all items.
+//
+// Parameters:
+//   - currentPage: the requested page number
+//   - perPage: the requested number of items per page
+//   - totalItems: the total number of items in the dataset
+//   - opts: the limits
+//
+// Returns:
+//   - *PaginationInfo: the pagination, nil on error
+//   - error: a 400 *ErrorPlus wrapping ErrPageOutOfRange, with the public context "parameter"
+//     ("page" or "per_page") and "max", the highest allowed value
+//
+// Example usage:
+//
+//	pagination, err := gonuts.NewPaginationInfoOpts(page, perPage, total, gonuts.PaginationOptions{
+//	    MaxPerPage: 100,
+//	    MaxOffset:  10000,
+//	    Reject:     true,
+//	})
+//	if err != nil {
+//	    err.(*gonuts.ErrorPlus).WriteHTTP(w)
+//	    return
+//	}
+func NewPaginationInfoOpts(currentPage, perPage int, totalItems int64, opts PaginationOptions) (*PaginationInfo, error) {
+	if perPage < 1 {
+		perPage = 10 // Default to 10 items per page
+	}
+	clamped := false
+	if opts.MaxPerPage > 0 && perPage > opts.MaxPerPage {
+		if opts.Reject {
+			return nil, pageOutOfRange("per_page", perPage, opts.MaxPerPage)
+		}
+		perPage = opts.MaxPerPage
+		clamped = true
+	}
+	maxPage := opts.maxPage(perPage)
+	if maxPage > 0 && currentPage > maxPage {
+		if opts.Reject {
+			return nil, pageOutOfRange("page", currentPage, maxPage)
+		}
+		currentPage = maxPage
+		clamped = true
+	}
+
+	p := NewPaginationInfo(currentPage, perPage, totalItems)
+	if maxPage > 0 && p.LastPage > maxPage {
+		p.LastPage = maxPage
+		if p.CurrentPage >= maxPage {
+			p.NextPage = nil
+		}
+	}
+	p.Clamped = clamped
+	return p, nil
+}
+
+// pageOutOfRange returns the error of NewPaginationInfoOpts for a value beyond its limit
+func pageOutOfRange(parameter string, value, max int) *ErrorPlus {
+	return NewBadRequestError(fmt.Sprintf("%s must not exceed %d", parameter, max), ErrPageOutOfRange).
+		WithPublicContext("parameter", parameter).
+		WithPublicContext("max", max).
+		WithContext("value", value)
+}
+
+// Offset calculates the offset for database queries. It is never negative, also not for the
+// page 0 of an empty result.
 //
 // Returns:
 //   - int: the offset to use in database queries
 func (p *PaginationInfo) Offset() int {
-	return (p.CurrentPage - 1) * p.PerPage
+	return max(0, (p.CurrentPage-1)*p.PerPage)
 }
 
 // Limit returns the number of items per page
@@ -166,3 +267,443 @@ func (p *PaginationInfo) String() string {
 	return fmt.Sprintf("Page %d of %d (Total items: %d, Per page: %d)",
 		p.CurrentPage, p.TotalPages, p.TotalItems, p.PerPage)
 }
+
+// SQLClause returns a parameterized LIMIT/OFFSET clause for the current page
+//
+// Returns:
+//   - string: the SQL clause ("LIMIT ? OFFSET ?")
+//   - []interface{}: the arguments for the placeholders, in order (limit, offset)
+//
+// Example usage:
+//
+//	clause, args := pagination.SQLClause()
+//	rows, err := db.Query("SELECT * FROM users ORDER BY id "+clause, args...)
+func (p *PaginationInfo) SQLClause() (string, []interface{}) {
+	return "LIMIT ? OFFSET ?", []interface{}{p.Limit(), p.Offset()}
+}
+
+// OrderColumn describes one column of a keyset (cursor) pagination ordering
+type OrderColumn struct {
+	Column     string      // Column name (identifier, may be qualified like "u.created_at")
+	Descending bool        // Whether the column is ordered descending
+	After      interface{} // The column value of the last row of the previous page
+}
+
+// ErrInvalidKeyset is returned when a keyset definition cannot be turned into a WHERE clause
+var ErrInvalidKeyset = errors.New("invalid keyset")
+
+var sqlIdentifierRegEx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// KeysetWhere generates a parameterized WHERE clause for keyset (cursor) pagination
+//
+// Parameters:
+//   - orderColumns: the ordering columns in ORDER BY order, each with the value of the last seen row
+//
+// Returns:
+//   - string: the WHERE clause condition (without the WHERE keyword)
+//   - []interface{}: the arguments for the placeholders, in order
+//   - error: ErrInvalidKeyset if no columns are given or a column name is not a plain identifier
+//
+// The clause is the expanded form of a tuple comparison, which supports mixed
+// ascending/descending orderings. For columns (a ASC, b DESC) it produces:
+//
+//	((a > ?) OR (a = ? AND b < ?))
+//
+// Values are never interpolated into the SQL text, only column names are, and those
+// are validated to be plain (optionally table-qualified) identifiers.
+//
+// Example usage:
+//
+//	where, args, err := gonuts.KeysetWhere([]gonuts.OrderColumn{
+//	    {Column: "created_at", Descending: true, After: lastCreatedAt},
+//	    {Column: "id", After: lastID},
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	query := "SELECT * FROM posts WHERE " + where + " ORDER BY created_at DESC, id ASC LIMIT 20"
+//	rows, err := db.Query(query, args...)
+func KeysetWhere(orderColumns []OrderColumn) (string, []interface{}, error) {
+	if len(orderColumns) == 0 {
+		return "", nil, fmt.Errorf("%w: no order columns given", ErrInvalidKeyset)
+	}
+	for _, col := range orderColumns {
+		if !sqlIdentifierRegEx.MatchString(col.Column) {
+			return "", nil, fmt.Errorf("%w: illegal column name %q", ErrInvalidKeyset, col.Column)
+		}
+	}
+
+	var args []interface{}
+	branches := make([]string, 0, len(orderColumns))
+	for i, col := range orderColumns {
+		conditions := make([]string, 0, i+1)
+		for _, prev := range orderColumns[:i] {
+			conditions = append(conditions, prev.Column+" = ?")
+			args = append(args, prev.After)
+		}
+		operator := ">"
+		if col.Descending {
+			operator = "<"
+		}
+		conditions = append(conditions, col.Column+" "+operator+" ?")
+		args = append(args, col.After)
+		branches = append(branches, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return "(" + strings.Join(branches, " OR ") + ")", args, nil
+}
+
+// PaginationHeaderOptions configures PaginationInfo.WriteHeaders
+type PaginationHeaderOptions struct {
+	BaseURL      string // URL used for the Link header; no Link header is written if empty
+	PageParam    string // query parameter for the page number (default "page")
+	PerPageParam string // query parameter for the page size (default "per_page")
+}
+
+// WriteHeaders conveys the pagination state in response headers instead of the body.
+//
+// It sets X-Total-Count, X-Page, X-Per-Page and X-Total-Pages and, if opts.BaseURL is set,
+// an RFC 8288 Link header with first, prev, next and last relations. Existing query
+// parameters of the base URL are kept.
+//
+// Parameters:
+//   - w: the response writer; headers must be written before the body
+//   - opts: the header options
+//
+// Returns:
+//   - error: if opts.BaseURL cannot be parsed
+//
+// Example usage:
+//
+//	pagination := gonuts.NewPaginationInfo(page, perPage, total)
+//	err := pagination.WriteHeaders(w, gonuts.PaginationHeaderOptions{BaseURL: "https://api.example.com/users"})
+func (p *PaginationInfo) WriteHeaders(w http.ResponseWriter, opts PaginationHeaderOptions) error {
+	h := w.Header()
+	h.Set("X-Total-Count", strconv.FormatInt(p.TotalItems, 10))
+	h.Set("X-Page", strconv.Itoa(p.CurrentPage))
+	h.Set("X-Per-Page", strconv.Itoa(p.PerPage))
+	h.Set("X-Total-Pages", strconv.Itoa(p.TotalPages))
+
+	if opts.BaseURL == "" {
+		return nil
+	}
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+	pageParam, perPageParam := paginationParamNames(opts.PageParam, opts.PerPageParam)
+
+	pageURL := func(page int) string {
+		u := *base
+		q := u.Query()
+		q.Set(pageParam, strconv.Itoa(page))
+		q.Set(perPageParam, strconv.Itoa(p.PerPage))
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	var links []string
+	if p.TotalPages > 0 {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"first\"", pageURL(1)))
+	}
+	if p.PreviousPage != nil {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"prev\"", pageURL(*p.PreviousPage)))
+	}
+	if p.NextPage != nil {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"next\"", pageURL(*p.NextPage)))
+	}
+	if p.TotalPages > 0 {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"last\"", pageURL(p.TotalPages)))
+	}
+	if len(links) > 0 {
+		h.Set("Link", strings.Join(links, ", "))
+	}
+	return nil
+}
+
+// ErrInvalidPaginationParam is wrapped by the errors of ParsePaginationFromRequest for out-of-range values
+var ErrInvalidPaginationParam = errors.New("invalid pagination parameter")
+
+// PaginationDefaults configures ParsePaginationFromRequest
+type PaginationDefaults struct {
+	PageParam    string // query parameter for the page number (default "page")
+	PerPageParam string // query parameter for the page size (default "per_page")
+	PerPage      int    // page size if the parameter is absent (default 10)
+	MaxPerPage   int    // largest accepted page size (default 100)
+}
+
+// ParsePaginationFromRequest reads the requested page and page size from the query string
+//
+// Parameters:
+//   - r: the incoming request
+//   - defaults: parameter names and limits; zero values use the documented defaults
+//
+// Returns:
+//   - *PaginationInfo: CurrentPage and PerPage as requested (the total is not known yet)
+//   - error: a 400 *ErrorPlus if a parameter is not a number or out of range
+//
+// Absent parameters fall back to page 1 and defaults.PerPage. Once the total is known,
+// build the full pagination with NewPaginationInfo.
+//
+// Example usage:
+//
+//	req, err := gonuts.ParsePaginationFromRequest(r, gonuts.PaginationDefaults{MaxPerPage: 50})
+//	if err != nil {
+//	    // respond with 400
+//	}
+//	total := countUsers()
+//	pagination := gonuts.NewPaginationInfo(req.CurrentPage, req.PerPage, total)
+func ParsePaginationFromRequest(r *http.Request, defaults PaginationDefaults) (*PaginationInfo, error) {
+	pageParam, perPageParam := paginationParamNames(defaults.PageParam, defaults.PerPageParam)
+	perPage := defaults.PerPage
+	if perPage < 1 {
+		perPage = 10
+	}
+	maxPerPage := defaults.MaxPerPage
+	if maxPerPage < 1 {
+		maxPerPage = 100
+	}
+
+	query := r.URL.Query()
+	page, err := parsePaginationParam(query, pageParam, 1, 1, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	perPage, err = parsePaginationParam(query, perPageParam, perPage, 1, maxPerPage)
+	if err != nil {
+		return nil, err
+	}
+	return &PaginationInfo{CurrentPage: page, PerPage: perPage, FirstPage: 1}, nil
+}
+
+// paginationParamNames applies the default query parameter names
+func paginationParamNames(pageParam, perPageParam string) (string, string) {
+	if pageParam == "" {
+		pageParam = "page"
+	}
+	if perPageParam == "" {
+		perPageParam = "per_page"
+	}
+	return pageParam, perPageParam
+}
+
+// parsePaginationParam parses a numeric query parameter within [lo, hi]
+func parsePaginationParam(query url.Values, name string, fallback, lo, hi int) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, NewBadRequestError(fmt.Sprintf("query parameter %s must be a number", name), err).
+			WithContext("parameter", name).
+			WithContext("value", raw)
+	}
+	if value < lo || value > hi {
+		return 0, NewBadRequestError(fmt.Sprintf("query parameter %s must be between %d and %d", name, lo, hi), ErrInvalidPaginationParam).
+			WithContext("parameter", name).
+			WithContext("value", raw)
+	}
+	return value, nil
+}
+
+// Edge is an item of a Connection with its cursor
+type Edge[T any] struct {
+	Node   T      `json:"node"`
+	Cursor string `json:"cursor"`
+}
+
+// PageInfo is the pageInfo object of a Relay connection. The cursors are nil for empty pages.
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// Connection is a GraphQL (Relay) connection envelope of a page of items
+type Connection[T any] struct {
+	Edges      []Edge[T] `json:"edges"`
+	PageInfo   PageInfo  `json:"pageInfo"`
+	TotalCount int64     `json:"totalCount"`
+}
+
+// ToConnection builds a Relay connection from a page of items and its pagination
+//
+// Parameters:
+//   - items: the items of the page, in order
+//   - p: the pagination of the page, used for hasNextPage, hasPreviousPage and totalCount (may be nil)
+//   - cursorFn: returns the cursor of an item, e.g. an encoded keyset or OffsetCursor
+//
+// Returns:
+//   - Connection[T]: the connection with one edge per item
+//
+// Example usage:
+//
+//	pagination := gonuts.NewPaginationInfo(page, perPage, total)
+//	conn := gonuts.ToConnection(users, pagination, func(u User) string { return u.ID })
+func ToConnection[T any](items []T, p *PaginationInfo, cursorFn func(T) string) Connection[T] {
+	conn := Connection[T]{Edges: make([]Edge[T], len(items))}
+	for i, item := range items {
+		conn.Edges[i] = Edge[T]{Node: item, Cursor: cursorFn(item)}
+	}
+	if len(conn.Edges) > 0 {
+		start, end := conn.Edges[0].Cursor, conn.Edges[len(conn.Edges)-1].Cursor
+		conn.PageInfo.StartCursor, conn.PageInfo.EndCursor = &start, &end
+	}
+	if p != nil {
+		conn.PageInfo.HasNextPage = p.HasNextPage()
+		conn.PageInfo.HasPreviousPage = p.HasPreviousPage()
+		conn.TotalCount = p.TotalItems
+	}
+	return conn
+}
+
+// ErrInvalidConnectionArgs is wrapped by the errors of ParseConnectionArgs and ConnectionArgs.OffsetLimit
+var ErrInvalidConnectionArgs = errors.New("invalid connection arguments")
+
+// ConnectionArgs are validated Relay connection arguments, paginating either forward
+// (first/after) or backward (last/before)
+type ConnectionArgs struct {
+	Backward bool   // paginate backward from Cursor (last/before)
+	Limit    int    // first or last; -1 if neither was given
+	Cursor   string // the after or before cursor, empty if none was given
+}
+
+// ParseConnectionArgs validates the Relay connection arguments first, after, last and before,
+// as passed to a GraphQL resolver (nil for absent arguments). Only unambiguous combinations are
+// accepted: first with an optional after cursor, or last with an optional before cursor.
+//
+// Rejected combinations:
+//   - first or last is negative
+//   - first and last are both given
+//   - first with before, or last with after
+//   - after and before are both given
+//
+// Returns:
+//   - ConnectionArgs: the direction, limit and cursor; use OffsetLimit for offset pagination,
+//     or decode Cursor yourself for keyset pagination (see KeysetWhere)
+//   - error: a 400 *ErrorPlus wrapping ErrInvalidConnectionArgs
+//
+// Example usage:
+//
+//	args, err := gonuts.ParseConnectionArgs(p.First, p.After, p.Last, p.Before)
+//	if err != nil {
+//	    return nil, err
+//	}
+//	offset, limit, err := args.OffsetLimit(total, 20)
+func ParseConnectionArgs(first *int, after *string, last *int, before *string) (ConnectionArgs, error) {
+	invalid := func(msg string) (ConnectionArgs, error) {
+		return ConnectionArgs{}, NewBadRequestError(msg, ErrInvalidConnectionArgs)
+	}
+	switch {
+	case first != nil && *first < 0:
+		return invalid("first must not be negative")
+	case last != nil && *last < 0:
+		return invalid("last must not be negative")
+	case first != nil && last != nil:
+		return invalid("first and last cannot be combined")
+	case after != nil && before != nil:
+		return invalid("after and before cannot be combined")
+	case first != nil && before != nil:
+		return invalid("first cannot be combined with before, use last")
+	case last != nil && after != nil:
+		return invalid("last cannot be combined with after, use first")
+	}
+
+	args := ConnectionArgs{Limit: -1}
+	switch {
+	case last != nil || before != nil:
+		args.Backward = true
+		if last != nil {
+			args.Limit = *last
+		}
+		if before != nil {
+			args.Cursor = *before
+		}
+	default:
+		if first != nil {
+			args.Limit = *first
+		}
+		if after != nil {
+			args.Cursor = *after
+		}
+	}
+	return args, nil
+}
+
+// OffsetLimit converts the arguments into offset pagination. The cursor must be an offset
+// cursor (see OffsetCursor); backward pagination without a cursor starts from the end.
+//
+// Parameters:
+//   - total: the total number of items
+//   - defaultLimit: the limit if neither first nor last was given
+//
+// Returns:
+//   - offset, limit: the range of items to return, for LIMIT/OFFSET queries
+//   - err: a 400 *ErrorPlus wrapping ErrInvalidConnectionArgs if the cursor is not an offset cursor
+func (a ConnectionArgs) OffsetLimit(total int64, defaultLimit int) (offset, limit int, err error) {
+	limit = a.Limit
+	if limit < 0 {
+		limit = defaultLimit
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	cursor := -1
+	if a.Cursor != "" {
+		if cursor, err = ParseOffsetCursor(a.Cursor); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if !a.Backward {
+		start := int64(cursor + 1)
+		end := start + int64(limit)
+		if end > total {
+			end = total
+		}
+		if start > end {
+			start = end
+		}
+		return int(start), int(end - start), nil
+	}
+
+	end := total
+	if cursor >= 0 && int64(cursor) < end {
+		end = int64(cursor)
+	}
+	start := end - int64(limit)
+	if start < 0 {
+		start = 0
+	}
+	return int(start), int(end - start), nil
+}
+
+const offsetCursorPrefix = "offset:"
+
+// OffsetCursor encodes the offset of an item as an opaque cursor
+//
+// Example usage:
+//
+//	cursor := gonuts.OffsetCursor(41) // the 42nd item
+//	args, _ := gonuts.ParseConnectionArgs(&first, &cursor, nil, nil)
+//	offset, limit, _ := args.OffsetLimit(total, 20) // offset 42
+func OffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetCursorPrefix + strconv.Itoa(offset)))
+}
+
+// ParseOffsetCursor decodes a cursor created by OffsetCursor
+//
+// Returns:
+//   - int: the offset
+//   - error: a 400 *ErrorPlus wrapping ErrInvalidConnectionArgs if the cursor is not an offset cursor
+func ParseOffsetCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(data), offsetCursorPrefix) {
+		offset, convErr := strconv.Atoi(strings.TrimPrefix(string(data), offsetCursorPrefix))
+		if convErr == nil && offset >= 0 {
+			return offset, nil
+		}
+	}
+	return 0, NewBadRequestError("invalid cursor", ErrInvalidConnectionArgs).WithContext("cursor", cursor)
+}
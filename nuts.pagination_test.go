@@ -0,0 +1,314 @@
+package gonuts
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPaginationSQLClauseEmptyResult(t *testing.T) {
+	p := NewPaginationInfo(1, 10, 0)
+	clause, args := p.SQLClause()
+	if clause != "LIMIT ? OFFSET ?" {
+		t.Errorf("clause = %q", clause)
+	}
+	if len(args) != 2 || args[0] != 10 || args[1] != 0 {
+		t.Errorf("args = %v, want [10 0]", args)
+	}
+}
+
+func TestPaginationOffset(t *testing.T) {
+	tests := []struct {
+		current, perPage int
+		total            int64
+		want             int
+	}{
+		{1, 10, 0, 0},
+		{1, 10, 95, 0},
+		{3, 10, 95, 20},
+		{10, 10, 95, 90},
+	}
+	for _, tt := range tests {
+		if got := NewPaginationInfo(tt.current, tt.perPage, tt.total).Offset(); got != tt.want {
+			t.Errorf("NewPaginationInfo(%d, %d, %d).Offset() = %d, want %d", tt.current, tt.perPage, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  []OrderColumn
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "one column",
+			columns:  []OrderColumn{{Column: "id", After: 42}},
+			want:     "((id > ?))",
+			wantArgs: []interface{}{42},
+		},
+		{
+			name: "two columns, descending first",
+			columns: []OrderColumn{
+				{Column: "created_at", Descending: true, After: "2024-01-02"},
+				{Column: "id", After: 7},
+			},
+			want:     "((created_at < ?) OR (created_at = ? AND id > ?))",
+			wantArgs: []interface{}{"2024-01-02", "2024-01-02", 7},
+		},
+		{
+			name: "three columns, qualified",
+			columns: []OrderColumn{
+				{Column: "p.score", Descending: true, After: 9.5},
+				{Column: "p.name", After: "bob"},
+				{Column: "p.id", Descending: true, After: 3},
+			},
+			want:     "((p.score < ?) OR (p.score = ? AND p.name > ?) OR (p.score = ? AND p.name = ? AND p.id < ?))",
+			wantArgs: []interface{}{9.5, 9.5, "bob", 9.5, "bob", 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := KeysetWhere(tt.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("clause = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeysetWhereInvalid(t *testing.T) {
+	for _, columns := range [][]OrderColumn{
+		nil,
+		{{Column: "id; DROP TABLE users", After: 1}},
+		{{Column: "id", After: 1}, {Column: "a.b.c", After: 2}},
+	} {
+		if _, _, err := KeysetWhere(columns); !errors.Is(err, ErrInvalidKeyset) {
+			t.Errorf("KeysetWhere(%v) error = %v, want ErrInvalidKeyset", columns, err)
+		}
+	}
+}
+
+func TestPaginationWriteHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	p := NewPaginationInfo(2, 10, 95)
+	if err := p.WriteHeaders(rec, PaginationHeaderOptions{BaseURL: "https://api.example.com/users?sort=name"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := rec.Header()
+	for name, want := range map[string]string{"X-Total-Count": "95", "X-Page": "2", "X-Per-Page": "10", "X-Total-Pages": "10"} {
+		if got := h.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	want := `<https://api.example.com/users?page=1&per_page=10&sort=name>; rel="first", ` +
+		`<https://api.example.com/users?page=1&per_page=10&sort=name>; rel="prev", ` +
+		`<https://api.example.com/users?page=3&per_page=10&sort=name>; rel="next", ` +
+		`<https://api.example.com/users?page=10&per_page=10&sort=name>; rel="last"`
+	if got := h.Get("Link"); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+}
+
+func TestPaginationWriteHeadersEdges(t *testing.T) {
+	// The first page has no prev relation, custom parameter names are used
+	rec := httptest.NewRecorder()
+	opts := PaginationHeaderOptions{BaseURL: "/items", PageParam: "p", PerPageParam: "size"}
+	if err := NewPaginationInfo(1, 50, 60).WriteHeaders(rec, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `</items?p=1&size=50>; rel="first", </items?p=2&size=50>; rel="next", </items?p=2&size=50>; rel="last"`
+	if got := rec.Header().Get("Link"); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	// An empty result has no Link header, and none is written without a base URL
+	for _, opts := range []PaginationHeaderOptions{{BaseURL: "/items"}, {}} {
+		rec := httptest.NewRecorder()
+		if err := NewPaginationInfo(1, 10, 0).WriteHeaders(rec, opts); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := rec.Header()["Link"]; ok || rec.Header().Get("X-Total-Count") != "0" {
+			t.Errorf("headers = %v, want X-Total-Count 0 and no Link", rec.Header())
+		}
+	}
+
+	if err := NewPaginationInfo(1, 10, 5).WriteHeaders(httptest.NewRecorder(), PaginationHeaderOptions{BaseURL: "://bad"}); err == nil {
+		t.Error("WriteHeaders with an invalid base URL succeeded")
+	}
+}
+
+func TestParsePaginationFromRequest(t *testing.T) {
+	defaults := PaginationDefaults{PerPage: 20, MaxPerPage: 50}
+	tests := []struct {
+		query         string
+		page, perPage int
+		wantErr       bool
+	}{
+		{"", 1, 20, false},
+		{"page=3", 3, 20, false},
+		{"page=2&per_page=50", 2, 50, false},
+		{"page=0", 0, 0, true},
+		{"per_page=51", 0, 0, true},
+		{"page=abc", 0, 0, true},
+		{"per_page=-1", 0, 0, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/users?"+tt.query, nil)
+		p, err := ParsePaginationFromRequest(r, defaults)
+		if tt.wantErr {
+			var ep *ErrorPlus
+			if !errors.As(err, &ep) || ep.Code() != 400 {
+				t.Errorf("%q: error = %v, want a 400 *ErrorPlus", tt.query, err)
+			}
+			continue
+		}
+		if err != nil || p.CurrentPage != tt.page || p.PerPage != tt.perPage {
+			t.Errorf("%q: got %+v, %v; want page %d and per_page %d", tt.query, p, err, tt.page, tt.perPage)
+		}
+	}
+
+	// Out-of-range values wrap ErrInvalidPaginationParam, custom names are read
+	r := httptest.NewRequest("GET", "/users?p=4&size=500", nil)
+	if _, err := ParsePaginationFromRequest(r, PaginationDefaults{PageParam: "p", PerPageParam: "size"}); !errors.Is(err, ErrInvalidPaginationParam) {
+		t.Errorf("size=500 error = %v, want ErrInvalidPaginationParam", err)
+	}
+}
+
+func TestParseConnectionArgsMatrix(t *testing.T) {
+	ptr := IntPtr
+	cursor := func(offset int) *string { return StrPtr(OffsetCursor(offset)) }
+	tests := []struct {
+		name                string
+		first, last         *int
+		after, before       *string
+		wantErr             bool
+		wantOffset, wantLen int
+	}{
+		{name: "none", wantOffset: 0, wantLen: 10},
+		{name: "first", first: ptr(5), wantOffset: 0, wantLen: 5},
+		{name: "first after", first: ptr(5), after: cursor(9), wantOffset: 10, wantLen: 5},
+		{name: "first after near the end", first: ptr(5), after: cursor(47), wantOffset: 48, wantLen: 2},
+		{name: "after past the end", first: ptr(5), after: cursor(80), wantOffset: 50, wantLen: 0},
+		{name: "after only", after: cursor(44), wantOffset: 45, wantLen: 5},
+		{name: "last", last: ptr(5), wantOffset: 45, wantLen: 5},
+		{name: "last before", last: ptr(5), before: cursor(20), wantOffset: 15, wantLen: 5},
+		{name: "last before near the start", last: ptr(5), before: cursor(3), wantOffset: 0, wantLen: 3},
+		{name: "before only", before: cursor(12), wantOffset: 2, wantLen: 10},
+		{name: "first 0", first: ptr(0), wantOffset: 0, wantLen: 0},
+		{name: "negative first", first: ptr(-1), wantErr: true},
+		{name: "negative last", last: ptr(-1), wantErr: true},
+		{name: "first and last", first: ptr(1), last: ptr(1), wantErr: true},
+		{name: "after and before", after: cursor(1), before: cursor(5), wantErr: true},
+		{name: "first before", first: ptr(1), before: cursor(5), wantErr: true},
+		{name: "last after", last: ptr(1), after: cursor(5), wantErr: true},
+	}
+	const total = 50
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := ParseConnectionArgs(tt.first, tt.after, tt.last, tt.before)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConnectionArgs) {
+					t.Fatalf("error = %v, want ErrInvalidConnectionArgs", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backward := tt.last != nil || tt.before != nil; args.Backward != backward {
+				t.Errorf("Backward = %v, want %v", args.Backward, backward)
+			}
+			offset, limit, err := args.OffsetLimit(total, 10)
+			if err != nil || offset != tt.wantOffset || limit != tt.wantLen {
+				t.Errorf("OffsetLimit = %d, %d, %v; want %d, %d", offset, limit, err, tt.wantOffset, tt.wantLen)
+			}
+		})
+	}
+
+	args, _ := ParseConnectionArgs(ptr(1), StrPtr("not a cursor"), nil, nil)
+	if _, _, err := args.OffsetLimit(total, 10); !errors.Is(err, ErrInvalidConnectionArgs) {
+		t.Errorf("OffsetLimit with an invalid cursor: error = %v, want ErrInvalidConnectionArgs", err)
+	}
+}
+
+func TestToConnection(t *testing.T) {
+	items := []int{11, 12, 13}
+	conn := ToConnection(items, NewPaginationInfo(2, 3, 10), func(i int) string { return OffsetCursor(i) })
+	if len(conn.Edges) != 3 || conn.TotalCount != 10 {
+		t.Fatalf("got %d edges and total %d, want 3 and 10", len(conn.Edges), conn.TotalCount)
+	}
+	for i, edge := range conn.Edges {
+		if edge.Node != items[i] || edge.Cursor != OffsetCursor(items[i]) {
+			t.Errorf("edge %d = %+v", i, edge)
+		}
+	}
+	info := conn.PageInfo
+	if !info.HasNextPage || !info.HasPreviousPage || *info.StartCursor != OffsetCursor(11) || *info.EndCursor != OffsetCursor(13) {
+		t.Errorf("PageInfo = %+v", info)
+	}
+
+	empty := ToConnection([]int{}, nil, func(i int) string { return OffsetCursor(i) })
+	if len(empty.Edges) != 0 || empty.PageInfo.StartCursor != nil || empty.PageInfo.EndCursor != nil || empty.PageInfo.HasNextPage {
+		t.Errorf("empty connection = %+v, want no edges and nil cursors", empty)
+	}
+}
+
+func TestNewPaginationInfoOptsBoundaries(t *testing.T) {
+	opts := PaginationOptions{MaxPage: 20, MaxPerPage: 50}
+	tests := []struct {
+		name             string
+		page, perPage    int
+		opts             PaginationOptions
+		wantPage         int
+		wantPerPage      int
+		wantLastPage     int
+		wantClamped      bool
+		wantNext         bool
+		wantErrParameter string
+	}{
+		{name: "within limits", page: 20, perPage: 50, opts: opts, wantPage: 20, wantPerPage: 50, wantLastPage: 20},
+		{name: "page above max", page: 21, perPage: 10, opts: opts, wantPage: 20, wantPerPage: 10, wantLastPage: 20, wantClamped: true},
+		{name: "per_page above max", page: 1, perPage: 51, opts: opts, wantPage: 1, wantPerPage: 50, wantLastPage: 20, wantClamped: true, wantNext: true},
+		{name: "max page is the last page", page: 19, perPage: 10, opts: opts, wantPage: 19, wantPerPage: 10, wantLastPage: 20, wantNext: true},
+		{name: "max offset", page: 12, perPage: 10, opts: PaginationOptions{MaxOffset: 100}, wantPage: 11, wantPerPage: 10, wantLastPage: 11, wantClamped: true},
+		{name: "no limits", page: 1000, perPage: 1000, wantPage: 1000, wantPerPage: 1000, wantLastPage: 1000},
+		{name: "reject page", page: 21, perPage: 10, opts: PaginationOptions{MaxPage: 20, Reject: true}, wantErrParameter: "page"},
+		{name: "reject per_page", page: 1, perPage: 51, opts: PaginationOptions{MaxPerPage: 50, Reject: true}, wantErrParameter: "per_page"},
+		{name: "reject max offset", page: 12, perPage: 10, opts: PaginationOptions{MaxOffset: 100, Reject: true}, wantErrParameter: "page"},
+		{name: "reject at the limit", page: 20, perPage: 50, opts: PaginationOptions{MaxPage: 20, MaxPerPage: 50, Reject: true}, wantPage: 20, wantPerPage: 50, wantLastPage: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPaginationInfoOpts(tt.page, tt.perPage, 1_000_000, tt.opts)
+			if tt.wantErrParameter != "" {
+				var ep *ErrorPlus
+				if p != nil || !errors.Is(err, ErrPageOutOfRange) || !errors.As(err, &ep) || ep.Code() != 400 {
+					t.Fatalf("got %+v, %v; want a 400 error wrapping ErrPageOutOfRange", p, err)
+				}
+				if parameter, _ := ep.RevealContext("parameter"); parameter != tt.wantErrParameter {
+					t.Errorf("parameter = %v, want %s", parameter, tt.wantErrParameter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.CurrentPage != tt.wantPage || p.PerPage != tt.wantPerPage || p.LastPage != tt.wantLastPage ||
+				p.Clamped != tt.wantClamped || (p.NextPage != nil) != tt.wantNext {
+				t.Errorf("got page %d, per_page %d, last %d, clamped %v, next %v; want %d, %d, %d, %v, %v",
+					p.CurrentPage, p.PerPage, p.LastPage, p.Clamped, p.NextPage != nil,
+					tt.wantPage, tt.wantPerPage, tt.wantLastPage, tt.wantClamped, tt.wantNext)
+			}
+		})
+	}
+}
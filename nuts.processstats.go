@@ -4,8 +4,46 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 )
 
+// processStart approximates the start of the process for ProcessStats.Uptime
+var processStart = time.Now()
+
+// ProcessStats is a snapshot of the memory and goroutine statistics of the process
+type ProcessStats struct {
+	PID             int           `json:"pid"`
+	Goroutines      int           `json:"goroutines"`
+	AllocBytes      uint64        `json:"alloc_bytes"`       // bytes of allocated heap objects
+	TotalAllocBytes uint64        `json:"total_alloc_bytes"` // cumulative bytes allocated
+	SysBytes        uint64        `json:"sys_bytes"`         // bytes obtained from the OS
+	HeapObjects     uint64        `json:"heap_objects"`
+	NumGC           uint32        `json:"num_gc"`
+	Uptime          time.Duration `json:"uptime"`
+}
+
+// GetProcessStats returns a snapshot of the memory and goroutine statistics of the process.
+// It calls runtime.ReadMemStats, which briefly stops the world, so avoid it in hot paths.
+//
+// Example usage:
+//
+//	stats := gonuts.GetProcessStats()
+//	L.Infow("process stats", "goroutines", stats.Goroutines, "alloc", BytesToNiceString(int64(stats.AllocBytes)))
+func GetProcessStats() ProcessStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return ProcessStats{
+		PID:             os.Getpid(),
+		Goroutines:      runtime.NumGoroutine(),
+		AllocBytes:      m.Alloc,
+		TotalAllocBytes: m.TotalAlloc,
+		SysBytes:        m.Sys,
+		HeapObjects:     m.HeapObjects,
+		NumGC:           m.NumGC,
+		Uptime:          time.Since(processStart),
+	}
+}
+
 func PrintMemoryUsage() bool {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
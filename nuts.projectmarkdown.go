@@ -1,11 +1,16 @@
 package gonuts
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/bmatcuk/doublestar/v4"
@@ -20,8 +25,91 @@ type MarkdownGeneratorConfig struct {
 	PrependText     *string  `yaml:"prependText"`
 	Title           *string  `yaml:"title"`
 	OutputFile      *string  `yaml:"outputFile"`
+	Incremental     *bool    `yaml:"incremental"`  // reuse cached per-file sections for unchanged files
+	ForceFull       *bool    `yaml:"forceFull"`    // ignore the manifest and cache and re-read every file
+	CacheDir        *string  `yaml:"cacheDir"`     // directory for cached per-file sections (incremental mode)
+	IncludeStats    *bool    `yaml:"includeStats"` // append a per-language statistics table
 }
 
+// GenerationReport summarizes the files rendered by GenerateMarkdownFromFilesWithReport.
+type GenerationReport struct {
+	OutputFile string
+	// Languages holds the statistics per language, largest first. Files whose language could
+	// not be inferred are counted as "Unknown"; files that could not be read are not counted.
+	Languages []LanguageStats
+	Total     LanguageStats // totals over all languages, with an empty Language
+}
+
+// LanguageStats holds the statistics of the files of one language.
+type LanguageStats struct {
+	Language string
+	Files    int
+	Lines    int
+	Bytes    int64
+}
+
+// markdownUnknownLanguage is the language reported for files chroma cannot identify.
+const markdownUnknownLanguage = "Unknown"
+
+// markdownStats collects the LanguageStats of the rendered files.
+type markdownStats map[string]*LanguageStats
+
+// add counts a file of the given language.
+func (ms markdownStats) add(language string, lines int, size int64) {
+	if language == "" {
+		language = markdownUnknownLanguage
+	}
+	stats := ms[language]
+	if stats == nil {
+		stats = &LanguageStats{Language: language}
+		ms[language] = stats
+	}
+	stats.Files++
+	stats.Lines += lines
+	stats.Bytes += size
+}
+
+// report returns the per-language statistics, largest first, and the totals.
+func (ms markdownStats) report() ([]LanguageStats, LanguageStats) {
+	languages := make([]LanguageStats, 0, len(ms))
+	var total LanguageStats
+	for _, stats := range ms {
+		languages = append(languages, *stats)
+		total.Files += stats.Files
+		total.Lines += stats.Lines
+		total.Bytes += stats.Bytes
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Bytes != languages[j].Bytes {
+			return languages[i].Bytes > languages[j].Bytes
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages, total
+}
+
+// markdownManifest records the state of every file rendered in incremental mode.
+type markdownManifest struct {
+	Files map[string]markdownManifestEntry `json:"files"`
+}
+
+type markdownManifestEntry struct {
+	SHA256        string    `json:"sha256"`
+	Size          int64     `json:"size"`
+	ModTime       time.Time `json:"mtime"`
+	SectionSHA256 string    `json:"sectionSha256"`
+	// Stats of the file; entries of older manifests without stats are re-read once.
+	Stats *markdownFileStats `json:"stats,omitempty"`
+}
+
+type markdownFileStats struct {
+	Language string `json:"language"`
+	Lines    int    `json:"lines"`
+}
+
+// markdownReadFile reads source files for the markdown generator. It is a variable so reads can be instrumented.
+var markdownReadFile = os.ReadFile
+
 var (
 	defaultExcludes = []string{
 		"**/vendor/**",
@@ -115,6 +203,23 @@ func ApplyDefaults(config *MarkdownGeneratorConfig) error {
 		config.OutputFile = &defaultOutputFile
 	}
 
+	if config.Incremental == nil {
+		config.Incremental = BoolPtr(false)
+	}
+
+	if config.ForceFull == nil {
+		config.ForceFull = BoolPtr(false)
+	}
+
+	if config.CacheDir == nil {
+		defaultCacheDir := filepath.Join(filepath.Dir(*config.OutputFile), ".cache", "markdown")
+		config.CacheDir = &defaultCacheDir
+	}
+
+	if config.IncludeStats == nil {
+		config.IncludeStats = BoolPtr(false)
+	}
+
 	return nil
 }
 
@@ -134,29 +239,59 @@ func ApplyDefaults(config *MarkdownGeneratorConfig) error {
 //		Title: gonuts.Ptr("My Custom Project Documentation"),
 //		BaseHeaderLevel: gonuts.Ptr(3),
 //	}
+//
+// With Incremental set, a manifest (path -> sha256, size, mtime) is persisted next to the output file
+// and each file's rendered section is cached in CacheDir. Subsequent runs only re-read files whose
+// size or mtime changed; missing or corrupted cache entries fall back to re-reading the file.
+// Set ForceFull to ignore the manifest and cache for one run.
+//
+// With IncludeStats set, a table of the file count, lines and size per language is appended.
 func GenerateMarkdownFromFiles(config *MarkdownGeneratorConfig) error {
+	_, err := GenerateMarkdownFromFilesWithReport(config)
+	return err
+}
+
+// GenerateMarkdownFromFilesWithReport works like GenerateMarkdownFromFiles and also returns
+// the per-language statistics of the rendered files, whether or not IncludeStats is set.
+//
+// Example usage:
+//
+//	report, err := gonuts.GenerateMarkdownFromFilesWithReport(config)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, lang := range report.Languages {
+//	    fmt.Printf("%s: %d files, %d lines\n", lang.Language, lang.Files, lang.Lines)
+//	}
+func GenerateMarkdownFromFilesWithReport(config *MarkdownGeneratorConfig) (*GenerationReport, error) {
 	err := ApplyDefaults(config)
 	if err != nil {
-		return fmt.Errorf("error applying defaults: %w", err)
+		return nil, fmt.Errorf("error applying defaults: %w", err)
 	}
 
 	// Validate config
 	if *config.BaseDir == "" {
-		return fmt.Errorf("base directory is required")
+		return nil, fmt.Errorf("base directory is required")
 	}
 	if *config.OutputFile == "" {
-		return fmt.Errorf("output file is required")
+		return nil, fmt.Errorf("output file is required")
 	}
 
-	// Add output file to excludes
+	// Add output file, manifest and cache to excludes
 	config.Excludes = append(config.Excludes, *config.OutputFile)
+	if rel, err := filepath.Rel(*config.BaseDir, *config.OutputFile); err == nil {
+		config.Excludes = append(config.Excludes, rel, rel+markdownManifestSuffix)
+	}
+	if rel, err := filepath.Rel(*config.BaseDir, *config.CacheDir); err == nil {
+		config.Excludes = append(config.Excludes, rel+"/**")
+	}
 
 	// Get all files matching include patterns
 	var files []string
 	for _, pattern := range config.Includes {
 		matches, err := doublestar.Glob(os.DirFS(*config.BaseDir), pattern)
 		if err != nil {
-			return fmt.Errorf("error matching include pattern %s: %w", pattern, err)
+			return nil, fmt.Errorf("error matching include pattern %s: %w", pattern, err)
 		}
 		files = append(files, matches...)
 	}
@@ -170,15 +305,30 @@ func GenerateMarkdownFromFiles(config *MarkdownGeneratorConfig) error {
 	})
 
 	// Generate markdown content
-	content := generateMarkdownContent(files, config)
+	var content string
+	stats := make(markdownStats)
+	if *config.Incremental {
+		content, err = generateMarkdownContentIncremental(files, config, stats)
+		if err != nil {
+			return nil, fmt.Errorf("error generating markdown incrementally: %w", err)
+		}
+	} else {
+		content = generateMarkdownContent(files, config, stats)
+	}
+
+	report := &GenerationReport{OutputFile: *config.OutputFile}
+	report.Languages, report.Total = stats.report()
+	if *config.IncludeStats {
+		content += generateStatsSection(report, config)
+	}
 
 	// Write markdown file
 	err = os.WriteFile(*config.OutputFile, []byte(content), 0644)
 	if err != nil {
-		return fmt.Errorf("error writing markdown file: %w", err)
+		return nil, fmt.Errorf("error writing markdown file: %w", err)
 	}
 
-	return nil
+	return report, nil
 }
 
 func filterExcludedFiles(files, excludes []string) []string {
@@ -209,33 +359,165 @@ func getPatternIndex(file string, patterns []string) int {
 	return len(patterns)
 }
 
-func generateMarkdownContent(files []string, config *MarkdownGeneratorConfig) string {
+func generateMarkdownContent(files []string, config *MarkdownGeneratorConfig, stats markdownStats) string {
 	var sb strings.Builder
+	writeMarkdownHeader(&sb, config)
 
-	// Add title
-	sb.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", *config.BaseHeaderLevel), *config.Title))
+	// Add file contents
+	for _, file := range files {
+		content, err := markdownReadFile(filepath.Join(*config.BaseDir, file))
+		var language string
+		if err == nil {
+			language = inferLanguage(file, string(content))
+			stats.add(language, countLines(content), int64(len(content)))
+		}
+		sb.WriteString(generateFileSection(file, content, language, err, config))
+	}
 
-	// Add prepend text
-	sb.WriteString(*config.PrependText + "\n\n")
+	return sb.String()
+}
+
+const markdownManifestSuffix = ".manifest.json"
+
+// generateMarkdownContentIncremental renders the markdown content, reusing cached sections of unchanged files.
+func generateMarkdownContentIncremental(files []string, config *MarkdownGeneratorConfig, stats markdownStats) (string, error) {
+	manifestPath := *config.OutputFile + markdownManifestSuffix
+	previous := markdownManifest{Files: make(map[string]markdownManifestEntry)}
+	if !*config.ForceFull {
+		previous = loadMarkdownManifest(manifestPath)
+	}
+	if err := os.MkdirAll(*config.CacheDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	current := markdownManifest{Files: make(map[string]markdownManifestEntry, len(files))}
+	var sb strings.Builder
+	writeMarkdownHeader(&sb, config)
 
-	// Add file contents
 	for _, file := range files {
-		relPath, _ := filepath.Rel(*config.BaseDir, file)
-		sb.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", *config.BaseHeaderLevel+1), relPath))
+		fullPath := filepath.Join(*config.BaseDir, file)
+		cachePath := markdownSectionCachePath(config, file)
+		info, statErr := os.Stat(fullPath)
+
+		// Reuse the cached section if the file looks unchanged and the cache entry is intact
+		if entry, ok := previous.Files[file]; ok && entry.Stats != nil && statErr == nil && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+			section, err := os.ReadFile(cachePath)
+			if err == nil && sha256Hex(section) == entry.SectionSHA256 {
+				sb.Write(section)
+				current.Files[file] = entry
+				stats.add(entry.Stats.Language, entry.Stats.Lines, entry.Size)
+				continue
+			}
+		}
 
-		content, err := os.ReadFile(filepath.Join(*config.BaseDir, file))
-		if err != nil {
-			sb.WriteString(fmt.Sprintf("Error reading file: %s\n\n", err))
+		content, err := markdownReadFile(fullPath)
+		var language string
+		if err == nil {
+			language = inferLanguage(file, string(content))
+			stats.add(language, countLines(content), int64(len(content)))
+		}
+		section := generateFileSection(file, content, language, err, config)
+		sb.WriteString(section)
+		if err != nil || statErr != nil {
+			continue
+		}
+		if err := os.WriteFile(cachePath, []byte(section), 0644); err != nil {
 			continue
 		}
+		current.Files[file] = markdownManifestEntry{
+			SHA256:        sha256Hex(content),
+			Size:          info.Size(),
+			ModTime:       info.ModTime(),
+			SectionSHA256: sha256Hex([]byte(section)),
+			Stats:         &markdownFileStats{Language: language, Lines: countLines(content)},
+		}
+	}
+
+	// Remove cached sections of files that no longer exist
+	for file := range previous.Files {
+		if _, ok := current.Files[file]; !ok {
+			os.Remove(markdownSectionCachePath(config, file))
+		}
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing manifest: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// loadMarkdownManifest reads a manifest, returning an empty one if it is missing or invalid.
+func loadMarkdownManifest(path string) markdownManifest {
+	manifest := markdownManifest{}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+	}
+	if err != nil || manifest.Files == nil {
+		manifest.Files = make(map[string]markdownManifestEntry)
+	}
+	return manifest
+}
+
+func markdownSectionCachePath(config *MarkdownGeneratorConfig, file string) string {
+	return filepath.Join(*config.CacheDir, sha256Hex([]byte(file))+".section")
+}
+
+func writeMarkdownHeader(sb *strings.Builder, config *MarkdownGeneratorConfig) {
+	// Add title
+	sb.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", *config.BaseHeaderLevel), *config.Title))
+
+	// Add prepend text
+	sb.WriteString(*config.PrependText + "\n\n")
+}
+
+// generateFileSection renders the markdown section of a single file.
+// file is relative to the base directory, as returned by the include globs.
+func generateFileSection(file string, content []byte, language string, readErr error, config *MarkdownGeneratorConfig) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", *config.BaseHeaderLevel+1), file))
 
-		language := inferLanguage(file, string(content))
-		sb.WriteString(fmt.Sprintf("```%s\n%s\n```\n\n", language, string(content)))
+	if readErr != nil {
+		sb.WriteString(fmt.Sprintf("Error reading file: %s\n\n", readErr))
+		return sb.String()
 	}
 
+	sb.WriteString(fmt.Sprintf("```%s\n%s\n```\n\n", language, string(content)))
 	return sb.String()
 }
 
+// generateStatsSection renders the statistics of a report as a GitHub-flavored markdown table.
+func generateStatsSection(report *GenerationReport, config *MarkdownGeneratorConfig) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s Statistics\n\n", strings.Repeat("#", *config.BaseHeaderLevel+1)))
+	sb.WriteString("| Language | Files | Lines | Size |\n")
+	sb.WriteString("| --- | ---: | ---: | ---: |\n")
+	for _, lang := range report.Languages {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", lang.Language, lang.Files, lang.Lines, BytesToNiceString(lang.Bytes)))
+	}
+	sb.WriteString(fmt.Sprintf("| **Total** | **%d** | **%d** | **%s** |\n\n", report.Total.Files, report.Total.Lines, BytesToNiceString(report.Total.Bytes)))
+	return sb.String()
+}
+
+// countLines returns the number of lines of a file; a last line without newline counts as well.
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte{'\n'})
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 func inferLanguage(filename, content string) string {
 	lexer := lexers.Match(filename)
 	if lexer == nil {
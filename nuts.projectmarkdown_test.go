@@ -0,0 +1,188 @@
+package gonuts
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeMarkdownTestFile writes a file below dir with the given modification time
+func writeMarkdownTestFile(t *testing.T, dir, name, content string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countMarkdownReads records the files read by the markdown generator until the test ends
+func countMarkdownReads(t *testing.T) *[]string {
+	var reads []string
+	original := markdownReadFile
+	markdownReadFile = func(name string) ([]byte, error) {
+		reads = append(reads, filepath.Base(name))
+		return original(name)
+	}
+	t.Cleanup(func() { markdownReadFile = original })
+	return &reads
+}
+
+func TestGenerateMarkdownIncremental(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	writeMarkdownTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n", mtime)
+	writeMarkdownTestFile(t, dir, "README.md", "# Readme\n", mtime)
+	writeMarkdownTestFile(t, dir, "scripts/build.py", "print('build')\n", mtime)
+	output := filepath.Join(dir, "project_code.md")
+	reads := countMarkdownReads(t)
+
+	generate := func(incremental, forceFull bool) string {
+		t.Helper()
+		*reads = nil
+		config := &MarkdownGeneratorConfig{
+			BaseDir:     Ptr(dir),
+			Includes:    []string{"**/*.go", "**/*.md", "**/*.py"},
+			OutputFile:  Ptr(output),
+			Incremental: Ptr(incremental),
+			ForceFull:   Ptr(forceFull),
+		}
+		if err := GenerateMarkdownFromFiles(config); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(*reads)
+		return string(data)
+	}
+
+	full := generate(false, false)
+	if first := generate(true, false); first != full || len(*reads) != 3 {
+		t.Fatalf("first incremental run read %v, output equal to a full run: %v", *reads, first == full)
+	}
+	if second := generate(true, false); second != full || len(*reads) != 0 {
+		t.Errorf("unchanged run read %v, output equal to a full run: %v", *reads, second == full)
+	}
+
+	// A changed file is read again, even if its size stayed the same
+	writeMarkdownTestFile(t, dir, "README.md", "# README\n", mtime.Add(time.Minute))
+	changed := generate(true, false)
+	if !slices.Equal(*reads, []string{"README.md"}) || !strings.Contains(changed, "# README\n") {
+		t.Errorf("run after changing README.md read %v", *reads)
+	}
+	if full = generate(false, false); changed != full {
+		t.Error("incremental output differs from a full run after a change")
+	}
+
+	// A corrupted cache entry falls back to reading the file
+	cacheDir := filepath.Join(dir, ".cache", "markdown")
+	if err := os.WriteFile(filepath.Join(cacheDir, sha256Hex([]byte("main.go"))+".section"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out := generate(true, false); out != full || !slices.Equal(*reads, []string{"main.go"}) {
+		t.Errorf("run with a corrupted cache entry read %v, output equal to a full run: %v", *reads, out == full)
+	}
+
+	if out := generate(true, true); out != full || len(*reads) != 3 {
+		t.Errorf("ForceFull run read %v", *reads)
+	}
+
+	// Removed files leave the manifest and the cache
+	if err := os.Remove(filepath.Join(dir, "scripts", "build.py")); err != nil {
+		t.Fatal(err)
+	}
+	generate(true, false)
+	manifest := loadMarkdownManifest(output + markdownManifestSuffix)
+	if _, ok := manifest.Files["scripts/build.py"]; ok || len(manifest.Files) != 2 {
+		t.Errorf("manifest files = %v after removing scripts/build.py", manifest.Files)
+	}
+	if sections, _ := filepath.Glob(filepath.Join(cacheDir, "*.section")); len(sections) != 2 {
+		t.Errorf("%d cached sections for 2 files", len(sections))
+	}
+}
+
+func TestGenerateMarkdownLanguageStats(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	writeMarkdownTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n", mtime)
+	writeMarkdownTestFile(t, dir, "util/strings.go", "package util", mtime) // no trailing newline
+	writeMarkdownTestFile(t, dir, "README.md", "# Readme\n", mtime)
+	writeMarkdownTestFile(t, dir, "broken.md", "# Broken\n", mtime)
+	output := filepath.Join(dir, "project_code.md")
+
+	original := markdownReadFile
+	markdownReadFile = func(name string) ([]byte, error) {
+		if filepath.Base(name) == "broken.md" {
+			return nil, os.ErrPermission
+		}
+		return original(name)
+	}
+	t.Cleanup(func() { markdownReadFile = original })
+
+	generate := func(includeStats, incremental bool) (*GenerationReport, string) {
+		t.Helper()
+		report, err := GenerateMarkdownFromFilesWithReport(&MarkdownGeneratorConfig{
+			BaseDir:      Ptr(dir),
+			Includes:     []string{"**/*.go", "**/*.md"},
+			OutputFile:   Ptr(output),
+			IncludeStats: Ptr(includeStats),
+			Incremental:  Ptr(incremental),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report, string(data)
+	}
+
+	report, content := generate(false, false)
+	want := []LanguageStats{
+		{Language: "Go", Files: 2, Lines: 4, Bytes: 41},
+		{Language: "markdown", Files: 1, Lines: 1, Bytes: 9},
+	}
+	if !slices.Equal(report.Languages, want) || report.Total != (LanguageStats{Files: 3, Lines: 5, Bytes: 50}) {
+		t.Errorf("report = %+v with total %+v, want %+v without the unreadable file", report.Languages, report.Total, want)
+	}
+	if report.OutputFile != output || strings.Contains(content, "Statistics") {
+		t.Errorf("output file %s has a statistics section without IncludeStats", report.OutputFile)
+	}
+
+	_, content = generate(true, false)
+	table := "### Statistics\n\n" +
+		"| Language | Files | Lines | Size |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| Go | 2 | 4 | " + BytesToNiceString(41) + " |\n" +
+		"| markdown | 1 | 1 | " + BytesToNiceString(9) + " |\n" +
+		"| **Total** | **3** | **5** | **" + BytesToNiceString(50) + "** |\n\n"
+	if !strings.HasSuffix(content, table) {
+		t.Errorf("output does not end with the statistics table:\n%s", content)
+	}
+
+	// Cached sections keep their statistics
+	generate(false, true)
+	if cached, _ := generate(false, true); !slices.Equal(cached.Languages, want) || cached.Total != report.Total {
+		t.Errorf("report from cached sections = %+v with total %+v", cached.Languages, cached.Total)
+	}
+
+	if languages, total := (markdownStats{}).report(); len(languages) != 0 || total != (LanguageStats{}) {
+		t.Errorf("empty statistics = %v, %+v", languages, total)
+	}
+	stats := markdownStats{}
+	stats.add("", 2, 10)
+	if languages, _ := stats.report(); len(languages) != 1 || languages[0].Language != markdownUnknownLanguage {
+		t.Errorf("statistics of a file without language = %+v", languages)
+	}
+}
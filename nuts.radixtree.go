@@ -0,0 +1,350 @@
+package gonuts
+
+import (
+	"sort"
+	"strings"
+)
+
+// radixNode represents a node in the RadixTree. Each node stores the compressed
+// edge label leading to it instead of a single rune.
+type radixNode struct {
+	prefix   string       // edge label from the parent to this node
+	children []*radixNode // sorted by the first byte of their prefix
+	isEnd    bool
+	value    interface{}
+}
+
+// RadixTree is a memory-efficient compressed variant of Trie.
+//
+// Chains of single-child nodes are collapsed into one node carrying a string segment,
+// so a dictionary of long keys with few branch points needs far fewer nodes than Trie,
+// which allocates one node (and one map) per rune. The trade-off is slightly more work
+// per lookup step (comparing segments and splitting edges on insert).
+// Results of AutoComplete are returned in lexicographic (byte) order.
+type RadixTree struct {
+	root *radixNode
+	size int
+}
+
+// NewRadixTree creates and returns a new RadixTree.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+func NewRadixTree() *RadixTree {
+	return &RadixTree{root: &radixNode{}}
+}
+
+// CompressTrie converts a Trie into a RadixTree containing the same words and values.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.BulkInsert([]string{"apple", "app", "application"})
+//	tree := CompressTrie(trie)
+//	fmt.Println(tree.Search("app"))  // Output: true
+func CompressTrie(t *Trie) *RadixTree {
+	tree := NewRadixTree()
+	if t == nil || t.root == nil {
+		return tree
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var walk func(node *TrieNode, word []rune)
+	walk = func(node *TrieNode, word []rune) {
+		if node.isEnd {
+			tree.InsertWithValue(string(word), node.value)
+		}
+		for ch, child := range node.children {
+			walk(child, append(word, ch))
+		}
+	}
+	walk(t.root, nil)
+	return tree
+}
+
+// Insert adds a word to the RadixTree.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.Insert("apple")
+func (rt *RadixTree) Insert(word string) {
+	node := rt.insertNode(word)
+	if !node.isEnd {
+		node.isEnd = true
+		rt.size++
+	}
+}
+
+// InsertWithValue adds a word to the RadixTree with an associated value.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.InsertWithValue("apple", 42)
+func (rt *RadixTree) InsertWithValue(word string, value interface{}) {
+	node := rt.insertNode(word)
+	if !node.isEnd {
+		node.isEnd = true
+		rt.size++
+	}
+	node.value = value
+}
+
+// BulkInsert efficiently inserts multiple words into the RadixTree.
+func (rt *RadixTree) BulkInsert(words []string) {
+	for _, word := range words {
+		rt.Insert(word)
+	}
+}
+
+// insertNode returns the node for key, creating and splitting nodes as needed.
+func (rt *RadixTree) insertNode(key string) *radixNode {
+	node := rt.root
+	for key != "" {
+		idx, child := node.findChild(key[0])
+		if child == nil {
+			leaf := &radixNode{prefix: key}
+			node.addChild(leaf)
+			return leaf
+		}
+
+		common := commonPrefixLength(key, child.prefix)
+		if common < len(child.prefix) {
+			// Split the edge: node -> split -> child
+			split := &radixNode{prefix: child.prefix[:common], children: []*radixNode{child}}
+			child.prefix = child.prefix[common:]
+			node.children[idx] = split
+			child = split
+		}
+		key = key[common:]
+		node = child
+	}
+	return node
+}
+
+// Search checks if a word exists in the RadixTree.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.Insert("apple")
+//	fmt.Println(tree.Search("apple"))  // Output: true
+//	fmt.Println(tree.Search("app"))    // Output: false
+func (rt *RadixTree) Search(word string) bool {
+	node := rt.findNode(word)
+	return node != nil && node.isEnd
+}
+
+// Get returns the value associated with a word and whether the word exists.
+func (rt *RadixTree) Get(word string) (interface{}, bool) {
+	node := rt.findNode(word)
+	if node == nil || !node.isEnd {
+		return nil, false
+	}
+	return node.value, true
+}
+
+// StartsWith checks if any word in the RadixTree starts with the given prefix.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.Insert("apple")
+//	fmt.Println(tree.StartsWith("app"))  // Output: true
+//	fmt.Println(tree.StartsWith("ban"))  // Output: false
+func (rt *RadixTree) StartsWith(prefix string) bool {
+	node, _ := rt.findPrefixNode(prefix)
+	return node != nil
+}
+
+// AutoComplete returns a list of words that start with the given prefix, in lexicographic order.
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.BulkInsert([]string{"apple", "app", "application", "appreciate"})
+//	suggestions := tree.AutoComplete("app", 3)
+//	fmt.Println(suggestions)  // Output: [app apple application]
+func (rt *RadixTree) AutoComplete(prefix string, limit int) []string {
+	result := []string{}
+	node, word := rt.findPrefixNode(prefix)
+	if node == nil {
+		return result
+	}
+	rt.dfs(node, word, &result, limit)
+	return result
+}
+
+// dfs is a helper function for AutoComplete.
+func (rt *RadixTree) dfs(node *radixNode, word string, result *[]string, limit int) {
+	if len(*result) == limit {
+		return
+	}
+	if node.isEnd {
+		*result = append(*result, word)
+	}
+	for _, child := range node.children {
+		rt.dfs(child, word+child.prefix, result, limit)
+	}
+}
+
+// Delete removes a word from the RadixTree, merging nodes that are no longer needed.
+//
+// Returns:
+//   - bool: true if the word existed and was removed
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.Insert("apple")
+//	fmt.Println(tree.Delete("apple"))  // Output: true
+func (rt *RadixTree) Delete(word string) bool {
+	if word == "" {
+		if !rt.root.isEnd {
+			return false
+		}
+		rt.root.isEnd = false
+		rt.root.value = nil
+		rt.size--
+		return true
+	}
+	if !rt.delete(rt.root, word) {
+		return false
+	}
+	rt.size--
+	return true
+}
+
+// delete removes key below parent and compacts the path on the way back up.
+func (rt *RadixTree) delete(parent *radixNode, key string) bool {
+	idx, child := parent.findChild(key[0])
+	if child == nil || !strings.HasPrefix(key, child.prefix) {
+		return false
+	}
+
+	rest := key[len(child.prefix):]
+	if rest == "" {
+		if !child.isEnd {
+			return false
+		}
+		child.isEnd = false
+		child.value = nil
+	} else if !rt.delete(child, rest) {
+		return false
+	}
+
+	switch {
+	case child.isEnd:
+	case len(child.children) == 0:
+		parent.children = append(parent.children[:idx], parent.children[idx+1:]...)
+	case len(child.children) == 1:
+		grandchild := child.children[0]
+		grandchild.prefix = child.prefix + grandchild.prefix
+		parent.children[idx] = grandchild
+	}
+	return true
+}
+
+// LongestPrefixOf returns the longest word in the RadixTree that is a prefix of s.
+//
+// Returns:
+//   - prefix: the longest stored word that s starts with
+//   - value: the value associated with that word
+//   - found: false if no stored word is a prefix of s
+//
+// Example:
+//
+//	tree := NewRadixTree()
+//	tree.BulkInsert([]string{"/api", "/api/v1"})
+//	prefix, _, _ := tree.LongestPrefixOf("/api/v1/users")
+//	fmt.Println(prefix)  // Output: /api/v1
+func (rt *RadixTree) LongestPrefixOf(s string) (prefix string, value interface{}, found bool) {
+	node := rt.root
+	consumed := 0
+	if node.isEnd {
+		value, found = node.value, true
+	}
+	for consumed < len(s) {
+		_, child := node.findChild(s[consumed])
+		if child == nil || !strings.HasPrefix(s[consumed:], child.prefix) {
+			break
+		}
+		consumed += len(child.prefix)
+		node = child
+		if node.isEnd {
+			prefix, value, found = s[:consumed], node.value, true
+		}
+	}
+	return prefix, value, found
+}
+
+// Len returns the number of words stored in the RadixTree.
+func (rt *RadixTree) Len() int {
+	return rt.size
+}
+
+// findNode returns the node that exactly matches word, or nil.
+func (rt *RadixTree) findNode(word string) *radixNode {
+	node := rt.root
+	for word != "" {
+		_, child := node.findChild(word[0])
+		if child == nil || !strings.HasPrefix(word, child.prefix) {
+			return nil
+		}
+		word = word[len(child.prefix):]
+		node = child
+	}
+	return node
+}
+
+// findPrefixNode returns the first node whose path starts with prefix, together with the full path to it.
+func (rt *RadixTree) findPrefixNode(prefix string) (*radixNode, string) {
+	node := rt.root
+	consumed := 0
+	for consumed < len(prefix) {
+		_, child := node.findChild(prefix[consumed])
+		if child == nil {
+			return nil, ""
+		}
+		rest := prefix[consumed:]
+		if strings.HasPrefix(child.prefix, rest) {
+			return child, prefix[:consumed] + child.prefix
+		}
+		if !strings.HasPrefix(rest, child.prefix) {
+			return nil, ""
+		}
+		consumed += len(child.prefix)
+		node = child
+	}
+	return node, prefix
+}
+
+// findChild returns the index and child whose prefix starts with b.
+func (n *radixNode) findChild(b byte) (int, *radixNode) {
+	idx := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= b
+	})
+	if idx < len(n.children) && n.children[idx].prefix[0] == b {
+		return idx, n.children[idx]
+	}
+	return idx, nil
+}
+
+// addChild inserts a child keeping the children sorted by their first byte.
+func (n *radixNode) addChild(child *radixNode) {
+	idx, _ := n.findChild(child.prefix[0])
+	n.children = append(n.children, nil)
+	copy(n.children[idx+1:], n.children[idx:])
+	n.children[idx] = child
+}
+
+// commonPrefixLength returns the length of the common byte prefix of a and b.
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
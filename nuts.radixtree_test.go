@@ -0,0 +1,238 @@
+package gonuts
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// radixNodeCount returns the number of nodes below and including n
+func radixNodeCount(n *radixNode) int {
+	count := 1
+	for _, child := range n.children {
+		count += radixNodeCount(child)
+	}
+	return count
+}
+
+func TestRadixTreeInsertSplitsEdges(t *testing.T) {
+	tree := NewRadixTree()
+	tree.Insert("application")
+	tree.Insert("apple")  // splits "application" at "appl"
+	tree.Insert("app")    // splits "appl" at "app"
+	tree.Insert("apple")  // duplicates do not count
+	tree.Insert("banana") // new branch at the root
+	tree.InsertWithValue("app", 7)
+
+	if n := tree.Len(); n != 4 {
+		t.Errorf("Len() = %d, want 4", n)
+	}
+	// root -> app -> l -> {e, ication}, root -> banana
+	if n := radixNodeCount(tree.root); n != 6 {
+		t.Errorf("%d nodes, want 6", n)
+	}
+	for _, word := range []string{"app", "apple", "application", "banana"} {
+		if !tree.Search(word) {
+			t.Errorf("Search(%q) = false", word)
+		}
+	}
+	for _, word := range []string{"", "a", "ap", "appl", "applications", "ban", "cherry"} {
+		if tree.Search(word) {
+			t.Errorf("Search(%q) = true for a word that was never inserted", word)
+		}
+	}
+	if v, ok := tree.Get("app"); !ok || v != 7 {
+		t.Errorf("Get(app) = %v, %v, want 7, true", v, ok)
+	}
+	if v, ok := tree.Get("appl"); ok {
+		t.Errorf("Get(appl) = %v, true for an inner node", v)
+	}
+
+	for prefix, want := range map[string]bool{"": true, "ap": true, "appli": true, "applicationx": false, "b": true, "c": false} {
+		if got := tree.StartsWith(prefix); got != want {
+			t.Errorf("StartsWith(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+func TestRadixTreeDeleteMergesNodes(t *testing.T) {
+	tree := NewRadixTree()
+	tree.BulkInsert([]string{"app", "apple", "application"})
+
+	if tree.Delete("appl") {
+		t.Error("Delete of an inner node returned true")
+	}
+	if tree.Delete("zebra") {
+		t.Error("Delete of a missing word returned true")
+	}
+	if !tree.Delete("apple") {
+		t.Fatal("Delete(apple) returned false")
+	}
+	// "l" has a single child left and is merged into "lication"
+	if n := radixNodeCount(tree.root); n != 3 {
+		t.Errorf("%d nodes after deleting apple, want 3", n)
+	}
+	if !tree.Delete("app") {
+		t.Fatal("Delete(app) returned false")
+	}
+	if n := radixNodeCount(tree.root); n != 2 || tree.root.children[0].prefix != "application" {
+		t.Errorf("%d nodes after deleting app, want the root and a single application node", n)
+	}
+	if !tree.Search("application") || tree.Search("app") || tree.Len() != 1 {
+		t.Errorf("Search(application) = %v, Search(app) = %v, Len() = %d", tree.Search("application"), tree.Search("app"), tree.Len())
+	}
+	if !tree.Delete("application") || radixNodeCount(tree.root) != 1 || tree.Len() != 0 {
+		t.Error("the tree is not empty after deleting every word")
+	}
+
+	tree.InsertWithValue("", "root")
+	if v, ok := tree.Get(""); !ok || v != "root" {
+		t.Errorf("Get(\"\") = %v, %v", v, ok)
+	}
+	if !tree.Delete("") || tree.Delete("") || tree.Len() != 0 {
+		t.Error("deleting the empty word twice did not succeed exactly once")
+	}
+}
+
+func TestRadixTreeAutoComplete(t *testing.T) {
+	tree := NewRadixTree()
+	tree.BulkInsert([]string{"appreciate", "apple", "app", "application", "apricot", "banana"})
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"app", 10, []string{"app", "apple", "application", "appreciate"}},
+		{"app", 3, []string{"app", "apple", "application"}},
+		{"appl", 10, []string{"apple", "application"}},
+		{"appli", 10, []string{"application"}}, // the prefix ends inside an edge
+		{"ap", 10, []string{"app", "apple", "application", "appreciate", "apricot"}},
+		{"", 2, []string{"app", "apple"}},
+		{"c", 10, []string{}},
+		{"applez", 10, []string{}},
+		{"app", 0, []string{}},
+	}
+	for _, tt := range tests {
+		if got := tree.AutoComplete(tt.prefix, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("AutoComplete(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestRadixTreeLongestPrefixOf(t *testing.T) {
+	tree := NewRadixTree()
+	tree.InsertWithValue("/api", "api")
+	tree.InsertWithValue("/api/v1", "v1")
+	tree.InsertWithValue("/api/v1/users", "users")
+
+	tests := []struct {
+		s, prefix string
+		value     interface{}
+		found     bool
+	}{
+		{"/api/v1/users/42", "/api/v1/users", "users", true},
+		{"/api/v1/user", "/api/v1", "v1", true}, // stops inside the edge "/users"
+		{"/api/v2", "/api", "api", true},
+		{"/api", "/api", "api", true},
+		{"/ap", "", nil, false},
+		{"/admin", "", nil, false},
+		{"", "", nil, false},
+	}
+	for _, tt := range tests {
+		prefix, value, found := tree.LongestPrefixOf(tt.s)
+		if prefix != tt.prefix || value != tt.value || found != tt.found {
+			t.Errorf("LongestPrefixOf(%q) = %q, %v, %v, want %q, %v, %v", tt.s, prefix, value, found, tt.prefix, tt.value, tt.found)
+		}
+	}
+
+	tree.InsertWithValue("", "default")
+	if prefix, value, found := tree.LongestPrefixOf("/admin"); prefix != "" || value != "default" || !found {
+		t.Errorf("LongestPrefixOf(/admin) = %q, %v, %v, want the empty word", prefix, value, found)
+	}
+}
+
+func TestCompressTrieKeepsWordsAndValues(t *testing.T) {
+	words := []string{"app", "apple", "application", "banana", "band", "bandana", "über", "übel"}
+	trie := NewTrie()
+	for i, word := range words {
+		trie.InsertWithValue(word, i)
+	}
+	tree := CompressTrie(trie)
+
+	if n := tree.Len(); n != len(words) {
+		t.Errorf("Len() = %d, want %d", n, len(words))
+	}
+	for i, word := range words {
+		if v, ok := tree.Get(word); !ok || v != i {
+			t.Errorf("Get(%q) = %v, %v, want %d, true", word, v, ok, i)
+		}
+	}
+	want := slices.Clone(words)
+	slices.Sort(want)
+	if got := tree.AutoComplete("", len(words)); !slices.Equal(got, want) {
+		t.Errorf("AutoComplete(\"\") = %v, want %v", got, want)
+	}
+	// Multi-byte runes share their first byte, so their edges are split inside the rune
+	if got := tree.AutoComplete("üb", 10); !slices.Equal(got, []string{"übel", "über"}) {
+		t.Errorf("AutoComplete(üb) = %v", got)
+	}
+
+	if tree := CompressTrie(nil); tree.Len() != 0 {
+		t.Error("CompressTrie(nil) is not empty")
+	}
+}
+
+// radixBenchWords returns n URL-like keys with long shared segments and few branch points
+func radixBenchWords(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("/organizations/%d/projects/%d/environments/production/deployments/%d", i%50, i%500, i)
+	}
+	return words
+}
+
+// heapInUse returns the bytes of live heap objects after a garbage collection
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkTrieMemory builds a Trie and a RadixTree from the same 10k keys and reports the live
+// heap they retain per word
+func BenchmarkTrieMemory(b *testing.B) {
+	words := radixBenchWords(10000)
+	for _, bm := range []struct {
+		name  string
+		build func() interface{}
+	}{
+		{"Trie", func() interface{} {
+			trie := NewTrie()
+			trie.BulkInsert(words)
+			return trie
+		}},
+		{"RadixTree", func() interface{} {
+			tree := NewRadixTree()
+			tree.BulkInsert(words)
+			return tree
+		}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				before := heapInUse()
+				tree := bm.build()
+				after := heapInUse()
+				runtime.KeepAlive(tree)
+				if after > before {
+					retained += after - before
+				}
+			}
+			b.ReportMetric(float64(retained)/float64(b.N)/float64(len(words)), "bytes/word")
+		})
+	}
+}
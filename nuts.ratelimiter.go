@@ -2,10 +2,71 @@ package gonuts
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"sync"
 	"time"
 )
 
+// rateLimiterRecheck is how often waiters re-check a limiter whose rate is <= 0, as SetRate may raise it
+const rateLimiterRecheck = 100 * time.Millisecond
+
+var (
+	// ErrRateLimited is returned by AllowFunc when not enough tokens are available
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrRefund can be returned (or wrapped) by the function passed to AllowFunc to give the consumed tokens back
+	ErrRefund = errors.New("rate limiter: refund requested")
+)
+
+// NonConsumingError wraps an error returned from the function passed to AllowFunc
+// to signal that the operation did no real work and its tokens should be refunded.
+// AllowFunc still returns the error to the caller.
+type NonConsumingError struct {
+	Err error
+}
+
+func (e *NonConsumingError) Error() string {
+	if e.Err == nil {
+		return "non-consuming error"
+	}
+	return e.Err.Error()
+}
+
+func (e *NonConsumingError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitReceipt records a single token acquisition so it can be refunded at most once
+type RateLimitReceipt struct {
+	limiter  *RateLimiter
+	tokens   float64
+	refunded bool // guarded by limiter.mu
+}
+
+// Tokens returns the number of tokens acquired with this receipt
+func (r *RateLimitReceipt) Tokens() float64 {
+	return r.tokens
+}
+
+// Refund returns the tokens of this acquisition to the bucket, capped at the bucket size
+//
+// Returns:
+//   - bool: false if the receipt has already been refunded
+func (r *RateLimitReceipt) Refund() bool {
+	rl := r.limiter
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if r.refunded {
+		return false
+	}
+	r.refunded = true
+	rl.refill(time.Now())
+	rl.tokens = min(rl.bucketSize, rl.tokens+r.tokens)
+	return true
+}
+
 // RateLimiter implements a token bucket rate limiter
 type RateLimiter struct {
 	rate       float64
@@ -75,6 +136,80 @@ func (rl *RateLimiter) AllowN(n float64) bool {
 	return false
 }
 
+// AllowNWithReceipt is like AllowN but returns a receipt that can refund the acquired tokens once
+//
+// Parameters:
+//   - n: the number of tokens to request
+//
+// Returns:
+//   - *RateLimitReceipt: the receipt of the acquisition, nil if not allowed
+//   - bool: true if the requests are allowed, false otherwise
+//
+// Example usage:
+//
+//	receipt, ok := limiter.AllowNWithReceipt(1)
+//	if !ok {
+//	    return ErrTooManyRequests
+//	}
+//	if err := validate(req); err != nil {
+//	    receipt.Refund() // no real work was done
+//	    return err
+//	}
+func (rl *RateLimiter) AllowNWithReceipt(n float64) (*RateLimitReceipt, bool) {
+	if !rl.AllowN(n) {
+		return nil, false
+	}
+	return &RateLimitReceipt{limiter: rl, tokens: n}, true
+}
+
+// Refund adds n tokens back to the bucket, e.g. for work that was aborted right after Allow.
+// The bucket never exceeds its capacity. Prefer AllowNWithReceipt when the same acquisition
+// might be refunded from more than one code path.
+//
+// Parameters:
+//   - n: the number of tokens to return; values <= 0 are ignored
+func (rl *RateLimiter) Refund(n float64) {
+	if n <= 0 {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	rl.tokens = min(rl.bucketSize, rl.tokens+n)
+}
+
+// AllowFunc acquires n tokens and runs f. If f returns ErrRefund or a *NonConsumingError
+// (possibly wrapped), the tokens are refunded. The error of f is returned unchanged.
+//
+// Parameters:
+//   - n: the number of tokens to request
+//   - f: the rate-limited operation
+//
+// Returns:
+//   - error: ErrRateLimited if not enough tokens are available, otherwise the result of f
+//
+// Example usage:
+//
+//	err := limiter.AllowFunc(1, func() error {
+//	    if !req.Valid() {
+//	        return &gonuts.NonConsumingError{Err: ErrInvalidRequest}
+//	    }
+//	    return process(req)
+//	})
+func (rl *RateLimiter) AllowFunc(n float64, f func() error) error {
+	receipt, ok := rl.AllowNWithReceipt(n)
+	if !ok {
+		return ErrRateLimited
+	}
+	err := f()
+	var nonConsuming *NonConsumingError
+	if errors.Is(err, ErrRefund) || errors.As(err, &nonConsuming) {
+		receipt.Refund()
+	}
+	return err
+}
+
 // Wait blocks until a request is allowed or the context is cancelled
 //
 // Parameters:
@@ -98,28 +233,131 @@ func (rl *RateLimiter) Wait(ctx context.Context) error {
 	return rl.WaitN(ctx, 1)
 }
 
-// WaitN blocks until n requests are allowed or the context is cancelled
+// WaitN blocks until n requests are allowed or the context is cancelled. It sleeps until
+// enough tokens will have been added and checks again on wake, as competing waiters may have
+// taken them in the meantime.
 //
 // Parameters:
 //   - ctx: a context for cancellation
 //   - n: the number of tokens to request
 //
 // Returns:
-//   - error: nil if tokens were acquired, or an error if the context was cancelled
+//   - error: nil if tokens were acquired, the context's error if it was cancelled, or
+//     ErrRateLimited if n exceeds the bucket size and can never be acquired
 func (rl *RateLimiter) WaitN(ctx context.Context, n float64) error {
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if rl.AllowN(n) {
-				return nil
-			}
-			time.Sleep(time.Millisecond * 10) // Small sleep to prevent tight loop
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		delay, err := rl.acquireOrDelay(n)
+		if err != nil || delay == 0 {
+			return err
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
 		}
 	}
 }
 
+// Reserve takes n tokens now and returns how long the caller has to wait before acting on
+// them, without blocking. The bucket may go into debt, so later requests wait until it is paid
+// back. Callers that decide not to wait should give the tokens back with Refund(n).
+//
+// Parameters:
+//   - n: the number of tokens to reserve
+//
+// Returns:
+//   - time.Duration: the time until the tokens are available, 0 if they are available now
+//   - bool: false if the tokens can never be available (n exceeds the bucket size, or the
+//     rate is <= 0 and the bucket holds too few tokens); nothing is reserved then
+//
+// Example usage:
+//
+//	delay, ok := limiter.Reserve(1)
+//	if !ok || delay > maxDelay {
+//	    if ok {
+//	        limiter.Refund(1)
+//	    }
+//	    return ErrTooManyRequests
+//	}
+//	time.Sleep(delay)
+func (rl *RateLimiter) Reserve(n float64) (time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(time.Now())
+	if n > rl.bucketSize {
+		return 0, false
+	}
+	if rl.tokens >= n {
+		rl.tokens -= n
+		return 0, true
+	}
+	if rl.rate <= 0 {
+		return 0, false
+	}
+	delay := rl.delayFor(n)
+	rl.tokens -= n
+	return delay, true
+}
+
+// Rate returns the current rate in tokens per second
+func (rl *RateLimiter) Rate() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate
+}
+
+// SetRate changes the rate at which tokens are added to the bucket. Tokens accrued so far
+// are credited at the previous rate.
+//
+// Parameters:
+//   - rate: the new rate in tokens per second
+func (rl *RateLimiter) SetRate(rate float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(time.Now())
+	rl.rate = rate
+}
+
+// acquireOrDelay takes n tokens if they are available and returns 0, otherwise it returns the
+// time until they will be
+func (rl *RateLimiter) acquireOrDelay(n float64) (time.Duration, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if n > rl.bucketSize {
+		return 0, fmt.Errorf("%w: %v tokens exceed the bucket size of %v", ErrRateLimited, n, rl.bucketSize)
+	}
+	rl.refill(time.Now())
+	if rl.tokens >= n {
+		rl.tokens -= n
+		return 0, nil
+	}
+	if rl.rate <= 0 {
+		return rateLimiterRecheck, nil
+	}
+	return rl.delayFor(n), nil
+}
+
+// delayFor returns the time until the bucket holds n tokens; the caller holds rl.mu and
+// ensures rl.rate > 0. It is rounded up, so the tokens are there when the delay has passed.
+func (rl *RateLimiter) delayFor(n float64) time.Duration {
+	return time.Duration(math.Ceil((n - rl.tokens) / rl.rate * float64(time.Second)))
+}
+
+// sleepContext sleeps for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func (rl *RateLimiter) refill(now time.Time) {
 	elapsed := now.Sub(rl.lastRefill).Seconds()
 	rl.tokens = min(rl.bucketSize, rl.tokens+elapsed*rl.rate)
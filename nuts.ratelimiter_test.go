@@ -0,0 +1,280 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// drainedLimiter returns a limiter with an empty bucket
+func drainedLimiter(rate, bucketSize float64) *RateLimiter {
+	rl := NewRateLimiter(rate, bucketSize)
+	rl.AllowN(bucketSize)
+	return rl
+}
+
+func TestRateLimiterWaitNSleepsUntilTokensAreAvailable(t *testing.T) {
+	rl := drainedLimiter(100, 10)
+	start := time.Now()
+	if err := rl.WaitN(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond || elapsed > 150*time.Millisecond {
+		t.Errorf("WaitN(5) at 100/s took %s, want about 50ms", elapsed)
+	}
+
+	if err := rl.WaitN(context.Background(), 11); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("WaitN beyond the bucket size: error = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimiterWaitNCancelled(t *testing.T) {
+	rl := drainedLimiter(0.001, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := rl.WaitN(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitN error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("WaitN returned %s after the context was cancelled", elapsed)
+	}
+}
+
+func TestRateLimiterWaitNWakesOnSetRate(t *testing.T) {
+	rl := drainedLimiter(0, 1)
+	done := make(chan error, 1)
+	go func() { done <- rl.Wait(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	rl.SetRate(1000)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after the rate was raised")
+	}
+}
+
+func TestRateLimiterWaitNSharesTokensAmongWaiters(t *testing.T) {
+	const waiters, rate = 20, 200
+	rl := drainedLimiter(rate, 1)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := rl.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every waiter gets exactly one token, so together they take waiters/rate
+	want := waiters * time.Second / rate
+	if elapsed := time.Since(start); elapsed < want-10*time.Millisecond || elapsed > 3*want {
+		t.Errorf("%d waiters at %d/s took %s, want about %s", waiters, rate, elapsed, want)
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	rl := NewRateLimiter(100, 10)
+	if delay, ok := rl.Reserve(10); !ok || delay != 0 {
+		t.Fatalf("Reserve(10) on a full bucket = %s, %v, want 0, true", delay, ok)
+	}
+
+	// The bucket goes into debt, so every reservation waits for the previous ones
+	first, ok := rl.Reserve(5)
+	if !ok || first < 45*time.Millisecond || first > 50*time.Millisecond {
+		t.Fatalf("Reserve(5) on an empty bucket = %s, %v, want about 50ms", first, ok)
+	}
+	second, _ := rl.Reserve(5)
+	if second < first+45*time.Millisecond || second > 100*time.Millisecond {
+		t.Errorf("second Reserve(5) = %s, want about 100ms after the first one of %s", second, first)
+	}
+
+	// Giving back a reservation shortens the wait of later ones
+	rl.Refund(5)
+	if third, _ := rl.Reserve(5); third > second {
+		t.Errorf("Reserve(5) after a refund = %s, want at most %s", third, second)
+	}
+
+	if _, ok := rl.Reserve(11); ok {
+		t.Error("Reserve beyond the bucket size succeeded")
+	}
+}
+
+func TestRateLimiterReserveWithoutRate(t *testing.T) {
+	rl := NewRateLimiter(0, 2)
+	if delay, ok := rl.Reserve(2); !ok || delay != 0 {
+		t.Fatalf("Reserve(2) = %s, %v, want the tokens of the full bucket", delay, ok)
+	}
+	if _, ok := rl.Reserve(1); ok {
+		t.Error("Reserve succeeded on an empty bucket that never refills")
+	}
+	rl.Refund(1)
+	if !rl.Allow() {
+		t.Error("the failed Reserve took tokens: Allow failed after a refund")
+	}
+}
+
+// pollingWaitN is how WaitN used to work: try AllowN and sleep 10ms until it succeeds
+func pollingWaitN(ctx context.Context, rl *RateLimiter, n float64) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			if rl.AllowN(n) {
+				return nil
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// BenchmarkRateLimiterWait has 8 goroutines take b.N tokens from a limiter refilling 2000 per
+// second into a bucket of one token. Ideally one operation takes 500µs; with polling every 10ms,
+// the tokens overflowing the bucket between two polls are lost, so it takes about 10ms.
+func BenchmarkRateLimiterWait(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		wait func(ctx context.Context, rl *RateLimiter) error
+	}{
+		{"polling", func(ctx context.Context, rl *RateLimiter) error { return pollingWaitN(ctx, rl, 1) }},
+		{"WaitN", func(ctx context.Context, rl *RateLimiter) error { return rl.WaitN(ctx, 1) }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			rl := drainedLimiter(2000, 1)
+			work := make(chan struct{}, b.N)
+			for i := 0; i < b.N; i++ {
+				work <- struct{}{}
+			}
+			close(work)
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range work {
+						_ = bm.wait(context.Background(), rl)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// tokensOf returns the tokens in the bucket; the limiters of these tests never refill
+func tokensOf(rl *RateLimiter) float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.tokens
+}
+
+func TestRateLimitReceiptRefundsOnce(t *testing.T) {
+	rl := NewRateLimiter(0, 10)
+	receipt, ok := rl.AllowNWithReceipt(4)
+	if !ok || receipt.Tokens() != 4 {
+		t.Fatalf("AllowNWithReceipt(4) = %v, %v", receipt, ok)
+	}
+	rl.AllowN(2)
+
+	if !receipt.Refund() {
+		t.Fatal("first Refund returned false")
+	}
+	if receipt.Refund() {
+		t.Error("second Refund returned true")
+	}
+	if n := tokensOf(rl); n != 8 {
+		t.Errorf("%v tokens after refunding 4 of 10 with 2 taken, want 8", n)
+	}
+
+	// Refunds racing from several code paths still refund once
+	receipt, _ = rl.AllowNWithReceipt(8)
+	var refunds atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if receipt.Refund() {
+				refunds.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := refunds.Load(); n != 1 || tokensOf(rl) != 8 {
+		t.Errorf("%d concurrent refunds succeeded with %v tokens left, want 1 and 8", n, tokensOf(rl))
+	}
+
+	if receipt, ok := rl.AllowNWithReceipt(11); ok || receipt != nil {
+		t.Errorf("AllowNWithReceipt beyond the tokens = %v, %v, want nil, false", receipt, ok)
+	}
+}
+
+func TestRateLimitRefundCappedAtBucketSize(t *testing.T) {
+	rl := NewRateLimiter(0, 10)
+	receipt, _ := rl.AllowNWithReceipt(5)
+	rl.Refund(3) // another code path gives tokens back
+	rl.Refund(-1)
+	receipt.Refund()
+	if n := tokensOf(rl); n != 10 {
+		t.Errorf("%v tokens after refunding 8 of 5 taken, want the bucket size 10", n)
+	}
+	rl.Refund(100)
+	if n := tokensOf(rl); n != 10 {
+		t.Errorf("%v tokens after refunding 100, want the bucket size 10", n)
+	}
+}
+
+func TestRateLimiterAllowFuncRefunds(t *testing.T) {
+	invalid := errors.New("invalid request")
+	failed := errors.New("upstream failed")
+	tests := []struct {
+		name     string
+		err      error
+		refunded bool
+	}{
+		{"success", nil, false},
+		{"failure", failed, false},
+		{"ErrRefund", ErrRefund, true},
+		{"wrapped ErrRefund", fmt.Errorf("cache hit: %w", ErrRefund), true},
+		{"NonConsumingError", &NonConsumingError{Err: invalid}, true},
+		{"wrapped NonConsumingError", fmt.Errorf("validate: %w", &NonConsumingError{Err: invalid}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(0, 3)
+			if err := rl.AllowFunc(2, func() error { return tt.err }); err != tt.err {
+				t.Errorf("AllowFunc error = %v, want the error of f unchanged", err)
+			}
+			want := 1.0
+			if tt.refunded {
+				want = 3
+			}
+			if n := tokensOf(rl); n != want {
+				t.Errorf("%v tokens after AllowFunc, want %v", n, want)
+			}
+		})
+	}
+
+	rl := NewRateLimiter(0, 1)
+	called := false
+	if err := rl.AllowFunc(2, func() error { called = true; return nil }); !errors.Is(err, ErrRateLimited) || called {
+		t.Errorf("AllowFunc beyond the tokens = %v with f called %v, want ErrRateLimited without calling f", err, called)
+	}
+	if !errors.Is(&NonConsumingError{Err: invalid}, invalid) {
+		t.Error("NonConsumingError does not unwrap to its error")
+	}
+}
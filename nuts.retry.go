@@ -2,8 +2,12 @@ package gonuts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	randv2 "math/rand/v2"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,7 +21,7 @@ import (
 //   - f: The function to be executed.
 //
 // Returns:
-//   - error: nil if the function succeeds, otherwise the last error encountered.
+//   - error: nil if the function succeeds, otherwise a *RetryError with the errors of all attempts (see RetryWithOptions).
 //
 // The function uses exponential backoff with jitter to space out retry attempts.
 // It will stop retrying if the context is cancelled or the maximum number of attempts is reached.
@@ -31,32 +35,435 @@ import (
 //	    log.Printf("Operation failed after retries: %v", err)
 //	}
 func Retry(ctx context.Context, attempts int, initialDelay, maxDelay time.Duration, f func() error) error {
+	opts := RetryOptions{
+		Attempts:     attempts,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+	}
+	return RetryWithOptions(ctx, opts, func(context.Context) error {
+		return f()
+	})
+}
+
+// RetryOptions configures the behavior of RetryWithOptions.
+type RetryOptions struct {
+	Attempts       int           // Maximum number of attempts
+	InitialDelay   time.Duration // Delay before the first retry
+	MaxDelay       time.Duration // Upper bound for the backoff delay
+	AttemptTimeout time.Duration // Timeout for a single attempt (0 means no per-attempt timeout)
+
+	// Backoff selects how the delay grows between attempts (BackoffExponential by default).
+	Backoff BackoffStrategy
+	// MaxElapsed stops retrying once the next attempt would start later than this after the
+	// first one (0 means no limit). With MaxElapsed set, Attempts <= 0 means unlimited attempts.
+	MaxElapsed time.Duration
+
+	// Jitter returns a random value in [0, 1) used to randomize the backoff delay.
+	// nil uses the lock-free math/rand/v2 global source.
+	Jitter func() float64
+	// NoJitter disables the jitter, so the delays follow the backoff strategy exactly.
+	NoJitter bool
+	// IsRetryable reports whether a failed attempt may be retried (nil means every error is).
+	// A non-retryable error is returned unchanged, without further attempts.
+	IsRetryable func(err error) bool
+	// OnRetry is called after a failed attempt, before waiting delay for the next attempt.
+	// attempt is 1-based.
+	OnRetry func(attempt int, err error, delay time.Duration)
+	// Budget limits retries shared across call sites (nil means unlimited).
+	Budget *RetryBudget
+}
+
+// BackoffStrategy selects how RetryWithOptions spaces out attempts. Delays are capped at
+// RetryOptions.MaxDelay and then randomized by the jitter, unless NoJitter is set.
+type BackoffStrategy int
+
+const (
+	// BackoffExponential doubles the delay after every attempt: 1, 2, 4, 8, ... times InitialDelay
+	BackoffExponential BackoffStrategy = iota
+	// BackoffConstant waits InitialDelay between all attempts
+	BackoffConstant
+	// BackoffFibonacci grows the delay along the Fibonacci sequence: 1, 1, 2, 3, 5, ... times InitialDelay
+	BackoffFibonacci
+)
+
+// ErrRetryBudgetExhausted is wrapped by the error of RetryWithOptions when a retry was skipped
+// because the RetryBudget had no tokens left.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget limits the total number of retries across all call sites sharing it, protecting
+// a struggling dependency from retry storms. It is a token bucket (see RateLimiter): each retry
+// consumes one token, first attempts are never limited. It is safe for concurrent use.
+type RetryBudget struct {
+	limiter *RateLimiter
+	denied  atomic.Int64
+}
+
+// NewRetryBudget creates a new RetryBudget
+//
+// Parameters:
+//   - retriesPerSecond: the rate at which the budget refills
+//   - burst: the maximum number of retries available at once
+//
+// Example usage:
+//
+//	var apiRetryBudget = gonuts.NewRetryBudget(10, 50)
+//
+//	opts := gonuts.DefaultRetryOptions().WithBudget(apiRetryBudget)
+func NewRetryBudget(retriesPerSecond, burst float64) *RetryBudget {
+	return &RetryBudget{limiter: NewRateLimiter(retriesPerSecond, burst)}
+}
+
+// allow consumes a token for one retry.
+func (b *RetryBudget) allow() bool {
+	if b.limiter.Allow() {
+		return true
+	}
+	b.denied.Add(1)
+	return false
+}
+
+// Denied returns the number of retries skipped because the budget was exhausted.
+func (b *RetryBudget) Denied() int64 {
+	return b.denied.Load()
+}
+
+// DefaultRetryOptions returns a RetryOptions with sensible defaults:
+// 3 attempts, 100ms initial delay and a 10s maximum delay.
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		Attempts:     3,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+	}
+}
+
+// WithAttemptTimeout returns a copy of the options with the given per-attempt timeout.
+//
+// Each attempt receives a context derived from the parent context with this timeout.
+// An attempt that exceeds the timeout counts as a failed attempt and is eligible for backoff.
+func (o RetryOptions) WithAttemptTimeout(d time.Duration) RetryOptions {
+	o.AttemptTimeout = d
+	return o
+}
+
+// WithRand returns a copy of the options that draws jitter from r, making the backoff
+// schedule reproducible with a fixed seed. A *rand.Rand is not safe for concurrent use,
+// so do not share r between concurrent retries.
+//
+// Example usage:
+//
+//	opts := gonuts.DefaultRetryOptions().WithRand(rand.New(rand.NewSource(42)))
+func (o RetryOptions) WithRand(r *rand.Rand) RetryOptions {
+	o.Jitter = r.Float64
+	return o
+}
+
+// WithOnRetry returns a copy of the options with the given retry callback.
+//
+// Example usage:
+//
+//	opts := gonuts.DefaultRetryOptions().WithOnRetry(func(attempt int, err error, delay time.Duration) {
+//	    log.Printf("attempt %d failed: %v, retrying in %s", attempt, err, delay)
+//	})
+func (o RetryOptions) WithOnRetry(fn func(attempt int, err error, delay time.Duration)) RetryOptions {
+	o.OnRetry = fn
+	return o
+}
+
+// WithRetryable returns a copy of the options that only retries errors for which isRetryable
+// returns true; other errors are returned immediately.
+//
+// Example usage:
+//
+//	opts := gonuts.DefaultRetryOptions().WithRetryable(func(err error) bool {
+//	    var ep *gonuts.ErrorPlus
+//	    return !errors.As(err, &ep) || ep.Code() >= 500 // do not retry 4xx responses
+//	})
+func (o RetryOptions) WithRetryable(isRetryable func(err error) bool) RetryOptions {
+	o.IsRetryable = isRetryable
+	return o
+}
+
+// WithBackoff returns a copy of the options with the given backoff strategy.
+func (o RetryOptions) WithBackoff(strategy BackoffStrategy) RetryOptions {
+	o.Backoff = strategy
+	return o
+}
+
+// WithoutJitter returns a copy of the options whose delays are not randomized.
+func (o RetryOptions) WithoutJitter() RetryOptions {
+	o.NoJitter = true
+	return o
+}
+
+// WithMaxElapsed returns a copy of the options that stops retrying once the next attempt would
+// start more than d after the first one.
+//
+// Example usage:
+//
+//	// retry for up to 30 seconds, however many attempts that takes
+//	opts := gonuts.RetryOptions{InitialDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}.WithMaxElapsed(30 * time.Second)
+func (o RetryOptions) WithMaxElapsed(d time.Duration) RetryOptions {
+	o.MaxElapsed = d
+	return o
+}
+
+// WithBudget returns a copy of the options that consults the given shared retry budget
+// before every retry. When the budget is exhausted, the remaining retries are skipped and
+// the returned error wraps ErrRetryBudgetExhausted.
+func (o RetryOptions) WithBudget(b *RetryBudget) RetryOptions {
+	o.Budget = b
+	return o
+}
+
+// RetryWithOptions attempts to execute the given context-aware function with backoff
+// (exponential unless opts.Backoff says otherwise).
+//
+// Parameters:
+//   - ctx: A context.Context for cancellation.
+//   - opts: The retry configuration.
+//   - f: The function to be executed. It receives the per-attempt context.
+//
+// Returns:
+//   - error: nil if the function succeeds, a *RetryError with the errors of all attempts if
+//     every attempt failed, opts.MaxElapsed was reached or ctx was cancelled (see
+//     RetryError.Cancelled), the budget error, or the unchanged error of an attempt that
+//     opts.IsRetryable rejected.
+//
+// When opts.AttemptTimeout is set, a hung attempt is abandoned once its timeout expires so
+// the remaining attempts still run. f should honor ctx.Done(), since an abandoned attempt
+// keeps running in the background until it returns.
+//
+// Example usage:
+//
+//	opts := gonuts.DefaultRetryOptions().WithAttemptTimeout(2 * time.Second)
+//	err := gonuts.RetryWithOptions(ctx, opts, func(ctx context.Context) error {
+//	    return callRemoteService(ctx)
+//	})
+func RetryWithOptions(ctx context.Context, opts RetryOptions, f func(ctx context.Context) error) error {
+	_, err := retry(ctx, opts, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	})
+	return err
+}
+
+// RetryResult is like RetryWithOptions for functions returning a result, so callers do not need
+// to capture it in a closure. The result of an attempt abandoned by opts.AttemptTimeout is discarded.
+//
+// Returns:
+//   - T: the result of the successful attempt, the zero value otherwise
+//   - error: as for RetryWithOptions
+//
+// Example usage:
+//
+//	user, err := gonuts.RetryResult(ctx, gonuts.DefaultRetryOptions(), func(ctx context.Context) (User, error) {
+//	    return client.GetUser(ctx, id)
+//	})
+func RetryResult[T any](ctx context.Context, opts RetryOptions, f func(ctx context.Context) (T, error)) (T, error) {
+	return retry(ctx, opts, f)
+}
+
+// retry implements RetryWithOptions and RetryResult
+func retry[T any](ctx context.Context, opts RetryOptions, f func(ctx context.Context) (T, error)) (T, error) {
+	jitter := opts.Jitter
+	if jitter == nil {
+		jitter = randv2.Float64
+	}
+	if opts.NoJitter {
+		jitter = nil
+	}
+	maxAttempts := opts.Attempts
+	if maxAttempts <= 0 && opts.MaxElapsed > 0 {
+		maxAttempts = math.MaxInt
+	}
+
+	var zero T
 	var err error
-	for i := 0; i < attempts; i++ {
-		err = f()
+	var attempts []AttemptResult
+	var delay time.Duration
+	start := time.Now()
+	for i := 0; i < maxAttempts; i++ {
+		startedAt := time.Now()
+		var result T
+		result, err = runAttempt(ctx, opts.AttemptTimeout, f)
 		if err == nil {
-			return nil
+			return result, nil
+		}
+		attempts = append(attempts, AttemptResult{Attempt: i + 1, Err: err, Delay: delay, StartedAt: startedAt})
+
+		if ctx.Err() != nil {
+			return zero, &RetryError{attempts: attempts, cancelled: ctx.Err()}
+		}
+
+		if opts.IsRetryable != nil && !opts.IsRetryable(err) {
+			return zero, err
 		}
 
-		if i == attempts-1 {
+		if i == maxAttempts-1 {
 			break
 		}
 
+		delay = backoffDuration(opts.Backoff, i, opts.InitialDelay, opts.MaxDelay, jitter)
+		if opts.MaxElapsed > 0 && time.Since(start)+delay > opts.MaxElapsed {
+			break
+		}
+
+		if opts.Budget != nil && !opts.Budget.allow() {
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrRetryBudgetExhausted, i+1, err)
+		}
+
+		if opts.OnRetry != nil {
+			opts.OnRetry(i+1, err, delay)
+		}
+
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("operation cancelled: %w", ctx.Err())
-		case <-time.After(backoffDuration(i, initialDelay, maxDelay)):
+			return zero, &RetryError{attempts: attempts, cancelled: ctx.Err()}
+		case <-time.After(delay):
 		}
 	}
-	return fmt.Errorf("operation failed after %d attempts: %w", attempts, err)
+	if len(attempts) == 0 {
+		return zero, fmt.Errorf("operation failed after %d attempts: %w", opts.Attempts, err)
+	}
+	return zero, &RetryError{attempts: attempts}
+}
+
+// AttemptResult describes a failed attempt of RetryWithOptions
+type AttemptResult struct {
+	Attempt   int           // 1-based attempt number
+	Err       error         // the error of the attempt
+	Delay     time.Duration // the backoff delay waited before the attempt (0 for the first)
+	StartedAt time.Time
 }
 
-func backoffDuration(attempt int, initialDelay, maxDelay time.Duration) time.Duration {
-	delay := initialDelay * (1 << uint(attempt))
+// RetryError is returned by Retry and RetryWithOptions when all attempts failed or the context
+// was cancelled after an attempt failed. It keeps the error of every attempt. errors.Is and
+// errors.As match the context error of a cancellation first, then the errors of all attempts,
+// the last attempt first, so checks against the last error and context.Canceled keep working.
+//
+// Formatting with %+v prints the timeline of all attempts.
+//
+// Example usage:
+//
+//	var retryErr *gonuts.RetryError
+//	if errors.As(err, &retryErr) {
+//	    if retryErr.Cancelled() {
+//	        log.Printf("gave up early: %v", err)
+//	    }
+//	    for _, attempt := range retryErr.Attempts() {
+//	        log.Printf("attempt %d after %s: %v", attempt.Attempt, attempt.Delay, attempt.Err)
+//	    }
+//	}
+type RetryError struct {
+	attempts  []AttemptResult
+	cancelled error // the context error if retrying stopped because the context was done
+}
+
+// Attempts returns the failed attempts in order
+func (e *RetryError) Attempts() []AttemptResult {
+	return append([]AttemptResult(nil), e.attempts...)
+}
+
+// Last returns the error of the last attempt
+func (e *RetryError) Last() error {
+	return e.attempts[len(e.attempts)-1].Err
+}
+
+// Cancelled reports whether retrying stopped because the context was cancelled or its deadline
+// passed, rather than because the attempts were exhausted
+func (e *RetryError) Cancelled() bool {
+	return e.cancelled != nil
+}
+
+// Error returns "operation failed after N attempts: " followed by the last error, or
+// "operation cancelled after N attempts: " followed by the context error and the last error
+func (e *RetryError) Error() string {
+	if e.cancelled != nil {
+		return fmt.Sprintf("operation cancelled after %d attempts: %v (last error: %v)", len(e.attempts), e.cancelled, e.Last())
+	}
+	return fmt.Sprintf("operation failed after %d attempts: %v", len(e.attempts), e.Last())
+}
+
+// Unwrap returns the context error of a cancellation, then the errors of all attempts, the
+// last attempt first
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.attempts)+1)
+	if e.cancelled != nil {
+		errs = append(errs, e.cancelled)
+	}
+	for i := len(e.attempts) - 1; i >= 0; i-- {
+		errs = append(errs, e.attempts[i].Err)
+	}
+	return errs
+}
+
+// Format implements fmt.Formatter; %+v prints one line per attempt
+func (e *RetryError) Format(f fmt.State, c rune) {
+	if c != 'v' || !f.Flag('+') {
+		fmt.Fprint(f, e.Error())
+		return
+	}
+	if e.cancelled != nil {
+		fmt.Fprintf(f, "operation cancelled after %d attempts: %v", len(e.attempts), e.cancelled)
+	} else {
+		fmt.Fprintf(f, "operation failed after %d attempts:", len(e.attempts))
+	}
+	for _, attempt := range e.attempts {
+		fmt.Fprintf(f, "\n  attempt %d at %s (after %s): %v",
+			attempt.Attempt, attempt.StartedAt.Format("15:04:05.000"), attempt.Delay, attempt.Err)
+	}
+}
+
+// runAttempt executes a single attempt, enforcing the per-attempt timeout if one is set.
+func runAttempt[T any](ctx context.Context, timeout time.Duration, f func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type attemptOutcome struct {
+		result T
+		err    error
+	}
+	outcome := make(chan attemptOutcome, 1)
+	go func() {
+		result, err := f(attemptCtx)
+		outcome <- attemptOutcome{result, err}
+	}()
+
+	select {
+	case o := <-outcome:
+		return o.result, o.err
+	case <-attemptCtx.Done():
+		var zero T
+		return zero, fmt.Errorf("attempt timed out after %s: %w", timeout, attemptCtx.Err())
+	}
+}
+
+// backoffDuration returns the delay after the given 0-based attempt; jitter may be nil
+func backoffDuration(strategy BackoffStrategy, attempt int, initialDelay, maxDelay time.Duration, jitter func() float64) time.Duration {
+	delay := initialDelay
+	switch strategy {
+	case BackoffConstant:
+	case BackoffFibonacci:
+		var previous time.Duration
+		for i := 0; i < attempt && delay > 0 && delay <= maxDelay; i++ {
+			previous, delay = delay, delay+previous
+		}
+	default:
+		// Stop growing at maxDelay, so many attempts cannot overflow the delay
+		for i := 0; i < attempt && delay > 0 && delay <= maxDelay; i++ {
+			delay *= 2
+		}
+	}
 	if delay > maxDelay {
 		delay = maxDelay
 	}
+	if jitter == nil {
+		return delay
+	}
 	// Add jitter
-	jitter := time.Duration(float64(delay) * (0.5 + rand.Float64()/2))
-	return jitter
+	return time.Duration(float64(delay) * (0.5 + jitter()/2))
 }
@@ -0,0 +1,228 @@
+package gonuts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAttemptTimeoutLetsLaterAttemptsRun(t *testing.T) {
+	var attempts atomic.Int32
+	opts := RetryOptions{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}.
+		WithAttemptTimeout(20 * time.Millisecond)
+
+	start := time.Now()
+	err := RetryWithOptions(context.Background(), opts, func(ctx context.Context) error {
+		if attempts.Add(1) == 1 {
+			// The first attempt hangs far longer than the per-attempt timeout
+			time.Sleep(time.Second)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RetryWithOptions = %v, want success of the second attempt", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("%d attempts, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s, the hung attempt blocked the retries", elapsed)
+	}
+}
+
+func TestRetryAttemptTimeoutCountsAsFailedAttempt(t *testing.T) {
+	var attempts atomic.Int32
+	var retried []int
+	opts := RetryOptions{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}.
+		WithAttemptTimeout(10 * time.Millisecond).
+		WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			retried = append(retried, attempt)
+		})
+
+	err := RetryWithOptions(context.Background(), opts, func(ctx context.Context) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Cancelled() {
+		t.Fatalf("RetryWithOptions = %v, want an exhausted *RetryError", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v does not match context.DeadlineExceeded", err)
+	}
+	if n := attempts.Load(); n != 3 || len(retryErr.Attempts()) != 3 || len(retried) != 2 {
+		t.Errorf("%d attempts, %d recorded, %d retries, want 3, 3 and 2", n, len(retryErr.Attempts()), len(retried))
+	}
+}
+
+func TestRetryCancellationDistinguishableFromExhaustion(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	opts := RetryOptions{Attempts: 5, InitialDelay: time.Hour, MaxDelay: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err := RetryWithOptions(ctx, opts, func(context.Context) error { return errFlaky })
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !retryErr.Cancelled() {
+		t.Fatalf("RetryWithOptions = %v, want a cancelled *RetryError", err)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errFlaky) {
+		t.Errorf("error %v must match context.Canceled and the attempt error", err)
+	}
+	if len(retryErr.Attempts()) != 1 {
+		t.Errorf("%d attempts recorded, want 1", len(retryErr.Attempts()))
+	}
+
+	exhausted := RetryWithOptions(context.Background(), RetryOptions{Attempts: 2},
+		func(context.Context) error { return errFlaky })
+	if !errors.As(exhausted, &retryErr) || retryErr.Cancelled() || errors.Is(exhausted, context.Canceled) {
+		t.Errorf("RetryWithOptions = %v, want an exhausted *RetryError", exhausted)
+	}
+}
+
+func TestRetryWithRandSameSeedSameSchedule(t *testing.T) {
+	schedule := func(seed int64) []time.Duration {
+		var delays []time.Duration
+		opts := RetryOptions{Attempts: 6, InitialDelay: 100 * time.Microsecond, MaxDelay: time.Millisecond}.
+			WithRand(rand.New(rand.NewSource(seed))).
+			WithOnRetry(func(attempt int, err error, delay time.Duration) {
+				delays = append(delays, delay)
+			})
+		_ = RetryWithOptions(context.Background(), opts, func(context.Context) error { return errors.New("flaky") })
+		return delays
+	}
+
+	first, second := schedule(42), schedule(42)
+	if len(first) != 5 || !slices.Equal(first, second) {
+		t.Fatalf("schedules with the same seed differ: %v and %v", first, second)
+	}
+	if other := schedule(7); slices.Equal(first, other) {
+		t.Errorf("schedules with seeds 42 and 7 are both %v, want jittered delays", first)
+	}
+
+	// backoffDuration draws the same jitter from the same seed
+	a, b := rand.New(rand.NewSource(42)), rand.New(rand.NewSource(42))
+	for attempt := 0; attempt < 10; attempt++ {
+		da := backoffDuration(BackoffExponential, attempt, 10*time.Millisecond, time.Second, a.Float64)
+		db := backoffDuration(BackoffExponential, attempt, 10*time.Millisecond, time.Second, b.Float64)
+		if da != db {
+			t.Fatalf("attempt %d: delays %s and %s with the same seed", attempt, da, db)
+		}
+	}
+}
+
+func TestRetryBudgetBoundsRetriesAcrossParallelOperations(t *testing.T) {
+	const operations, burst = 1000, 50
+	budget := NewRetryBudget(0.001, burst) // refills far too slowly to matter during the test
+	var retries, exhausted atomic.Int64
+	opts := RetryOptions{Attempts: 3, InitialDelay: time.Microsecond, MaxDelay: time.Microsecond}.
+		WithBudget(budget).
+		WithOnRetry(func(int, error, time.Duration) { retries.Add(1) })
+
+	done := make(chan struct{})
+	for i := 0; i < operations; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			err := RetryWithOptions(context.Background(), opts, func(context.Context) error { return errors.New("unavailable") })
+			if errors.Is(err, ErrRetryBudgetExhausted) {
+				exhausted.Add(1)
+			}
+		}()
+	}
+	for i := 0; i < operations; i++ {
+		<-done
+	}
+
+	if n := retries.Load(); n > burst+1 || n < burst {
+		t.Errorf("%d retries across %d operations, want the budget of %d", n, operations, burst)
+	}
+	if budget.Denied() != exhausted.Load() || exhausted.Load() < operations-burst {
+		t.Errorf("%d denied, %d operations stopped by the budget, want equal and at least %d",
+			budget.Denied(), exhausted.Load(), operations-burst)
+	}
+}
+
+// attemptErrors returns a function failing with a distinct error on every call, and those errors
+func attemptErrors(n int) (func(context.Context) error, []error) {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = fmt.Errorf("attempt %d failed", i+1)
+	}
+	var calls atomic.Int32
+	return func(context.Context) error { return errs[calls.Add(1)-1] }, errs
+}
+
+func TestRetryErrorUnwrapKeepsEveryAttempt(t *testing.T) {
+	f, errs := attemptErrors(4)
+	err := RetryWithOptions(context.Background(), RetryOptions{Attempts: 4}.WithoutJitter(), f)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("RetryWithOptions = %v, want a *RetryError", err)
+	}
+	want := []error{errs[3], errs[2], errs[1], errs[0]} // the last attempt first
+	if got := retryErr.Unwrap(); !slices.Equal(got, want) {
+		t.Errorf("Unwrap() = %v, want %v", got, want)
+	}
+	for _, attemptErr := range errs {
+		if !errors.Is(err, attemptErr) {
+			t.Errorf("errors.Is(err, %v) = false", attemptErr)
+		}
+	}
+	if retryErr.Last() != errs[3] {
+		t.Errorf("Last() = %v, want %v", retryErr.Last(), errs[3])
+	}
+}
+
+func TestRetryErrorCollectsAttemptsWithTimeline(t *testing.T) {
+	f, errs := attemptErrors(3)
+	opts := RetryOptions{Attempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Second}.WithoutJitter()
+	err := RetryWithOptions(context.Background(), opts, f)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("RetryWithOptions = %v, want a *RetryError", err)
+	}
+	attempts := retryErr.Attempts()
+	if len(attempts) != 3 {
+		t.Fatalf("%d attempts recorded, want 3", len(attempts))
+	}
+	wantDelays := []time.Duration{0, time.Millisecond, 2 * time.Millisecond}
+	for i, attempt := range attempts {
+		if attempt.Attempt != i+1 || attempt.Err != errs[i] || attempt.Delay != wantDelays[i] {
+			t.Errorf("attempt %d = %+v, want error %v after %s", i+1, attempt, errs[i], wantDelays[i])
+		}
+		if i > 0 && !attempt.StartedAt.After(attempts[i-1].StartedAt) {
+			t.Errorf("attempt %d started at %s, not after attempt %d", i+1, attempt.StartedAt, i)
+		}
+	}
+
+	if got, want := err.Error(), "operation failed after 3 attempts: attempt 3 failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%v", err); got != err.Error() {
+		t.Errorf("%%v = %q, want Error()", got)
+	}
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	if len(lines) != 4 || lines[0] != "operation failed after 3 attempts:" {
+		t.Fatalf("%%+v = %q, want a header and one line per attempt", lines)
+	}
+	for i, line := range lines[1:] {
+		wantPrefix := fmt.Sprintf("  attempt %d at %s (after %s): ", i+1, attempts[i].StartedAt.Format("15:04:05.000"), wantDelays[i])
+		if !strings.HasPrefix(line, wantPrefix) || !strings.HasSuffix(line, errs[i].Error()) {
+			t.Errorf("%%+v line %d = %q, want %q followed by %q", i+1, line, wantPrefix, errs[i])
+		}
+	}
+}
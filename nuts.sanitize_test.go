@@ -0,0 +1,233 @@
+package gonuts
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateRedirectTarget(t *testing.T) {
+	allowed := []string{"app.example.com", "*.cdn.example.com"}
+	tests := []struct {
+		name          string
+		target        string
+		allowRelative bool
+		safe          bool
+	}{
+		// Accepted targets
+		{"allowed host", "https://app.example.com/account?tab=1", false, true},
+		{"allowed host over http with port", "http://app.example.com:8443/x", false, true},
+		{"case and trailing dot", "HTTPS://APP.Example.COM./x", false, true},
+		{"subdomain of a wildcard", "https://eu.cdn.example.com/a.js", false, true},
+		{"nested subdomain of a wildcard", "https://a.b.cdn.example.com/", false, true},
+		{"relative path", "/account", true, true},
+		{"relative path without a slash", "account/settings", true, true},
+		{"relative path with an external URL in the query", "/login?next=https://evil.com", true, true},
+		{"percent-encoded slashes stay a path", "/%2F%2Fevil.com", true, true},
+
+		// Hosts that are not on the allowlist
+		{"other host", "https://evil.com", false, false},
+		{"allowed host as a subdomain of another host", "https://app.example.com.evil.com/", false, false},
+		{"allowed host as a suffix", "https://evilapp.example.com/", false, false},
+		{"wildcard does not match its apex", "https://cdn.example.com/", false, false},
+		{"wildcard does not match a suffix", "https://evilcdn.example.com/", false, false},
+		{"homoglyph host", "https://аpp.example.com/", false, false}, // Cyrillic а
+		{"allowed host in the fragment", "https://evil.com#@app.example.com", false, false},
+		{"allowed host in the path", "https://evil.com/app.example.com", false, false},
+		{"allowed host in the query", "https://evil.com/?app.example.com", false, false},
+
+		// Userinfo that makes an allowed host look like the target
+		{"allowed host as userinfo", "https://app.example.com@evil.com/", false, false},
+		{"userinfo before an allowed host", "https://evil.com@app.example.com/", false, false},
+		{"userinfo with password", "https://user:pw@app.example.com/", false, false},
+
+		// Scheme-relative targets and browser normalization tricks
+		{"scheme-relative", "//evil.com", true, false},
+		{"scheme-relative with an allowed host", "//app.example.com/x", true, false},
+		{"triple slash", "///evil.com", true, false},
+		{"backslashes", `/\evil.com`, true, false},
+		{"double backslashes", `\\evil.com`, true, false},
+		{"backslash before userinfo", `https://evil.com\@app.example.com`, false, false},
+		{"leading tab", "\t//evil.com", true, false},
+		{"tab between slashes", "/\t/evil.com", true, false},
+		{"newline", "/account\n//evil.com", true, false},
+		{"leading space", " https://app.example.com", false, false},
+		{"null byte", "/account\x00", true, false},
+
+		// Other schemes and malformed absolute URLs
+		{"javascript", "javascript:alert(1)", true, false},
+		{"javascript in mixed case", "JaVaScRiPt:alert(document.cookie)", true, false},
+		{"data", "data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==", true, false},
+		{"vbscript", "vbscript:msgbox(1)", true, false},
+		{"ftp", "ftp://app.example.com/file", false, false},
+		{"opaque http", "http:evil.com", true, false},
+		{"http with a single slash", "https:/evil.com", true, false},
+		{"http without a host", "https://", false, false},
+		{"unparseable", "https://app.example.com/%zz", false, false},
+
+		// Relative targets when they are not allowed
+		{"relative not allowed", "/account", false, false},
+		{"empty", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRedirectTarget(tt.target, allowed, tt.allowRelative)
+			if tt.safe && err != nil {
+				t.Errorf("ValidateRedirectTarget(%q) = %v, want it accepted", tt.target, err)
+			}
+			if !tt.safe && !errors.Is(err, ErrUnsafeRedirect) {
+				t.Errorf("ValidateRedirectTarget(%q) = %v, want ErrUnsafeRedirect", tt.target, err)
+			}
+		})
+	}
+
+	if err := ValidateRedirectTarget("https://app.example.com", nil, true); !errors.Is(err, ErrUnsafeRedirect) {
+		t.Errorf("absolute target with an empty allowlist = %v, want ErrUnsafeRedirect", err)
+	}
+}
+
+func TestSanitizeURLForLogging(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"https://user:pw@api.example.com/v1?id=7&api_key=abc", "https://api.example.com/v1?id=7&api_key=REDACTED"},
+		{"https://api.example.com/v1?Token=abc&page=2&X-Amz-Signature=f00", "https://api.example.com/v1?Token=REDACTED&page=2&X-Amz-Signature=REDACTED"},
+		{"https://api.example.com/?pass%77ord=abc", "https://api.example.com/?pass%77ord=REDACTED"}, // escaped parameter names
+		{"https://api.example.com/?session&id=1", "https://api.example.com/?session&id=1"},          // no value to redact
+		{"https://app.example.com/cb#access_token=abc&state=xyz", "https://app.example.com/cb#access_token=REDACTED&state=xyz"},
+		{"https://app.example.com/docs#section-2", "https://app.example.com/docs#section-2"},
+		{"/relative?secret=1", "/relative?secret=REDACTED"},
+		{"https://api.example.com/v1", "https://api.example.com/v1"},
+		{"https://user:pw@api.example.com/%zz", "[invalid URL]"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeURLForLogging(tt.raw); got != tt.want {
+			t.Errorf("SanitizeURLForLogging(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+type sanitizeTestAddress struct {
+	Lines []string `sanitize:"trim,maxlen=8"`
+	City  *string  `sanitize:"trim,upper"`
+}
+
+type sanitizeTestSignup struct {
+	Email    string   `sanitize:"trim,lower,maxlen=254"`
+	Name     string   `sanitize:" trim , nocontrol,maxlen=5 "`
+	Tags     []string `sanitize:"trim,lower"`
+	Password string   `sanitize:"-"`
+	Comment  string
+	Address  *sanitizeTestAddress
+	Previous []sanitizeTestAddress
+	Self     *sanitizeTestSignup
+	note     string
+}
+
+func TestSanitizeStruct(t *testing.T) {
+	city := "  berlin "
+	signup := sanitizeTestSignup{
+		Email:    "  Jane.Doe@Example.COM ",
+		Name:     " Jäne\x00Doe ",
+		Tags:     []string{" Go ", "RUST"},
+		Password: "  keep me  ",
+		Comment:  "  untagged  ",
+		Address:  &sanitizeTestAddress{Lines: []string{"  Main St 1  ", "a very long line"}, City: &city},
+		Previous: []sanitizeTestAddress{{Lines: []string{" Old St "}}},
+		note:     "  unexported  ",
+	}
+	signup.Self = &signup // the walk stops at a cycle
+
+	if err := SanitizeStruct(&signup); err != nil {
+		t.Fatal(err)
+	}
+	want := sanitizeTestSignup{
+		Email:    "jane.doe@example.com",
+		Name:     "JäneD", // maxlen counts runes
+		Tags:     []string{"go", "rust"},
+		Password: "  keep me  ",
+		Comment:  "  untagged  ",
+		note:     "  unexported  ",
+	}
+	if signup.Email != want.Email || signup.Name != want.Name || signup.Password != want.Password ||
+		signup.Comment != want.Comment || signup.note != want.note || !slices.Equal(signup.Tags, want.Tags) {
+		t.Errorf("SanitizeStruct = %+v, want %+v", signup, want)
+	}
+	if lines := signup.Address.Lines; !slices.Equal(lines, []string{"Main St ", "a very l"}) {
+		t.Errorf("Address.Lines = %q", lines)
+	}
+	if city != "BERLIN" {
+		t.Errorf("Address.City = %q, want the pointed-to string sanitized", city)
+	}
+	if lines := signup.Previous[0].Lines; lines[0] != "Old St" {
+		t.Errorf("Previous[0].Lines = %q", lines)
+	}
+}
+
+func TestSanitizeStructRuleFailures(t *testing.T) {
+	RegisterSanitizeRule("sanitizetest-nodigits", func(s, _ string) (string, error) {
+		if strings.ContainsAny(s, "0123456789") {
+			return s, errors.New("digits are not allowed")
+		}
+		return s, nil
+	})
+	var input struct {
+		Name  string   `sanitize:"trim,sanitizetest-nodigits,upper"`
+		Lines []string `sanitize:"trim,sanitizetest-nodigits"`
+		Email string   `sanitize:"trim,lower"`
+	}
+	input.Name = " r2d2 "
+	input.Lines = []string{" ok ", " line 2 "}
+	input.Email = " A@B.C "
+
+	err := SanitizeStruct(&input)
+	var ep *ErrorPlus
+	if !errors.As(err, &ep) || !errors.Is(err, ErrValidation) || ep.Code() != 400 {
+		t.Fatalf("SanitizeStruct error = %v, want a 400 ErrorPlus wrapping ErrValidation", err)
+	}
+	fields, _ := ep.Context()["fields"].([]FieldError)
+	if len(fields) != 2 || fields[0].Field != "Name" || fields[1].Field != "Lines[1]" || fields[0].Rule != "sanitizetest-nodigits" {
+		t.Errorf("failed fields = %+v, want Name and Lines[1] with the failing rule", fields)
+	}
+	// Fields whose rules failed keep their value, the others are sanitized
+	if input.Name != " r2d2 " || input.Lines[1] != " line 2 " {
+		t.Errorf("failed fields changed to %q and %q", input.Name, input.Lines[1])
+	}
+	if input.Lines[0] != "ok" || input.Email != "a@b.c" {
+		t.Errorf("the other fields were not sanitized: %q, %q", input.Lines[0], input.Email)
+	}
+
+	var badLength struct {
+		Name string `sanitize:"maxlen=abc"`
+	}
+	if err := SanitizeStruct(&badLength); !errors.Is(err, ErrValidation) {
+		t.Errorf("maxlen=abc error = %v, want a validation error", err)
+	}
+}
+
+func TestSanitizeStructInvalidTargets(t *testing.T) {
+	var unknown struct {
+		Name  string `sanitize:"trim,shout"`
+		Email string `sanitize:"lower"`
+	}
+	unknown.Email = "A@B.C"
+	if err := SanitizeStruct(&unknown); !errors.Is(err, ErrUnknownSanitizeRule) {
+		t.Errorf("unknown rule error = %v, want ErrUnknownSanitizeRule", err)
+	}
+	if unknown.Email != "A@B.C" {
+		t.Error("a field was changed although the tags are invalid")
+	}
+
+	var wrongType struct {
+		Age int `sanitize:"trim"`
+	}
+	if err := SanitizeStruct(&wrongType); !errors.Is(err, ErrSanitizeTarget) {
+		t.Errorf("tag on an int field error = %v, want ErrSanitizeTarget", err)
+	}
+	for _, target := range []any{nil, sanitizeTestSignup{}, (*sanitizeTestSignup)(nil), new(string)} {
+		if err := SanitizeStruct(target); !errors.Is(err, ErrSanitizeTarget) {
+			t.Errorf("SanitizeStruct(%T) error = %v, want ErrSanitizeTarget", target, err)
+		}
+	}
+}
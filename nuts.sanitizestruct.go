@@ -0,0 +1,257 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnknownSanitizeRule is wrapped by the error of SanitizeStruct if a sanitize tag names a rule that is not registered
+	ErrUnknownSanitizeRule = errors.New("unknown sanitize rule")
+	// ErrSanitizeTarget is wrapped by the error of SanitizeStruct if it is not passed a pointer to a struct,
+	// or a sanitize tag is set on a field that holds no strings
+	ErrSanitizeTarget = errors.New("invalid sanitize target")
+)
+
+// SanitizeRule transforms a string. arg is the text after "=" in the tag, e.g. "64" for
+// "maxlen=64", or empty.
+type SanitizeRule func(value, arg string) (string, error)
+
+var (
+	sanitizeRulesMu sync.RWMutex
+	sanitizeRules   = map[string]SanitizeRule{
+		"trim":  func(s, _ string) (string, error) { return strings.TrimSpace(s), nil },
+		"lower": func(s, _ string) (string, error) { return strings.ToLower(s), nil },
+		"upper": func(s, _ string) (string, error) { return strings.ToUpper(s), nil },
+		"maxlen": func(s, arg string) (string, error) {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				return s, fmt.Errorf("maxlen needs a non-negative length, got %q", arg)
+			}
+			if utf8.RuneCountInString(s) <= n {
+				return s, nil
+			}
+			return string([]rune(s)[:n]), nil
+		},
+		"sqlsafe":   func(s, _ string) (string, error) { return SanitizeString(SANITIZE_SQLSAFER, s), nil },
+		"sqlescape": func(s, _ string) (string, error) { return SafeSQLString(s), nil },
+		"nocontrol": func(s, _ string) (string, error) {
+			return strings.Map(func(r rune) rune {
+				if unicode.IsControl(r) {
+					return -1
+				}
+				return r
+			}, s), nil
+		},
+	}
+)
+
+// RegisterSanitizeRule registers a rule for sanitize tags, replacing a rule of the same name,
+// including the built-in ones. Names must not contain "," or "=".
+//
+// Example usage:
+//
+//	gonuts.RegisterSanitizeRule("digits", func(s, _ string) (string, error) {
+//	    return strings.Map(func(r rune) rune {
+//	        if unicode.IsDigit(r) {
+//	            return r
+//	        }
+//	        return -1
+//	    }, s), nil
+//	})
+func RegisterSanitizeRule(name string, fn func(value, arg string) (string, error)) {
+	sanitizeRulesMu.Lock()
+	defer sanitizeRulesMu.Unlock()
+	sanitizeRules[name] = fn
+}
+
+// sanitizeTarget is a string field (or element) and the rules of its tag
+type sanitizeTarget struct {
+	path  string
+	value reflect.Value
+	rules []sanitizeStep
+}
+
+type sanitizeStep struct {
+	name string
+	arg  string
+	fn   SanitizeRule
+}
+
+// SanitizeStruct applies the rules of the sanitize tags of a struct's string fields, in the
+// order they are listed, e.g. `sanitize:"trim,lower,maxlen=64"`. Tags may be set on fields of
+// a string kind, pointers to them and slices or arrays of them. Nested structs, pointers to
+// structs and slices of structs are walked; `sanitize:"-"` skips a field. Unexported fields are
+// ignored.
+//
+// Built-in rules are trim, lower, upper, maxlen=N (in runes), sqlsafe (SanitizeString with
+// SANITIZE_SQLSAFER), sqlescape (SafeSQLString) and nocontrol; more can be added with
+// RegisterSanitizeRule. Tags are checked before any field is changed. A field whose rule fails
+// keeps its value, while the other fields are sanitized.
+//
+// Parameters:
+//   - ptr: a pointer to the struct to sanitize in place
+//
+// Returns:
+//   - error: nil on success; an internal ErrorPlus wrapping ErrSanitizeTarget or
+//     ErrUnknownSanitizeRule for invalid targets and tags; or a 400 ErrorPlus wrapping
+//     ErrValidation (see ValidationErrors) whose "fields" context names the paths of the fields
+//     whose rules failed, e.g. "Address.Lines[1]"
+//
+// Example usage:
+//
+//	type Signup struct {
+//	    Email   string   `sanitize:"trim,lower,maxlen=254"`
+//	    Name    string   `sanitize:"trim,nocontrol,maxlen=64"`
+//	    Tags    []string `sanitize:"trim,lower"`
+//	    Address *Address
+//	}
+//
+//	if err := gonuts.SanitizeStruct(&signup); err != nil {
+//	    return err
+//	}
+func SanitizeStruct(ptr any) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return NewInternalError("sanitize failed", fmt.Errorf("%w: want a non-nil pointer to a struct, got %T", ErrSanitizeTarget, ptr))
+	}
+
+	w := sanitizeWalker{visited: make(map[uintptr]bool)}
+	w.walkStruct(v.Elem(), "")
+	if len(w.problems) > 0 {
+		return NewInternalError("invalid sanitize tags", errors.Join(w.problems...))
+	}
+
+	failed := NewValidationErrors()
+	for _, t := range w.targets {
+		value := t.value.String()
+		var err error
+		for _, step := range t.rules {
+			if value, err = step.fn(value, step.arg); err != nil {
+				failed.AddField(t.path, step.name, err.Error(), t.value.String())
+				break
+			}
+		}
+		if err == nil {
+			t.value.SetString(value)
+		}
+	}
+	if failed.HasErrors() {
+		return failed.Err()
+	}
+	return nil
+}
+
+// sanitizeWalker collects the tagged string values of a struct and the problems of its tags
+type sanitizeWalker struct {
+	targets  []sanitizeTarget
+	problems []error
+	visited  map[uintptr]bool // structs reached through pointers, against cycles
+}
+
+func (w *sanitizeWalker) walkStruct(v reflect.Value, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("sanitize")
+		if tag == "-" {
+			continue
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		if !tagged || strings.TrimSpace(tag) == "" {
+			w.walkValue(v.Field(i), fieldPath)
+			continue
+		}
+		rules, ok := w.parseTag(fieldPath, tag)
+		if !ok {
+			continue
+		}
+		if !holdsStrings(field.Type) {
+			w.problems = append(w.problems, fmt.Errorf("%w: sanitize tag on field %s of type %v", ErrSanitizeTarget, fieldPath, field.Type))
+			continue
+		}
+		w.collect(v.Field(i), fieldPath, rules)
+	}
+}
+
+// walkValue looks for nested structs in an untagged value
+func (w *sanitizeWalker) walkValue(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		w.walkStruct(v, path)
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct || w.visited[v.Pointer()] {
+			return
+		}
+		w.visited[v.Pointer()] = true
+		w.walkStruct(v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if kind := v.Type().Elem().Kind(); kind != reflect.Struct && kind != reflect.Ptr {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.walkValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// collect adds the settable strings of a tagged value as targets
+func (w *sanitizeWalker) collect(v reflect.Value, path string, rules []sanitizeStep) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			w.targets = append(w.targets, sanitizeTarget{path: path, value: v, rules: rules})
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			w.collect(v.Elem(), path, rules)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.collect(v.Index(i), fmt.Sprintf("%s[%d]", path, i), rules)
+		}
+	}
+}
+
+// parseTag resolves the rules of a tag like "trim,maxlen=64"
+func (w *sanitizeWalker) parseTag(path, tag string) ([]sanitizeStep, bool) {
+	sanitizeRulesMu.RLock()
+	defer sanitizeRulesMu.RUnlock()
+
+	var steps []sanitizeStep
+	ok := true
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		fn, exists := sanitizeRules[name]
+		if !exists || fn == nil {
+			w.problems = append(w.problems, fmt.Errorf("%w %q on field %s", ErrUnknownSanitizeRule, name, path))
+			ok = false
+			continue
+		}
+		steps = append(steps, sanitizeStep{name: name, arg: arg, fn: fn})
+	}
+	return steps, ok
+}
+
+// holdsStrings reports whether a sanitize tag can apply to a field of type t
+func holdsStrings(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
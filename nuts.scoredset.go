@@ -0,0 +1,252 @@
+package gonuts
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+const scoredSetMaxLevel = 32
+
+// ScoredItem is an item of a ScoredSet with its score
+type ScoredItem[T comparable] struct {
+	Item  T
+	Score float64
+}
+
+// ScoredSet is a thread-safe set of items with scores, ordered by descending score, like a
+// leaderboard. Items with equal scores are ordered by the time they got their score, earlier
+// first, so ties are stable.
+//
+// The order is kept in a skiplist indexed by a map, so Add, IncrBy and Remove take O(log n)
+// and TopN and Range take O(log n + k) for k returned items.
+type ScoredSet[T comparable] struct {
+	mu    sync.RWMutex
+	nodes map[T]*scoredNode[T]
+	head  *scoredNode[T]
+	level int
+	seq   uint64 // incremented on every score change, orders ties
+}
+
+type scoredNode[T comparable] struct {
+	item  T
+	score float64
+	seq   uint64
+	next  []*scoredNode[T]
+}
+
+// NewScoredSet creates a new ScoredSet
+//
+// Example usage:
+//
+//	board := gonuts.NewScoredSet[string]()
+//	board.Add("alice", 120).Add("bob", 95)
+//	board.IncrBy("bob", 30)
+//	top := board.TopN(10) // [{bob 125} {alice 120}]
+func NewScoredSet[T comparable]() *ScoredSet[T] {
+	return &ScoredSet[T]{
+		nodes: make(map[T]*scoredNode[T]),
+		head:  &scoredNode[T]{next: make([]*scoredNode[T], scoredSetMaxLevel)},
+		level: 1,
+	}
+}
+
+// Add adds an item with a score, or sets the score of an existing item. NaN scores are ignored.
+//
+// Parameters:
+//   - item: the item
+//   - score: the score of the item
+//
+// Returns:
+//   - *ScoredSet[T]: the ScoredSet instance for method chaining
+func (s *ScoredSet[T]) Add(item T, score float64) *ScoredSet[T] {
+	if math.IsNaN(score) {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(item, score)
+	return s
+}
+
+// IncrBy adds delta to the score of an item, adding the item with a score of delta if it is not
+// in the set. If the new score would be NaN, the score is left unchanged.
+//
+// Returns:
+//   - float64: the new score of the item
+func (s *ScoredSet[T]) IncrBy(item T, delta float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := delta
+	if node, exists := s.nodes[item]; exists {
+		score = node.score + delta
+		if math.IsNaN(score) {
+			return node.score
+		}
+	} else if math.IsNaN(score) {
+		return 0
+	}
+	s.set(item, score)
+	return score
+}
+
+// Score returns the score of an item
+//
+// Returns:
+//   - float64: the score of the item
+//   - bool: false if the item is not in the set
+func (s *ScoredSet[T]) Score(item T) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	node, exists := s.nodes[item]
+	if !exists {
+		return 0, false
+	}
+	return node.score, true
+}
+
+// Remove removes items from the set
+//
+// Returns:
+//   - *ScoredSet[T]: the ScoredSet instance for method chaining
+func (s *ScoredSet[T]) Remove(items ...T) *ScoredSet[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		if node, exists := s.nodes[item]; exists {
+			s.unlink(node)
+			delete(s.nodes, item)
+		}
+	}
+	return s
+}
+
+// Len returns the number of items in the set
+func (s *ScoredSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
+// TopN returns the n items with the highest scores in descending order
+//
+// Example usage:
+//
+//	for i, entry := range board.TopN(3) {
+//		fmt.Printf("%d. %s (%.0f)\n", i+1, entry.Item, entry.Score)
+//	}
+func (s *ScoredSet[T]) TopN(n int) []ScoredItem[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if n > len(s.nodes) {
+		n = len(s.nodes)
+	}
+	if n <= 0 {
+		return []ScoredItem[T]{}
+	}
+	items := make([]ScoredItem[T], 0, n)
+	for node := s.head.next[0]; node != nil && len(items) < n; node = node.next[0] {
+		items = append(items, ScoredItem[T]{Item: node.item, Score: node.score})
+	}
+	return items
+}
+
+// Range returns the items with scores between min and max (inclusive) in descending order
+//
+// Example usage:
+//
+//	silver := board.Range(100, 199.99)
+func (s *ScoredSet[T]) Range(min, max float64) []ScoredItem[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := []ScoredItem[T]{}
+	if min > max {
+		return items
+	}
+	node := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].score > max {
+			node = node.next[i]
+		}
+	}
+	for node = node.next[0]; node != nil && node.score >= min; node = node.next[0] {
+		items = append(items, ScoredItem[T]{Item: node.item, Score: node.score})
+	}
+	return items
+}
+
+// String returns a string representation of the set in descending order
+func (s *ScoredSet[T]) String() string {
+	items := s.TopN(s.Len())
+	parts := make([]string, len(items))
+	for i, entry := range items {
+		parts[i] = fmt.Sprintf("%v:%v", entry.Item, entry.Score)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// set sets the score of an item; the caller holds the write lock. An unchanged score keeps
+// the position of the item among its ties.
+func (s *ScoredSet[T]) set(item T, score float64) {
+	if node, exists := s.nodes[item]; exists {
+		if node.score == score {
+			return
+		}
+		s.unlink(node)
+	}
+	s.seq++
+	node := &scoredNode[T]{item: item, score: score, seq: s.seq}
+	s.insert(node)
+	s.nodes[item] = node
+}
+
+// precedes reports whether n is ordered before a node with the given score and sequence number
+func (n *scoredNode[T]) precedes(score float64, seq uint64) bool {
+	return n.score > score || (n.score == score && n.seq < seq)
+}
+
+// predecessors returns the last node before the position of (score, seq) on every level
+func (s *ScoredSet[T]) predecessors(score float64, seq uint64) [scoredSetMaxLevel]*scoredNode[T] {
+	var update [scoredSetMaxLevel]*scoredNode[T]
+	node := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].precedes(score, seq) {
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+	return update
+}
+
+func (s *ScoredSet[T]) insert(node *scoredNode[T]) {
+	update := s.predecessors(node.score, node.seq)
+	level := 1
+	for level < scoredSetMaxLevel && rand.Uint32()&3 == 0 {
+		level++
+	}
+	for i := s.level; i < level; i++ {
+		update[i] = s.head
+	}
+	if level > s.level {
+		s.level = level
+	}
+	node.next = make([]*scoredNode[T], level)
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+}
+
+func (s *ScoredSet[T]) unlink(node *scoredNode[T]) {
+	update := s.predecessors(node.score, node.seq)
+	for i := 0; i < s.level; i++ {
+		if update[i].next[i] == node {
+			update[i].next[i] = node.next[i]
+		}
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+}
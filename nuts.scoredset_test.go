@@ -0,0 +1,126 @@
+package gonuts
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestScoredSetTopN(t *testing.T) {
+	board := NewScoredSet[string]()
+	board.Add("alice", 120).Add("bob", 95).Add("carol", 120).Add("dave", 10)
+	if score := board.IncrBy("bob", 30); score != 125 {
+		t.Errorf("IncrBy(bob, 30) = %v, want 125", score)
+	}
+
+	// Ties are ordered by the time the items got their score: alice before carol
+	want := []ScoredItem[string]{{"bob", 125}, {"alice", 120}, {"carol", 120}}
+	if got := board.TopN(3); !slices.Equal(got, want) {
+		t.Errorf("TopN(3) = %v, want %v", got, want)
+	}
+
+	// Re-adding an unchanged score keeps the position, a changed score moves behind the ties
+	board.Add("alice", 120)
+	if got := board.TopN(3); !slices.Equal(got, want) {
+		t.Errorf("TopN(3) after re-adding alice with the same score = %v, want %v", got, want)
+	}
+	board.Add("alice", 119).Add("alice", 120)
+	want = []ScoredItem[string]{{"bob", 125}, {"carol", 120}, {"alice", 120}}
+	if got := board.TopN(3); !slices.Equal(got, want) {
+		t.Errorf("TopN(3) after the score of alice changed and back = %v, want %v", got, want)
+	}
+
+	if got := board.TopN(10); len(got) != 4 || got[3].Item != "dave" {
+		t.Errorf("TopN beyond the size = %v, want all 4 items", got)
+	}
+	if got := board.TopN(0); len(got) != 0 {
+		t.Errorf("TopN(0) = %v", got)
+	}
+	if got := NewScoredSet[int]().TopN(3); got == nil || len(got) != 0 {
+		t.Errorf("TopN on an empty set = %#v, want an empty slice", got)
+	}
+
+	board.Remove("bob", "nobody")
+	if _, ok := board.Score("bob"); ok || board.Len() != 3 || board.TopN(1)[0].Item != "carol" {
+		t.Errorf("after Remove(bob): Len() = %d, TopN(1) = %v", board.Len(), board.TopN(1))
+	}
+}
+
+func TestScoredSetRangeAndNaN(t *testing.T) {
+	board := NewScoredSet[string]()
+	board.Add("gold", 250).Add("silver-1", 150).Add("silver-2", 100).Add("bronze", 99.5).Add("lead", -5)
+
+	want := []ScoredItem[string]{{"silver-1", 150}, {"silver-2", 100}}
+	if got := board.Range(100, 199.99); !slices.Equal(got, want) {
+		t.Errorf("Range(100, 199.99) = %v, want %v", got, want)
+	}
+	if got := board.Range(math.Inf(-1), 0); len(got) != 1 || got[0].Item != "lead" {
+		t.Errorf("Range(-Inf, 0) = %v, want [lead]", got)
+	}
+	if got := board.Range(200, 100); len(got) != 0 {
+		t.Errorf("Range with min > max = %v", got)
+	}
+
+	board.Add("gold", math.NaN())
+	if score, _ := board.Score("gold"); score != 250 {
+		t.Errorf("Add with NaN changed the score to %v", score)
+	}
+	if score := board.IncrBy("gold", math.Inf(1)); !math.IsInf(score, 1) {
+		t.Errorf("IncrBy(+Inf) = %v", score)
+	}
+	if score := board.IncrBy("gold", math.Inf(-1)); !math.IsInf(score, 1) {
+		t.Errorf("IncrBy(-Inf) on +Inf = %v, want the score unchanged instead of NaN", score)
+	}
+	if board.IncrBy("new", math.NaN()); board.Len() != 5 {
+		t.Errorf("IncrBy with NaN added an item: Len() = %d", board.Len())
+	}
+}
+
+// TestScoredSetMatchesSortedModel applies random updates and compares TopN and Range with a
+// sorted copy of the scores
+func TestScoredSetMatchesSortedModel(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	board := NewScoredSet[int]()
+	scores := map[int]float64{}
+	for step := 0; step < 3000; step++ {
+		item := rng.Intn(200)
+		switch rng.Intn(4) {
+		case 0:
+			board.Remove(item)
+			delete(scores, item)
+		case 1:
+			scores[item] = board.IncrBy(item, float64(rng.Intn(21)-10))
+		default:
+			score := float64(rng.Intn(50))
+			board.Add(item, score)
+			scores[item] = score
+		}
+	}
+
+	model := make([]float64, 0, len(scores))
+	for _, score := range scores {
+		model = append(model, score)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(model)))
+
+	top := board.TopN(len(scores))
+	if len(top) != len(model) || board.Len() != len(scores) {
+		t.Fatalf("TopN returned %d items, Len() = %d, want %d", len(top), board.Len(), len(model))
+	}
+	for i, entry := range top {
+		if entry.Score != model[i] || entry.Score != scores[entry.Item] {
+			t.Fatalf("TopN[%d] = %v, want score %v", i, entry, model[i])
+		}
+	}
+	inRange := 0
+	for _, score := range model {
+		if score >= 10 && score <= 30 {
+			inRange++
+		}
+	}
+	if got := board.Range(10, 30); len(got) != inRange {
+		t.Errorf("Range(10, 30) returned %d items, want %d", len(got), inRange)
+	}
+}
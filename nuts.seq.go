@@ -0,0 +1,296 @@
+package gonuts
+
+import (
+	"context"
+	"iter"
+	"runtime"
+)
+
+// Seq is a lazy sequence built from a slice or a channel and transformed with MapSeq,
+// FilterSeq and TakeSeq. Transformations are fused: Collect and ForEach pull every element
+// through the whole pipeline without materializing intermediate slices.
+//
+// A Seq is immutable and can be shared between goroutines; a Seq built from a slice can be
+// consumed any number of times, one built from a channel only once.
+type Seq[T any] struct {
+	seq   iter.Seq[T]
+	split func(ctx context.Context, parts int) ([]iter.Seq[T], error) // ordered sub-sequences for ParallelCollect
+	bound int                                                         // maximum number of elements, -1 if unknown
+}
+
+// SeqFromSlice returns a Seq of the elements of a slice. The slice must not be modified while
+// the Seq is in use.
+//
+// Example usage:
+//
+//	squares := gonuts.MapSeq(gonuts.SeqFromSlice([]int{1, 2, 3}), func(x int) int { return x * x }).Collect()
+//	fmt.Println(squares)  // Output: [1 4 9]
+func SeqFromSlice[T any](slice []T) Seq[T] {
+	return Seq[T]{
+		seq: func(yield func(T) bool) {
+			for _, v := range slice {
+				if !yield(v) {
+					return
+				}
+			}
+		},
+		split: func(_ context.Context, parts int) ([]iter.Seq[T], error) {
+			return splitSlice(slice, parts), nil
+		},
+		bound: len(slice),
+	}
+}
+
+// SeqFromChan returns a Seq of the values received from a channel until it is closed.
+// ParallelCollect receives all values before processing them in parallel.
+//
+// Example usage:
+//
+//	ids := gonuts.TakeSeq(gonuts.SeqFromChan(idChan), 100).Collect()
+func SeqFromChan[T any](ch <-chan T) Seq[T] {
+	return Seq[T]{
+		seq: func(yield func(T) bool) {
+			for v := range ch {
+				if !yield(v) {
+					return
+				}
+			}
+		},
+		split: func(ctx context.Context, parts int) ([]iter.Seq[T], error) {
+			var values []T
+			for {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case v, ok := <-ch:
+					if !ok {
+						return splitSlice(values, parts), nil
+					}
+					values = append(values, v)
+				}
+			}
+		},
+		bound: -1,
+	}
+}
+
+// MapSeq returns a Seq that applies f to every element of s. With ParallelCollect, f is
+// called concurrently and must be safe for concurrent use.
+//
+// Example usage:
+//
+//	names := gonuts.MapSeq(gonuts.SeqFromSlice(users), func(u User) string { return u.Name })
+func MapSeq[T, R any](s Seq[T], f func(T) R) Seq[R] {
+	return Seq[R]{
+		seq: mapIter(s.seq, f),
+		split: func(ctx context.Context, parts int) ([]iter.Seq[R], error) {
+			seqs, err := s.split(ctx, parts)
+			if err != nil {
+				return nil, err
+			}
+			mapped := make([]iter.Seq[R], len(seqs))
+			for i, seq := range seqs {
+				mapped[i] = mapIter(seq, f)
+			}
+			return mapped, nil
+		},
+		bound: s.bound,
+	}
+}
+
+// FilterSeq returns a Seq of the elements of s for which predicate returns true. With
+// ParallelCollect, predicate is called concurrently and must be safe for concurrent use.
+//
+// Example usage:
+//
+//	active := gonuts.FilterSeq(gonuts.SeqFromSlice(users), func(u User) bool { return u.Active })
+func FilterSeq[T any](s Seq[T], predicate func(T) bool) Seq[T] {
+	return Seq[T]{
+		seq: filterIter(s.seq, predicate),
+		split: func(ctx context.Context, parts int) ([]iter.Seq[T], error) {
+			seqs, err := s.split(ctx, parts)
+			if err != nil {
+				return nil, err
+			}
+			filtered := make([]iter.Seq[T], len(seqs))
+			for i, seq := range seqs {
+				filtered[i] = filterIter(seq, predicate)
+			}
+			return filtered, nil
+		},
+		bound: s.bound,
+	}
+}
+
+// TakeSeq returns a Seq of the first n elements of s. Elements after the n-th are not pulled
+// from s in sequential pipelines. ParallelCollect evaluates the stages before TakeSeq for all
+// elements, so put TakeSeq as early as possible in parallel pipelines.
+//
+// Example usage:
+//
+//	firstTen := gonuts.TakeSeq(gonuts.SeqFromChan(events), 10).Collect()
+func TakeSeq[T any](s Seq[T], n int) Seq[T] {
+	if n < 0 {
+		n = 0
+	}
+	bound := s.bound
+	if bound > n {
+		bound = n
+	}
+	return Seq[T]{
+		seq: func(yield func(T) bool) {
+			if n == 0 {
+				return
+			}
+			taken := 0
+			for v := range s.seq {
+				if !yield(v) {
+					return
+				}
+				taken++
+				if taken == n {
+					return
+				}
+			}
+		},
+		split: func(ctx context.Context, parts int) ([]iter.Seq[T], error) {
+			values, err := s.collectParallel(ctx, parts)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) > n {
+				values = values[:n]
+			}
+			return splitSlice(values, parts), nil
+		},
+		bound: bound,
+	}
+}
+
+// All returns the sequence as an iter.Seq for use with range.
+//
+// Example usage:
+//
+//	for name := range names.All() {
+//	    fmt.Println(name)
+//	}
+func (s Seq[T]) All() iter.Seq[T] {
+	return s.seq
+}
+
+// Collect returns the elements of the sequence as a slice. For sequences built from a slice,
+// the result is allocated once with the length of the source slice as capacity.
+func (s Seq[T]) Collect() []T {
+	var result []T
+	if s.bound >= 0 {
+		result = make([]T, 0, s.bound)
+	}
+	for v := range s.seq {
+		result = append(result, v)
+	}
+	return result
+}
+
+// ForEach calls fn for every element of the sequence
+func (s Seq[T]) ForEach(fn func(T)) {
+	for v := range s.seq {
+		fn(v)
+	}
+}
+
+// ParallelCollect evaluates the sequence concurrently and returns the elements in order.
+// The source is split into one part per worker, and the parts run through the whole pipeline
+// via ParallelSliceMap, so at most GOMAXPROCS parts are processed at the same time.
+//
+// Parameters:
+//   - ctx: A context for cancellation
+//   - workers: the number of parts (GOMAXPROCS if workers <= 0)
+//
+// Returns:
+//   - []T: the elements of the sequence, in order
+//   - error: an error if the context was cancelled
+//
+// Example usage:
+//
+//	hashes, err := gonuts.MapSeq(gonuts.SeqFromSlice(files), hashFile).ParallelCollect(ctx, 8)
+func (s Seq[T]) ParallelCollect(ctx context.Context, workers int) ([]T, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return s.collectParallel(ctx, workers)
+}
+
+func (s Seq[T]) collectParallel(ctx context.Context, parts int) ([]T, error) {
+	seqs, err := s.split(ctx, parts)
+	if err != nil {
+		return nil, err
+	}
+	results, err := ParallelSliceMap(ctx, seqs, func(seq iter.Seq[T]) []T {
+		var part []T
+		for v := range seq {
+			part = append(part, v)
+			if len(part)%1024 == 0 && ctx.Err() != nil {
+				return nil
+			}
+		}
+		return part
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, part := range results {
+		total += len(part)
+	}
+	collected := make([]T, 0, total)
+	for _, part := range results {
+		collected = append(collected, part...)
+	}
+	return collected, nil
+}
+
+func mapIter[T, R any](seq iter.Seq[T], f func(T) R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+func filterIter[T any](seq iter.Seq[T], predicate func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if predicate(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// splitSlice returns up to parts sequences over consecutive ranges of slice
+func splitSlice[T any](slice []T, parts int) []iter.Seq[T] {
+	if parts < 1 {
+		parts = 1
+	}
+	if parts > len(slice) {
+		parts = len(slice)
+	}
+	seqs := make([]iter.Seq[T], 0, parts)
+	for i := 0; i < parts; i++ {
+		part := slice[i*len(slice)/parts : (i+1)*len(slice)/parts]
+		seqs = append(seqs, func(yield func(T) bool) {
+			for _, v := range part {
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+	return seqs
+}
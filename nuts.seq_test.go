@@ -0,0 +1,92 @@
+package gonuts
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSeqPipelines(t *testing.T) {
+	source := make([]int, 10_000)
+	for i := range source {
+		source[i] = i
+	}
+	square := func(x int) int { return x * x }
+	even := func(x int) bool { return x%2 == 0 }
+
+	want := Filter(Map(source, square), even)
+	pipeline := FilterSeq(MapSeq(SeqFromSlice(source), square), even)
+
+	if got := pipeline.Collect(); !slices.Equal(got, want) {
+		t.Errorf("Collect differs from Map+Filter: %d vs %d elements", len(got), len(want))
+	}
+	got, err := pipeline.ParallelCollect(context.Background(), 4)
+	if err != nil || !slices.Equal(got, want) {
+		t.Errorf("ParallelCollect = %d elements, %v; want the %d elements of Map+Filter in order", len(got), err, len(want))
+	}
+	if got := TakeSeq(pipeline, 3).Collect(); !slices.Equal(got, want[:3]) {
+		t.Errorf("TakeSeq(3) = %v, want %v", got, want[:3])
+	}
+}
+
+func TestSeqTakeStopsPullingFromChannel(t *testing.T) {
+	ch := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	if got := TakeSeq(SeqFromChan(ch), 3).Collect(); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("TakeSeq(3) = %v, want [0 1 2]", got)
+	}
+	if n := len(ch); n != 7 {
+		t.Errorf("%d values left in the channel, want 7", n)
+	}
+}
+
+func TestSeqParallelCollectCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MapSeq(SeqFromSlice([]int{1, 2, 3}), func(x int) int { return x }).ParallelCollect(ctx, 2); err == nil {
+		t.Error("ParallelCollect with a cancelled context succeeded")
+	}
+}
+
+// BenchmarkSeqVersusChainedSliceHelpers compares a Map -> Filter -> Map pipeline on 1M elements:
+// the chained slice helpers materialize a slice per stage, the Seq only the result
+func BenchmarkSeqVersusChainedSliceHelpers(b *testing.B) {
+	source := make([]int, 1_000_000)
+	for i := range source {
+		source[i] = i
+	}
+	double := func(x int) int { return x * 2 }
+	keep := func(x int) bool { return x%3 == 0 }
+	inc := func(x int) int { return x + 1 }
+
+	b.Run("chained", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Map(Filter(Map(source, double), keep), inc)
+		}
+	})
+	b.Run("Seq.Collect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = MapSeq(FilterSeq(MapSeq(SeqFromSlice(source), double), keep), inc).Collect()
+		}
+	})
+	b.Run("Seq.ForEach", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sum := 0
+			MapSeq(FilterSeq(MapSeq(SeqFromSlice(source), double), keep), inc).ForEach(func(x int) { sum += x })
+		}
+	})
+	b.Run("Seq.ParallelCollect", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = MapSeq(FilterSeq(MapSeq(SeqFromSlice(source), double), keep), inc).ParallelCollect(ctx, 0)
+		}
+	})
+}
@@ -186,3 +186,203 @@ func (s *Set[T]) String() string {
 	}
 	return fmt.Sprintf("Set{%s}", strings.Join(items, ", "))
 }
+
+// ImmutableSet is a persistent set: With and Without return a new set and never modify the receiver,
+// so a set can be shared between goroutines without copying or locking.
+//
+// Updates share structure with the original set: a new set reuses the (never mutated) base map
+// of its predecessor and only carries a small overlay of added and removed items. Once the overlay
+// grows beyond max(32, size/8) items it is flattened into a fresh base map. This makes single-item
+// updates on large sets cost O(overlay) instead of O(n) for a full copy, at the price of one or two
+// extra map lookups in Contains. Bulk operations (Union, Intersect, Diff) always produce a flattened set.
+type ImmutableSet[T comparable] struct {
+	base    map[T]struct{} // shared between versions, never mutated
+	added   map[T]struct{} // items not in base that are part of the set
+	removed map[T]struct{} // items in base that are not part of the set
+	size    int
+}
+
+// NewImmutableSet creates a new ImmutableSet containing the given items
+//
+// Example usage:
+//
+//	s1 := gonuts.NewImmutableSet(1, 2, 3)
+//	s2 := s1.With(4).Without(1)
+//	fmt.Println(s1.Size(), s2.Size()) // Output: 3 3
+func NewImmutableSet[T comparable](items ...T) *ImmutableSet[T] {
+	base := make(map[T]struct{}, len(items))
+	for _, item := range items {
+		base[item] = struct{}{}
+	}
+	return &ImmutableSet[T]{base: base, size: len(base)}
+}
+
+// ImmutableSetFromSet creates an ImmutableSet snapshot of a mutable Set
+func ImmutableSetFromSet[T comparable](s *Set[T]) *ImmutableSet[T] {
+	return NewImmutableSet(s.ToSlice()...)
+}
+
+// ToSet returns a mutable Set containing the items of the ImmutableSet
+func (s *ImmutableSet[T]) ToSet() *Set[T] {
+	return NewSet[T]().Add(s.ToSlice()...)
+}
+
+// With returns a new set containing the items of this set plus the given items
+func (s *ImmutableSet[T]) With(items ...T) *ImmutableSet[T] {
+	next := s.cloneOverlay()
+	for _, item := range items {
+		if next.Contains(item) {
+			continue
+		}
+		if _, inBase := next.base[item]; inBase {
+			delete(next.removed, item)
+		} else {
+			next.added[item] = struct{}{}
+		}
+		next.size++
+	}
+	return next.compact()
+}
+
+// Without returns a new set containing the items of this set except the given items
+func (s *ImmutableSet[T]) Without(items ...T) *ImmutableSet[T] {
+	next := s.cloneOverlay()
+	for _, item := range items {
+		if !next.Contains(item) {
+			continue
+		}
+		if _, inBase := next.base[item]; inBase {
+			next.removed[item] = struct{}{}
+		} else {
+			delete(next.added, item)
+		}
+		next.size--
+	}
+	return next.compact()
+}
+
+// Contains checks if an item is in the set
+func (s *ImmutableSet[T]) Contains(item T) bool {
+	if _, ok := s.added[item]; ok {
+		return true
+	}
+	if _, ok := s.removed[item]; ok {
+		return false
+	}
+	_, ok := s.base[item]
+	return ok
+}
+
+// Size returns the number of items in the set
+func (s *ImmutableSet[T]) Size() int {
+	return s.size
+}
+
+// Range calls f for each item in the set until f returns false
+func (s *ImmutableSet[T]) Range(f func(item T) bool) {
+	for item := range s.base {
+		if _, ok := s.removed[item]; ok {
+			continue
+		}
+		if !f(item) {
+			return
+		}
+	}
+	for item := range s.added {
+		if !f(item) {
+			return
+		}
+	}
+}
+
+// ToSlice returns a slice of all items in the set
+func (s *ImmutableSet[T]) ToSlice() []T {
+	slice := make([]T, 0, s.size)
+	s.Range(func(item T) bool {
+		slice = append(slice, item)
+		return true
+	})
+	return slice
+}
+
+// Union returns a new set containing the items of both sets
+func (s *ImmutableSet[T]) Union(other *ImmutableSet[T]) *ImmutableSet[T] {
+	base := make(map[T]struct{}, s.size+other.size)
+	s.Range(func(item T) bool {
+		base[item] = struct{}{}
+		return true
+	})
+	other.Range(func(item T) bool {
+		base[item] = struct{}{}
+		return true
+	})
+	return &ImmutableSet[T]{base: base, size: len(base)}
+}
+
+// Intersect returns a new set containing the items present in both sets
+func (s *ImmutableSet[T]) Intersect(other *ImmutableSet[T]) *ImmutableSet[T] {
+	base := make(map[T]struct{})
+	s.Range(func(item T) bool {
+		if other.Contains(item) {
+			base[item] = struct{}{}
+		}
+		return true
+	})
+	return &ImmutableSet[T]{base: base, size: len(base)}
+}
+
+// Diff returns a new set containing the items of this set that are not in the other set
+func (s *ImmutableSet[T]) Diff(other *ImmutableSet[T]) *ImmutableSet[T] {
+	base := make(map[T]struct{})
+	s.Range(func(item T) bool {
+		if !other.Contains(item) {
+			base[item] = struct{}{}
+		}
+		return true
+	})
+	return &ImmutableSet[T]{base: base, size: len(base)}
+}
+
+// String returns a string representation of the set
+func (s *ImmutableSet[T]) String() string {
+	items := make([]string, 0, s.size)
+	s.Range(func(item T) bool {
+		items = append(items, fmt.Sprintf("%v", item))
+		return true
+	})
+	return fmt.Sprintf("ImmutableSet{%s}", strings.Join(items, ", "))
+}
+
+// cloneOverlay returns a new version sharing the base map and owning copies of the overlay maps
+func (s *ImmutableSet[T]) cloneOverlay() *ImmutableSet[T] {
+	next := &ImmutableSet[T]{
+		base:    s.base,
+		added:   make(map[T]struct{}, len(s.added)),
+		removed: make(map[T]struct{}, len(s.removed)),
+		size:    s.size,
+	}
+	for item := range s.added {
+		next.added[item] = struct{}{}
+	}
+	for item := range s.removed {
+		next.removed[item] = struct{}{}
+	}
+	return next
+}
+
+// compact flattens the overlay into a new base map once it grows too large
+func (s *ImmutableSet[T]) compact() *ImmutableSet[T] {
+	limit := len(s.base) / 8
+	if limit < 32 {
+		limit = 32
+	}
+	if len(s.added)+len(s.removed) <= limit {
+		return s
+	}
+	base := make(map[T]struct{}, s.size)
+	s.Range(func(item T) bool {
+		base[item] = struct{}{}
+		return true
+	})
+	return &ImmutableSet[T]{base: base, size: len(base)}
+}
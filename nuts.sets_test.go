@@ -0,0 +1,132 @@
+package gonuts
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// immutableSetMatches reports whether s holds exactly the items of want
+func immutableSetMatches(s *ImmutableSet[int], want map[int]bool) bool {
+	if s.Size() != len(want) || len(s.ToSlice()) != len(want) {
+		return false
+	}
+	for item := range want {
+		if !s.Contains(item) {
+			return false
+		}
+	}
+	ok := true
+	s.Range(func(item int) bool {
+		ok = want[item]
+		return ok
+	})
+	return ok
+}
+
+// TestImmutableSetVersionsAreIsolated derives a tree of versions from random earlier versions,
+// crossing the compaction threshold many times, and checks after every update that no version
+// sees a change made to another one
+func TestImmutableSetVersionsAreIsolated(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	versions := []*ImmutableSet[int]{NewImmutableSet(1, 2, 3)}
+	models := []map[int]bool{{1: true, 2: true, 3: true}}
+
+	for step := 0; step < 400; step++ {
+		from := rng.Intn(len(versions))
+		items := make([]int, 1+rng.Intn(40))
+		for i := range items {
+			items[i] = rng.Intn(300)
+		}
+
+		model := make(map[int]bool, len(models[from]))
+		for item := range models[from] {
+			model[item] = true
+		}
+		var next *ImmutableSet[int]
+		if rng.Intn(3) == 0 {
+			next = versions[from].Without(items...)
+			for _, item := range items {
+				delete(model, item)
+			}
+		} else {
+			next = versions[from].With(items...)
+			for _, item := range items {
+				model[item] = true
+			}
+		}
+		versions = append(versions, next)
+		models = append(models, model)
+
+		for i, v := range versions {
+			if !immutableSetMatches(v, models[i]) {
+				t.Fatalf("step %d: version %d = %v, want the items %v", step, i, v, models[i])
+			}
+		}
+	}
+}
+
+func TestImmutableSetSharesBase(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	s1 := NewImmutableSet(items...)
+	s2 := s1.With(1000).Without(0)
+	s3 := s2.With(0)
+
+	// Small updates reuse the base map of the original set
+	base := reflect.ValueOf(s1.base).Pointer()
+	if reflect.ValueOf(s2.base).Pointer() != base || reflect.ValueOf(s3.base).Pointer() != base {
+		t.Error("a single-item update copied the base map")
+	}
+	if s1.Contains(1000) || !s1.Contains(0) || s1.Size() != 1000 {
+		t.Errorf("s1 changed by updates of its successors: Contains(1000) = %v, Contains(0) = %v, Size() = %d", s1.Contains(1000), s1.Contains(0), s1.Size())
+	}
+	if s2.Contains(0) || !s2.Contains(1000) || s2.Size() != 1000 {
+		t.Errorf("s2 changed by s3: Contains(0) = %v, Size() = %d", s2.Contains(0), s2.Size())
+	}
+	if !s3.Contains(0) || s3.Size() != 1001 {
+		t.Errorf("s3: Contains(0) = %v, Size() = %d, want true and 1001", s3.Contains(0), s3.Size())
+	}
+
+	// Updates beyond max(32, size/8) items are flattened into a new base without an overlay
+	many := make([]int, 200)
+	for i := range many {
+		many[i] = 2000 + i
+	}
+	s4 := s1.With(many...)
+	if reflect.ValueOf(s4.base).Pointer() == base || len(s4.added) != 0 || len(s4.removed) != 0 {
+		t.Error("a large update was not compacted into a new base map")
+	}
+	if s1.Size() != 1000 || s1.Contains(2000) || s4.Size() != 1200 {
+		t.Errorf("Size() = %d and %d after compaction, want 1000 and 1200", s1.Size(), s4.Size())
+	}
+}
+
+func TestImmutableSetBulkOperations(t *testing.T) {
+	a := NewImmutableSet(1, 2, 3).Without(3).With(4) // with an overlay
+	b := NewImmutableSet(2, 4, 5)
+
+	if u := a.Union(b); !immutableSetMatches(u, map[int]bool{1: true, 2: true, 4: true, 5: true}) {
+		t.Errorf("Union = %v", u)
+	}
+	if i := a.Intersect(b); !immutableSetMatches(i, map[int]bool{2: true, 4: true}) {
+		t.Errorf("Intersect = %v", i)
+	}
+	if d := a.Diff(b); !immutableSetMatches(d, map[int]bool{1: true}) {
+		t.Errorf("Diff = %v", d)
+	}
+	if !immutableSetMatches(a, map[int]bool{1: true, 2: true, 4: true}) || !immutableSetMatches(b, map[int]bool{2: true, 4: true, 5: true}) {
+		t.Errorf("bulk operations changed their operands: %v, %v", a, b)
+	}
+
+	// Converting from and to a mutable Set copies the items
+	mutable := a.ToSet()
+	mutable.Add(99)
+	snapshot := ImmutableSetFromSet(mutable)
+	mutable.Remove(1)
+	if a.Contains(99) || !snapshot.Contains(1) || !snapshot.Contains(99) {
+		t.Error("an ImmutableSet shares items with a mutable Set")
+	}
+}
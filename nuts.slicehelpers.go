@@ -190,3 +190,77 @@ func Join(slice []string, separator string) string {
 		return acc + separator + s
 	})
 }
+
+// Insert returns a new slice with items inserted at the given index.
+// The input slice is never modified (no aliasing with the result).
+// Out-of-range indexes are clamped: negative indexes insert at the start,
+// indexes beyond the length append at the end.
+//
+// Example:
+//
+//	letters := []string{"a", "d"}
+//	fmt.Println(Insert(letters, 1, "b", "c")) // Output: [a b c d]
+//	fmt.Println(Insert(letters, 99, "e"))     // Output: [a d e]
+func Insert[T any](slice []T, index int, items ...T) []T {
+	if index < 0 {
+		index = 0
+	}
+	if index > len(slice) {
+		index = len(slice)
+	}
+	result := make([]T, 0, len(slice)+len(items))
+	result = append(result, slice[:index]...)
+	result = append(result, items...)
+	result = append(result, slice[index:]...)
+	return result
+}
+
+// BinarySearch searches a slice sorted according to less for target.
+// It returns the index of the first element not less than target and whether
+// that element equals target (neither less(a, b) nor less(b, a)).
+// If target is not found, index is the position where it would be inserted.
+//
+// Example:
+//
+//	numbers := []int{1, 3, 5, 7}
+//	idx, found := BinarySearch(numbers, 5, func(a, b int) bool { return a < b })
+//	fmt.Println(idx, found) // Output: 2 true
+func BinarySearch[T any](slice []T, target T, less func(a, b T) bool) (index int, found bool) {
+	index = sort.Search(len(slice), func(i int) bool {
+		return !less(slice[i], target)
+	})
+	found = index < len(slice) && !less(target, slice[index])
+	return index, found
+}
+
+// InsertSorted returns a new slice with item inserted into a slice sorted according to less,
+// keeping it sorted. Equal elements keep their insertion order (item is placed after them).
+// The input slice is never modified.
+//
+// Example:
+//
+//	numbers := []int{1, 3, 5, 7}
+//	fmt.Println(InsertSorted(numbers, 4, func(a, b int) bool { return a < b })) // Output: [1 3 4 5 7]
+func InsertSorted[T any](slice []T, item T, less func(a, b T) bool) []T {
+	index := sort.Search(len(slice), func(i int) bool {
+		return less(item, slice[i])
+	})
+	return Insert(slice, index, item)
+}
+
+// Dedup returns a new slice with consecutive duplicate elements removed.
+// On an already-sorted slice this removes all duplicates in O(n).
+//
+// Example:
+//
+//	numbers := []int{1, 1, 2, 3, 3, 3, 4}
+//	fmt.Println(Dedup(numbers)) // Output: [1 2 3 4]
+func Dedup[T comparable](slice []T) []T {
+	result := make([]T, 0, len(slice))
+	for i, v := range slice {
+		if i == 0 || v != slice[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package gonuts
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	letters := []string{"a", "d"}
+	tests := []struct {
+		index int
+		items []string
+		want  []string
+	}{
+		{1, []string{"b", "c"}, []string{"a", "b", "c", "d"}},
+		{0, []string{"z"}, []string{"z", "a", "d"}},
+		{2, []string{"e"}, []string{"a", "d", "e"}},
+		{-5, []string{"z"}, []string{"z", "a", "d"}},
+		{99, []string{"e"}, []string{"a", "d", "e"}},
+		{1, nil, []string{"a", "d"}},
+	}
+	for _, tt := range tests {
+		if got := Insert(letters, tt.index, tt.items...); !slices.Equal(got, tt.want) {
+			t.Errorf("Insert(%v, %d, %v) = %v, want %v", letters, tt.index, tt.items, got, tt.want)
+		}
+	}
+
+	// The result never aliases the input, even when the input has spare capacity
+	spare := make([]int, 3, 10)
+	result := Insert(spare, 3, 4)
+	result[0] = 99
+	if spare[0] != 0 {
+		t.Error("the result of Insert shares its elements with the input")
+	}
+	if extended := spare[:4]; extended[3] != 0 {
+		t.Errorf("Insert wrote %d into the spare capacity of the input", extended[3])
+	}
+	if got := Insert[int](nil, 0, 1); !slices.Equal(got, []int{1}) {
+		t.Errorf("Insert(nil, 0, 1) = %v", got)
+	}
+}
+
+type slicehelperVersion struct {
+	major int
+	name  string
+}
+
+func TestBinarySearch(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	numbers := []int{1, 3, 5, 5, 7}
+	tests := []struct {
+		target int
+		index  int
+		found  bool
+	}{
+		{5, 2, true}, // the first of equal elements
+		{1, 0, true},
+		{7, 4, true},
+		{0, 0, false},
+		{4, 2, false},
+		{8, 5, false},
+	}
+	for _, tt := range tests {
+		if index, found := BinarySearch(numbers, tt.target, less); index != tt.index || found != tt.found {
+			t.Errorf("BinarySearch(%d) = %d, %v, want %d, %v", tt.target, index, found, tt.index, tt.found)
+		}
+	}
+	if index, found := BinarySearch(nil, 1, less); index != 0 || found {
+		t.Errorf("BinarySearch on an empty slice = %d, %v", index, found)
+	}
+
+	// Equality is derived from less, so elements equal by key are found
+	versions := []slicehelperVersion{{1, "one"}, {2, "two"}}
+	byMajor := func(a, b slicehelperVersion) bool { return a.major < b.major }
+	if index, found := BinarySearch(versions, slicehelperVersion{major: 2}, byMajor); index != 1 || !found {
+		t.Errorf("BinarySearch by major = %d, %v, want 1, true", index, found)
+	}
+}
+
+func TestInsertSorted(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	numbers := []int{1, 3, 5, 7}
+	for _, tt := range []struct {
+		item int
+		want []int
+	}{
+		{4, []int{1, 3, 4, 5, 7}},
+		{0, []int{0, 1, 3, 5, 7}},
+		{9, []int{1, 3, 5, 7, 9}},
+	} {
+		if got := InsertSorted(numbers, tt.item, less); !slices.Equal(got, tt.want) {
+			t.Errorf("InsertSorted(%d) = %v, want %v", tt.item, got, tt.want)
+		}
+	}
+	if !slices.Equal(numbers, []int{1, 3, 5, 7}) {
+		t.Errorf("InsertSorted modified the input: %v", numbers)
+	}
+
+	// An equal element is placed after the existing ones
+	byMajor := func(a, b slicehelperVersion) bool { return a.major < b.major }
+	var versions []slicehelperVersion
+	for _, v := range []slicehelperVersion{{2, "first two"}, {1, "one"}, {2, "second two"}, {3, "three"}} {
+		versions = InsertSorted(versions, v, byMajor)
+	}
+	want := []slicehelperVersion{{1, "one"}, {2, "first two"}, {2, "second two"}, {3, "three"}}
+	if !slices.Equal(versions, want) {
+		t.Errorf("InsertSorted with equal elements = %v, want %v", versions, want)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	tests := []struct {
+		in, want []int
+	}{
+		{[]int{1, 1, 2, 3, 3, 3, 4}, []int{1, 2, 3, 4}},
+		{[]int{1, 2, 1, 1}, []int{1, 2, 1}}, // only consecutive duplicates
+		{[]int{5}, []int{5}},
+		{nil, []int{}},
+	}
+	for _, tt := range tests {
+		in := slices.Clone(tt.in)
+		if got := Dedup(in); !slices.Equal(got, tt.want) {
+			t.Errorf("Dedup(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+		if !slices.Equal(in, tt.in) {
+			t.Errorf("Dedup modified its input %v to %v", tt.in, in)
+		}
+	}
+}
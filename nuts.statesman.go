@@ -1,9 +1,12 @@
 package gonuts
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,12 +22,31 @@ type SMAction func(context map[string]interface{})
 // SMCondition represents a function that returns a boolean based on the context.
 type SMCondition func(context map[string]interface{}) bool
 
+// SMEventAction is an action that receives the persistent machine context and, separately,
+// the data of the event being handled (nil for timed transitions). The event data must not be modified.
+type SMEventAction func(machineCtx, eventData map[string]interface{})
+
+// SMEventCondition is a condition that receives the persistent machine context and,
+// separately, the data of the event being handled.
+type SMEventCondition func(machineCtx, eventData map[string]interface{}) bool
+
 // State represents a state in the state machine.
 type State struct {
 	ID           StateID
 	Name         string
-	EntryActions []SMAction
-	ExitActions  []SMAction
+	EntryActions []SMAction `json:"-"`
+	ExitActions  []SMAction `json:"-"`
+
+	// Actions that receive the event data separately (see AddEventState).
+	EventEntryActions []SMEventAction `json:"-"`
+	EventExitActions  []SMEventAction `json:"-"`
+
+	// Names of registered actions (see RegisterAction); unlike closures these survive Export/Import.
+	EntryActionNames []string `json:",omitempty"`
+	ExitActionNames  []string `json:",omitempty"`
+
+	// Parent is the enclosing state of a substate (see AddSubState), empty for top-level states.
+	Parent StateID `json:",omitempty"`
 }
 
 // Transition represents a transition between states.
@@ -32,8 +54,16 @@ type Transition struct {
 	From      StateID
 	To        StateID
 	Event     EventID
-	Condition SMCondition
-	Actions   []SMAction
+	Condition SMCondition `json:"-"`
+	Actions   []SMAction  `json:"-"`
+
+	// Condition and actions that receive the event data separately (see AddEventTransition).
+	EventCondition SMEventCondition `json:"-"`
+	EventActions   []SMEventAction  `json:"-"`
+
+	// Names of a registered guard and registered actions (see RegisterGuard and RegisterAction).
+	GuardName   string   `json:",omitempty"`
+	ActionNames []string `json:",omitempty"`
 }
 
 // TimedTransition represents a transition that occurs after a specified duration.
@@ -41,14 +71,38 @@ type TimedTransition struct {
 	Transition
 	Duration time.Duration
 	timer    *time.Timer
+	seq      uint64    // identifies the armed timer, so that a stale firing is ignored
+	deadline time.Time // when the armed timer fires
 }
 
 // EventData encapsulates event information passed to the state machine.
 type EventData struct {
 	EventID EventID
 	Data    map[string]interface{}
+
+	timed   *timedFiring   // set when a timed transition's timer fired
+	timeout *timeoutFiring // set when the state timeout timer fired
+}
+
+// timedFiring identifies the timer of a timed transition that fired.
+type timedFiring struct {
+	index int    // index in TimedTransitions
+	seq   uint64 // TimedTransition.seq when the timer was armed
+}
+
+// timeoutFiring identifies the state timeout timer that injected an event.
+type timeoutFiring struct {
+	state StateID // the state the timer was armed for
+	seq   uint64  // StatesMan.stateTimerSeq when the timer was armed
 }
 
+// ErrStatesManRunning is returned by RunWithContext when the event loop is already running.
+var ErrStatesManRunning = errors.New("state machine is already running")
+
+// ErrUnhandledEvent is reported (see SetErrorEmitter) when the current state and its parents
+// have no transition for an event. Events rejected by conditions or guards are not reported.
+var ErrUnhandledEvent = errors.New("no transition for event")
+
 // StatesMan is a flexible, concurrent-safe state machine manager.
 type StatesMan struct {
 	Name             string
@@ -57,10 +111,65 @@ type StatesMan struct {
 	Transitions      []Transition
 	TimedTransitions []TimedTransition
 	CurrentState     StateID
+	InitialState     StateID
 	EventChannel     chan EventData
 	Context          map[string]interface{}
 	PreHooks         []SMAction
 	PostHooks        []SMAction
+
+	stateTimeouts map[StateID]stateTimeout
+	stateTimer    *time.Timer
+	stateTimerSeq uint64
+	stateDeadline time.Time // when stateTimer fires
+	timedSeq      uint64
+
+	subscribers   map[uint64]*TransitionSubscription
+	subscriberSeq uint64
+
+	history      []TransitionRecord // ring buffer, see SetHistoryLimit
+	historyNext  int
+	historyLimit int
+
+	actions      map[string]registeredAction
+	guards       map[string]registeredGuard
+	dependencies map[string]interface{}
+
+	isolateEventData bool          // do not merge event data into Context (see SetEventDataIsolation)
+	errorEmitter     *EventEmitter // receives asynchronous errors, DefaultEmitter if nil
+
+	idempotency *idempotencyWindow // recently seen keys of TriggerEventIdempotent
+
+	running bool          // the event loop is running
+	stopped bool          // the event loop was stopped; timers are re-armed on the next run
+	stopCh  chan struct{} // closed by Stop, nil when not running or already stopping
+	doneCh  chan struct{} // closed when the current (or last) event loop has exited
+
+	sim *smSimulation // set on the clone run by Simulate
+}
+
+// TransitionEvent describes a completed state transition delivered to subscribers.
+// Event is empty for timed transitions. Data is a copy of the event data that caused the transition.
+type TransitionEvent struct {
+	From      StateID
+	To        StateID
+	Event     EventID
+	Timestamp time.Time
+	Data      map[string]interface{}
+}
+
+// TransitionSubscription is the handle of a Subscribe call.
+type TransitionSubscription struct {
+	sm      *StatesMan
+	id      uint64
+	ch      chan TransitionEvent
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// stateTimeout describes the event injected when the machine stays in a state for too long.
+type stateTimeout struct {
+	Duration time.Duration
+	Event    EventID
 }
 
 // AnyState represents a wildcard state that matches any current state.
@@ -69,11 +178,18 @@ const AnyState StateID = "*"
 // NewStatesMan creates a new StatesMan instance.
 func NewStatesMan(name string) *StatesMan {
 	return &StatesMan{
-		Name:         name,
-		States:       make(map[StateID]*State),
-		Transitions:  []Transition{},
-		EventChannel: make(chan EventData, 10),
-		Context:      make(map[string]interface{}),
+		Name:          name,
+		States:        make(map[StateID]*State),
+		Transitions:   []Transition{},
+		EventChannel:  make(chan EventData, 10),
+		Context:       make(map[string]interface{}),
+		stateTimeouts: make(map[StateID]stateTimeout),
+		subscribers:   make(map[uint64]*TransitionSubscription),
+		actions:       make(map[string]registeredAction),
+		guards:        make(map[string]registeredGuard),
+		dependencies:  make(map[string]interface{}),
+		historyLimit:  DefaultStatesManHistoryLimit,
+		idempotency:   newIdempotencyWindow(DefaultIdempotencyTTL, DefaultIdempotencyMaxKeys),
 	}
 }
 
@@ -97,6 +213,8 @@ func (sm *StatesMan) SetInitialState(id StateID) error {
 		return fmt.Errorf("state %s does not exist", id)
 	}
 	sm.CurrentState = id
+	sm.InitialState = id
+	sm.armStateTimeout(id)
 	return nil
 }
 
@@ -113,6 +231,103 @@ func (sm *StatesMan) AddTransition(from, to StateID, event EventID, condition SM
 	})
 }
 
+// AddEventState adds a new state whose entry and exit actions receive the event data
+// separately from the machine context.
+func (sm *StatesMan) AddEventState(id StateID, name string, entryActions, exitActions []SMEventAction) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.States[id] = &State{
+		ID:                id,
+		Name:              name,
+		EventEntryActions: entryActions,
+		EventExitActions:  exitActions,
+	}
+}
+
+// AddEventTransition adds a new transition whose condition and actions receive the event data
+// separately from the machine context.
+//
+// Example:
+//
+//	sm.SetEventDataIsolation(true)
+//	sm.AddEventTransition("Cart", "Checkout", "Pay",
+//		func(machineCtx, event map[string]interface{}) bool { return event["amount"].(float64) > 0 },
+//		func(machineCtx, event map[string]interface{}) { machineCtx["paid"] = event["amount"] },
+//	)
+func (sm *StatesMan) AddEventTransition(from, to StateID, event EventID, condition SMEventCondition, actions ...SMEventAction) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.Transitions = append(sm.Transitions, Transition{
+		From:           from,
+		To:             to,
+		Event:          event,
+		EventCondition: condition,
+		EventActions:   actions,
+	})
+}
+
+// SetEventDataIsolation controls whether event data is merged into the persistent Context.
+// By default (false) every event's data is merged into Context before conditions and actions
+// run, as in earlier versions. When enabled, Context only changes through actions and
+// SetContextValue/ClearContext, and event data is only available to SMEventAction,
+// SMEventCondition and ActionScope.EventData.
+func (sm *StatesMan) SetEventDataIsolation(enabled bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.isolateEventData = enabled
+}
+
+// SetErrorEmitter sets the EventEmitter that errors of the event loop, like ErrUnhandledEvent,
+// are reported to with EmitError (source "statesman.<Name>"). By default they go to
+// DefaultEmitter. Errors are emitted after the machine is unlocked, so error listeners may call
+// back into it.
+//
+// Example:
+//
+//	emitter.OnError("unhandled", func(err *gonuts.ErrorPlus) {
+//		if errors.Is(err, gonuts.ErrUnhandledEvent) {
+//			L.Warnf("%v", err)
+//		}
+//	})
+//	sm.SetErrorEmitter(emitter)
+func (sm *StatesMan) SetErrorEmitter(ee *EventEmitter) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.errorEmitter = ee
+}
+
+// reportError emits an error of the event loop; it must be called without holding sm.mu
+func (sm *StatesMan) reportError(ee *EventEmitter, err error) {
+	if ee == nil {
+		ee = DefaultEmitter()
+	}
+	if emitErr := ee.EmitError("statesman."+sm.Name, err); emitErr != nil {
+		L.Errorf("[statesman] reporting %v failed: %v", err, emitErr)
+	}
+}
+
+// SetContextValue sets a value in the persistent machine context.
+func (sm *StatesMan) SetContextValue(key string, value interface{}) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.Context[key] = value
+}
+
+// GetContextValue returns a value from the persistent machine context.
+func (sm *StatesMan) GetContextValue(key string) (interface{}, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	value, ok := sm.Context[key]
+	return value, ok
+}
+
+// ClearContext removes all values from the persistent machine context.
+func (sm *StatesMan) ClearContext() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.Context = make(map[string]interface{})
+}
+
 // AddTimedTransition adds a new timed transition to the state machine.
 func (sm *StatesMan) AddTimedTransition(from, to StateID, duration time.Duration, actions ...SMAction) {
 	sm.mu.Lock()
@@ -127,11 +342,145 @@ func (sm *StatesMan) AddTimedTransition(from, to StateID, duration time.Duration
 	})
 }
 
+// SetStateTimeout registers an event that is triggered when the machine stays in a state
+// longer than the given duration without any transition.
+//
+// Unlike a TimedTransition, the timeout does not force a transition: the event is injected
+// through the normal event path, so conditions and hooks apply and the event can be ignored
+// if no transition matches. The timer is armed on entering the state, cancelled on leaving it,
+// and reset when the state is re-entered (including self-transitions).
+// A duration of 0 removes the timeout for the state.
+//
+// Example:
+//
+//	sm.SetStateTimeout("Processing", 30*time.Second, "Timeout")
+//	sm.AddTransition("Processing", "Failed", "Timeout", nil)
+func (sm *StatesMan) SetStateTimeout(state StateID, d time.Duration, event EventID) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if d <= 0 {
+		delete(sm.stateTimeouts, state)
+	} else {
+		sm.stateTimeouts[state] = stateTimeout{Duration: d, Event: event}
+	}
+	if sm.CurrentState == state {
+		sm.armStateTimeout(state)
+	}
+}
+
+// armStateTimeout cancels a running state timeout timer and arms a new one if the state has a timeout.
+// The caller must hold sm.mu.
+func (sm *StatesMan) armStateTimeout(state StateID) {
+	sm.stateTimerSeq++
+	if sm.stateTimer != nil {
+		sm.stateTimer.Stop()
+		sm.stateTimer = nil
+	}
+	timeout, ok := sm.stateTimeouts[state]
+	if !ok {
+		return
+	}
+	sm.startStateTimer(state, timeout, timeout.Duration)
+}
+
+// startStateTimer arms the state timeout timer to fire after d. The caller must hold sm.mu
+// and must have stopped the previous timer.
+func (sm *StatesMan) startStateTimer(state StateID, timeout stateTimeout, d time.Duration) {
+	firing := &timeoutFiring{state: state, seq: sm.stateTimerSeq}
+	sm.stateDeadline = sm.now().Add(d)
+	if sm.sim != nil {
+		sm.stateTimer = sm.sim.timer // fired by Simulate on the virtual clock
+		return
+	}
+	sm.stateTimer = time.AfterFunc(d, func() {
+		sm.mu.RLock()
+		stale := sm.stateTimerSeq != firing.seq || sm.CurrentState != state || sm.stopped
+		stop := sm.stopCh
+		sm.mu.RUnlock()
+		// When the machine is not running, RunWithContext re-arms the due timer when it starts
+		if stale || stop == nil {
+			return
+		}
+		select {
+		case sm.EventChannel <- EventData{EventID: timeout.Event, timeout: firing}:
+		case <-stop:
+		}
+	})
+}
+
 // TriggerEvent triggers an event in the state machine without knowing the next state.
 func (sm *StatesMan) TriggerEvent(event EventID, data map[string]interface{}) {
 	sm.EventChannel <- EventData{EventID: event, Data: data}
 }
 
+// Subscribe returns a read-only channel that receives a TransitionEvent after each successful
+// transition, including timed transitions. Notifications never block the machine: when the
+// subscriber's buffer is full the notification is dropped and counted (see Dropped).
+//
+// Example:
+//
+//	events, sub := sm.Subscribe(16)
+//	defer sub.Unsubscribe()
+//	go func() {
+//		for ev := range events {
+//			fmt.Printf("%s -> %s on %s\n", ev.From, ev.To, ev.Event)
+//		}
+//	}()
+func (sm *StatesMan) Subscribe(buffer int) (<-chan TransitionEvent, *TransitionSubscription) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.subscriberSeq++
+	sub := &TransitionSubscription{
+		sm: sm,
+		id: sm.subscriberSeq,
+		ch: make(chan TransitionEvent, buffer),
+	}
+	sm.subscribers[sub.id] = sub
+	return sub.ch, sub
+}
+
+// Unsubscribe stops the delivery of notifications and closes the subscription channel.
+// It is safe to call Unsubscribe more than once, but not from within an action or hook.
+func (s *TransitionSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.sm.mu.Lock()
+		defer s.sm.mu.Unlock()
+		delete(s.sm.subscribers, s.id)
+		close(s.ch)
+	})
+}
+
+// Dropped returns the number of notifications dropped because the subscriber was too slow.
+func (s *TransitionSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// notifySubscribers delivers a transition notification without blocking.
+// The caller must hold sm.mu.
+func (sm *StatesMan) notifySubscribers(from, to StateID, event EventID, data map[string]interface{}) {
+	if len(sm.subscribers) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, sub := range sm.subscribers {
+		var dataCopy map[string]interface{}
+		if data != nil {
+			dataCopy = make(map[string]interface{}, len(data))
+			for k, v := range data {
+				dataCopy[k] = v
+			}
+		}
+		select {
+		case sub.ch <- TransitionEvent{From: from, To: to, Event: event, Timestamp: now, Data: dataCopy}:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
 // AddPreHook adds a pre-transition hook.
 func (sm *StatesMan) AddPreHook(hook SMAction) {
 	sm.mu.Lock()
@@ -146,46 +495,213 @@ func (sm *StatesMan) AddPostHook(hook SMAction) {
 	sm.PostHooks = append(sm.PostHooks, hook)
 }
 
-// Run starts the state machine event loop.
+// Run starts the state machine event loop and blocks until Stop is called or EventChannel is closed.
+// Calling Run while the loop is already running returns immediately.
 func (sm *StatesMan) Run() {
-	for eventData := range sm.EventChannel {
+	_ = sm.RunWithContext(context.Background())
+}
+
+// RunWithContext starts the state machine event loop and blocks until the context is cancelled,
+// Stop is called or EventChannel is closed.
+//
+// When the loop ends, all timed transition and state timeout timers are stopped and events
+// still queued in EventChannel are discarded, so a stopped machine never transitions on its own.
+// The machine keeps its current state and can be started again with Run or RunWithContext,
+// which re-arms the timers of the current state.
+// Timers armed before the first run, e.g. by SetInitialState or RestoreSnapshot, that came due
+// while the loop was not running fire when it starts.
+//
+// Returns:
+//   - error: ErrStatesManRunning if the loop is already running, the context error if the
+//     context was cancelled, nil after Stop or when EventChannel was closed
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	go func() {
+//		if err := sm.RunWithContext(ctx); err != nil && !errors.Is(err, context.Canceled) {
+//			L.Errorf("state machine stopped: %v", err)
+//		}
+//	}()
+//	defer cancel()
+func (sm *StatesMan) RunWithContext(ctx context.Context) error {
+	sm.mu.Lock()
+	if sm.running {
+		sm.mu.Unlock()
+		return ErrStatesManRunning
+	}
+	sm.running = true
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	sm.stopCh = stop
+	sm.doneCh = done
+	if sm.stopped {
+		sm.stopped = false
+		if sm.CurrentState != "" {
+			sm.armStateTimeout(sm.CurrentState)
+			sm.resetTimedTransitions(sm.CurrentState)
+		}
+	} else if sm.CurrentState != "" {
+		sm.rearmDueTimers()
+	}
+	sm.mu.Unlock()
+
+	defer func() {
 		sm.mu.Lock()
-		sm.handleEvent(eventData)
+		sm.shutdown()
+		sm.mu.Unlock()
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		case eventData, ok := <-sm.EventChannel:
+			if !ok {
+				return nil
+			}
+			sm.mu.Lock()
+			err := sm.handleEvent(eventData)
+			emitter := sm.errorEmitter
+			sm.mu.Unlock()
+			if err != nil {
+				sm.reportError(emitter, err)
+			}
+		}
+	}
+}
+
+// rearmDueTimers re-arms the timers of the current state that came due before the event loop
+// started, e.g. after SetInitialState or RestoreSnapshot, so they fire right away. Their
+// firings were dropped because there was no loop to deliver them to. The caller must hold sm.mu.
+func (sm *StatesMan) rearmDueTimers() {
+	now := sm.now()
+	if sm.stateTimer != nil && !sm.stateDeadline.After(now) {
+		if timeout, ok := sm.stateTimeouts[sm.CurrentState]; ok {
+			sm.stateTimerSeq++
+			sm.stateTimer.Stop()
+			sm.startStateTimer(sm.CurrentState, timeout, 0)
+		}
+	}
+	for i := range sm.TimedTransitions {
+		tt := &sm.TimedTransitions[i]
+		if tt.timer != nil && tt.From == sm.CurrentState && !tt.deadline.After(now) {
+			tt.timer.Stop()
+			sm.armTimedTransition(i, 0)
+		}
+	}
+}
+
+// Stop stops the event loop and waits until it has exited. An event that is being handled
+// is completed first; events still queued are discarded (see RunWithContext).
+// Stop is a no-op if the loop is not running. It must not be called from actions or hooks.
+func (sm *StatesMan) Stop() {
+	sm.mu.Lock()
+	if !sm.running {
 		sm.mu.Unlock()
+		return
+	}
+	if sm.stopCh != nil {
+		close(sm.stopCh)
+		sm.stopCh = nil
+	}
+	done := sm.doneCh
+	sm.mu.Unlock()
+	<-done
+}
+
+// shutdown stops all timers and discards queued events. The caller must hold sm.mu.
+func (sm *StatesMan) shutdown() {
+	sm.running = false
+	sm.stopped = true
+	sm.stopCh = nil
+
+	sm.stateTimerSeq++
+	if sm.stateTimer != nil {
+		sm.stateTimer.Stop()
+		sm.stateTimer = nil
+	}
+	for i := range sm.TimedTransitions {
+		if tt := &sm.TimedTransitions[i]; tt.timer != nil {
+			tt.timer.Stop()
+			tt.timer = nil
+		}
+	}
+
+	for {
+		select {
+		case _, ok := <-sm.EventChannel:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
 	}
 }
 
 // handleEvent processes an incoming event and executes the appropriate transition.
-func (sm *StatesMan) handleEvent(eventData EventData) {
+// It returns ErrUnhandledEvent if the current state has no transition for the event.
+func (sm *StatesMan) handleEvent(eventData EventData) error {
+	if eventData.timed != nil {
+		sm.handleTimedTransition(*eventData.timed)
+		return nil
+	}
+	// A state timeout queued behind an event that left (or re-entered) the state is stale
+	if t := eventData.timeout; t != nil && (t.seq != sm.stateTimerSeq || t.state != sm.CurrentState) {
+		return nil
+	}
+
 	currentState := sm.States[sm.CurrentState]
 	event := eventData.EventID
 	context := sm.Context
 
-	// Merge event data into context
-	for k, v := range eventData.Data {
-		context[k] = v
+	// Merge event data into context unless event data is isolated
+	if !sm.isolateEventData {
+		for k, v := range eventData.Data {
+			context[k] = v
+		}
 	}
 
-	// Process transitions - if not found, no transition is executed - we might want to handle this case in a newer version
-	// foundTransition := false
-	for _, t := range sm.Transitions {
-		if (t.From == sm.CurrentState || t.From == AnyState) && t.Event == event {
-			if t.Condition == nil || t.Condition(context) {
-				sm.executeTransition(currentState, sm.States[t.To], t.Actions)
-				// foundTransition = true
-				break
+	// Transitions of the current state (and AnyState) are checked first, then those of its
+	// parent states from the innermost outwards.
+	from := sm.CurrentState
+	rejected, taken := false, false
+levels:
+	for _, level := range sm.stateChain(sm.CurrentState) {
+		for _, t := range sm.Transitions {
+			if t.Event != event || (t.From != level && (t.From != AnyState || level != sm.CurrentState)) {
+				continue
 			}
+			if (t.Condition == nil || t.Condition(context)) &&
+				(t.EventCondition == nil || t.EventCondition(context, eventData.Data)) &&
+				sm.runGuard(t.GuardName, context, eventData.Data) {
+				sm.executeTransition(currentState, sm.States[t.To], t, transitionCause{data: eventData.Data, rejected: rejected})
+				taken = true
+				break levels
+			}
+			rejected = true
 		}
 	}
-	// if !foundTransition {
-	// 	// Optionally handle no matching transition
-
-	// }
 	sm.checkTimedTransitions()
+	if !taken && !rejected {
+		return fmt.Errorf("%w %q in state %q", ErrUnhandledEvent, event, from)
+	}
+	return nil
+}
+
+// transitionCause describes what led to a transition, for subscribers and the history.
+type transitionCause struct {
+	data     map[string]interface{} // event data, nil for timed transitions
+	timed    bool                   // caused by a timed transition
+	rejected bool                   // a condition or guard rejected an earlier candidate transition
 }
 
 // executeTransition performs the transition between states.
-func (sm *StatesMan) executeTransition(from, to *State, actions []SMAction) {
+func (sm *StatesMan) executeTransition(from, to *State, t Transition, cause transitionCause) {
 	context := sm.Context
 
 	// Execute pre-hooks
@@ -193,24 +709,45 @@ func (sm *StatesMan) executeTransition(from, to *State, actions []SMAction) {
 		hook(context)
 	}
 
-	// Execute exit actions of the current state
-	for _, action := range from.ExitActions {
-		action(context)
+	exits, entries := sm.transitionPath(from, to)
+
+	// Execute exit actions of the current state and every parent state that is left, innermost first
+	for _, state := range exits {
+		for _, action := range state.ExitActions {
+			action(context)
+		}
+		for _, action := range state.EventExitActions {
+			action(context, cause.data)
+		}
+		sm.runActions(state.ExitActionNames, context, cause.data)
 	}
 
 	// Execute transition actions
-	for _, action := range actions {
+	for _, action := range t.Actions {
 		action(context)
 	}
+	for _, action := range t.EventActions {
+		action(context, cause.data)
+	}
+	sm.runActions(t.ActionNames, context, cause.data)
 
 	// Update current state
 	sm.CurrentState = to.ID
 
-	// Execute entry actions of the new state
-	for _, action := range to.EntryActions {
-		action(context)
+	// Execute entry actions of every parent state that is entered and the new state, outermost first
+	for _, state := range entries {
+		for _, action := range state.EntryActions {
+			action(context)
+		}
+		for _, action := range state.EventEntryActions {
+			action(context, cause.data)
+		}
+		sm.runActions(state.EntryActionNames, context, cause.data)
 	}
 
+	// Re-arm the state timeout for the new state
+	sm.armStateTimeout(to.ID)
+
 	// Execute post-hooks
 	for _, hook := range sm.PostHooks {
 		hook(context)
@@ -218,6 +755,12 @@ func (sm *StatesMan) executeTransition(from, to *State, actions []SMAction) {
 
 	// Reset and start timed transitions for the new state
 	sm.resetTimedTransitions(to.ID)
+
+	if sm.sim != nil {
+		sm.sim.taken = &t
+	}
+	sm.recordHistory(from.ID, to.ID, t.Event, cause)
+	sm.notifySubscribers(from.ID, to.ID, t.Event, cause.data)
 }
 
 // checkTimedTransitions initializes timers for timed transitions from the current state.
@@ -225,11 +768,7 @@ func (sm *StatesMan) checkTimedTransitions() {
 	for i := range sm.TimedTransitions {
 		tt := &sm.TimedTransitions[i]
 		if tt.From == sm.CurrentState && tt.timer == nil {
-			tt.timer = time.AfterFunc(tt.Duration, func() {
-				sm.mu.Lock()
-				defer sm.mu.Unlock()
-				sm.executeTransition(sm.States[tt.From], sm.States[tt.To], tt.Actions)
-			})
+			sm.armTimedTransition(i, tt.Duration)
 		}
 	}
 }
@@ -243,15 +782,56 @@ func (sm *StatesMan) resetTimedTransitions(stateID StateID) {
 			tt.timer = nil
 		}
 		if tt.From == stateID {
-			tt.timer = time.AfterFunc(tt.Duration, func() {
-				sm.mu.Lock()
-				defer sm.mu.Unlock()
-				sm.executeTransition(sm.States[tt.From], sm.States[tt.To], tt.Actions)
-			})
+			sm.armTimedTransition(i, tt.Duration)
 		}
 	}
 }
 
+// armTimedTransition starts the timer of a timed transition to fire after d. When it fires, the timer does not
+// transition by itself but queues the firing on EventChannel, so timed transitions are
+// serialized with events and checked against the state at the time they are handled.
+// Firings while the machine is not running are dropped and re-armed by RunWithContext.
+// The caller must hold sm.mu.
+func (sm *StatesMan) armTimedTransition(index int, d time.Duration) {
+	tt := &sm.TimedTransitions[index]
+	sm.timedSeq++
+	tt.seq = sm.timedSeq
+	tt.deadline = sm.now().Add(d)
+	if sm.sim != nil {
+		tt.timer = sm.sim.timer // fired by Simulate on the virtual clock
+		return
+	}
+	firing := &timedFiring{index: index, seq: tt.seq}
+	tt.timer = time.AfterFunc(d, func() {
+		sm.mu.RLock()
+		stop := sm.stopCh
+		sm.mu.RUnlock()
+		// When the machine is not running, RunWithContext re-arms the due timer when it starts
+		if stop == nil {
+			return
+		}
+		select {
+		case sm.EventChannel <- EventData{timed: firing}:
+		case <-stop:
+		}
+	})
+}
+
+// handleTimedTransition executes a timed transition whose timer fired, unless the timer was
+// stopped or re-armed meanwhile or the machine already left the transition's source state.
+// The caller must hold sm.mu.
+func (sm *StatesMan) handleTimedTransition(firing timedFiring) {
+	if firing.index >= len(sm.TimedTransitions) {
+		return
+	}
+	tt := &sm.TimedTransitions[firing.index]
+	if tt.timer == nil || tt.seq != firing.seq || sm.CurrentState != tt.From {
+		return
+	}
+	tt.timer = nil
+	sm.executeTransition(sm.States[tt.From], sm.States[tt.To], tt.Transition, transitionCause{timed: true})
+}
+
 // GetCurrentState returns the current state of the state machine.
 func (sm *StatesMan) GetCurrentState() StateID {
 	sm.mu.RLock()
@@ -259,18 +839,30 @@ func (sm *StatesMan) GetCurrentState() StateID {
 	return sm.CurrentState
 }
 
-// Export exports the state machine configuration to JSON.
-func (sm *StatesMan) Export() (string, error) {
+// Export exports the state machine configuration to JSON, including timed transitions.
+// Use ExportSnapshot for the runtime state and WithExportHistory to include the transition history.
+func (sm *StatesMan) Export(opts ...ExportOption) (string, error) {
+	config := &exportConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 	export := struct {
-		Name        string
-		States      map[StateID]*State
-		Transitions []Transition
+		Name             string
+		States           map[StateID]*State
+		Transitions      []Transition
+		TimedTransitions []TimedTransition  `json:",omitempty"`
+		History          []TransitionRecord `json:",omitempty"`
 	}{
-		Name:        sm.Name,
-		States:      sm.States,
-		Transitions: sm.Transitions,
+		Name:             sm.Name,
+		States:           sm.States,
+		Transitions:      sm.Transitions,
+		TimedTransitions: sm.TimedTransitions,
+	}
+	if config.history {
+		export.History = sm.historySnapshot()
 	}
 	data, err := json.MarshalIndent(export, "", "  ")
 	if err != nil {
@@ -279,32 +871,57 @@ func (sm *StatesMan) Export() (string, error) {
 	return string(data), nil
 }
 
-// Import imports the state machine configuration from JSON.
+// Import imports the state machine configuration from JSON. Timed transitions are only
+// replaced if the JSON contains some.
+// Actions and guards are rebound by name from the registry; the import fails with
+// ErrInvalidStateMachine if one is not registered or lacks a dependency.
 func (sm *StatesMan) Import(jsonStr string) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	var imp struct {
-		Name        string
-		States      map[StateID]*State
-		Transitions []Transition
+		Name             string
+		States           map[StateID]*State
+		Transitions      []Transition
+		TimedTransitions []TimedTransition
 	}
 	err := json.Unmarshal([]byte(jsonStr), &imp)
 	if err != nil {
 		return err
 	}
+	all := imp.Transitions
+	for _, tt := range imp.TimedTransitions {
+		all = append(all, tt.Transition)
+	}
+	if err := sm.validate(imp.States, all); err != nil {
+		return err
+	}
 	sm.Name = imp.Name
 	sm.States = imp.States
 	sm.Transitions = imp.Transitions
+	if imp.TimedTransitions != nil {
+		for i := range sm.TimedTransitions {
+			if tt := &sm.TimedTransitions[i]; tt.timer != nil {
+				tt.timer.Stop()
+				tt.timer = nil
+			}
+		}
+		sm.TimedTransitions = imp.TimedTransitions
+		if sm.CurrentState != "" && !sm.stopped {
+			sm.checkTimedTransitions()
+		}
+	}
 	return nil
 }
 
 // GenerateDOT generates a DOT representation of the state machine for visualization.
+// Parent states are rendered as clusters containing their substates.
 func (sm *StatesMan) GenerateDOT() string {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 	dot := "digraph " + sm.Name + " {\n"
-	for _, state := range sm.States {
-		dot += fmt.Sprintf("  %s [label=\"%s\"];\n", state.ID, state.Name)
+	children := sm.childStates()
+	for _, id := range children[""] {
+		dot += sm.dotState(id, children, "  ")
 	}
 	for _, t := range sm.Transitions {
 		dot += fmt.Sprintf("  %s -> %s [label=\"%s\"];\n", t.From, t.To, t.Event)
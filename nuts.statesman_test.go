@@ -0,0 +1,301 @@
+package gonuts
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTimedTestMachine returns a machine in state A with a timed transition A -> B after d,
+// a self-transition A -> A on "poke" (which re-arms the timed transition) and A -> C on "leave"
+func newTimedTestMachine(t *testing.T, d time.Duration) *StatesMan {
+	t.Helper()
+	sm := NewStatesMan("test")
+	sm.SetErrorEmitter(NewEventEmitter())
+	for _, id := range []StateID{"A", "B", "C"} {
+		sm.AddState(id, string(id), nil, nil)
+	}
+	sm.AddTimedTransition("A", "B", d)
+	sm.AddTransition("A", "A", "poke", nil)
+	sm.AddTransition("A", "C", "leave", nil)
+	if err := sm.SetInitialState("A"); err != nil {
+		t.Fatal(err)
+	}
+	return sm
+}
+
+// runTestMachine runs the event loop until the test ends
+func runTestMachine(t *testing.T, sm *StatesMan) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.Run()
+	}()
+	t.Cleanup(func() {
+		sm.Stop()
+		<-done
+	})
+}
+
+func waitForState(t *testing.T, sm *StatesMan, want StateID) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for sm.GetCurrentState() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", sm.GetCurrentState(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTimedTransitionStaleFiringDropped(t *testing.T) {
+	sm := newTimedTestMachine(t, time.Hour)
+	snapshot, err := sm.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RestoreSnapshot(snapshot); err != nil { // arms the timed transition
+		t.Fatal(err)
+	}
+
+	sm.mu.Lock()
+	stale := timedFiring{index: 0, seq: sm.TimedTransitions[0].seq}
+	sm.resetTimedTransitions("A")
+	fresh := timedFiring{index: 0, seq: sm.TimedTransitions[0].seq}
+	sm.mu.Unlock()
+	if stale.seq == fresh.seq {
+		t.Fatal("re-arming did not change the timer sequence")
+	}
+
+	events, sub := sm.Subscribe(4)
+	defer sub.Unsubscribe()
+	runTestMachine(t, sm)
+
+	// The firing of the replaced timer arrives after re-arming, as if its timer had already fired
+	sm.EventChannel <- EventData{timed: &stale}
+	sm.TriggerEvent("leave", nil)
+	if e := <-events; e.From != "A" || e.To != "C" {
+		t.Fatalf("first transition %s -> %s, want A -> C: the stale firing was not dropped", e.From, e.To)
+	}
+}
+
+func TestTimedTransitionRearmedWhileRunning(t *testing.T) {
+	const duration = 200 * time.Millisecond
+	sm := newTimedTestMachine(t, duration)
+	events, sub := sm.Subscribe(1024)
+	defer sub.Unsubscribe()
+	runTestMachine(t, sm)
+
+	// Every poke re-arms the timer, so replaced timers that already fired must be dropped
+	for start := time.Now(); time.Since(start) < duration+100*time.Millisecond; {
+		sm.TriggerEvent("poke", nil)
+		time.Sleep(time.Millisecond)
+	}
+	for len(events) > 0 {
+		if e := <-events; e.To != "A" {
+			t.Fatalf("transition %s -> %s while the timer was re-armed", e.From, e.To)
+		}
+	}
+
+	waitForState(t, sm, "B")
+}
+
+func TestTimersArmedBeforeRunDoNotBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		arm  func(t *testing.T, sm *StatesMan)
+		want StateID
+	}{
+		{"timed transition", func(t *testing.T, sm *StatesMan) {
+			snapshot, err := sm.ExportSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sm.RestoreSnapshot(snapshot); err != nil {
+				t.Fatal(err)
+			}
+		}, "B"},
+		{"state timeout", func(t *testing.T, sm *StatesMan) {
+			sm.SetStateTimeout("A", 5*time.Millisecond, "leave")
+		}, "C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTimedTestMachine(t, 5*time.Millisecond)
+			if tt.want != "B" {
+				sm.TimedTransitions = nil
+			}
+			for len(sm.EventChannel) < cap(sm.EventChannel) {
+				sm.TriggerEvent("poke", nil)
+			}
+			goroutines := runtime.NumGoroutine()
+			tt.arm(t, sm)
+
+			// The timer fires while the machine is not running and EventChannel is full;
+			// its goroutine must not wait for a loop that may never run
+			time.Sleep(50 * time.Millisecond)
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > goroutines {
+				if time.Now().After(deadline) {
+					t.Fatalf("%d goroutines, want %d: the timer goroutine is blocked", runtime.NumGoroutine(), goroutines)
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			// The due timer fires once the machine runs
+			runTestMachine(t, sm)
+			waitForState(t, sm, tt.want)
+		})
+	}
+}
+
+// newTimeoutTestMachine returns a machine in state A with a state timeout on A and B that
+// moves to Failed, a self-transition A -> A on "poke" and A -> B on "leave"
+func newTimeoutTestMachine(t *testing.T, d time.Duration) *StatesMan {
+	t.Helper()
+	sm := NewStatesMan("test")
+	sm.SetErrorEmitter(NewEventEmitter())
+	for _, id := range []StateID{"A", "B", "Failed"} {
+		sm.AddState(id, string(id), nil, nil)
+	}
+	sm.AddTransition("A", "A", "poke", nil)
+	sm.AddTransition("A", "B", "leave", nil)
+	sm.AddTransition(AnyState, "Failed", "timeout", nil)
+	if err := sm.SetInitialState("A"); err != nil {
+		t.Fatal(err)
+	}
+	sm.SetStateTimeout("A", d, "timeout")
+	return sm
+}
+
+func TestStateTimeoutQueuedBehindLeavingEventDropped(t *testing.T) {
+	sm := newTimeoutTestMachine(t, time.Hour)
+	sm.mu.RLock()
+	stale := timeoutFiring{state: "A", seq: sm.stateTimerSeq}
+	sm.mu.RUnlock()
+
+	events, sub := sm.Subscribe(4)
+	defer sub.Unsubscribe()
+	runTestMachine(t, sm)
+
+	// The timeout of A fires while "leave" is still queued
+	sm.TriggerEvent("leave", nil)
+	sm.EventChannel <- EventData{EventID: "timeout", timeout: &stale}
+	sm.TriggerEvent("timeout", nil) // an explicit timeout event is not affected
+	if e := <-events; e.From != "A" || e.To != "B" {
+		t.Fatalf("first transition %s -> %s, want A -> B", e.From, e.To)
+	}
+	if e := <-events; e.From != "B" || e.To != "Failed" || len(events) != 0 {
+		t.Fatalf("second transition %s -> %s, want B -> Failed from the explicit event only", e.From, e.To)
+	}
+}
+
+func TestStateTimeoutResetOnReentry(t *testing.T) {
+	const timeout = 150 * time.Millisecond
+	sm := newTimeoutTestMachine(t, timeout)
+	runTestMachine(t, sm)
+
+	// Every self-transition re-enters A and resets the timer, so the timeout never fires
+	start := time.Now()
+	for time.Since(start) < 2*timeout {
+		sm.TriggerEvent("poke", nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state := sm.GetCurrentState(); state != "A" {
+		t.Fatalf("state = %s after re-entering A for %s, want A", state, 2*timeout)
+	}
+
+	// Once the pokes stop, the timeout fires one duration after the last re-entry
+	waitForState(t, sm, "Failed")
+	if elapsed := time.Since(start); elapsed < 2*timeout+timeout-20*time.Millisecond {
+		t.Errorf("timeout fired after %s, want it reset by the last re-entry", elapsed)
+	}
+}
+
+func TestSubscribeBlockedSubscriberDoesNotBlockTransitions(t *testing.T) {
+	const events = 100
+	sm := newTimedTestMachine(t, time.Hour)
+	_, blocked := sm.Subscribe(1) // never read
+	healthy, sub := sm.Subscribe(events)
+	defer sub.Unsubscribe()
+	runTestMachine(t, sm)
+
+	for i := 0; i < events; i++ {
+		sm.TriggerEvent("poke", nil)
+	}
+	timeout := time.After(2 * time.Second)
+	for i := 0; i < events; i++ {
+		select {
+		case e := <-healthy:
+			if e.From != "A" || e.To != "A" || e.Event != "poke" {
+				t.Fatalf("notification %d = %+v, want A -> A on poke", i, e)
+			}
+		case <-timeout:
+			t.Fatalf("%d of %d notifications delivered: transitions are blocked", i, events)
+		}
+	}
+	if n := blocked.Dropped(); n != events-1 {
+		t.Errorf("blocked subscriber dropped %d notifications, want %d", n, events-1)
+	}
+	if n := sub.Dropped(); n != 0 {
+		t.Errorf("healthy subscriber dropped %d notifications, want 0", n)
+	}
+
+	// After Unsubscribe nothing is delivered or counted any more
+	blocked.Unsubscribe()
+	sm.TriggerEvent("poke", nil)
+	<-healthy
+	if n := blocked.Dropped(); n != events-1 {
+		t.Errorf("unsubscribed subscriber dropped %d notifications, want still %d", n, events-1)
+	}
+}
+
+// TestTimedTransitionsFireWhileHandlingEvents is a regression test for -race: timed transitions
+// keep firing while several goroutines trigger events and read the machine
+func TestTimedTransitionsFireWhileHandlingEvents(t *testing.T) {
+	sm := NewStatesMan("test")
+	sm.SetErrorEmitter(NewEventEmitter())
+	for _, id := range []StateID{"A", "B"} {
+		sm.AddState(id, string(id), nil, nil)
+		sm.AddTransition(id, id, "poke", nil)
+	}
+	sm.AddTimedTransition("A", "B", time.Millisecond)
+	sm.AddTimedTransition("B", "A", time.Millisecond)
+	if err := sm.SetInitialState("A"); err != nil {
+		t.Fatal(err)
+	}
+	events, sub := sm.Subscribe(1 << 16)
+	defer sub.Unsubscribe()
+	runTestMachine(t, sm)
+
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				sm.TriggerEvent("poke", map[string]interface{}{"at": time.Now()})
+				_ = sm.GetCurrentState()
+				_ = sm.History()
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+	sm.Stop()
+
+	timed, pokes := 0, 0
+	for len(events) > 0 {
+		if e := <-events; e.Event == "" {
+			timed++
+		} else {
+			pokes++
+		}
+	}
+	if timed == 0 || pokes == 0 {
+		t.Errorf("%d timed transitions and %d pokes, want both interleaved", timed, pokes)
+	}
+}
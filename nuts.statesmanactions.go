@@ -0,0 +1,227 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidStateMachine is returned when a state machine references unknown actions, guards
+// or parent states, or when dependencies are missing.
+var ErrInvalidStateMachine = errors.New("invalid state machine")
+
+// ActionScope is passed to registered actions and guards. It carries the machine context,
+// the data of the event being handled and the dependencies the action declared at registration.
+type ActionScope struct {
+	Context      map[string]interface{}
+	EventData    map[string]interface{} // nil for timed transitions
+	dependencies map[string]interface{}
+}
+
+// Dependency returns the dependency registered under name via SetDependency.
+// Only dependencies declared at registration are available.
+//
+// Example:
+//
+//	mailer := scope.Dependency("mailer").(*Mailer)
+func (s ActionScope) Dependency(name string) interface{} {
+	return s.dependencies[name]
+}
+
+// ScopedAction is a registered action that receives its dependencies through an ActionScope.
+type ScopedAction func(scope ActionScope)
+
+// ScopedCondition is a registered guard that receives its dependencies through an ActionScope.
+type ScopedCondition func(scope ActionScope) bool
+
+type registeredAction struct {
+	fn           ScopedAction
+	dependencies []string
+}
+
+type registeredGuard struct {
+	fn           ScopedCondition
+	dependencies []string
+}
+
+// SetDependency registers a value in the machine's dependency container.
+//
+// Example:
+//
+//	sm.SetDependency("mailer", mailer)
+func (sm *StatesMan) SetDependency(name string, value interface{}) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.dependencies[name] = value
+}
+
+// RegisterAction registers a named action and the names of the dependencies it needs.
+// Named actions are referenced by State.EntryActionNames, State.ExitActionNames and
+// Transition.ActionNames, so they survive Export and Import.
+//
+// Example:
+//
+//	sm.RegisterAction("sendWelcomeMail", func(scope gonuts.ActionScope) {
+//		mailer := scope.Dependency("mailer").(*Mailer)
+//		mailer.Send(scope.Context["email"].(string))
+//	}, "mailer")
+func (sm *StatesMan) RegisterAction(name string, action ScopedAction, dependencies ...string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.actions[name] = registeredAction{fn: action, dependencies: dependencies}
+}
+
+// RegisterGuard registers a named guard and the names of the dependencies it needs.
+// Named guards are referenced by Transition.GuardName.
+func (sm *StatesMan) RegisterGuard(name string, guard ScopedCondition, dependencies ...string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.guards[name] = registeredGuard{fn: guard, dependencies: dependencies}
+}
+
+// AddNamedState adds a new state whose entry and exit actions are registered action names.
+func (sm *StatesMan) AddNamedState(id StateID, name string, entryActions, exitActions []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.States[id] = &State{
+		ID:               id,
+		Name:             name,
+		EntryActionNames: entryActions,
+		ExitActionNames:  exitActions,
+	}
+}
+
+// AddNamedTransition adds a new transition that uses a registered guard (empty for none)
+// and registered actions.
+//
+// Example:
+//
+//	sm.AddNamedTransition("Registered", "Active", "Confirm", "hasValidToken", "sendWelcomeMail")
+func (sm *StatesMan) AddNamedTransition(from, to StateID, event EventID, guard string, actions ...string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.Transitions = append(sm.Transitions, Transition{
+		From:        from,
+		To:          to,
+		Event:       event,
+		GuardName:   guard,
+		ActionNames: actions,
+	})
+}
+
+// Validate checks that all referenced actions and guards are registered, that all
+// their declared dependencies are set and that all parent states exist without cycles.
+//
+// Returns:
+//   - error: ErrInvalidStateMachine listing every unknown action or guard, missing dependency and invalid parent
+func (sm *StatesMan) Validate() error {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.validate(sm.States, sm.Transitions)
+}
+
+// validate checks the given states and transitions against the registry. The caller must hold sm.mu.
+func (sm *StatesMan) validate(states map[StateID]*State, transitions []Transition) error {
+	problems := make(map[string]bool)
+	checkAction := func(name string) {
+		action, ok := sm.actions[name]
+		if !ok {
+			problems[fmt.Sprintf("action %q is not registered", name)] = true
+			return
+		}
+		for _, dep := range action.dependencies {
+			if _, ok := sm.dependencies[dep]; !ok {
+				problems[fmt.Sprintf("action %q: missing dependency %q", name, dep)] = true
+			}
+		}
+	}
+
+	for id, state := range states {
+		if state.Parent != "" {
+			if _, ok := states[state.Parent]; !ok {
+				problems[fmt.Sprintf("state %q: parent %q does not exist", id, state.Parent)] = true
+			} else if hasParentCycle(states, id) {
+				problems[fmt.Sprintf("state %q: parent cycle", id)] = true
+			}
+		}
+		for _, name := range state.EntryActionNames {
+			checkAction(name)
+		}
+		for _, name := range state.ExitActionNames {
+			checkAction(name)
+		}
+	}
+	for _, t := range transitions {
+		for _, name := range t.ActionNames {
+			checkAction(name)
+		}
+		if t.GuardName == "" {
+			continue
+		}
+		guard, ok := sm.guards[t.GuardName]
+		if !ok {
+			problems[fmt.Sprintf("guard %q is not registered", t.GuardName)] = true
+			continue
+		}
+		for _, dep := range guard.dependencies {
+			if _, ok := sm.dependencies[dep]; !ok {
+				problems[fmt.Sprintf("guard %q: missing dependency %q", t.GuardName, dep)] = true
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(problems))
+	for problem := range problems {
+		list = append(list, problem)
+	}
+	sort.Strings(list)
+	return fmt.Errorf("%w: %s", ErrInvalidStateMachine, strings.Join(list, "; "))
+}
+
+// hasParentCycle reports whether following the parents of a state leads back to a state already visited
+func hasParentCycle(states map[StateID]*State, id StateID) bool {
+	seen := map[StateID]bool{id: true}
+	for state := states[id]; state != nil && state.Parent != ""; state = states[state.Parent] {
+		if seen[state.Parent] {
+			return true
+		}
+		seen[state.Parent] = true
+	}
+	return false
+}
+
+// scope builds the ActionScope for the given declared dependencies. The caller must hold sm.mu.
+func (sm *StatesMan) scope(context, eventData map[string]interface{}, dependencies []string) ActionScope {
+	deps := make(map[string]interface{}, len(dependencies))
+	for _, name := range dependencies {
+		deps[name] = sm.dependencies[name]
+	}
+	return ActionScope{Context: context, EventData: eventData, dependencies: deps}
+}
+
+// runActions executes registered actions by name. Unknown names are skipped; use Validate
+// to detect them up front. The caller must hold sm.mu.
+func (sm *StatesMan) runActions(names []string, context, eventData map[string]interface{}) {
+	for _, name := range names {
+		if action, ok := sm.actions[name]; ok {
+			action.fn(sm.scope(context, eventData, action.dependencies))
+		}
+	}
+}
+
+// runGuard evaluates a registered guard by name. An empty name always passes and an
+// unknown guard never does. The caller must hold sm.mu.
+func (sm *StatesMan) runGuard(name string, context, eventData map[string]interface{}) bool {
+	if name == "" {
+		return true
+	}
+	guard, ok := sm.guards[name]
+	if !ok {
+		return false
+	}
+	return guard.fn(sm.scope(context, eventData, guard.dependencies))
+}
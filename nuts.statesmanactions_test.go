@@ -0,0 +1,115 @@
+package gonuts
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testMailer struct{ sent []string }
+
+func (m *testMailer) Send(to string) { m.sent = append(m.sent, to) }
+
+// registerRegistrationActions registers the guard and the action of the AddNamedTransition
+// example, which get their dependencies injected, and the dependencies
+func registerRegistrationActions(t *testing.T, sm *StatesMan, mailer *testMailer) {
+	sm.SetDependency("mailer", mailer)
+	sm.SetDependency("tokens", map[string]bool{"valid": true})
+	sm.RegisterGuard("hasValidToken", func(scope ActionScope) bool {
+		tokens := scope.Dependency("tokens").(map[string]bool)
+		token, _ := scope.EventData["token"].(string)
+		return tokens[token]
+	}, "tokens")
+	sm.RegisterAction("sendWelcomeMail", func(scope ActionScope) {
+		if scope.Dependency("tokens") != nil {
+			t.Error("the action got the undeclared dependency tokens")
+		}
+		scope.Dependency("mailer").(*testMailer).Send(scope.Context["email"].(string))
+	}, "mailer")
+}
+
+// newRegistrationTestMachine returns the machine of the AddNamedTransition example
+func newRegistrationTestMachine(t *testing.T) (*StatesMan, *testMailer) {
+	t.Helper()
+	mailer := &testMailer{}
+	sm := NewStatesMan("registration")
+	sm.SetErrorEmitter(NewEventEmitter())
+	registerRegistrationActions(t, sm, mailer)
+	sm.AddNamedState("Registered", "Registered", nil, nil)
+	sm.AddNamedState("Active", "Active", []string{"sendWelcomeMail"}, nil)
+	sm.AddNamedTransition("Registered", "Active", "Confirm", "hasValidToken")
+	if err := sm.SetInitialState("Registered"); err != nil {
+		t.Fatal(err)
+	}
+	sm.SetContextValue("email", "ada@example.com")
+	return sm, mailer
+}
+
+func TestNamedGuardAndActionGetDependencies(t *testing.T) {
+	sm, mailer := newRegistrationTestMachine(t)
+	if err := sm.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The guard reads the injected tokens and the event data
+	_ = handleTestEvent(sm, "Confirm", map[string]interface{}{"token": "forged"})
+	if state := sm.GetCurrentState(); state != "Registered" || len(mailer.sent) != 0 {
+		t.Fatalf("state %s with mails %v, want the guard to reject the forged token", state, mailer.sent)
+	}
+	_ = handleTestEvent(sm, "Confirm", map[string]interface{}{"token": "valid"})
+	if state := sm.GetCurrentState(); state != "Active" {
+		t.Fatalf("state = %s, want Active", state)
+	}
+	// The entry action used the injected mailer
+	if want := []string{"ada@example.com"}; !slices.Equal(mailer.sent, want) {
+		t.Errorf("sent mails = %v, want %v", mailer.sent, want)
+	}
+}
+
+func TestNamedActionsSurviveExportImport(t *testing.T) {
+	sm, mailer := newRegistrationTestMachine(t)
+	exported, err := sm.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry and dependencies stay with the machine, the definition comes from JSON
+	imported := NewStatesMan("registration")
+	registerRegistrationActions(t, imported, mailer)
+	if err := imported.Import(exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := imported.SetInitialState("Registered"); err != nil {
+		t.Fatal(err)
+	}
+	imported.SetContextValue("email", "ada@example.com")
+	_ = handleTestEvent(imported, "Confirm", map[string]interface{}{"token": "valid"})
+	if state := imported.GetCurrentState(); state != "Active" || len(mailer.sent) != 1 {
+		t.Errorf("state %s with mails %v, want Active and one mail", state, mailer.sent)
+	}
+}
+
+func TestValidateReportsMissingDependencies(t *testing.T) {
+	sm := NewStatesMan("registration")
+	sm.RegisterAction("sendWelcomeMail", func(ActionScope) {}, "mailer")
+	sm.RegisterGuard("hasValidToken", func(ActionScope) bool { return true }, "tokens")
+	sm.AddNamedState("Registered", "Registered", nil, []string{"sendWelcomeMail", "audit"})
+	sm.AddNamedTransition("Registered", "Registered", "Confirm", "hasValidToken")
+	sm.AddNamedTransition("Registered", "Registered", "Reject", "isAdmin")
+
+	err := sm.Validate()
+	if !errors.Is(err, ErrInvalidStateMachine) {
+		t.Fatalf("Validate() = %v, want ErrInvalidStateMachine", err)
+	}
+	for _, problem := range []string{
+		`action "audit" is not registered`,
+		`action "sendWelcomeMail": missing dependency "mailer"`,
+		`guard "hasValidToken": missing dependency "tokens"`,
+		`guard "isAdmin" is not registered`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Validate() = %v, missing %q", err, problem)
+		}
+	}
+}
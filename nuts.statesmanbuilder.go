@@ -0,0 +1,298 @@
+package gonuts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MachineBuilder configures a StatesMan fluently and validates the whole configuration at once
+// in Build. It is the recommended way to define machines; AddState, AddTransition and the other
+// methods of StatesMan remain available, e.g. to extend a built machine.
+//
+// State and Transition start a new definition; the methods of StateBuilder and
+// TransitionBuilder configure it, and State, Transition and Build continue with the next one.
+//
+// Example:
+//
+//	sm, err := gonuts.NewMachineBuilder("JobProcessor").
+//		State("Idle").Name("Idle State").Initial().
+//		State("Processing").Name("Processing State").OnEntry(startJob).
+//		State("Completed").
+//		State("Failed").
+//		Transition().From("Idle").To("Processing").On("StartProcessing").
+//		Transition().From("Processing").To("Completed").On("Success").Do(notify).
+//		Transition().From("Processing").To("Failed").On("Failure").
+//		Transition().From("Processing").To("Failed").After(time.Minute).
+//		Transition().From(gonuts.AnyState).To("Idle").On("Reset").
+//		Build()
+type MachineBuilder struct {
+	name        string
+	states      []*StateBuilder
+	transitions []*TransitionBuilder
+	initial     []StateID
+}
+
+// StateBuilder configures a state of a MachineBuilder
+type StateBuilder struct {
+	mb     *MachineBuilder
+	id     StateID
+	name   string
+	entry  []SMAction
+	exit   []SMAction
+	parent StateID
+}
+
+// TransitionBuilder configures a transition of a MachineBuilder. A transition is triggered
+// either by an event (On) or after a duration in the From state (After).
+type TransitionBuilder struct {
+	mb      *MachineBuilder
+	from    StateID
+	to      StateID
+	event   EventID
+	after   time.Duration
+	guard   SMCondition
+	actions []SMAction
+}
+
+// NewMachineBuilder creates a MachineBuilder for a machine with the given name
+func NewMachineBuilder(name string) *MachineBuilder {
+	return &MachineBuilder{name: name}
+}
+
+// State starts the definition of a state. Its name defaults to the ID.
+func (mb *MachineBuilder) State(id StateID) *StateBuilder {
+	sb := &StateBuilder{mb: mb, id: id, name: string(id)}
+	mb.states = append(mb.states, sb)
+	return sb
+}
+
+// Transition starts the definition of a transition
+func (mb *MachineBuilder) Transition() *TransitionBuilder {
+	tb := &TransitionBuilder{mb: mb}
+	mb.transitions = append(mb.transitions, tb)
+	return tb
+}
+
+// Initial sets the initial state, like StateBuilder.Initial
+func (mb *MachineBuilder) Initial(id StateID) *MachineBuilder {
+	mb.initial = append(mb.initial, id)
+	return mb
+}
+
+// Build validates the configuration and creates the machine. All problems are reported
+// together: states defined twice, unknown states referenced by transitions or as parents,
+// a missing or ambiguous initial state, transitions without a target or trigger, and
+// unguarded transitions for the same state and event, of which only the first could fire.
+//
+// Returns:
+//   - *StatesMan: the configured machine in its initial state, not yet running
+//   - error: ErrInvalidStateMachine listing every problem
+func (mb *MachineBuilder) Build() (*StatesMan, error) {
+	if problems := mb.validate(); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStateMachine, strings.Join(problems, "; "))
+	}
+
+	sm := NewStatesMan(mb.name)
+	for _, sb := range mb.states {
+		sm.AddState(sb.id, sb.name, sb.entry, sb.exit)
+	}
+	for _, sb := range mb.states {
+		sm.States[sb.id].Parent = sb.parent
+	}
+	for _, tb := range mb.transitions {
+		if tb.after > 0 {
+			sm.AddTimedTransition(tb.from, tb.to, tb.after, tb.actions...)
+		} else {
+			sm.AddTransition(tb.from, tb.to, tb.event, tb.guard, tb.actions...)
+		}
+	}
+	if err := sm.SetInitialState(mb.initial[0]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStateMachine, err)
+	}
+	return sm, nil
+}
+
+// validate returns the problems of the configuration in definition order
+func (mb *MachineBuilder) validate() []string {
+	var problems []string
+	states := make(map[StateID]*StateBuilder, len(mb.states))
+	for _, sb := range mb.states {
+		if sb.id == "" || sb.id == AnyState {
+			problems = append(problems, fmt.Sprintf("invalid state ID %q", sb.id))
+			continue
+		}
+		if _, exists := states[sb.id]; exists {
+			problems = append(problems, fmt.Sprintf("state %q is defined more than once", sb.id))
+			continue
+		}
+		states[sb.id] = sb
+	}
+	for _, sb := range mb.states {
+		if sb.parent == "" {
+			continue
+		}
+		if _, exists := states[sb.parent]; !exists {
+			problems = append(problems, fmt.Sprintf("state %q: parent %q does not exist", sb.id, sb.parent))
+		} else if sb.parent == sb.id {
+			problems = append(problems, fmt.Sprintf("state %q cannot be its own parent", sb.id))
+		}
+	}
+	if len(problems) == 0 {
+		parents := make(map[StateID]*State, len(states))
+		for id, sb := range states {
+			parents[id] = &State{ID: id, Parent: sb.parent}
+		}
+		for _, sb := range mb.states {
+			if hasParentCycle(parents, sb.id) {
+				problems = append(problems, fmt.Sprintf("state %q: parent cycle", sb.id))
+			}
+		}
+	}
+
+	switch {
+	case len(mb.initial) == 0:
+		problems = append(problems, "no initial state set")
+	case len(mb.initial) > 1:
+		problems = append(problems, fmt.Sprintf("initial state set more than once: %v", mb.initial))
+	default:
+		if _, exists := states[mb.initial[0]]; !exists {
+			problems = append(problems, fmt.Sprintf("initial state %q does not exist", mb.initial[0]))
+		}
+	}
+
+	type trigger struct {
+		from  StateID
+		event EventID
+	}
+	unguarded := make(map[trigger]int)
+	for i, tb := range mb.transitions {
+		name := fmt.Sprintf("transition %d (%s -> %s)", i+1, tb.from, tb.to)
+		if tb.from == "" {
+			problems = append(problems, name+": no source state (From)")
+		} else if _, exists := states[tb.from]; !exists && tb.from != AnyState {
+			problems = append(problems, fmt.Sprintf("%s: state %q does not exist", name, tb.from))
+		}
+		if tb.to == "" {
+			problems = append(problems, name+": no target state (To)")
+		} else if _, exists := states[tb.to]; !exists {
+			problems = append(problems, fmt.Sprintf("%s: state %q does not exist", name, tb.to))
+		}
+		switch {
+		case tb.event != "" && tb.after != 0:
+			problems = append(problems, name+": both an event (On) and a duration (After) set")
+		case tb.event == "" && tb.after <= 0:
+			problems = append(problems, name+": no event (On) or positive duration (After)")
+		case tb.after > 0 && tb.from == AnyState:
+			problems = append(problems, name+": timed transitions cannot start from AnyState")
+		case tb.after > 0 && tb.guard != nil:
+			problems = append(problems, name+": timed transitions cannot have a guard")
+		case tb.event != "" && tb.guard == nil:
+			key := trigger{tb.from, tb.event}
+			if first, exists := unguarded[key]; exists {
+				problems = append(problems, fmt.Sprintf("%s: duplicates unguarded transition %d on event %q", name, first, tb.event))
+			} else {
+				unguarded[key] = i + 1
+			}
+		}
+	}
+	return problems
+}
+
+// Name sets the display name of the state
+func (sb *StateBuilder) Name(name string) *StateBuilder {
+	sb.name = name
+	return sb
+}
+
+// OnEntry adds actions that run when the state is entered
+func (sb *StateBuilder) OnEntry(actions ...SMAction) *StateBuilder {
+	sb.entry = append(sb.entry, actions...)
+	return sb
+}
+
+// OnExit adds actions that run when the state is left
+func (sb *StateBuilder) OnExit(actions ...SMAction) *StateBuilder {
+	sb.exit = append(sb.exit, actions...)
+	return sb
+}
+
+// Parent makes the state a substate of another state (see AddSubState). The parent may be
+// defined later in the builder.
+func (sb *StateBuilder) Parent(parent StateID) *StateBuilder {
+	sb.parent = parent
+	return sb
+}
+
+// Initial makes the state the initial state of the machine
+func (sb *StateBuilder) Initial() *StateBuilder {
+	sb.mb.Initial(sb.id)
+	return sb
+}
+
+// State starts the definition of the next state
+func (sb *StateBuilder) State(id StateID) *StateBuilder {
+	return sb.mb.State(id)
+}
+
+// Transition starts the definition of a transition
+func (sb *StateBuilder) Transition() *TransitionBuilder {
+	return sb.mb.Transition()
+}
+
+// Build validates the configuration and creates the machine (see MachineBuilder.Build)
+func (sb *StateBuilder) Build() (*StatesMan, error) {
+	return sb.mb.Build()
+}
+
+// From sets the source state; AnyState matches every state
+func (tb *TransitionBuilder) From(from StateID) *TransitionBuilder {
+	tb.from = from
+	return tb
+}
+
+// To sets the target state
+func (tb *TransitionBuilder) To(to StateID) *TransitionBuilder {
+	tb.to = to
+	return tb
+}
+
+// On sets the event that triggers the transition
+func (tb *TransitionBuilder) On(event EventID) *TransitionBuilder {
+	tb.event = event
+	return tb
+}
+
+// After makes the transition a timed transition that fires after the machine stayed in the
+// source state for d (see AddTimedTransition)
+func (tb *TransitionBuilder) After(d time.Duration) *TransitionBuilder {
+	tb.after = d
+	return tb
+}
+
+// Guard sets the condition that must hold for the transition to fire
+func (tb *TransitionBuilder) Guard(guard SMCondition) *TransitionBuilder {
+	tb.guard = guard
+	return tb
+}
+
+// Do adds actions that run during the transition
+func (tb *TransitionBuilder) Do(actions ...SMAction) *TransitionBuilder {
+	tb.actions = append(tb.actions, actions...)
+	return tb
+}
+
+// State starts the definition of a state
+func (tb *TransitionBuilder) State(id StateID) *StateBuilder {
+	return tb.mb.State(id)
+}
+
+// Transition starts the definition of the next transition
+func (tb *TransitionBuilder) Transition() *TransitionBuilder {
+	return tb.mb.Transition()
+}
+
+// Build validates the configuration and creates the machine (see MachineBuilder.Build)
+func (tb *TransitionBuilder) Build() (*StatesMan, error) {
+	return tb.mb.Build()
+}
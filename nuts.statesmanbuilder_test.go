@@ -0,0 +1,122 @@
+package gonuts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobProcessorByMethods builds the JobProcessor example with the StatesMan methods
+func jobProcessorByMethods(t *testing.T) *StatesMan {
+	t.Helper()
+	sm := NewStatesMan("JobProcessor")
+	sm.AddState("Idle", "Idle State", nil, nil)
+	sm.AddState("Processing", "Processing State", nil, nil)
+	sm.AddState("Completed", "Completed State", nil, nil)
+	sm.AddState("Failed", "Failed State", nil, nil)
+	if err := sm.SetInitialState("Idle"); err != nil {
+		t.Fatal(err)
+	}
+	sm.AddTransition("Idle", "Processing", "StartProcessing", nil)
+	sm.AddTransition("Processing", "Completed", "Success", nil)
+	sm.AddTransition("Processing", "Failed", "Failure", nil)
+	sm.AddTimedTransition("Processing", "Failed", time.Minute)
+	sm.AddTransition(AnyState, "Idle", "Reset", nil)
+	return sm
+}
+
+func TestMachineBuilderMatchesMethods(t *testing.T) {
+	built, err := NewMachineBuilder("JobProcessor").
+		State("Idle").Name("Idle State").Initial().
+		State("Processing").Name("Processing State").
+		State("Completed").Name("Completed State").
+		State("Failed").Name("Failed State").
+		Transition().From("Idle").To("Processing").On("StartProcessing").
+		Transition().From("Processing").To("Completed").On("Success").
+		Transition().From("Processing").To("Failed").On("Failure").
+		Transition().From("Processing").To("Failed").After(time.Minute).
+		Transition().From(AnyState).To("Idle").On("Reset").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want, err := jobProcessorByMethods(t).Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := built.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Export of the built machine differs:\n%s\nwant:\n%s", got, want)
+	}
+	if state := built.GetCurrentState(); state != "Idle" {
+		t.Errorf("current state = %s, want Idle", state)
+	}
+}
+
+func TestMachineBuilderReportsAllProblems(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*StatesMan, error)
+		want  []string
+	}{
+		{
+			name: "unknown states and no initial state",
+			build: NewMachineBuilder("m").
+				State("Idle").
+				Transition().From("Idle").To("Running").On("Start").
+				Transition().From("Stopped").To("Idle").On("Reset").Build,
+			want: []string{
+				"no initial state set",
+				`transition 1 (Idle -> Running): state "Running" does not exist`,
+				`transition 2 (Stopped -> Idle): state "Stopped" does not exist`,
+			},
+		},
+		{
+			name: "duplicates",
+			build: NewMachineBuilder("m").
+				State("Idle").Initial().
+				State("Idle").
+				State("Running").Initial().
+				Transition().From("Idle").To("Running").On("Start").
+				Transition().From("Idle").To("Idle").On("Start").
+				Transition().From("Idle").To("Running").On("Start").Guard(func(map[string]interface{}) bool { return true }).Build,
+			want: []string{
+				`state "Idle" is defined more than once`,
+				"initial state set more than once: [Idle Running]",
+				`transition 2 (Idle -> Idle): duplicates unguarded transition 1 on event "Start"`,
+			},
+		},
+		{
+			name: "incomplete transitions",
+			build: NewMachineBuilder("m").
+				State("Idle").Initial().Parent("Missing").
+				Transition().From("Idle").
+				Transition().From(AnyState).To("Idle").After(time.Second).
+				Transition().From("Idle").To("Idle").On("Tick").After(time.Second).Build,
+			want: []string{
+				`state "Idle": parent "Missing" does not exist`,
+				"transition 1 (Idle -> ): no target state (To)",
+				"transition 1 (Idle -> ): no event (On) or positive duration (After)",
+				"transition 2 (* -> Idle): timed transitions cannot start from AnyState",
+				"transition 3 (Idle -> Idle): both an event (On) and a duration (After) set",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := tt.build()
+			if sm != nil || !errors.Is(err, ErrInvalidStateMachine) {
+				t.Fatalf("Build = %v, %v, want ErrInvalidStateMachine", sm, err)
+			}
+			want := ErrInvalidStateMachine.Error() + ": " + strings.Join(tt.want, "; ")
+			if err.Error() != want {
+				t.Errorf("error:\n%s\nwant:\n%s", err, want)
+			}
+		})
+	}
+}
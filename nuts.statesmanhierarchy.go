@@ -0,0 +1,147 @@
+package gonuts
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddSubState adds a new state nested in a parent state. Events without a matching transition
+// in the substate are handled by the transitions of its parent states, and entry and exit
+// actions run for every level that a transition crosses.
+//
+// Example:
+//
+//	sm.AddState("Active", "Active", nil, nil)
+//	sm.AddSubState("Active", "Playing", "Playing", nil, nil)
+//	sm.AddSubState("Active", "Paused", "Paused", nil, nil)
+//	sm.AddState("Off", "Off", nil, nil)
+//	sm.AddTransition("Active", "Off", "PowerOff", nil) // works from Playing and Paused
+func (sm *StatesMan) AddSubState(parent, id StateID, name string, entryActions, exitActions []SMAction) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, exists := sm.States[parent]; !exists {
+		return fmt.Errorf("parent state %s does not exist", parent)
+	}
+	if parent == id {
+		return fmt.Errorf("state %s cannot be its own parent", id)
+	}
+	sm.States[id] = &State{
+		ID:           id,
+		Name:         name,
+		EntryActions: entryActions,
+		ExitActions:  exitActions,
+		Parent:       parent,
+	}
+	return nil
+}
+
+// AddChildState makes an existing state a substate of an existing parent state (see AddSubState).
+func (sm *StatesMan) AddChildState(parentID, childID StateID) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	child, exists := sm.States[childID]
+	if !exists {
+		return fmt.Errorf("state %s does not exist", childID)
+	}
+	if _, exists := sm.States[parentID]; !exists {
+		return fmt.Errorf("parent state %s does not exist", parentID)
+	}
+	for _, id := range sm.stateChain(parentID) {
+		if id == childID {
+			return fmt.Errorf("making %s a child of %s would create a cycle", childID, parentID)
+		}
+	}
+	child.Parent = parentID
+	return nil
+}
+
+// stateChain returns the state followed by its parent states, innermost first.
+// The caller must hold sm.mu.
+func (sm *StatesMan) stateChain(id StateID) []StateID {
+	chain := []StateID{id}
+	for state := sm.States[id]; state != nil && state.Parent != ""; state = sm.States[state.Parent] {
+		if len(chain) > len(sm.States) {
+			break // parent cycle, rejected by validate
+		}
+		chain = append(chain, state.Parent)
+	}
+	return chain
+}
+
+// transitionPath returns the states to exit (innermost first) and to enter (outermost first)
+// for a transition. All states below the closest common parent are left and entered, so a
+// transition to the current state or to one of its parents exits and re-enters that state.
+// The caller must hold sm.mu.
+func (sm *StatesMan) transitionPath(from, to *State) (exits, entries []*State) {
+	fromChain := sm.stateChain(from.ID)
+	toChain := sm.stateChain(to.ID)
+
+	common := StateID("")
+	for _, id := range fromChain[1:] {
+		for _, other := range toChain[1:] {
+			if id == other {
+				common = id
+				break
+			}
+		}
+		if common != "" {
+			break
+		}
+	}
+
+	for _, id := range fromChain {
+		if id == common {
+			break
+		}
+		if state := sm.States[id]; state != nil {
+			exits = append(exits, state)
+		}
+	}
+	start := len(toChain) - 1
+	for i, id := range toChain {
+		if id == common {
+			start = i - 1
+			break
+		}
+	}
+	for i := start; i >= 0; i-- {
+		if state := sm.States[toChain[i]]; state != nil {
+			entries = append(entries, state)
+		}
+	}
+	return exits, entries
+}
+
+// childStates returns the sorted substates per parent; top-level states are listed under "".
+// The caller must hold sm.mu.
+func (sm *StatesMan) childStates() map[StateID][]StateID {
+	children := make(map[StateID][]StateID)
+	for id, state := range sm.States {
+		parent := state.Parent
+		if _, exists := sm.States[parent]; !exists {
+			parent = ""
+		}
+		children[parent] = append(children[parent], id)
+	}
+	for _, ids := range children {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return children
+}
+
+// dotState renders a state, and its substates as a cluster, in DOT syntax. The caller must hold sm.mu.
+func (sm *StatesMan) dotState(id StateID, children map[StateID][]StateID, indent string) string {
+	state := sm.States[id]
+	node := fmt.Sprintf("%s%s [label=\"%s\"];\n", indent, state.ID, state.Name)
+	if len(children[id]) == 0 {
+		return node
+	}
+	dot := fmt.Sprintf("%ssubgraph cluster_%s {\n", indent, state.ID)
+	dot += fmt.Sprintf("%s  label=\"%s\";\n", indent, state.Name)
+	dot += "  " + node
+	for _, child := range children[id] {
+		dot += sm.dotState(child, children, indent+"  ")
+	}
+	dot += indent + "}\n"
+	return dot
+}
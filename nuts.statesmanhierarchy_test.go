@@ -0,0 +1,76 @@
+package gonuts
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newPlayerTestMachine returns the player of the AddSubState example in Playing, recording
+// entry and exit actions in the returned log
+func newPlayerTestMachine(t *testing.T) (*StatesMan, *[]string) {
+	t.Helper()
+	var log []string
+	record := func(entry string) []SMAction {
+		return []SMAction{func(map[string]interface{}) { log = append(log, entry) }}
+	}
+	sm := NewStatesMan("player")
+	sm.SetErrorEmitter(NewEventEmitter())
+	sm.AddState("Active", "Active", record("enter Active"), record("exit Active"))
+	for _, id := range []StateID{"Playing", "Paused"} {
+		if err := sm.AddSubState("Active", id, string(id), record("enter "+string(id)), record("exit "+string(id))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sm.AddState("Off", "Off", record("enter Off"), nil)
+	sm.AddTransition("Playing", "Paused", "Pause", nil)
+	sm.AddTransition("Active", "Off", "PowerOff", nil)
+	sm.AddTransition("Active", "Playing", "Reset", nil)
+	if err := sm.SetInitialState("Playing"); err != nil {
+		t.Fatal(err)
+	}
+	log = nil
+	return sm, &log
+}
+
+func TestSubStateFallsBackToParentTransition(t *testing.T) {
+	sm, log := newPlayerTestMachine(t)
+
+	if err := handleTestEvent(sm, "Pause", nil); err != nil || sm.GetCurrentState() != "Paused" {
+		t.Fatalf("Pause: state %s, %v; want Paused", sm.GetCurrentState(), err)
+	}
+	if want := []string{"exit Playing", "enter Paused"}; !slices.Equal(*log, want) {
+		t.Errorf("actions = %v, want %v: the parent is not left between siblings", *log, want)
+	}
+
+	// Paused has no PowerOff transition, its parent Active has
+	*log = nil
+	if err := handleTestEvent(sm, "PowerOff", nil); err != nil || sm.GetCurrentState() != "Off" {
+		t.Fatalf("PowerOff: state %s, %v; want Off from the parent transition", sm.GetCurrentState(), err)
+	}
+	if want := []string{"exit Paused", "exit Active", "enter Off"}; !slices.Equal(*log, want) {
+		t.Errorf("actions = %v, want %v", *log, want)
+	}
+
+	// Off has no parent to fall back to
+	if err := handleTestEvent(sm, "Pause", nil); !errors.Is(err, ErrUnhandledEvent) {
+		t.Errorf("Pause in Off: error = %v, want ErrUnhandledEvent", err)
+	}
+}
+
+func TestSubStateOwnTransitionWinsOverParent(t *testing.T) {
+	sm, log := newPlayerTestMachine(t)
+	sm.AddTransition("Playing", "Paused", "PowerOff", nil) // overrides the parent in Playing only
+
+	if err := handleTestEvent(sm, "PowerOff", nil); err != nil || sm.GetCurrentState() != "Paused" {
+		t.Fatalf("PowerOff in Playing: state %s, %v; want Paused", sm.GetCurrentState(), err)
+	}
+	// A parent transition to a substate exits and re-enters the states below the parent only
+	*log = nil
+	if err := handleTestEvent(sm, "Reset", nil); err != nil || sm.GetCurrentState() != "Playing" {
+		t.Fatalf("Reset: state %s, %v; want Playing", sm.GetCurrentState(), err)
+	}
+	if want := []string{"exit Paused", "enter Playing"}; !slices.Equal(*log, want) {
+		t.Errorf("actions = %v, want %v", *log, want)
+	}
+}
@@ -0,0 +1,113 @@
+package gonuts
+
+import "time"
+
+// DefaultStatesManHistoryLimit is the number of transitions a new StatesMan keeps in its history.
+const DefaultStatesManHistoryLimit = 100
+
+// TransitionRecord is an entry of the transition history.
+type TransitionRecord struct {
+	From      StateID
+	To        StateID
+	Event     EventID // empty for timed transitions
+	Timestamp time.Time
+	// Data is a snapshot of the event data that caused the transition. It must not be modified.
+	Data map[string]interface{} `json:",omitempty"`
+	// Timed is true if the transition was a timed transition.
+	Timed bool
+	// Rejected is true if a condition or guard rejected another candidate transition for the
+	// same event before this one fired.
+	Rejected bool
+}
+
+// ExportOption configures Export
+type ExportOption func(*exportConfig)
+
+type exportConfig struct {
+	history bool
+}
+
+// WithExportHistory includes the transition history in the exported JSON.
+// Import ignores the history.
+//
+// Example:
+//
+//	data, err := sm.Export(gonuts.WithExportHistory())
+func WithExportHistory() ExportOption {
+	return func(c *exportConfig) {
+		c.history = true
+	}
+}
+
+// SetHistoryLimit sets how many transitions are kept in the history (DefaultStatesManHistoryLimit
+// by default). The most recent records are kept when the limit is lowered; 0 disables the history.
+func (sm *StatesMan) SetHistoryLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	records := sm.historySnapshot()
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	sm.historyLimit = n
+	sm.history = records
+	sm.historyNext = 0
+	if n > 0 {
+		sm.historyNext = len(records) % n
+	}
+}
+
+// History returns the recorded transitions, oldest first.
+//
+// Example:
+//
+//	for _, rec := range sm.History() {
+//		fmt.Printf("%s %s -> %s on %q (timed=%t)\n", rec.Timestamp.Format(time.RFC3339), rec.From, rec.To, rec.Event, rec.Timed)
+//	}
+func (sm *StatesMan) History() []TransitionRecord {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.historySnapshot()
+}
+
+// historySnapshot returns a copy of the history in chronological order. The caller must hold sm.mu.
+func (sm *StatesMan) historySnapshot() []TransitionRecord {
+	records := make([]TransitionRecord, 0, len(sm.history))
+	if len(sm.history) < sm.historyLimit {
+		return append(records, sm.history...)
+	}
+	records = append(records, sm.history[sm.historyNext:]...)
+	return append(records, sm.history[:sm.historyNext]...)
+}
+
+// recordHistory adds a transition to the history. The caller must hold sm.mu.
+func (sm *StatesMan) recordHistory(from, to StateID, event EventID, cause transitionCause) {
+	if sm.historyLimit == 0 {
+		return
+	}
+	var data map[string]interface{}
+	if cause.data != nil {
+		data = make(map[string]interface{}, len(cause.data))
+		for k, v := range cause.data {
+			data[k] = v
+		}
+	}
+	rec := TransitionRecord{
+		From:      from,
+		To:        to,
+		Event:     event,
+		Timestamp: sm.now(),
+		Data:      data,
+		Timed:     cause.timed,
+		Rejected:  cause.rejected,
+	}
+	if len(sm.history) < sm.historyLimit {
+		sm.history = append(sm.history, rec)
+		sm.historyNext = len(sm.history) % sm.historyLimit
+		return
+	}
+	sm.history[sm.historyNext] = rec
+	sm.historyNext = (sm.historyNext + 1) % sm.historyLimit
+}
@@ -0,0 +1,74 @@
+package gonuts
+
+import (
+	"slices"
+	"testing"
+)
+
+// handleTestEvent handles an event synchronously, without running the event loop
+func handleTestEvent(sm *StatesMan, event EventID, data map[string]interface{}) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.handleEvent(EventData{EventID: event, Data: data})
+}
+
+// historyPokes returns the "i" data values of the recorded transitions, oldest first
+func historyPokes(sm *StatesMan) []int {
+	var pokes []int
+	for _, rec := range sm.History() {
+		pokes = append(pokes, rec.Data["i"].(int))
+	}
+	return pokes
+}
+
+func TestHistoryRingBufferWraps(t *testing.T) {
+	sm := NewStatesMan("test")
+	sm.AddState("A", "A", nil, nil)
+	sm.AddTransition("A", "A", "poke", nil)
+	if err := sm.SetInitialState("A"); err != nil {
+		t.Fatal(err)
+	}
+	sm.SetHistoryLimit(5)
+
+	for i := 0; i < 12; i++ {
+		if err := handleTestEvent(sm, "poke", map[string]interface{}{"i": i}); err != nil {
+			t.Fatal(err)
+		}
+		want := i + 1
+		if want > 5 {
+			want = 5
+		}
+		if n := len(sm.History()); n != want {
+			t.Fatalf("after %d transitions the history has %d records, want %d", i+1, n, want)
+		}
+	}
+	if got, want := historyPokes(sm), []int{7, 8, 9, 10, 11}; !slices.Equal(got, want) {
+		t.Errorf("history = %v, want the 5 most recent %v", got, want)
+	}
+
+	// Lowering the limit keeps the most recent records, and the buffer keeps wrapping
+	sm.SetHistoryLimit(3)
+	if got, want := historyPokes(sm), []int{9, 10, 11}; !slices.Equal(got, want) {
+		t.Errorf("history after SetHistoryLimit(3) = %v, want %v", got, want)
+	}
+	for i := 12; i < 14; i++ {
+		_ = handleTestEvent(sm, "poke", map[string]interface{}{"i": i})
+	}
+	if got, want := historyPokes(sm), []int{11, 12, 13}; !slices.Equal(got, want) {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+
+	// Raising the limit keeps all records and fills up before wrapping again
+	sm.SetHistoryLimit(4)
+	_ = handleTestEvent(sm, "poke", map[string]interface{}{"i": 14})
+	_ = handleTestEvent(sm, "poke", map[string]interface{}{"i": 15})
+	if got, want := historyPokes(sm), []int{12, 13, 14, 15}; !slices.Equal(got, want) {
+		t.Errorf("history after SetHistoryLimit(4) = %v, want %v", got, want)
+	}
+
+	sm.SetHistoryLimit(0)
+	_ = handleTestEvent(sm, "poke", map[string]interface{}{"i": 16})
+	if n := len(sm.History()); n != 0 {
+		t.Errorf("history with limit 0 has %d records, want none", n)
+	}
+}
@@ -0,0 +1,186 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long a new StatesMan remembers idempotency keys.
+	DefaultIdempotencyTTL = 10 * time.Minute
+	// DefaultIdempotencyMaxKeys is the number of idempotency keys a new StatesMan remembers at most.
+	DefaultIdempotencyMaxKeys = 10000
+)
+
+// ErrDuplicateEvent is reported (see SetErrorEmitter) when TriggerEventIdempotent drops an event
+// whose idempotency key was seen within the idempotency window.
+var ErrDuplicateEvent = errors.New("duplicate event")
+
+// idempotencyWindow remembers recently seen idempotency keys, bounded by age and count
+type idempotencyWindow struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxKeys int
+	expiry  map[string]time.Time
+	order   []idempotencyEntry // keys in the order they were seen, oldest first from head
+	head    int
+	dropped uint64
+}
+
+type idempotencyEntry struct {
+	key    string
+	expiry time.Time
+}
+
+func newIdempotencyWindow(ttl time.Duration, maxKeys int) *idempotencyWindow {
+	return &idempotencyWindow{ttl: ttl, maxKeys: maxKeys, expiry: make(map[string]time.Time)}
+}
+
+// settings returns the TTL and the maximum number of keys
+func (w *idempotencyWindow) settings() (time.Duration, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ttl, w.maxKeys
+}
+
+// seen records key and reports whether it was already seen and has not expired
+func (w *idempotencyWindow) seen(key string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.purge(now)
+	if expiry, ok := w.expiry[key]; ok && now.Before(expiry) {
+		w.dropped++
+		return true
+	}
+	w.add(key, now.Add(w.ttl))
+	return false
+}
+
+// add records a key; the oldest keys are forgotten beyond maxKeys. The caller must hold w.mu.
+func (w *idempotencyWindow) add(key string, expiry time.Time) {
+	w.expiry[key] = expiry
+	w.order = append(w.order, idempotencyEntry{key: key, expiry: expiry})
+	for len(w.expiry) > w.maxKeys {
+		w.popOldest()
+	}
+}
+
+// purge forgets the expired keys at the head of the queue. Keys are queued in the order they
+// were seen, so with a fixed TTL the expired keys are exactly those at the head. The caller
+// must hold w.mu.
+func (w *idempotencyWindow) purge(now time.Time) {
+	for w.head < len(w.order) && !now.Before(w.order[w.head].expiry) {
+		w.popOldest()
+	}
+}
+
+// popOldest forgets the oldest queued key. The caller must hold w.mu.
+func (w *idempotencyWindow) popOldest() {
+	entry := w.order[w.head]
+	w.order[w.head] = idempotencyEntry{}
+	w.head++
+	// The key may have been seen again after it expired, then its newer entry is further back
+	if w.expiry[entry.key].Equal(entry.expiry) {
+		delete(w.expiry, entry.key)
+	}
+	if w.head > len(w.order)/2 {
+		w.order = append([]idempotencyEntry(nil), w.order[w.head:]...)
+		w.head = 0
+	}
+}
+
+// snapshot returns the keys that have not expired with their expiry
+func (w *idempotencyWindow) snapshot(now time.Time) map[string]time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.purge(now)
+	if len(w.expiry) == 0 {
+		return nil
+	}
+	keys := make(map[string]time.Time, len(w.expiry))
+	for key, expiry := range w.expiry {
+		if now.Before(expiry) {
+			keys[key] = expiry
+		}
+	}
+	return keys
+}
+
+// restore replaces the remembered keys, skipping expired ones
+func (w *idempotencyWindow) restore(keys map[string]time.Time, now time.Time) {
+	entries := make([]idempotencyEntry, 0, len(keys))
+	for key, expiry := range keys {
+		if now.Before(expiry) {
+			entries = append(entries, idempotencyEntry{key: key, expiry: expiry})
+		}
+	}
+	// Oldest first, so the queue order matches the expiry order again
+	Sort(entries, func(a, b idempotencyEntry) bool { return a.expiry.Before(b.expiry) })
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expiry = make(map[string]time.Time, len(entries))
+	w.order = nil
+	w.head = 0
+	for _, entry := range entries {
+		w.add(entry.key, entry.expiry)
+	}
+}
+
+// SetIdempotencyWindow configures how long (DefaultIdempotencyTTL by default) and how many
+// (DefaultIdempotencyMaxKeys by default) idempotency keys TriggerEventIdempotent remembers.
+// When more keys arrive within the TTL, the oldest are forgotten first. Keys already seen keep
+// their expiry.
+func (sm *StatesMan) SetIdempotencyWindow(ttl time.Duration, maxKeys int) {
+	if maxKeys < 1 {
+		maxKeys = 1
+	}
+	w := sm.idempotency
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ttl = ttl
+	w.maxKeys = maxKeys
+	for len(w.expiry) > w.maxKeys {
+		w.popOldest()
+	}
+}
+
+// TriggerEventIdempotent triggers an event like TriggerEvent unless an event with the same
+// idempotency key was triggered within the idempotency window (see SetIdempotencyWindow), e.g.
+// because an upstream system delivered a message twice. Duplicates are dropped, counted (see
+// DuplicateEvents) and reported as ErrDuplicateEvent to the error emitter (see SetErrorEmitter).
+//
+// The key is recorded when the event is triggered, not when it is handled. The window is kept
+// in memory; it only survives a restart if it is persisted with ExportSnapshot and restored
+// with RestoreSnapshot.
+//
+// Returns:
+//   - bool: true if the event was triggered, false if it was dropped as a duplicate
+//
+// Example:
+//
+//	for msg := range deliveries {
+//		sm.TriggerEventIdempotent("PaymentReceived", msg.ID, msg.Data)
+//	}
+func (sm *StatesMan) TriggerEventIdempotent(event EventID, idempotencyKey string, data map[string]interface{}) bool {
+	if sm.idempotency.seen(idempotencyKey, time.Now()) {
+		sm.mu.RLock()
+		ee := sm.errorEmitter
+		sm.mu.RUnlock()
+		sm.reportError(ee, fmt.Errorf("%w %q with idempotency key %q", ErrDuplicateEvent, event, idempotencyKey))
+		return false
+	}
+	sm.TriggerEvent(event, data)
+	return true
+}
+
+// DuplicateEvents returns the number of events TriggerEventIdempotent dropped as duplicates
+func (sm *StatesMan) DuplicateEvents() uint64 {
+	sm.idempotency.mu.Lock()
+	defer sm.idempotency.mu.Unlock()
+	return sm.idempotency.dropped
+}
@@ -0,0 +1,97 @@
+package gonuts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newPaymentMachine returns a machine that stays in Open on "Pay"
+func newPaymentMachine(t *testing.T) *StatesMan {
+	t.Helper()
+	sm := NewStatesMan("payments")
+	sm.SetErrorEmitter(NewEventEmitter())
+	sm.AddState("Open", "Open", nil, nil)
+	sm.AddTransition("Open", "Open", "Pay", nil)
+	if err := sm.SetInitialState("Open"); err != nil {
+		t.Fatal(err)
+	}
+	return sm
+}
+
+func TestTriggerEventIdempotentDropsDuplicates(t *testing.T) {
+	sm := newPaymentMachine(t)
+	ee := NewEventEmitter()
+	var reported []error
+	if _, err := ee.OnError("collect", func(ep *ErrorPlus) { reported = append(reported, ep) }); err != nil {
+		t.Fatal(err)
+	}
+	sm.SetErrorEmitter(ee)
+
+	if !sm.TriggerEventIdempotent("Pay", "msg-1", nil) {
+		t.Fatal("first delivery of msg-1 was dropped")
+	}
+	if sm.TriggerEventIdempotent("Pay", "msg-1", nil) {
+		t.Fatal("second delivery of msg-1 was triggered")
+	}
+	if !sm.TriggerEventIdempotent("Pay", "msg-2", nil) {
+		t.Fatal("first delivery of msg-2 was dropped")
+	}
+	if n := sm.DuplicateEvents(); n != 1 {
+		t.Errorf("DuplicateEvents() = %d, want 1", n)
+	}
+	if n := len(sm.EventChannel); n != 2 {
+		t.Errorf("%d events queued, want 2", n)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrDuplicateEvent) {
+		t.Errorf("reported errors = %v, want one ErrDuplicateEvent", reported)
+	}
+}
+
+func TestTriggerEventIdempotentWindowBounds(t *testing.T) {
+	sm := newPaymentMachine(t)
+	sm.SetIdempotencyWindow(20*time.Millisecond, 2)
+
+	for _, key := range []string{"a", "b", "c"} {
+		sm.TriggerEventIdempotent("Pay", key, nil)
+	}
+	// "a" is the oldest key beyond maxKeys and was forgotten, "c" is still remembered
+	if !sm.TriggerEventIdempotent("Pay", "a", nil) {
+		t.Error("key beyond maxKeys was not forgotten")
+	}
+	if sm.TriggerEventIdempotent("Pay", "c", nil) {
+		t.Error("recent key was forgotten")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !sm.TriggerEventIdempotent("Pay", "c", nil) {
+		t.Error("expired key was not forgotten")
+	}
+}
+
+func TestTriggerEventIdempotentAcrossRestart(t *testing.T) {
+	before := newPaymentMachine(t)
+	before.TriggerEventIdempotent("Pay", "msg-1", nil)
+	snapshot, err := before.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted machine restored from the persisted snapshot still knows the key
+	restored := newPaymentMachine(t)
+	if err := restored.RestoreSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if restored.TriggerEventIdempotent("Pay", "msg-1", nil) {
+		t.Error("redelivery after restoring the snapshot was triggered")
+	}
+	if !restored.TriggerEventIdempotent("Pay", "msg-2", nil) {
+		t.Error("new key after restoring the snapshot was dropped")
+	}
+
+	// The window is kept in memory only: without the snapshot the key is unknown
+	fresh := newPaymentMachine(t)
+	if !fresh.TriggerEventIdempotent("Pay", "msg-1", nil) {
+		t.Error("redelivery to a machine without the snapshot was dropped")
+	}
+}
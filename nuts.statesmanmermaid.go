@@ -0,0 +1,162 @@
+package gonuts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mermaidIdentifier matches state IDs that can be used in Mermaid diagrams without an alias.
+var mermaidIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// mermaidKeywords cannot be used as Mermaid state IDs.
+var mermaidKeywords = map[string]bool{
+	"state": true, "note": true, "end": true, "direction": true, "class": true, "classDef": true,
+}
+
+// GenerateMermaid generates a Mermaid stateDiagram-v2 representation of the state machine.
+//
+// The initial state is marked with "[*] -->", parent states are rendered as composite states,
+// timed transitions are labeled with their duration ("after 30s") and transitions with a
+// condition get a "[guarded]" suffix ("[guardName]" for registered guards). AnyState
+// transitions are expanded to one edge per state. State IDs that are not valid Mermaid
+// identifiers are replaced by generated ones, keeping the original as the displayed name.
+//
+// Example:
+//
+//	fmt.Println("```mermaid\n" + sm.GenerateMermaid() + "```")
+func (sm *StatesMan) GenerateMermaid() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	children := sm.childStates()
+	ids := sm.mermaidIDs()
+
+	var sb strings.Builder
+	sb.WriteString("stateDiagram-v2\n")
+	for _, id := range children[""] {
+		sm.mermaidState(&sb, id, children, ids, "    ")
+	}
+
+	initial := sm.InitialState
+	if initial == "" {
+		initial = sm.CurrentState
+	}
+	if _, exists := sm.States[initial]; exists {
+		sb.WriteString(fmt.Sprintf("    [*] --> %s\n", ids[initial]))
+	}
+
+	all := make([]StateID, 0, len(sm.States))
+	for id := range sm.States {
+		all = append(all, id)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	for _, t := range sm.Transitions {
+		label := string(t.Event)
+		if t.GuardName != "" {
+			label += " [" + t.GuardName + "]"
+		} else if t.Condition != nil || t.EventCondition != nil {
+			label += " [guarded]"
+		}
+		from := []StateID{t.From}
+		if t.From == AnyState {
+			from = all
+		}
+		for _, f := range from {
+			sm.mermaidEdge(&sb, ids, f, t.To, label)
+		}
+	}
+	for _, tt := range sm.TimedTransitions {
+		sm.mermaidEdge(&sb, ids, tt.From, tt.To, "after "+tt.Duration.String())
+	}
+	return sb.String()
+}
+
+// mermaidIDs maps every state to a unique Mermaid identifier. The caller must hold sm.mu.
+func (sm *StatesMan) mermaidIDs() map[StateID]string {
+	states := make([]StateID, 0, len(sm.States))
+	for id := range sm.States {
+		states = append(states, id)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+
+	ids := make(map[StateID]string, len(states))
+	used := make(map[string]bool, len(states))
+	// Valid identifiers are kept first, so generated ones cannot take their place
+	for _, id := range states {
+		if mermaidIdentifier.MatchString(string(id)) && !mermaidKeywords[string(id)] {
+			ids[id] = string(id)
+			used[string(id)] = true
+		}
+	}
+	for _, id := range states {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		base := "s_" + strings.Map(func(r rune) rune {
+			if r < 128 && (r == '_' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+				return r
+			}
+			return '_'
+		}, string(id))
+		candidate := base
+		for n := 2; used[candidate]; n++ {
+			candidate = fmt.Sprintf("%s_%d", base, n)
+		}
+		ids[id] = candidate
+		used[candidate] = true
+	}
+	return ids
+}
+
+// mermaidState renders a state declaration, and its substates as a composite state. The caller must hold sm.mu.
+func (sm *StatesMan) mermaidState(sb *strings.Builder, id StateID, children map[StateID][]StateID, ids map[StateID]string, indent string) {
+	state := sm.States[id]
+	name := state.Name
+	if name == "" {
+		name = string(state.ID)
+	}
+	if name != ids[id] {
+		sb.WriteString(fmt.Sprintf("%sstate \"%s\" as %s\n", indent, mermaidEscape(name), ids[id]))
+	} else if len(children[id]) == 0 {
+		sb.WriteString(fmt.Sprintf("%s%s\n", indent, ids[id]))
+	}
+	if len(children[id]) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("%sstate %s {\n", indent, ids[id]))
+	for _, child := range children[id] {
+		sm.mermaidState(sb, child, children, ids, indent+"    ")
+	}
+	sb.WriteString(indent + "}\n")
+}
+
+// mermaidEdge renders a transition; edges from or to unknown states are skipped. The caller must hold sm.mu.
+func (sm *StatesMan) mermaidEdge(sb *strings.Builder, ids map[StateID]string, from, to StateID, label string) {
+	fromID, ok := ids[from]
+	if !ok {
+		return
+	}
+	toID, ok := ids[to]
+	if !ok {
+		return
+	}
+	if label = strings.TrimSpace(label); label == "" {
+		sb.WriteString(fmt.Sprintf("    %s --> %s\n", fromID, toID))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("    %s --> %s : %s\n", fromID, toID, mermaidEscape(label)))
+}
+
+// mermaidEscape replaces characters that end or break a Mermaid name or label with entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		";", "#59;",
+		":", "#58;",
+		"\r", " ",
+		"\n", " ",
+	).Replace(s)
+}
@@ -0,0 +1,55 @@
+package gonuts
+
+import "testing"
+
+const jobProcessorMermaidGolden = `stateDiagram-v2
+    state "Completed State" as Completed
+    state "Failed State" as Failed
+    state "Idle State" as Idle
+    state "Processing State" as Processing
+    state Processing {
+        Uploading
+        state "End" as s_end
+    }
+    state "Waiting for retry" as s_Wait_ing
+    [*] --> Idle
+    Idle --> Processing : StartProcessing
+    Processing --> Completed : Success
+    Processing --> Failed : Failure
+    Completed --> Idle : Reset
+    Failed --> Idle : Reset
+    Idle --> Idle : Reset
+    Processing --> Idle : Reset
+    Uploading --> Idle : Reset
+    s_Wait_ing --> Idle : Reset
+    s_end --> Idle : Reset
+    Failed --> s_Wait_ing : Retry [guarded]
+    Uploading --> s_end : Uploaded [hasChunks]
+    Processing --> Failed : after 1m0s
+`
+
+// TestGenerateMermaidGolden covers composite states, AnyState expansion, conditions, named
+// guards, timed transitions and state IDs that need an alias (invalid identifiers and keywords)
+func TestGenerateMermaidGolden(t *testing.T) {
+	sm := jobProcessorByMethods(t)
+	if err := sm.AddSubState("Processing", "Uploading", "Uploading", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.AddSubState("Processing", "end", "End", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	sm.AddState("Wait-ing", "Waiting for retry", nil, nil)
+	sm.AddTransition("Failed", "Wait-ing", "Retry", func(map[string]interface{}) bool { return true })
+	sm.RegisterGuard("hasChunks", func(ActionScope) bool { return true })
+	sm.AddNamedTransition("Uploading", "end", "Uploaded", "hasChunks")
+
+	if got := sm.GenerateMermaid(); got != jobProcessorMermaidGolden {
+		t.Errorf("GenerateMermaid() =\n%s\nwant:\n%s", got, jobProcessorMermaidGolden)
+	}
+	// The output is stable across calls despite map iteration
+	for i := 0; i < 10; i++ {
+		if got := sm.GenerateMermaid(); got != jobProcessorMermaidGolden {
+			t.Fatalf("call %d returned a different diagram", i+2)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSimulationSteps is returned by Simulate when the simulation exceeds SimOptions.MaxSteps,
+// e.g. because timed transitions with a duration of 0 form a cycle.
+var ErrSimulationSteps = errors.New("simulation exceeded the maximum number of steps")
+
+// DefaultSimMaxSteps is the number of steps a simulation may record unless SimOptions.MaxSteps is set.
+const DefaultSimMaxSteps = 10000
+
+// SimOptions configures Simulate.
+type SimOptions struct {
+	// Start is the virtual time of the start of the simulation, the Unix epoch (UTC) if zero,
+	// so results are reproducible.
+	Start time.Time
+	// Step is the virtual time that passes before each event. Timed transitions and state
+	// timeouts that become due meanwhile fire first, in the order of their deadlines.
+	Step time.Duration
+	// Settle is the virtual time that passes after the last event, firing the timers that
+	// become due, e.g. to see where a machine ends up when no more events arrive.
+	Settle time.Duration
+	// FreezeTimers stops the virtual clock for timers: timed transitions and state timeouts never fire.
+	FreezeTimers bool
+	// MaxSteps limits the number of recorded steps (DefaultSimMaxSteps if <= 0).
+	MaxSteps int
+}
+
+// SimStep is a step of a simulation: an event or a fired timer.
+type SimStep struct {
+	Time    time.Time
+	Event   EventID `json:",omitempty"` // empty for timed transitions
+	Timed   bool    `json:",omitempty"` // a timed transition fired
+	Timeout bool    `json:",omitempty"` // the event was injected by a state timeout (see SetStateTimeout)
+	Before  StateID
+	After   StateID
+	// Transition is the transition that was taken, nil if the step did not change the state.
+	Transition *Transition `json:",omitempty"`
+	// Context is a copy of the context after the step. Values are copied shallowly.
+	Context map[string]interface{}
+}
+
+// SimResult is the result of Simulate. It can be encoded as JSON, e.g. for golden files,
+// as long as the context values can.
+type SimResult struct {
+	Machine    string
+	Steps      []SimStep
+	FinalState StateID
+	End        time.Time // virtual time at the end of the simulation
+}
+
+// smSimulation is the virtual clock of a machine run by Simulate.
+type smSimulation struct {
+	now   time.Time
+	timer *time.Timer // a stopped timer marking armed timers; Simulate fires them
+	taken *Transition // the transition taken by the current step
+}
+
+// now returns the current time, or the virtual time of a simulation.
+func (sm *StatesMan) now() time.Time {
+	if sm.sim != nil {
+		return sm.sim.now
+	}
+	return time.Now()
+}
+
+// Clone returns a copy of the state machine with the same states, transitions, hooks, registered
+// actions and guards, current state, context and history. Actions, conditions and hooks are
+// shared, context values are copied shallowly. Subscribers are not copied, and the clone is not
+// running: its timers are armed when it is started with Run or RunWithContext.
+//
+// Example:
+//
+//	trial := sm.Clone()
+//	go trial.Run()
+//	trial.TriggerEvent("StartProcessing", nil)
+func (sm *StatesMan) Clone() *StatesMan {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	c := NewStatesMan(sm.Name)
+	c.EventChannel = make(chan EventData, cap(sm.EventChannel))
+	for id, state := range sm.States {
+		copied := *state
+		c.States[id] = &copied
+	}
+	c.Transitions = append([]Transition{}, sm.Transitions...)
+	c.TimedTransitions = make([]TimedTransition, len(sm.TimedTransitions))
+	for i, tt := range sm.TimedTransitions {
+		c.TimedTransitions[i] = TimedTransition{Transition: tt.Transition, Duration: tt.Duration}
+	}
+	c.CurrentState = sm.CurrentState
+	c.InitialState = sm.InitialState
+	for k, v := range sm.Context {
+		c.Context[k] = v
+	}
+	c.PreHooks = append([]SMAction(nil), sm.PreHooks...)
+	c.PostHooks = append([]SMAction(nil), sm.PostHooks...)
+	for id, timeout := range sm.stateTimeouts {
+		c.stateTimeouts[id] = timeout
+	}
+	c.historyLimit = sm.historyLimit
+	c.history = sm.historySnapshot()
+	if c.historyLimit > 0 {
+		c.historyNext = len(c.history) % c.historyLimit
+	}
+	for name, action := range sm.actions {
+		c.actions[name] = action
+	}
+	for name, guard := range sm.guards {
+		c.guards[name] = guard
+	}
+	for name, dependency := range sm.dependencies {
+		c.dependencies[name] = dependency
+	}
+	c.isolateEventData = sm.isolateEventData
+	c.errorEmitter = sm.errorEmitter
+	c.SetIdempotencyWindow(sm.idempotency.settings())
+	c.idempotency.restore(sm.idempotency.snapshot(time.Now()), time.Now())
+	c.stopped = true
+	return c
+}
+
+// Simulate replays events on a Clone of the machine synchronously, without goroutines or real
+// timers, and records every step. Timed transitions and state timeouts run on a virtual clock
+// that advances by opts.Step before each event and by opts.Settle after the last one; timers of
+// the current state are armed with their full duration when the simulation starts. The machine
+// itself is never changed, but actions, conditions and hooks do run, so they should not have
+// side effects outside the context.
+//
+// Parameters:
+//   - events: the events to replay in order
+//   - opts: the virtual clock settings
+//
+// Returns:
+//   - SimResult: the recorded steps and the final state
+//   - error: ErrInvalidStateMachine if the machine has no current state, ErrSimulationSteps
+//     if the simulation recorded more than opts.MaxSteps steps (the result holds the steps so far)
+//
+// Example usage:
+//
+//	sm, _ := gonuts.NewMachineBuilder("JobProcessor").
+//		State("Idle").Initial().
+//		State("Processing").
+//		State("Failed").
+//		Transition().From("Idle").To("Processing").On("StartProcessing").
+//		Transition().From("Processing").To("Failed").After(30 * time.Second).
+//		Build()
+//
+//	result, err := sm.Simulate([]gonuts.EventData{{EventID: "StartProcessing"}},
+//		gonuts.SimOptions{Step: time.Second, Settle: time.Minute})
+//	// result.Steps: Idle -> Processing on StartProcessing, Processing -> Failed (timed, 31s)
+//	golden, _ := json.MarshalIndent(result, "", "  ")
+func (sm *StatesMan) Simulate(events []EventData, opts SimOptions) (SimResult, error) {
+	c := sm.Clone()
+	result := SimResult{Machine: c.Name, Steps: []SimStep{}}
+	if c.CurrentState == "" {
+		return result, fmt.Errorf("%w: no current state to simulate from", ErrInvalidStateMachine)
+	}
+	maxSteps := opts.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultSimMaxSteps
+	}
+	start := opts.Start
+	if start.IsZero() {
+		start = time.Unix(0, 0).UTC()
+	}
+	placeholder := time.AfterFunc(time.Hour, func() {})
+	placeholder.Stop()
+	c.sim = &smSimulation{now: start, timer: placeholder}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = false
+	c.armStateTimeout(c.CurrentState)
+	c.resetTimedTransitions(c.CurrentState)
+
+	record := func(step SimStep) error {
+		step.After = c.CurrentState
+		step.Transition = c.sim.taken
+		step.Context = make(map[string]interface{}, len(c.Context))
+		for k, v := range c.Context {
+			step.Context[k] = v
+		}
+		result.Steps = append(result.Steps, step)
+		if len(result.Steps) > maxSteps {
+			return fmt.Errorf("%w (%d)", ErrSimulationSteps, maxSteps)
+		}
+		return nil
+	}
+	finish := func() {
+		result.FinalState = c.CurrentState
+		result.End = c.sim.now
+	}
+
+	for _, event := range events {
+		if err := c.advanceSimulation(c.sim.now.Add(opts.Step), opts.FreezeTimers, record); err != nil {
+			finish()
+			return result, err
+		}
+		c.sim.taken = nil
+		step := SimStep{Time: c.sim.now, Event: event.EventID, Before: c.CurrentState}
+		c.handleEvent(EventData{EventID: event.EventID, Data: event.Data})
+		if err := record(step); err != nil {
+			finish()
+			return result, err
+		}
+	}
+	err := c.advanceSimulation(c.sim.now.Add(opts.Settle), opts.FreezeTimers, record)
+	finish()
+	return result, err
+}
+
+// advanceSimulation moves the virtual clock to until, firing the timers that become due in the
+// order of their deadlines unless they are frozen. The caller must hold sm.mu.
+func (sm *StatesMan) advanceSimulation(until time.Time, frozen bool, record func(SimStep) error) error {
+	for !frozen {
+		index, deadline := -1, until
+		for i := range sm.TimedTransitions {
+			if tt := &sm.TimedTransitions[i]; tt.timer != nil && !tt.deadline.After(deadline) && (index < 0 || tt.deadline.Before(deadline)) {
+				index, deadline = i, tt.deadline
+			}
+		}
+		timeout := sm.stateTimer != nil && !sm.stateDeadline.After(until) && (index < 0 || sm.stateDeadline.Before(deadline))
+		if index < 0 && !timeout {
+			break
+		}
+
+		sm.sim.taken = nil
+		if timeout {
+			sm.sim.now = sm.stateDeadline
+			sm.stateTimer = nil // fires once, like the real timer
+			timeoutEvent, ok := sm.stateTimeouts[sm.CurrentState]
+			if !ok {
+				continue
+			}
+			event := timeoutEvent.Event
+			step := SimStep{Time: sm.sim.now, Event: event, Timeout: true, Before: sm.CurrentState}
+			sm.handleEvent(EventData{EventID: event})
+			if err := record(step); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tt := &sm.TimedTransitions[index]
+		sm.sim.now = tt.deadline
+		step := SimStep{Time: sm.sim.now, Timed: true, Before: sm.CurrentState}
+		sm.handleTimedTransition(timedFiring{index: index, seq: tt.seq})
+		if sm.sim.taken == nil {
+			tt.timer = nil // stale, see handleTimedTransition
+			continue
+		}
+		if err := record(step); err != nil {
+			return err
+		}
+	}
+	if until.After(sm.sim.now) {
+		sm.sim.now = until
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+const jobProcessorSimGolden = `{
+  "Machine": "JobProcessor",
+  "Steps": [
+    {
+      "Time": "1970-01-01T00:00:01Z",
+      "Event": "StartProcessing",
+      "Before": "Idle",
+      "After": "Processing",
+      "Transition": {
+        "From": "Idle",
+        "To": "Processing",
+        "Event": "StartProcessing"
+      },
+      "Context": {
+        "job": "j-1"
+      }
+    },
+    {
+      "Time": "1970-01-01T00:00:02Z",
+      "Event": "Bogus",
+      "Before": "Processing",
+      "After": "Processing",
+      "Context": {
+        "job": "j-1"
+      }
+    },
+    {
+      "Time": "1970-01-01T00:01:01Z",
+      "Timed": true,
+      "Before": "Processing",
+      "After": "Failed",
+      "Transition": {
+        "From": "Processing",
+        "To": "Failed",
+        "Event": ""
+      },
+      "Context": {
+        "job": "j-1"
+      }
+    }
+  ],
+  "FinalState": "Failed",
+  "End": "1970-01-01T00:02:02Z"
+}`
+
+// TestSimulateJobProcessor replays the JobProcessor example: the job starts, an unknown event
+// changes nothing and the timed transition fails the job after a minute without a result
+func TestSimulateJobProcessor(t *testing.T) {
+	sm := jobProcessorByMethods(t)
+	events := []EventData{
+		{EventID: "StartProcessing", Data: map[string]interface{}{"job": "j-1"}},
+		{EventID: "Bogus"},
+	}
+	result, err := sm.Simulate(events, SimOptions{Step: time.Second, Settle: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != jobProcessorSimGolden {
+		t.Errorf("simulation result:\n%s\nwant:\n%s", got, jobProcessorSimGolden)
+	}
+
+	// The real machine is not changed
+	if state := sm.GetCurrentState(); state != "Idle" {
+		t.Errorf("current state = %s after Simulate, want Idle", state)
+	}
+	if _, ok := sm.GetContextValue("job"); ok || len(sm.History()) != 0 {
+		t.Error("Simulate changed the context or history of the real machine")
+	}
+}
+
+func TestSimulateFreezeTimers(t *testing.T) {
+	sm := jobProcessorByMethods(t)
+	events := []EventData{{EventID: "StartProcessing"}, {EventID: "Success"}}
+
+	frozen, err := sm.Simulate(events[:1], SimOptions{Step: time.Hour, Settle: time.Hour, FreezeTimers: true})
+	if err != nil || frozen.FinalState != "Processing" {
+		t.Errorf("frozen simulation ended in %s (%v), want Processing", frozen.FinalState, err)
+	}
+	// The Success event arrives before the timed transition is due
+	completed, err := sm.Simulate(events, SimOptions{Step: 30 * time.Second, Settle: time.Hour})
+	if err != nil || completed.FinalState != "Completed" || len(completed.Steps) != 2 {
+		t.Errorf("simulation ended in %s after %d steps (%v), want Completed after 2", completed.FinalState, len(completed.Steps), err)
+	}
+}
+
+func TestCloneWhileSettingIdempotencyWindow(t *testing.T) {
+	sm := jobProcessorByMethods(t)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			sm.SetIdempotencyWindow(time.Duration(i+1)*time.Second, i+1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = sm.Clone()
+		}
+	}()
+	wg.Wait()
+
+	ttl, maxKeys := sm.Clone().idempotency.settings()
+	if ttl != 1000*time.Second || maxKeys != 1000 {
+		t.Errorf("clone has window %s/%d, want 1000s/1000", ttl, maxKeys)
+	}
+}
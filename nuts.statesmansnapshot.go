@@ -0,0 +1,181 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrSnapshotContext is returned by ExportSnapshot when a context value cannot be encoded as JSON.
+	ErrSnapshotContext = errors.New("context value is not JSON-serializable")
+	// ErrInvalidSnapshot is returned by RestoreSnapshot when a snapshot does not match the machine.
+	ErrInvalidSnapshot = errors.New("invalid state machine snapshot")
+)
+
+// StatesManSnapshot is the runtime state of a state machine as written by ExportSnapshot.
+type StatesManSnapshot struct {
+	Name         string
+	CurrentState StateID
+	Context      map[string]interface{}
+	Timers       []TimerSnapshot `json:",omitempty"`
+	// StateTimeoutRemaining is the time left until the state timeout of CurrentState fires, 0 if none is armed.
+	StateTimeoutRemaining time.Duration `json:",omitempty"`
+	// IdempotencyKeys are the keys of TriggerEventIdempotent that have not expired, with their expiry.
+	IdempotencyKeys map[string]time.Time `json:",omitempty"`
+	Timestamp       time.Time
+}
+
+// TimerSnapshot is an armed timed transition in a StatesManSnapshot.
+type TimerSnapshot struct {
+	Index     int // position in TimedTransitions
+	From      StateID
+	To        StateID
+	Remaining time.Duration
+}
+
+// ExportSnapshot exports the runtime state of the machine to JSON: the current state, the
+// context, the time left on armed timed transitions and the state timeout, and the idempotency
+// keys seen by TriggerEventIdempotent that have not expired. Together with Export it allows a
+// crashed process to resume a machine where it left off.
+//
+// Returns:
+//   - []byte: the JSON encoded StatesManSnapshot
+//   - error: ErrSnapshotContext naming the first context value that cannot be encoded
+//
+// Example usage:
+//
+//	snapshot, err := sm.ExportSnapshot()
+//	if err != nil {
+//	    return err
+//	}
+//	_, err = db.Exec(ctx, "UPDATE jobs SET machine = $1 WHERE id = $2", snapshot, jobID)
+func (sm *StatesMan) ExportSnapshot() ([]byte, error) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	keys := make([]string, 0, len(sm.Context))
+	for key := range sm.Context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := json.Marshal(sm.Context[key]); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrSnapshotContext, key, err)
+		}
+	}
+
+	now := time.Now()
+	snapshot := StatesManSnapshot{
+		Name:            sm.Name,
+		CurrentState:    sm.CurrentState,
+		Context:         sm.Context,
+		IdempotencyKeys: sm.idempotency.snapshot(now),
+		Timestamp:       now,
+	}
+	for i, tt := range sm.TimedTransitions {
+		if tt.timer == nil {
+			continue
+		}
+		snapshot.Timers = append(snapshot.Timers, TimerSnapshot{
+			Index:     i,
+			From:      tt.From,
+			To:        tt.To,
+			Remaining: remainingUntil(tt.deadline, now),
+		})
+	}
+	if sm.stateTimer != nil {
+		snapshot.StateTimeoutRemaining = remainingUntil(sm.stateDeadline, now)
+	}
+	return json.Marshal(snapshot)
+}
+
+// RestoreSnapshot restores the runtime state written by ExportSnapshot. The states and
+// transitions must already be configured, in code or with Import. Timers are re-armed with
+// the time that was left when the snapshot was taken; the time between export and restore
+// does not count. Timed transitions of the current state missing from the snapshot are armed
+// with their full duration. Idempotency keys keep their absolute expiry.
+//
+// Context values are decoded from JSON, so numbers become float64 and structs become maps.
+// Pending events and the transition history are not part of the snapshot.
+//
+// Returns:
+//   - error: ErrInvalidSnapshot if the JSON is invalid or references unknown states or timed transitions
+//
+// Example usage:
+//
+//	sm := newJobMachine() // same configuration as the exporting process
+//	if err := sm.RestoreSnapshot(snapshot); err != nil {
+//	    return err
+//	}
+//	go sm.Run()
+func (sm *StatesMan) RestoreSnapshot(data []byte) error {
+	var snapshot StatesManSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, exists := sm.States[snapshot.CurrentState]; !exists {
+		return fmt.Errorf("%w: state %s does not exist", ErrInvalidSnapshot, snapshot.CurrentState)
+	}
+	remaining := make(map[int]time.Duration, len(snapshot.Timers))
+	for _, timer := range snapshot.Timers {
+		if timer.Index < 0 || timer.Index >= len(sm.TimedTransitions) ||
+			sm.TimedTransitions[timer.Index].From != timer.From || sm.TimedTransitions[timer.Index].To != timer.To {
+			return fmt.Errorf("%w: unknown timed transition %s -> %s", ErrInvalidSnapshot, timer.From, timer.To)
+		}
+		remaining[timer.Index] = timer.Remaining
+	}
+
+	sm.CurrentState = snapshot.CurrentState
+	sm.idempotency.restore(snapshot.IdempotencyKeys, time.Now())
+	sm.Context = snapshot.Context
+	if sm.Context == nil {
+		sm.Context = make(map[string]interface{})
+	}
+	// A stopped machine re-arms all timers with their full duration when it runs again,
+	// so the restored timers are armed right away instead, as for a new machine.
+	sm.stopped = false
+
+	sm.stateTimerSeq++
+	if sm.stateTimer != nil {
+		sm.stateTimer.Stop()
+		sm.stateTimer = nil
+	}
+	if timeout, ok := sm.stateTimeouts[sm.CurrentState]; ok {
+		d := timeout.Duration
+		if snapshot.StateTimeoutRemaining > 0 {
+			d = snapshot.StateTimeoutRemaining
+		}
+		sm.startStateTimer(sm.CurrentState, timeout, d)
+	}
+
+	for i := range sm.TimedTransitions {
+		tt := &sm.TimedTransitions[i]
+		if tt.timer != nil {
+			tt.timer.Stop()
+			tt.timer = nil
+		}
+		if tt.From != sm.CurrentState {
+			continue
+		}
+		d, ok := remaining[i]
+		if !ok {
+			d = tt.Duration
+		}
+		sm.armTimedTransition(i, d)
+	}
+	return nil
+}
+
+// remainingUntil returns the time left until deadline, at least 0
+func remainingUntil(deadline, now time.Time) time.Duration {
+	if d := deadline.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -1,7 +1,13 @@
 package gonuts
 
 import (
+	"container/heap"
+	"math"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // TrieNode represents a node in the Trie data structure.
@@ -9,10 +15,23 @@ type TrieNode struct {
 	children map[rune]*TrieNode
 	isEnd    bool
 	value    interface{} // This can be used to store additional information at each node
+	usage    *trieUsage  // Ranking metadata of terminal nodes, nil until a weight or use is recorded
+}
+
+// trieUsage holds the data used by AutoCompleteAdaptive to rank a word.
+type trieUsage struct {
+	weight   float64
+	uses     int
+	lastUsed time.Time
 }
 
 // Trie is a tree-like data structure for efficient string operations.
+//
+// A Trie is safe for concurrent use. Queries hold a read lock for their whole traversal, so they
+// run in parallel with each other and every result reflects a consistent state of the Trie:
+// an insert happens either completely before or completely after a query.
 type Trie struct {
+	mu   sync.RWMutex
 	root *TrieNode
 }
 
@@ -32,6 +51,13 @@ func NewTrie() *Trie {
 //	trie := NewTrie()
 //	trie.Insert("apple")
 func (t *Trie) Insert(word string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.insert(word)
+}
+
+// insert adds a word and returns its node; the caller holds the write lock.
+func (t *Trie) insert(word string) *TrieNode {
 	node := t.root
 	for _, ch := range word {
 		if node.children[ch] == nil {
@@ -40,6 +66,7 @@ func (t *Trie) Insert(word string) {
 		node = node.children[ch]
 	}
 	node.isEnd = true
+	return node
 }
 
 // InsertWithValue adds a word to the Trie with an associated value.
@@ -49,18 +76,13 @@ func (t *Trie) Insert(word string) {
 //	trie := NewTrie()
 //	trie.InsertWithValue("apple", 42)
 func (t *Trie) InsertWithValue(word string, value interface{}) {
-	node := t.root
-	for _, ch := range word {
-		if node.children[ch] == nil {
-			node.children[ch] = &TrieNode{children: make(map[rune]*TrieNode)}
-		}
-		node = node.children[ch]
-	}
-	node.isEnd = true
-	node.value = value
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.insert(word).value = value
 }
 
-// BulkInsert efficiently inserts multiple words into the Trie.
+// BulkInsert efficiently inserts multiple words into the Trie. Concurrent queries see either
+// none or all of the words.
 //
 // Example:
 //
@@ -68,8 +90,10 @@ func (t *Trie) InsertWithValue(word string, value interface{}) {
 //	words := []string{"apple", "app", "application"}
 //	trie.BulkInsert(words)
 func (t *Trie) BulkInsert(words []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	for _, word := range words {
-		t.Insert(word)
+		t.insert(word)
 	}
 }
 
@@ -82,6 +106,8 @@ func (t *Trie) BulkInsert(words []string) {
 //	fmt.Println(trie.Search("apple"))  // Output: true
 //	fmt.Println(trie.Search("app"))    // Output: false
 func (t *Trie) Search(word string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	node := t.findNode(word)
 	return node != nil && node.isEnd
 }
@@ -95,10 +121,12 @@ func (t *Trie) Search(word string) bool {
 //	fmt.Println(trie.StartsWith("app"))  // Output: true
 //	fmt.Println(trie.StartsWith("ban"))  // Output: false
 func (t *Trie) StartsWith(prefix string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.findNode(prefix) != nil
 }
 
-// findNode is a helper function to find a node for a given word or prefix.
+// findNode is a helper function to find a node for a given word or prefix; the caller holds the lock.
 func (t *Trie) findNode(word string) *TrieNode {
 	node := t.root
 	for _, ch := range word {
@@ -119,6 +147,8 @@ func (t *Trie) findNode(word string) *TrieNode {
 //	suggestions := trie.AutoComplete("app", 3)
 //	fmt.Println(suggestions)  // Output: [app apple application]
 func (t *Trie) AutoComplete(prefix string, limit int) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	node := t.findNode(prefix)
 	if node == nil {
 		return []string{}
@@ -152,6 +182,8 @@ func (t *Trie) dfs(node *TrieNode, prefix string, result *[]string, limit int) {
 //	matches := trie.WildcardSearch("r.t")
 //	fmt.Println(matches)  // Output: [rat]
 func (t *Trie) WildcardSearch(pattern string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	result := []string{}
 	t.wildcardDfs(t.root, "", pattern, &result)
 	return result
@@ -189,6 +221,8 @@ func (t *Trie) LongestCommonPrefix() string {
 	if t.root == nil {
 		return ""
 	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
 	var sb strings.Builder
 	node := t.root
@@ -208,3 +242,278 @@ func (t *Trie) LongestCommonPrefix() string {
 
 	return sb.String()
 }
+
+// LongestPrefixOf finds the longest inserted word that is a prefix of s, with its value, e.g. to
+// route a URL path to the handler of its longest registered prefix. It walks s once, so it takes
+// O(len(s)) regardless of the size of the Trie.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.InsertWithValue("/api", apiHandler)
+//	trie.InsertWithValue("/api/v1/users", usersHandler)
+//	prefix, handler, found := trie.LongestPrefixOf("/api/v1/users/42")
+//	fmt.Println(prefix, found)  // Output: /api/v1/users true
+func (t *Trie) LongestPrefixOf(s string) (prefix string, value interface{}, found bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	node := t.root
+	if node.isEnd {
+		value, found = node.value, true
+	}
+	for i := 0; i < len(s); {
+		ch, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if node = node.children[ch]; node == nil {
+			break
+		}
+		if node.isEnd {
+			prefix, value, found = s[:i], node.value, true
+		}
+	}
+	return prefix, value, found
+}
+
+// KeysWithPrefix returns up to limit words starting with prefix in lexicographic order, or all of
+// them if limit <= 0. Unlike AutoComplete, the order is deterministic.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.BulkInsert([]string{"/api", "/api/v1", "/api/v2", "/admin"})
+//	fmt.Println(trie.KeysWithPrefix("/api", 0))  // Output: [/api /api/v1 /api/v2]
+func (t *Trie) KeysWithPrefix(prefix string, limit int) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := []string{}
+	node := t.findNode(prefix)
+	if node == nil {
+		return result
+	}
+	var walk func(node *TrieNode, word []rune) bool
+	walk = func(node *TrieNode, word []rune) bool {
+		if node.isEnd {
+			result = append(result, string(word))
+			if len(result) == limit {
+				return false
+			}
+		}
+		keys := make([]rune, 0, len(node.children))
+		for ch := range node.children {
+			keys = append(keys, ch)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		for _, ch := range keys {
+			if !walk(node.children[ch], append(word, ch)) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(node, []rune(prefix))
+	return result
+}
+
+// InsertWithWeight adds a word to the Trie with a static ranking weight used by AutoCompleteAdaptive.
+// Words inserted without a weight have a weight of 1.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.InsertWithWeight("apple", 2.5)
+func (t *Trie) InsertWithWeight(word string, weight float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usageOf(t.insert(word)).weight = weight
+}
+
+// RecordUse records that a word was used at the given time, inserting it if necessary.
+//
+// Example:
+//
+//	trie.RecordUse("application", time.Now())
+func (t *Trie) RecordUse(word string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := usageOf(t.insert(word))
+	usage.uses++
+	if at.After(usage.lastUsed) {
+		usage.lastUsed = at
+	}
+}
+
+// usageOf returns the usage metadata of a terminal node, creating it if needed.
+func usageOf(node *TrieNode) *trieUsage {
+	if node.usage == nil {
+		node.usage = &trieUsage{weight: 1}
+	}
+	return node.usage
+}
+
+// AutoCompleteAdaptive returns up to limit words starting with prefix, ranked by recency-aware score.
+//
+// The score of a word is weight * uses * 2^(-age/halfLife), where age is the time since the word
+// was last used. Scores are computed at query time, so no background decay is needed.
+// Words with equal scores (including never used words) are ordered lexicographically.
+//
+// Example:
+//
+//	trie.RecordUse("apple", time.Now().Add(-time.Hour))
+//	trie.RecordUse("application", time.Now())
+//	suggestions := trie.AutoCompleteAdaptive("app", 2, 24*time.Hour)
+//	fmt.Println(suggestions)  // Output: [application apple]
+func (t *Trie) AutoCompleteAdaptive(prefix string, limit int, halfLife time.Duration) []string {
+	return t.AutoCompleteAdaptiveAt(prefix, limit, halfLife, time.Now())
+}
+
+// AutoCompleteAdaptiveAt is like AutoCompleteAdaptive but computes the ages relative to now.
+func (t *Trie) AutoCompleteAdaptiveAt(prefix string, limit int, halfLife time.Duration, now time.Time) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	node := t.findNode(prefix)
+	if node == nil || limit <= 0 {
+		return []string{}
+	}
+
+	return rankWords([]*TrieNode{node}, []string{prefix}, limit, func(usage *trieUsage) float64 {
+		return trieScore(usage, halfLife, now)
+	})
+}
+
+// StartsWithAny checks if any word in the Trie starts with one of the given prefixes.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.Insert("apple")
+//	fmt.Println(trie.StartsWithAny([]string{"ban", "app"}))  // Output: true
+func (t *Trie) StartsWithAny(prefixes []string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, prefix := range prefixes {
+		if t.findNode(prefix) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoCompleteMulti returns up to limit words starting with any of the given prefixes, e.g. the
+// typo variants of a search term, or all of them if limit <= 0. Every word is returned once and
+// the limit applies to the merged result, not per prefix.
+//
+// Words are ranked by weight * uses (see InsertWithWeight and RecordUse, without the decay of
+// AutoCompleteAdaptive); words with equal scores, including all words of a Trie without
+// recorded weights or uses, are ordered lexicographically. Prefixes that extend another given
+// prefix, like "appl" and "app", are covered by the shorter one, so shared subtrees are walked
+// only once.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.BulkInsert([]string{"apple", "application", "apply", "aple"})
+//	fmt.Println(trie.AutoCompleteMulti([]string{"app", "appl", "apl"}, 3))  // Output: [aple apple application]
+func (t *Trie) AutoCompleteMulti(prefixes []string, limit int) []string {
+	covering := append([]string(nil), prefixes...)
+	sort.Strings(covering)
+	kept := covering[:0]
+	for _, prefix := range covering {
+		// Sorted, a prefix extending another one directly follows it or one of its extensions
+		if len(kept) > 0 && strings.HasPrefix(prefix, kept[len(kept)-1]) {
+			continue
+		}
+		kept = append(kept, prefix)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var nodes []*TrieNode
+	var found []string
+	for _, prefix := range kept {
+		if node := t.findNode(prefix); node != nil {
+			nodes = append(nodes, node)
+			found = append(found, prefix)
+		}
+	}
+	return rankWords(nodes, found, limit, func(usage *trieUsage) float64 {
+		return trieScore(usage, 0, time.Time{})
+	})
+}
+
+// rankWords returns up to limit words of the subtrees below nodes (reached by prefixes), or all
+// of them if limit <= 0, best score first; the caller holds the lock.
+func rankWords(nodes []*TrieNode, prefixes []string, limit int, score func(*trieUsage) float64) []string {
+	h := &scoredWordHeap{}
+	var walk func(node *TrieNode, word []rune)
+	walk = func(node *TrieNode, word []rune) {
+		if node.isEnd {
+			candidate := scoredWord{word: string(word), score: score(node.usage)}
+			if limit <= 0 || h.Len() < limit {
+				heap.Push(h, candidate)
+			} else if h.less(h.items[0], candidate) {
+				h.items[0] = candidate
+				heap.Fix(h, 0)
+			}
+		}
+		for ch, child := range node.children {
+			walk(child, append(word, ch))
+		}
+	}
+	for i, node := range nodes {
+		walk(node, []rune(prefixes[i]))
+	}
+
+	sort.Slice(h.items, func(i, j int) bool {
+		return h.less(h.items[j], h.items[i])
+	})
+	result := make([]string, len(h.items))
+	for i, item := range h.items {
+		result[i] = item.word
+	}
+	return result
+}
+
+// trieScore computes weight * uses * 2^(-age/halfLife) for a word.
+func trieScore(usage *trieUsage, halfLife time.Duration, now time.Time) float64 {
+	if usage == nil || usage.uses == 0 {
+		return 0
+	}
+	score := usage.weight * float64(usage.uses)
+	if halfLife > 0 {
+		age := now.Sub(usage.lastUsed)
+		if age < 0 {
+			age = 0
+		}
+		score *= math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return score
+}
+
+// scoredWord is a ranking candidate of AutoCompleteAdaptive.
+type scoredWord struct {
+	word  string
+	score float64
+}
+
+// scoredWordHeap is a min-heap keeping the best candidates, the worst one at the top.
+type scoredWordHeap struct {
+	items []scoredWord
+}
+
+// less reports whether a ranks below b: lower score, or equal score and lexicographically greater word.
+func (h *scoredWordHeap) less(a, b scoredWord) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.word > b.word
+}
+
+func (h *scoredWordHeap) Len() int           { return len(h.items) }
+func (h *scoredWordHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *scoredWordHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *scoredWordHeap) Push(x interface{}) { h.items = append(h.items, x.(scoredWord)) }
+func (h *scoredWordHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,251 @@
+package gonuts
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTrieAutoCompleteAdaptiveDecaysOldUses(t *testing.T) {
+	const halfLife = time.Hour
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	trie := NewTrie()
+	trie.BulkInsert([]string{"apple", "application", "apply", "apricot", "banana"})
+
+	// apple was used 3 times two half-lives ago: 3 * 1/4 = 0.75
+	for i := 0; i < 3; i++ {
+		trie.RecordUse("apple", now.Add(-2*halfLife))
+	}
+	// application once right now: 1
+	trie.RecordUse("application", now)
+	// apply once half a life ago, with weight 2: 2 * 2^-0.5 ≈ 1.41
+	trie.InsertWithWeight("apply", 2)
+	trie.RecordUse("apply", now.Add(-halfLife/2))
+
+	want := []string{"apply", "application", "apple"}
+	if got := trie.AutoCompleteAdaptiveAt("app", 3, halfLife, now); !slices.Equal(got, want) {
+		t.Errorf("AutoCompleteAdaptiveAt(app) = %v, want %v", got, want)
+	}
+
+	// Without decay, apple's 3 uses outrank the others
+	want = []string{"apple", "apply", "application"}
+	if got := trie.AutoCompleteAdaptiveAt("app", 3, 0, now); !slices.Equal(got, want) {
+		t.Errorf("AutoCompleteAdaptiveAt(app) without decay = %v, want %v", got, want)
+	}
+
+	// Six half-lives later every score has decayed by the same factor, so the order is unchanged
+	want = []string{"apply", "application", "apple"}
+	if got := trie.AutoCompleteAdaptiveAt("app", 3, halfLife, now.Add(6*halfLife)); !slices.Equal(got, want) {
+		t.Errorf("AutoCompleteAdaptiveAt(app) 6h later = %v, want %v", got, want)
+	}
+	// A fresh use of apple brings it back to the top
+	trie.RecordUse("apple", now.Add(6*halfLife))
+	if got := trie.AutoCompleteAdaptiveAt("app", 1, halfLife, now.Add(6*halfLife)); !slices.Equal(got, []string{"apple"}) {
+		t.Errorf("AutoCompleteAdaptiveAt(app, 1) after a fresh use = %v, want [apple]", got)
+	}
+}
+
+func TestTrieAutoCompleteAdaptiveTies(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	trie := NewTrie()
+	trie.BulkInsert([]string{"cherry", "cat", "car", "cab"})
+
+	// Never used words score 0 and are ordered lexicographically
+	if got := trie.AutoCompleteAdaptiveAt("ca", 10, time.Hour, now); !slices.Equal(got, []string{"cab", "car", "cat"}) {
+		t.Errorf("AutoCompleteAdaptiveAt(ca) without uses = %v", got)
+	}
+
+	// An older RecordUse counts as a use but does not move lastUsed back
+	trie.RecordUse("cat", now)
+	trie.RecordUse("car", now)
+	trie.RecordUse("car", now.Add(-time.Hour))
+	if got := trie.AutoCompleteAdaptiveAt("ca", 2, time.Hour, now); !slices.Equal(got, []string{"car", "cat"}) {
+		t.Errorf("AutoCompleteAdaptiveAt(ca, 2) = %v, want [car cat]", got)
+	}
+	// A lastUsed in the future is treated as now, not amplified
+	trie.RecordUse("cab", now.Add(time.Hour))
+	if got := trie.AutoCompleteAdaptiveAt("ca", 10, time.Hour, now); !slices.Equal(got, []string{"car", "cab", "cat"}) {
+		t.Errorf("AutoCompleteAdaptiveAt(ca) with a future use = %v, want [car cab cat]", got)
+	}
+
+	if got := trie.AutoCompleteAdaptiveAt("ca", 0, time.Hour, now); len(got) != 0 {
+		t.Errorf("AutoCompleteAdaptiveAt with limit 0 = %v", got)
+	}
+	if got := trie.AutoCompleteAdaptiveAt("dog", 10, time.Hour, now); len(got) != 0 {
+		t.Errorf("AutoCompleteAdaptiveAt(dog) = %v", got)
+	}
+}
+
+// TestTrieConcurrentUse is meant to run with -race: writers insert batches while readers query
+// the Trie, and every query sees a batch either completely or not at all
+func TestTrieConcurrentUse(t *testing.T) {
+	const writers, batches, batchSize = 4, 50, 8
+	trie := NewTrie()
+	var done atomic.Bool
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func(r int) {
+			defer readers.Done()
+			now := time.Now()
+			for i := 0; !done.Load(); i++ {
+				prefix := fmt.Sprintf("w%d/b%d/", r%writers, i%batches)
+				if got := trie.AutoComplete(prefix, 0); len(got) != 0 && len(got) != batchSize {
+					t.Errorf("AutoComplete(%s) saw %d words of a batch of %d", prefix, len(got), batchSize)
+					return
+				}
+				if got := trie.KeysWithPrefix(prefix, 0); len(got) != 0 && len(got) != batchSize {
+					t.Errorf("KeysWithPrefix(%s) saw %d words of a batch of %d", prefix, len(got), batchSize)
+					return
+				}
+				trie.Search(prefix + "0")
+				trie.LongestPrefixOf(prefix + "0/extra")
+				trie.AutoCompleteAdaptiveAt(prefix, 3, time.Minute, now)
+				trie.AutoCompleteMulti([]string{prefix, "w0/"}, 5)
+				trie.FuzzySearch(prefix+"1", 1)
+				trie.RecordUse("used/"+prefix, now)
+				CompressTrie(trie)
+			}
+		}(r)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for b := 0; b < batches; b++ {
+				words := make([]string, batchSize)
+				for i := range words {
+					words[i] = fmt.Sprintf("w%d/b%d/%d", w, b, i)
+				}
+				trie.BulkInsert(words)
+			}
+		}(w)
+	}
+	wg.Wait()
+	done.Store(true)
+	readers.Wait()
+
+	if n := len(trie.KeysWithPrefix("w", 0)); n != writers*batches*batchSize {
+		t.Errorf("%d words after all inserts, want %d", n, writers*batches*batchSize)
+	}
+}
+
+func TestTrieLongestPrefixOf(t *testing.T) {
+	trie := NewTrie()
+	trie.InsertWithValue("/api", "api")
+	trie.InsertWithValue("/api/v1", "v1")
+	trie.InsertWithValue("/api/v1/users", "users")
+	trie.Insert("/über")
+
+	tests := []struct {
+		s, prefix string
+		value     interface{}
+		found     bool
+	}{
+		{"/api/v1/users/42", "/api/v1/users", "users", true},
+		{"/api/v1/user", "/api/v1", "v1", true},
+		{"/api/v2", "/api", "api", true},
+		{"/api", "/api", "api", true},
+		{"/über/alles", "/über", nil, true}, // a word without a value, ending after a multi-byte rune
+		{"/ap", "", nil, false},
+		{"/admin", "", nil, false},
+		{"", "", nil, false},
+	}
+	for _, tt := range tests {
+		prefix, value, found := trie.LongestPrefixOf(tt.s)
+		if prefix != tt.prefix || value != tt.value || found != tt.found {
+			t.Errorf("LongestPrefixOf(%q) = %q, %v, %v, want %q, %v, %v", tt.s, prefix, value, found, tt.prefix, tt.value, tt.found)
+		}
+	}
+
+	trie.InsertWithValue("", "default")
+	if prefix, value, found := trie.LongestPrefixOf("/admin"); prefix != "" || value != "default" || !found {
+		t.Errorf("LongestPrefixOf(/admin) = %q, %v, %v, want the empty word", prefix, value, found)
+	}
+}
+
+func TestTrieKeysWithPrefix(t *testing.T) {
+	trie := NewTrie()
+	trie.BulkInsert([]string{"/api/v2", "/admin", "/api", "/api/v1", "/api/v1/users", "/about"})
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"/api", 0, []string{"/api", "/api/v1", "/api/v1/users", "/api/v2"}},
+		{"/api", 2, []string{"/api", "/api/v1"}},
+		{"/api/", -1, []string{"/api/v1", "/api/v1/users", "/api/v2"}},
+		{"/a", 3, []string{"/about", "/admin", "/api"}},
+		{"/api/v3", 0, []string{}},
+	}
+	for _, tt := range tests {
+		// The order is deterministic, not the order of map iteration
+		for i := 0; i < 5; i++ {
+			if got := trie.KeysWithPrefix(tt.prefix, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("KeysWithPrefix(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTrieAutoCompleteMulti(t *testing.T) {
+	trie := NewTrie()
+	trie.BulkInsert([]string{"apple", "application", "apply", "aple", "banana", "band"})
+
+	tests := []struct {
+		prefixes []string
+		limit    int
+		want     []string
+	}{
+		// "appl" is covered by "app", so apple is not returned twice
+		{[]string{"app", "appl", "apl"}, 0, []string{"aple", "apple", "application", "apply"}},
+		// The limit applies to the merged result
+		{[]string{"app", "appl", "apl"}, 3, []string{"aple", "apple", "application"}},
+		{[]string{"ban", "xyz", "apl"}, 0, []string{"aple", "banana", "band"}},
+		{[]string{"apple", "apple"}, 0, []string{"apple"}},
+		{[]string{"xyz"}, 5, []string{}},
+		{nil, 5, []string{}},
+	}
+	for _, tt := range tests {
+		if got := trie.AutoCompleteMulti(tt.prefixes, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("AutoCompleteMulti(%q, %d) = %v, want %v", tt.prefixes, tt.limit, got, tt.want)
+		}
+	}
+
+	// Weights and uses rank the merged words, without decay
+	now := time.Now()
+	trie.InsertWithWeight("band", 3)
+	trie.RecordUse("band", now.Add(-365*24*time.Hour))
+	trie.RecordUse("apply", now)
+	trie.RecordUse("apply", now)
+	want := []string{"band", "apply", "aple"}
+	if got := trie.AutoCompleteMulti([]string{"apl", "app", "ban"}, 3); !slices.Equal(got, want) {
+		t.Errorf("AutoCompleteMulti with weights and uses = %v, want %v", got, want)
+	}
+}
+
+func TestTrieStartsWithAny(t *testing.T) {
+	trie := NewTrie()
+	trie.BulkInsert([]string{"apple", "banana"})
+	tests := []struct {
+		prefixes []string
+		want     bool
+	}{
+		{[]string{"ban", "app"}, true},
+		{[]string{"xyz", "apple"}, true},
+		{[]string{"xyz", "applez"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := trie.StartsWithAny(tt.prefixes); got != tt.want {
+			t.Errorf("StartsWithAny(%q) = %v, want %v", tt.prefixes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package gonuts
+
+import "sort"
+
+// MatchResult is a word found by a fuzzy search of a Trie with its edit distance to the query.
+type MatchResult struct {
+	Word     string
+	Distance int
+}
+
+// FuzzySearch returns the words within a Levenshtein distance of maxDistance from word, sorted by
+// distance and then lexicographically. Distances are counted in runes, so "Müller" and "Muller"
+// have a distance of 1.
+//
+// The search walks the Trie once, computing one row of the edit distance matrix per node and
+// skipping subtrees whose row exceeds maxDistance, so its cost depends on maxDistance rather
+// than on the number of stored words.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.BulkInsert([]string{"Straße", "Strasse", "Strand"})
+//	matches := trie.FuzzySearch("Strase", 1)
+//	fmt.Println(matches)  // Output: [{Strasse 1} {Straße 1}]
+func (t *Trie) FuzzySearch(word string, maxDistance int) []MatchResult {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := []MatchResult{}
+	if maxDistance < 0 {
+		return result
+	}
+
+	query := []rune(word)
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	if t.root.isEnd && row[len(query)] <= maxDistance {
+		result = append(result, MatchResult{Word: "", Distance: row[len(query)]})
+	}
+	for ch, child := range t.root.children {
+		t.fuzzyDfs(child, ch, []rune{ch}, query, row, maxDistance, &result)
+	}
+	sortMatchResults(result)
+	return result
+}
+
+// fuzzyDfs is a helper function for FuzzySearch.
+func (t *Trie) fuzzyDfs(node *TrieNode, ch rune, word []rune, query []rune, prevRow []int, maxDistance int, result *[]MatchResult) {
+	row, rowMin := nextEditRow(prevRow, query, ch)
+	if node.isEnd && row[len(query)] <= maxDistance {
+		*result = append(*result, MatchResult{Word: string(word), Distance: row[len(query)]})
+	}
+	if rowMin > maxDistance {
+		return
+	}
+	for next, child := range node.children {
+		t.fuzzyDfs(child, next, append(word, next), query, row, maxDistance, result)
+	}
+}
+
+// FuzzyAutoComplete returns up to limit words that start with a prefix within a Levenshtein
+// distance of maxDistance from prefix, so typos in the typed part are tolerated. The distance of
+// a word is the smallest distance of any of its prefixes; results are sorted by distance and then
+// lexicographically.
+//
+// Example:
+//
+//	trie := NewTrie()
+//	trie.BulkInsert([]string{"application", "apply", "banana"})
+//	matches := trie.FuzzyAutoComplete("aplp", 1, 10)
+//	fmt.Println(matches)  // Output: [{application 1} {apply 1}]
+func (t *Trie) FuzzyAutoComplete(prefix string, maxDistance, limit int) []MatchResult {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := []MatchResult{}
+	if maxDistance < 0 || limit <= 0 {
+		return result
+	}
+
+	query := []rune(prefix)
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	distance := len(query)
+	if distance == 0 {
+		collectMatches(t.root, nil, 0, &result)
+	} else {
+		if t.root.isEnd && distance <= maxDistance {
+			result = append(result, MatchResult{Word: "", Distance: distance})
+		}
+		for ch, child := range t.root.children {
+			if distance <= maxDistance {
+				t.fuzzyPrefixBestDfs(child, ch, []rune{ch}, query, row, distance, &result)
+			} else {
+				t.fuzzyPrefixDfs(child, ch, []rune{ch}, query, row, maxDistance, &result)
+			}
+		}
+	}
+	sortMatchResults(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// fuzzyPrefixDfs is a helper function for FuzzyAutoComplete. Once the typed prefix matches the
+// path, the subtree is walked further while the distance can still shrink and collected
+// afterwards.
+func (t *Trie) fuzzyPrefixDfs(node *TrieNode, ch rune, word []rune, query []rune, prevRow []int, maxDistance int, result *[]MatchResult) {
+	row, rowMin := nextEditRow(prevRow, query, ch)
+	distance := row[len(query)]
+	if distance <= maxDistance && distance == rowMin {
+		// no deeper prefix can be closer
+		collectMatches(node, word, distance, result)
+		return
+	}
+	if rowMin > maxDistance {
+		return
+	}
+	if node.isEnd && distance <= maxDistance {
+		*result = append(*result, MatchResult{Word: string(word), Distance: distance})
+	}
+	for next, child := range node.children {
+		if distance <= maxDistance {
+			t.fuzzyPrefixBestDfs(child, next, append(word, next), query, row, distance, result)
+		} else {
+			t.fuzzyPrefixDfs(child, next, append(word, next), query, row, maxDistance, result)
+		}
+	}
+}
+
+// fuzzyPrefixBestDfs walks the subtree below a matching prefix, keeping the smallest distance of
+// the prefixes of each word.
+func (t *Trie) fuzzyPrefixBestDfs(node *TrieNode, ch rune, word []rune, query []rune, prevRow []int, best int, result *[]MatchResult) {
+	row, rowMin := nextEditRow(prevRow, query, ch)
+	if row[len(query)] < best {
+		best = row[len(query)]
+	}
+	if rowMin >= best {
+		// the distance cannot shrink any further below this node
+		collectMatches(node, word, best, result)
+		return
+	}
+	if node.isEnd {
+		*result = append(*result, MatchResult{Word: string(word), Distance: best})
+	}
+	for next, child := range node.children {
+		t.fuzzyPrefixBestDfs(child, next, append(word, next), query, row, best, result)
+	}
+}
+
+// collectMatches adds all words of the subtree of node with the given distance.
+func collectMatches(node *TrieNode, word []rune, distance int, result *[]MatchResult) {
+	if node.isEnd {
+		*result = append(*result, MatchResult{Word: string(word), Distance: distance})
+	}
+	for ch, child := range node.children {
+		collectMatches(child, append(word, ch), distance, result)
+	}
+}
+
+// nextEditRow computes the edit distance row of the query against the path extended by ch and
+// returns it with its smallest value.
+func nextEditRow(prevRow []int, query []rune, ch rune) ([]int, int) {
+	row := make([]int, len(prevRow))
+	row[0] = prevRow[0] + 1
+	rowMin := row[0]
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if query[i-1] == ch {
+			cost = 0
+		}
+		row[i] = prevRow[i-1] + cost // substitution
+		if v := prevRow[i] + 1; v < row[i] {
+			row[i] = v // insertion
+		}
+		if v := row[i-1] + 1; v < row[i] {
+			row[i] = v // deletion
+		}
+		if row[i] < rowMin {
+			rowMin = row[i]
+		}
+	}
+	return row, rowMin
+}
+
+// sortMatchResults sorts matches by distance and then lexicographically.
+func sortMatchResults(matches []MatchResult) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Word < matches[j].Word
+	})
+}
@@ -0,0 +1,234 @@
+package gonuts
+
+import "sort"
+
+// typedTrieNode represents a node in the TypedTrie data structure.
+type typedTrieNode[V any] struct {
+	children map[rune]*typedTrieNode[V]
+	isEnd    bool
+	value    V
+}
+
+// TypedTrie is a string-keyed map backed by a trie, with typed values.
+//
+// Unlike Trie, whose values are interface{} and optional, every key of a TypedTrie has a
+// value of type V. Prefix queries (AutoComplete, Walk) return keys in lexicographic (rune) order.
+// A TypedTrie is not safe for concurrent use.
+//
+// Migrating from Trie values:
+//   - InsertWithValue(word, v) -> Put(key, v)
+//   - Insert(word)             -> Put(key, zero value), e.g. TypedTrie[struct{}]
+//   - Search(word)             -> Has(key), or Get(key) to also get the value
+//   - AutoComplete(prefix, n)  -> AutoComplete(prefix, n), which returns key-value pairs
+type TypedTrie[V any] struct {
+	root *typedTrieNode[V]
+	size int
+}
+
+// NewTypedTrie creates and returns a new TypedTrie.
+//
+// Example:
+//
+//	routes := NewTypedTrie[http.Handler]()
+func NewTypedTrie[V any]() *TypedTrie[V] {
+	return &TypedTrie[V]{root: newTypedTrieNode[V]()}
+}
+
+func newTypedTrieNode[V any]() *typedTrieNode[V] {
+	return &typedTrieNode[V]{children: make(map[rune]*typedTrieNode[V])}
+}
+
+// Put sets the value of a key, replacing an existing value.
+//
+// Example:
+//
+//	trie := NewTypedTrie[int]()
+//	trie.Put("apple", 42)
+func (t *TypedTrie[V]) Put(key string, value V) {
+	node := t.insertNode(key)
+	if !node.isEnd {
+		node.isEnd = true
+		t.size++
+	}
+	node.value = value
+}
+
+// Get returns the value of a key and whether the key exists.
+//
+// Example:
+//
+//	if count, ok := trie.Get("apple"); ok {
+//		fmt.Println(count)  // Output: 42
+//	}
+func (t *TypedTrie[V]) Get(key string) (V, bool) {
+	node := t.findNode(key)
+	if node == nil || !node.isEnd {
+		var zero V
+		return zero, false
+	}
+	return node.value, true
+}
+
+// Has checks if a key exists in the TypedTrie.
+func (t *TypedTrie[V]) Has(key string) bool {
+	node := t.findNode(key)
+	return node != nil && node.isEnd
+}
+
+// GetOrInsert returns the value of a key, inserting the value returned by mk if the key
+// does not exist. mk is only called for missing keys.
+//
+// Example:
+//
+//	trie := NewTypedTrie[[]string]()
+//	ids := trie.GetOrInsert("apple", func() []string { return nil })
+func (t *TypedTrie[V]) GetOrInsert(key string, mk func() V) V {
+	node := t.insertNode(key)
+	if !node.isEnd {
+		node.value = mk()
+		node.isEnd = true
+		t.size++
+	}
+	return node.value
+}
+
+// Replace sets the value of a key and returns the previous value.
+//
+// Returns:
+//   - old: the previous value, the zero value if the key did not exist
+//   - existed: whether the key existed
+//
+// Example:
+//
+//	old, existed := trie.Replace("apple", 43)
+//	fmt.Println(old, existed)  // Output: 42 true
+func (t *TypedTrie[V]) Replace(key string, value V) (old V, existed bool) {
+	node := t.insertNode(key)
+	old, existed = node.value, node.isEnd
+	if !existed {
+		node.isEnd = true
+		t.size++
+	}
+	node.value = value
+	return old, existed
+}
+
+// Delete removes a key and returns its value and whether it existed.
+// Nodes that no longer lead to a key are pruned.
+func (t *TypedTrie[V]) Delete(key string) (V, bool) {
+	var zero V
+	runes := []rune(key)
+	path := make([]*typedTrieNode[V], 0, len(runes)+1)
+	node := t.root
+	path = append(path, node)
+	for _, ch := range runes {
+		node = node.children[ch]
+		if node == nil {
+			return zero, false
+		}
+		path = append(path, node)
+	}
+	if !node.isEnd {
+		return zero, false
+	}
+	value := node.value
+	node.isEnd = false
+	node.value = zero
+	t.size--
+
+	for i := len(runes); i > 0; i-- {
+		n := path[i]
+		if n.isEnd || len(n.children) > 0 {
+			break
+		}
+		delete(path[i-1].children, runes[i-1])
+	}
+	return value, true
+}
+
+// Len returns the number of keys in the TypedTrie.
+func (t *TypedTrie[V]) Len() int {
+	return t.size
+}
+
+// AutoComplete returns up to limit key-value pairs whose keys start with the given prefix,
+// in lexicographic order. A limit of 0 or less returns all matches.
+//
+// Example:
+//
+//	trie := NewTypedTrie[int]()
+//	trie.Put("app", 1)
+//	trie.Put("apple", 2)
+//	trie.Put("banana", 3)
+//	for _, kv := range trie.AutoComplete("app", 10) {
+//		fmt.Println(kv.Key, kv.Value)  // Output: app 1, apple 2
+//	}
+func (t *TypedTrie[V]) AutoComplete(prefix string, limit int) []KV[string, V] {
+	result := []KV[string, V]{}
+	t.Walk(prefix, func(key string, value V) bool {
+		result = append(result, KV[string, V]{Key: key, Value: value})
+		return limit <= 0 || len(result) < limit
+	})
+	return result
+}
+
+// Walk calls fn for every key starting with prefix (all keys for an empty prefix) and its
+// value, in lexicographic order, until fn returns false. fn must not modify the TypedTrie.
+//
+// Example:
+//
+//	trie.Walk("app", func(key string, value int) bool {
+//		fmt.Println(key, value)
+//		return true
+//	})
+func (t *TypedTrie[V]) Walk(prefix string, fn func(key string, value V) bool) {
+	node := t.findNode(prefix)
+	if node == nil {
+		return
+	}
+	t.walk(node, []rune(prefix), fn)
+}
+
+// walk visits node and its descendants in lexicographic order. It returns false once fn stopped the walk.
+func (t *TypedTrie[V]) walk(node *typedTrieNode[V], key []rune, fn func(key string, value V) bool) bool {
+	if node.isEnd && !fn(string(key), node.value) {
+		return false
+	}
+	chars := make([]rune, 0, len(node.children))
+	for ch := range node.children {
+		chars = append(chars, ch)
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	for _, ch := range chars {
+		if !t.walk(node.children[ch], append(key, ch), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// findNode returns the node for a key or prefix, nil if there is none.
+func (t *TypedTrie[V]) findNode(key string) *typedTrieNode[V] {
+	node := t.root
+	for _, ch := range key {
+		node = node.children[ch]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// insertNode returns the node for a key, creating missing nodes.
+func (t *TypedTrie[V]) insertNode(key string) *typedTrieNode[V] {
+	node := t.root
+	for _, ch := range key {
+		child := node.children[ch]
+		if child == nil {
+			child = newTypedTrieNode[V]()
+			node.children[ch] = child
+		}
+		node = child
+	}
+	return node
+}
@@ -0,0 +1,60 @@
+package gonuts
+
+import "testing"
+
+func TestTypedTrieReplace(t *testing.T) {
+	trie := NewTypedTrie[int]()
+	trie.Put("apple", 42)
+
+	if old, existed := trie.Replace("apple", 43); old != 42 || !existed {
+		t.Errorf("Replace(apple) = %d, %v, want 42, true", old, existed)
+	}
+	if v, _ := trie.Get("apple"); v != 43 {
+		t.Errorf("Get(apple) = %d after Replace, want 43", v)
+	}
+
+	// "app" is an inner node of "apple": it does not exist and has no old value
+	if old, existed := trie.Replace("app", 7); old != 0 || existed {
+		t.Errorf("Replace(app) = %d, %v, want 0, false", old, existed)
+	}
+	if trie.Len() != 2 || !trie.Has("app") {
+		t.Errorf("Len() = %d, Has(app) = %v after inserting with Replace", trie.Len(), trie.Has("app"))
+	}
+
+	// A deleted key leaves no old value behind
+	trie.Delete("app")
+	if old, existed := trie.Replace("app", 8); old != 0 || existed {
+		t.Errorf("Replace(app) after Delete = %d, %v, want 0, false", old, existed)
+	}
+}
+
+func TestTypedTrieGetOrInsert(t *testing.T) {
+	trie := NewTypedTrie[[]string]()
+	calls := 0
+	mk := func() []string {
+		calls++
+		return []string{"new"}
+	}
+
+	ids := trie.GetOrInsert("apple", mk)
+	if calls != 1 || len(ids) != 1 || ids[0] != "new" {
+		t.Fatalf("GetOrInsert(apple) = %v with %d calls of mk, want [new] and 1 call", ids, calls)
+	}
+	trie.Put("apple", append(ids, "id-1"))
+	if ids := trie.GetOrInsert("apple", mk); calls != 1 || len(ids) != 2 {
+		t.Errorf("GetOrInsert of an existing key = %v with %d calls of mk, want the stored value and no call", ids, calls)
+	}
+
+	// An inner node is missing: mk is called and the key is counted
+	trie.GetOrInsert("app", mk)
+	if calls != 2 || trie.Len() != 2 {
+		t.Errorf("%d calls of mk, Len() = %d after GetOrInsert(app), want 2 and 2", calls, trie.Len())
+	}
+
+	// A stored zero value exists, so mk is not called
+	counts := NewTypedTrie[int]()
+	counts.Put("zero", 0)
+	if v := counts.GetOrInsert("zero", func() int { return 1 }); v != 0 {
+		t.Errorf("GetOrInsert of a key with the zero value = %d, want 0", v)
+	}
+}
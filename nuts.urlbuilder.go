@@ -1,9 +1,15 @@
 package gonuts
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // URLBuilder provides a fluent interface for constructing URLs
@@ -14,8 +20,84 @@ type URLBuilder struct {
 	host     string
 	port     string
 	path     string
-	query    url.Values
+	query    urlQuery
 	fragment string
+
+	headers         http.Header
+	basicAuthHeader bool
+	bearerToken     string
+}
+
+var (
+	// ErrURLBuilderAuthConflict is returned when both credentials and a bearer token are set.
+	ErrURLBuilderAuthConflict = errors.New("urlbuilder: credentials and bearer token are mutually exclusive")
+	// ErrURLBuilderInvalid is returned when the builder cannot produce a valid request URL.
+	ErrURLBuilderInvalid = errors.New("urlbuilder: invalid url")
+)
+
+// queryParam is a single query key/value pair
+type queryParam struct {
+	key   string
+	value string
+}
+
+// urlQuery is an ordered, allocation-light replacement for url.Values.
+// Params are kept sorted by key (values of the same key in insertion order), which is the
+// order url.Values.Encode produces, so building needs no map and no sorting.
+// Up to 8 params are stored inline without a separate allocation.
+type urlQuery struct {
+	params []queryParam
+	inline [8]queryParam
+}
+
+func (q *urlQuery) init() {
+	q.params = q.inline[:0]
+}
+
+func (q *urlQuery) add(key, value string) {
+	if q.params == nil {
+		q.init()
+	}
+	i := sort.Search(len(q.params), func(i int) bool { return q.params[i].key > key })
+	q.params = append(q.params, queryParam{})
+	copy(q.params[i+1:], q.params[i:])
+	q.params[i] = queryParam{key: key, value: value}
+}
+
+func (q *urlQuery) del(key string) {
+	kept := q.params[:0]
+	for _, p := range q.params {
+		if p.key != key {
+			kept = append(kept, p)
+		}
+	}
+	for i := len(kept); i < len(q.params); i++ {
+		q.params[i] = queryParam{}
+	}
+	q.params = kept
+}
+
+func (q *urlQuery) set(key, value string) {
+	q.del(key)
+	q.add(key, value)
+}
+
+func (q *urlQuery) addValues(values url.Values) {
+	for key, vals := range values {
+		for _, value := range vals {
+			q.add(key, value)
+		}
+	}
+}
+
+// reset clears all params, keeping the allocated capacity
+func (q *urlQuery) reset() {
+	for i := range q.params {
+		q.params[i] = queryParam{}
+	}
+	if q.params != nil {
+		q.params = q.params[:0]
+	}
 }
 
 // NewURLBuilder creates a new URLBuilder
@@ -24,9 +106,8 @@ type URLBuilder struct {
 //
 //	builder := NewURLBuilder("https://api.example.com")
 func NewURLBuilder(baseURL string) (*URLBuilder, error) {
-	builder := &URLBuilder{
-		query: make(url.Values),
-	}
+	builder := &URLBuilder{}
+	builder.query.init()
 
 	if baseURL != "" {
 		parsed, err := url.Parse(baseURL)
@@ -37,7 +118,7 @@ func NewURLBuilder(baseURL string) (*URLBuilder, error) {
 		builder.host = parsed.Hostname()
 		builder.port = parsed.Port()
 		builder.path = parsed.Path
-		builder.query = parsed.Query()
+		builder.query.addValues(parsed.Query())
 		builder.fragment = parsed.Fragment
 		if parsed.User != nil {
 			builder.username = parsed.User.Username()
@@ -115,7 +196,7 @@ func (b *URLBuilder) SetPath(path string) *URLBuilder {
 //
 //	builder.AddQuery("page", "1").AddQuery("limit", "10")
 func (b *URLBuilder) AddQuery(key, value string) *URLBuilder {
-	b.query.Add(key, value)
+	b.query.add(key, value)
 	return b
 }
 
@@ -125,7 +206,7 @@ func (b *URLBuilder) AddQuery(key, value string) *URLBuilder {
 //
 //	builder.SetQuery("page", "1")
 func (b *URLBuilder) SetQuery(key, value string) *URLBuilder {
-	b.query.Set(key, value)
+	b.query.set(key, value)
 	return b
 }
 
@@ -135,7 +216,7 @@ func (b *URLBuilder) SetQuery(key, value string) *URLBuilder {
 //
 //	builder.RemoveQuery("page")
 func (b *URLBuilder) RemoveQuery(key string) *URLBuilder {
-	b.query.Del(key)
+	b.query.del(key)
 	return b
 }
 
@@ -149,6 +230,92 @@ func (b *URLBuilder) SetFragment(fragment string) *URLBuilder {
 	return b
 }
 
+// AddHeader adds a header that is applied to requests created with Request
+//
+// Example:
+//
+//	builder.AddHeader("Accept", "application/json")
+func (b *URLBuilder) AddHeader(key, value string) *URLBuilder {
+	if b.headers == nil {
+		b.headers = make(http.Header)
+	}
+	b.headers.Add(key, value)
+	return b
+}
+
+// SetBasicAuthHeader sends the credentials set via SetCredentials (or the base URL)
+// as a basic auth Authorization header instead of embedding them in the URL
+//
+// Example:
+//
+//	builder.SetCredentials("username", "password").SetBasicAuthHeader()
+func (b *URLBuilder) SetBasicAuthHeader() *URLBuilder {
+	b.basicAuthHeader = true
+	return b
+}
+
+// SetBearer sets a bearer token that is sent as Authorization header by Request.
+// It is mutually exclusive with SetCredentials.
+//
+// Example:
+//
+//	builder.SetBearer(accessToken)
+func (b *URLBuilder) SetBearer(token string) *URLBuilder {
+	b.bearerToken = token
+	return b
+}
+
+// Validate checks that the builder can produce a valid absolute request URL
+// and that the authentication settings don't conflict.
+func (b *URLBuilder) Validate() error {
+	if b.scheme == "" {
+		return fmt.Errorf("%w: missing scheme", ErrURLBuilderInvalid)
+	}
+	if b.host == "" {
+		return fmt.Errorf("%w: missing host", ErrURLBuilderInvalid)
+	}
+	if b.bearerToken != "" && b.username != "" {
+		return ErrURLBuilderAuthConflict
+	}
+	if b.basicAuthHeader && b.username == "" {
+		return fmt.Errorf("%w: basic auth header requested without credentials", ErrURLBuilderInvalid)
+	}
+	return nil
+}
+
+// Request validates the builder and creates an http.Request for the built URL,
+// applying all registered headers and the configured authentication
+//
+// Example:
+//
+//	req, err := builder.SetBearer(token).AddHeader("Accept", "application/json").
+//	    Request(ctx, http.MethodGet, nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	resp, err := http.DefaultClient.Do(req)
+func (b *URLBuilder) Request(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.Build(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range b.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if b.basicAuthHeader {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	if b.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+b.bearerToken)
+	}
+	return req, nil
+}
+
 // Build constructs and returns the final URL as a string
 //
 // Example:
@@ -156,43 +323,132 @@ func (b *URLBuilder) SetFragment(fragment string) *URLBuilder {
 //	url := builder.Build()
 //	fmt.Println(url)  // https://api.example.com/v1/users?page=1&limit=10#section1
 func (b *URLBuilder) Build() string {
-	var sb strings.Builder
+	return string(b.AppendBuild(make([]byte, 0, b.estimatedLen())))
+}
 
+// AppendBuild appends the final URL to dst and returns the extended buffer.
+// Reusing the buffer avoids allocations on hot paths; the output is identical to Build.
+//
+// Example:
+//
+//	buf := make([]byte, 0, 256)
+//	for _, id := range ids {
+//	    buf = builder.SetQuery("id", id).AppendBuild(buf[:0])
+//	    fetch(buf)
+//	}
+func (b *URLBuilder) AppendBuild(dst []byte) []byte {
 	if b.scheme != "" {
-		sb.WriteString(b.scheme)
-		sb.WriteString("://")
+		dst = append(dst, b.scheme...)
+		dst = append(dst, "://"...)
 	}
 
-	if b.username != "" {
-		sb.WriteString(url.UserPassword(b.username, b.password).String())
-		sb.WriteString("@")
+	if b.username != "" && !b.basicAuthHeader {
+		dst = append(dst, url.UserPassword(b.username, b.password).String()...)
+		dst = append(dst, '@')
 	}
 
-	sb.WriteString(b.host)
+	dst = append(dst, b.host...)
 
 	if b.port != "" {
-		sb.WriteString(":")
-		sb.WriteString(b.port)
+		dst = append(dst, ':')
+		dst = append(dst, b.port...)
 	}
 
 	if b.path != "" && b.path != "/" {
 		if !strings.HasPrefix(b.path, "/") {
-			sb.WriteString("/")
+			dst = append(dst, '/')
 		}
-		sb.WriteString(b.path)
+		dst = append(dst, b.path...)
 	}
 
-	if len(b.query) > 0 {
-		sb.WriteString("?")
-		sb.WriteString(b.query.Encode())
+	for i, p := range b.query.params {
+		if i == 0 {
+			dst = append(dst, '?')
+		} else {
+			dst = append(dst, '&')
+		}
+		dst = appendQueryEscape(dst, p.key)
+		dst = append(dst, '=')
+		dst = appendQueryEscape(dst, p.value)
 	}
 
 	if b.fragment != "" {
-		sb.WriteString("#")
-		sb.WriteString(b.fragment)
+		dst = append(dst, '#')
+		dst = append(dst, b.fragment...)
+	}
+
+	return dst
+}
+
+// estimatedLen returns the length of the built URL assuming nothing needs escaping
+func (b *URLBuilder) estimatedLen() int {
+	n := len(b.scheme) + 3 + len(b.username) + len(b.password) + 2 + len(b.host) + 1 + len(b.port) +
+		1 + len(b.path) + 1 + len(b.fragment)
+	for _, p := range b.query.params {
+		n += len(p.key) + len(p.value) + 2
+	}
+	return n
+}
+
+// appendQueryEscape appends s escaped like url.QueryEscape
+func appendQueryEscape(dst []byte, s string) []byte {
+	const upperhex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			dst = append(dst, c)
+		case c == ' ':
+			dst = append(dst, '+')
+		default:
+			dst = append(dst, '%', upperhex[c>>4], upperhex[c&15])
+		}
 	}
+	return dst
+}
 
-	return sb.String()
+var urlBuilderPool = sync.Pool{
+	New: func() interface{} {
+		b := &URLBuilder{}
+		b.query.init()
+		return b
+	},
+}
+
+// AcquireURLBuilder returns an empty URLBuilder from a pool.
+// Return it with ReleaseURLBuilder once the built URL is no longer needed by the builder.
+//
+// Example:
+//
+//	b := AcquireURLBuilder()
+//	defer ReleaseURLBuilder(b)
+//	buf = b.SetScheme("https").SetHost("api.example.com").AddPath("users").AppendBuild(buf[:0])
+func AcquireURLBuilder() *URLBuilder {
+	return urlBuilderPool.Get().(*URLBuilder)
+}
+
+// ReleaseURLBuilder resets the builder and returns it to the pool.
+// The builder must not be used after it has been released.
+func ReleaseURLBuilder(b *URLBuilder) {
+	b.Reset()
+	urlBuilderPool.Put(b)
+}
+
+// Reset clears all URL parts, headers and authentication settings, keeping allocated capacity
+func (b *URLBuilder) Reset() *URLBuilder {
+	b.scheme = ""
+	b.username = ""
+	b.password = ""
+	b.host = ""
+	b.port = ""
+	b.path = ""
+	b.query.reset()
+	b.fragment = ""
+	b.headers = nil
+	b.basicAuthHeader = false
+	b.bearerToken = ""
+	return b
 }
 
 // BuildURL constructs and returns the final URL as a *url.URL
@@ -221,12 +477,14 @@ func (b *URLBuilder) Clone() *URLBuilder {
 		host:     b.host,
 		port:     b.port,
 		path:     b.path,
-		query:    make(url.Values),
 		fragment: b.fragment,
+
+		headers:         b.headers.Clone(),
+		basicAuthHeader: b.basicAuthHeader,
+		bearerToken:     b.bearerToken,
 	}
-	for k, v := range b.query {
-		newBuilder.query[k] = v
-	}
+	newBuilder.query.init()
+	newBuilder.query.params = append(newBuilder.query.params, b.query.params...)
 	return newBuilder
 }
 
@@ -249,9 +507,10 @@ func ParseURL(rawURL string) (*URLBuilder, error) {
 		host:     parsed.Hostname(),
 		port:     parsed.Port(),
 		path:     parsed.Path,
-		query:    parsed.Query(),
 		fragment: parsed.Fragment,
 	}
+	builder.query.init()
+	builder.query.addValues(parsed.Query())
 
 	if parsed.User != nil {
 		builder.username = parsed.User.Username()
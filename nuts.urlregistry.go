@@ -0,0 +1,140 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrBaseURLNotRegistered is wrapped by the errors of BaseURLRegistry.For for unknown names and environments.
+	ErrBaseURLNotRegistered = errors.New("urlregistry: base url not registered")
+	// ErrNoEnvironment is wrapped by the error of BaseURLRegistry.For before SetEnvironment was called.
+	ErrNoEnvironment = errors.New("urlregistry: no environment set")
+)
+
+var currentEnvironment atomic.Pointer[string]
+
+// SetEnvironment sets the process-wide environment (e.g. "dev", "stage", "prod") used by
+// BaseURLRegistry.For. It is usually called once at startup, but switching it later is safe.
+//
+// Example:
+//
+//	gonuts.SetEnvironment(os.Getenv("APP_ENV"))
+func SetEnvironment(env string) {
+	currentEnvironment.Store(&env)
+}
+
+// CurrentEnvironment returns the environment set by SetEnvironment, or "" if none was set.
+func CurrentEnvironment() string {
+	if env := currentEnvironment.Load(); env != nil {
+		return *env
+	}
+	return ""
+}
+
+// BaseURLRegistry holds the base URLs of upstream services per environment. It is safe for
+// concurrent registration and lookup.
+//
+// Example:
+//
+//	registry := gonuts.NewBaseURLRegistry()
+//	registry.RegisterBase("billing", "dev", "http://localhost:8081/api")
+//	registry.RegisterBase("billing", "prod", "https://billing.example.com/api")
+//
+//	gonuts.SetEnvironment("prod")
+//	builder, err := registry.For("billing")
+//	if err != nil {
+//	    return err
+//	}
+//	invoiceURL := builder.AddPath("invoices").AddPath(invoiceID).Build()
+type BaseURLRegistry struct {
+	mu    sync.RWMutex
+	bases map[string]map[string]*URLBuilder // name -> env -> builder
+}
+
+// NewBaseURLRegistry creates an empty BaseURLRegistry
+func NewBaseURLRegistry() *BaseURLRegistry {
+	return &BaseURLRegistry{bases: make(map[string]map[string]*URLBuilder)}
+}
+
+// RegisterBase registers the base URL of an upstream for an environment, replacing a previous
+// registration.
+//
+// Parameters:
+//   - name: the name of the upstream, e.g. "billing"
+//   - env: the environment, e.g. "prod"
+//   - rawURL: an absolute URL with scheme and host
+//
+// Returns:
+//   - error: an error wrapping ErrURLBuilderInvalid if rawURL is not an absolute URL
+func (r *BaseURLRegistry) RegisterBase(name, env, rawURL string) error {
+	builder, err := NewURLBuilder(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %s/%s: %v", ErrURLBuilderInvalid, name, env, err)
+	}
+	if err := builder.Validate(); err != nil {
+		return fmt.Errorf("%s/%s: %w", name, env, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	envs, exists := r.bases[name]
+	if !exists {
+		envs = make(map[string]*URLBuilder)
+		r.bases[name] = envs
+	}
+	envs[env] = builder
+	return nil
+}
+
+// For returns a builder for the base URL of an upstream in the current environment (see
+// SetEnvironment). The builder is a Clone, so it can be modified freely.
+//
+// Returns:
+//   - *URLBuilder: a copy of the registered builder
+//   - error: an *ErrorPlus with code 500 wrapping ErrBaseURLNotRegistered (listing the registered
+//     names or environments) or ErrNoEnvironment
+func (r *BaseURLRegistry) For(name string) (*URLBuilder, error) {
+	return r.ForEnvironment(name, CurrentEnvironment())
+}
+
+// ForEnvironment is like For, but looks the base URL up for the given environment.
+func (r *BaseURLRegistry) ForEnvironment(name, env string) (*URLBuilder, error) {
+	if env == "" {
+		return nil, NewInternalError("base url lookup failed for "+name, ErrNoEnvironment)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	envs, exists := r.bases[name]
+	if !exists {
+		err := fmt.Errorf("%w: unknown name %q, registered: [%s]", ErrBaseURLNotRegistered, name, strings.Join(sortedKeys(r.bases), ", "))
+		return nil, NewInternalError("base url lookup failed", err)
+	}
+	builder, exists := envs[env]
+	if !exists {
+		err := fmt.Errorf("%w: no environment %q for %q, registered: [%s]", ErrBaseURLNotRegistered, env, name, strings.Join(sortedKeys(envs), ", "))
+		return nil, NewInternalError("base url lookup failed", err)
+	}
+	return builder.Clone(), nil
+}
+
+// Names returns the registered upstream names in sorted order
+func (r *BaseURLRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.bases)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
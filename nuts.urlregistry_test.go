@@ -0,0 +1,96 @@
+package gonuts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// setTestEnvironment sets the process-wide environment and restores it when the test ends
+func setTestEnvironment(t *testing.T, env string) {
+	previous := CurrentEnvironment()
+	SetEnvironment(env)
+	t.Cleanup(func() { SetEnvironment(previous) })
+}
+
+func TestBaseURLRegistrySelectsCurrentEnvironment(t *testing.T) {
+	registry := NewBaseURLRegistry()
+	for _, reg := range [][3]string{
+		{"billing", "dev", "http://localhost:8081/api"},
+		{"billing", "prod", "https://billing.example.com/api"},
+		{"search", "prod", "https://search.example.com"},
+	} {
+		if err := registry.RegisterBase(reg[0], reg[1], reg[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setTestEnvironment(t, "dev")
+	builder, err := registry.For("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := builder.AddPath("invoices").Build(); got != "http://localhost:8081/api/invoices" {
+		t.Errorf("billing in dev = %s", got)
+	}
+
+	// Switching the environment switches every later lookup, and the builders are copies
+	SetEnvironment("prod")
+	builder, _ = registry.For("billing")
+	if got := builder.Build(); got != "https://billing.example.com/api" {
+		t.Errorf("billing in prod = %s, want the registered base without the path added to a previous copy", got)
+	}
+	if builder, err := registry.ForEnvironment("billing", "dev"); err != nil || builder.Build() != "http://localhost:8081/api" {
+		t.Errorf("ForEnvironment(billing, dev) = %v, %v", builder, err)
+	}
+
+	// A later registration replaces the earlier one
+	registry.RegisterBase("billing", "prod", "https://billing-v2.example.com")
+	if builder, _ := registry.For("billing"); builder.Build() != "https://billing-v2.example.com" {
+		t.Errorf("billing in prod after re-registering = %s", builder.Build())
+	}
+	if names := registry.Names(); len(names) != 2 || names[0] != "billing" || names[1] != "search" {
+		t.Errorf("Names() = %v, want [billing search]", names)
+	}
+}
+
+func TestBaseURLRegistryLookupErrors(t *testing.T) {
+	registry := NewBaseURLRegistry()
+	registry.RegisterBase("billing", "dev", "http://localhost:8081")
+	registry.RegisterBase("billing", "stage", "https://billing.stage.example.com")
+
+	tests := []struct {
+		name, env string
+		target    error
+		mentions  string
+	}{
+		{"billing", "", ErrNoEnvironment, ""},
+		{"billing", "prod", ErrBaseURLNotRegistered, "registered: [dev, stage]"},
+		{"payments", "dev", ErrBaseURLNotRegistered, "registered: [billing]"},
+	}
+	for _, tt := range tests {
+		builder, err := registry.ForEnvironment(tt.name, tt.env)
+		if builder != nil || !errors.Is(err, tt.target) {
+			t.Errorf("ForEnvironment(%s, %q) = %v, %v, want %v", tt.name, tt.env, builder, err, tt.target)
+			continue
+		}
+		var ep *ErrorPlus
+		if !errors.As(err, &ep) || ep.Code() != 500 {
+			t.Errorf("ForEnvironment(%s, %q) error = %v, want an *ErrorPlus with code 500", tt.name, tt.env, err)
+		}
+		if !strings.Contains(err.Error(), tt.mentions) {
+			t.Errorf("ForEnvironment(%s, %q) error = %q, want it to mention %q", tt.name, tt.env, err, tt.mentions)
+		}
+	}
+
+	setTestEnvironment(t, "")
+	if _, err := registry.For("billing"); !errors.Is(err, ErrNoEnvironment) {
+		t.Errorf("For without an environment: error = %v, want ErrNoEnvironment", err)
+	}
+
+	for _, rawURL := range []string{"localhost:8081", "/api", "://broken"} {
+		if err := registry.RegisterBase("billing", "prod", rawURL); !errors.Is(err, ErrURLBuilderInvalid) {
+			t.Errorf("RegisterBase(%q) error = %v, want ErrURLBuilderInvalid", rawURL, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gonuts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrValidation is wrapped by the errors returned by ValidationErrors.Err
+var ErrValidation = errors.New("validation failed")
+
+// FieldError is a validation problem of a single field. The rejected value is kept for
+// in-process inspection but never serialized.
+type FieldError struct {
+	Field   string      `json:"field"`
+	Rule    string      `json:"rule"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"-"`
+}
+
+// ValidationErrors collects field-level validation problems of a request payload and turns
+// them into a single 400 ErrorPlus. It is not safe for concurrent use.
+//
+// Example usage:
+//
+//	v := gonuts.NewValidationErrors()
+//	if req.Email == "" {
+//	    v.AddField("email", "required", "email is required", req.Email)
+//	}
+//	v.AddError("next", "redirect", gonuts.ValidateRedirectTarget(req.Next, allowedHosts, true), req.Next)
+//	if v.HasErrors() {
+//	    return v.Err()
+//	}
+type ValidationErrors struct {
+	fields []FieldError
+}
+
+// NewValidationErrors creates an empty ValidationErrors builder
+func NewValidationErrors() *ValidationErrors {
+	return &ValidationErrors{}
+}
+
+// AddField records a validation problem of a field
+//
+// Parameters:
+//   - field: the field name or path, e.g. "address.zip"
+//   - rule: the violated rule, e.g. "required" or "max_length"
+//   - message: a human-readable description
+//   - value: the rejected value (not serialized)
+//
+// Returns:
+//   - *ValidationErrors: the builder, for chaining
+func (v *ValidationErrors) AddField(field, rule, message string, value interface{}) *ValidationErrors {
+	v.fields = append(v.fields, FieldError{Field: field, Rule: rule, Message: message, Value: value})
+	return v
+}
+
+// AddError records the error of a validator, like ValidateRedirectTarget, as a problem of a
+// field. A nil error is ignored, so validators can be called inline.
+//
+// Example usage:
+//
+//	v.AddError("next", "redirect", gonuts.ValidateRedirectTarget(next, allowedHosts, true), next)
+func (v *ValidationErrors) AddError(field, rule string, err error, value interface{}) *ValidationErrors {
+	if err == nil {
+		return v
+	}
+	return v.AddField(field, rule, err.Error(), value)
+}
+
+// HasErrors reports whether any validation problem was recorded
+func (v *ValidationErrors) HasErrors() bool {
+	return len(v.fields) > 0
+}
+
+// Fields returns the recorded validation problems in the order they were added
+func (v *ValidationErrors) Fields() []FieldError {
+	return append([]FieldError(nil), v.fields...)
+}
+
+// Err returns a 400 ErrorPlus wrapping ErrValidation whose context holds the problems as a
+// "fields" array of {field, rule, message} objects, or nil if there are no problems.
+// Check HasErrors before returning the result as an error, since a nil *ErrorPlus stored in an
+// error interface is not nil.
+//
+// Example usage:
+//
+//	if v.HasErrors() {
+//	    v.Err().WriteHTTP(w) // 400 {"error": "2 field(s) are invalid", "code": 400, "fields": [{"field": "email", ...}]}
+//	    return
+//	}
+func (v *ValidationErrors) Err() *ErrorPlus {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	problems := make([]string, len(v.fields))
+	for i, f := range v.fields {
+		problems[i] = f.Field + ": " + f.Message
+	}
+	err := fmt.Errorf("%w: %s", ErrValidation, strings.Join(problems, "; "))
+	return newErrorPlus(err, fmt.Sprintf("%d field(s) are invalid", len(v.fields)), 400, ErrorKindValidation).
+		WithContext("fields", v.Fields())
+}
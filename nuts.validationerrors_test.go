@@ -0,0 +1,69 @@
+package gonuts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorsHTTPShape(t *testing.T) {
+	v := NewValidationErrors().
+		AddField("email", "required", "email is required", "").
+		AddError("next", "redirect", nil, "/ok"). // nil errors are ignored
+		AddError("address.zip", "pattern", errors.New("must be 5 digits"), "secret-1234")
+	err := v.Err()
+
+	rec := httptest.NewRecorder()
+	err.WriteHTTP(rec)
+	want := `{"error":"2 field(s) are invalid","code":400,"fields":[` +
+		`{"field":"email","rule":"required","message":"email is required"},` +
+		`{"field":"address.zip","rule":"pattern","message":"must be 5 digits"}]}`
+	if rec.Code != 400 || rec.Body.String() != want {
+		t.Errorf("WriteHTTP = %d %s\nwant 400 %s", rec.Code, rec.Body, want)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// The rejected values are kept in-process but never serialized
+	if fields := v.Fields(); fields[1].Value != "secret-1234" {
+		t.Errorf("Fields()[1].Value = %v, want the rejected value", fields[1].Value)
+	}
+	payload, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	if strings.Contains(string(payload), "secret-1234") {
+		t.Errorf("MarshalJSON leaks a rejected value: %s", payload)
+	}
+	var logged struct {
+		Context struct {
+			Fields []map[string]string `json:"fields"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal(payload, &logged); err != nil {
+		t.Fatal(err)
+	}
+	if len(logged.Context.Fields) != 2 || logged.Context.Fields[0]["field"] != "email" || len(logged.Context.Fields[0]) != 3 {
+		t.Errorf("MarshalJSON context fields = %v, want the 2 {field, rule, message} objects", logged.Context.Fields)
+	}
+
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Err() = %v, want it to wrap ErrValidation", err)
+	}
+	if msg := err.Error(); msg != "2 field(s) are invalid: validation failed: email: email is required; address.zip: must be 5 digits" {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+func TestValidationErrorsWithoutProblems(t *testing.T) {
+	v := NewValidationErrors().AddError("next", "redirect", nil, "/ok")
+	if v.HasErrors() {
+		t.Error("HasErrors() = true without problems")
+	}
+	if err := v.Err(); err != nil {
+		t.Errorf("Err() = %v without problems, want nil", err)
+	}
+}
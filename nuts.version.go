@@ -1,11 +1,16 @@
 package gonuts
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type VersionData struct {
@@ -92,6 +97,60 @@ func GetVersionData() VersionData {
 	return vData
 }
 
+// GetBuildFingerprint returns a short hash over the version, git commit, git branch and Go
+// version of the binary. Two binaries with the same fingerprint were built from the same inputs.
+//
+// Example usage:
+//
+//	w.Header().Set("X-Build", gonuts.GetBuildFingerprint())
+func GetBuildFingerprint() string {
+	return buildFingerprint(vData, runtime.Version())
+}
+
+func buildFingerprint(data VersionData, goVersion string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{data.Version, data.GitCommit, data.GitBranch, goVersion}, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+var startupBannerOnce sync.Once
+
+// PrintStartupBanner logs a single structured line at Info level with the version data, build
+// fingerprint, pid, GOMAXPROCS and hostname of the service, plus the given extra fields.
+// Only the first call logs; later calls are ignored.
+//
+// Example usage:
+//
+//	gonuts.InitVersion()
+//	gonuts.PrintStartupBanner("billing-api", map[string]string{"region": cfg.Region})
+func PrintStartupBanner(serviceName string, extra map[string]string) {
+	startupBannerOnce.Do(func() {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		fields := []interface{}{
+			"service", serviceName,
+			"version", vData.Version,
+			"gitCommit", vData.GitCommit,
+			"gitBranch", vData.GitBranch,
+			"goVersion", runtime.Version(),
+			"fingerprint", GetBuildFingerprint(),
+			"pid", os.Getpid(),
+			"gomaxprocs", runtime.GOMAXPROCS(0),
+			"hostname", hostname,
+		}
+		keys := make([]string, 0, len(extra))
+		for key := range extra {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fields = append(fields, key, extra[key])
+		}
+		L.Infow("[version.service] starting "+serviceName, fields...)
+	})
+}
+
 // ForceUpdateVersionData re-reads git data and updates the version file
 func ForceUpdateVersionData() error {
 	homedir, err := os.Getwd()
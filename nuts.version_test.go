@@ -0,0 +1,89 @@
+package gonuts
+
+import (
+	"os"
+	"regexp"
+	"runtime"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBuildFingerprint(t *testing.T) {
+	data := VersionData{Version: "1.4.0", GitCommit: "3f6f9c3", GitBranch: "main"}
+	fingerprint := buildFingerprint(data, "go1.24.1")
+	if !regexp.MustCompile(`^[0-9a-f]{12}$`).MatchString(fingerprint) {
+		t.Fatalf("fingerprint = %q, want 12 hex digits", fingerprint)
+	}
+	if again := buildFingerprint(data, "go1.24.1"); again != fingerprint {
+		t.Errorf("fingerprint is not stable: %q, then %q", fingerprint, again)
+	}
+
+	// Every input changes the fingerprint, and the inputs cannot shift into each other
+	others := map[string]string{
+		"version":   buildFingerprint(VersionData{Version: "1.4.1", GitCommit: "3f6f9c3", GitBranch: "main"}, "go1.24.1"),
+		"commit":    buildFingerprint(VersionData{Version: "1.4.0", GitCommit: "bf08e7f", GitBranch: "main"}, "go1.24.1"),
+		"branch":    buildFingerprint(VersionData{Version: "1.4.0", GitCommit: "3f6f9c3", GitBranch: "dev"}, "go1.24.1"),
+		"goVersion": buildFingerprint(data, "go1.24.2"),
+		"shifted":   buildFingerprint(VersionData{Version: "1.4.03f6f9c3", GitBranch: "main"}, "go1.24.1"),
+	}
+	for input, other := range others {
+		if other == fingerprint {
+			t.Errorf("changing the %s keeps the fingerprint %s", input, fingerprint)
+		}
+	}
+
+	if got := GetBuildFingerprint(); got != buildFingerprint(GetVersionData(), runtime.Version()) {
+		t.Errorf("GetBuildFingerprint() = %s, want the fingerprint of the version data and the running Go version", got)
+	}
+}
+
+func TestPrintStartupBanner(t *testing.T) {
+	logger, data := L, vData
+	core, logs := observer.New(zapcore.InfoLevel)
+	L = zap.New(core).Sugar()
+	vData = VersionData{Version: "1.4.0", GitCommit: "3f6f9c3", GitBranch: "main"}
+	startupBannerOnce = sync.Once{}
+	t.Cleanup(func() { L, vData = logger, data })
+
+	PrintStartupBanner("billing-api", map[string]string{"region": "eu-west-1", "env": "staging"})
+	PrintStartupBanner("billing-api", nil)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("%d banners logged, want only the first call", len(entries))
+	}
+	if entries[0].Message != "[version.service] starting billing-api" {
+		t.Errorf("message = %q", entries[0].Message)
+	}
+	fields := entries[0].ContextMap()
+	hostname, _ := os.Hostname()
+	want := map[string]interface{}{
+		"service":     "billing-api",
+		"version":     "1.4.0",
+		"gitCommit":   "3f6f9c3",
+		"gitBranch":   "main",
+		"goVersion":   runtime.Version(),
+		"fingerprint": buildFingerprint(vData, runtime.Version()),
+		"pid":         int64(os.Getpid()),
+		"gomaxprocs":  int64(runtime.GOMAXPROCS(0)),
+		"hostname":    hostname,
+		"region":      "eu-west-1",
+		"env":         "staging",
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("field %s = %v (%T), want %v", key, fields[key], fields[key], value)
+		}
+	}
+	if len(fields) != len(want) {
+		t.Errorf("fields = %v, want only %d", fields, len(want))
+	}
+	// The extra fields follow the built-in ones sorted by key
+	if keys := entries[0].Context; keys[len(keys)-2].Key != "env" || keys[len(keys)-1].Key != "region" {
+		t.Errorf("the extra fields are not sorted: %v", keys[len(keys)-2:])
+	}
+}